@@ -173,6 +173,31 @@ func (s *channel) NextTxData() txData {
 	return txdata
 }
 
+// NextFrames returns up to n frames of the channel, for a tx which is packed with frames of
+// multiple channels. If holdLast is true, it stops before the closing frame of the channel.
+// The tx data of the returned frames must be registered with AddPendingTx.
+func (s *channel) NextFrames(n int, holdLast bool) []frameData {
+	frames := make([]frameData, 0, n)
+	for len(frames) < n && s.channelBuilder.HasFrame() {
+		if holdLast && s.isClosingFrame(s.channelBuilder.frames[0]) {
+			break
+		}
+		frames = append(frames, s.channelBuilder.NextFrame())
+	}
+	return frames
+}
+
+// isClosingFrame returns whether the frame is the last frame of the full channel.
+func (s *channel) isClosingFrame(frame frameData) bool {
+	return s.IsFull() && int(frame.id.frameNumber) == s.TotalFrames()-1
+}
+
+// AddPendingTx records the tx data with the frames of this channel in the tx with the given ID.
+func (s *channel) AddPendingTx(id string, txdata txData) {
+	s.log.Debug("returning next tx data", "id", id, "num_frames", len(txdata.frames), "as_blob", txdata.asBlob)
+	s.pendingTransactions[id] = txdata
+}
+
 func (s *channel) HasTxData() bool {
 	if s.IsFull() || !s.cfg.UseBlobs {
 		return s.channelBuilder.HasFrame()
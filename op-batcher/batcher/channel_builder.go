@@ -179,7 +179,8 @@ func (c *ChannelBuilder) AddBlock(block *types.Block, l1Info *derive.L1BlockInfo
 		return fmt.Errorf("converting block to batch: %w", err)
 	}
 
-	if err = c.co.AddSingularBatch(batch, l1Info.SequenceNumber); errors.Is(err, derive.ErrTooManyRLPBytes) || errors.Is(err, derive.ErrCompressorFull) {
+	if err = c.co.AddSingularBatch(batch, l1Info.SequenceNumber); errors.Is(err, derive.ErrTooManyRLPBytes) ||
+		errors.Is(err, derive.ErrCompressorFull) || errors.Is(err, derive.ErrSpanBatchExclusionsActivation) {
 		c.setFullErr(err)
 		return c.FullErr()
 	} else if err != nil {
@@ -296,6 +297,8 @@ func (c *ChannelBuilder) IsFull() bool {
 //   - derive.ErrCompressorFull if the compressor target has been reached,
 //   - derive.MaxRLPBytesPerChannel if the general maximum amount of input data
 //     would have been exceeded by the latest AddBlock call,
+//   - derive.ErrSpanBatchExclusionsActivation if the latest AddBlock call
+//     crossed the span batch exclusions activation,
 //   - ErrMaxFrameIndex if the maximum number of frames has been generated
 //     (uint16),
 //   - ErrMaxDurationReached if the max channel duration got reached,
@@ -46,6 +46,11 @@ type ChannelConfig struct {
 	// UseBlobs indicates that this channel should be sent as a multi-blob
 	// transaction with one blob per frame.
 	UseBlobs bool
+
+	// MaxConcurrentChannels is the maximum number of channels to fill and
+	// submit frames from at the same time. If larger than 1, blob transactions
+	// are packed with frames of multiple channels.
+	MaxConcurrentChannels int
 }
 
 // ChannelConfig returns a copy of itself. This makes a ChannelConfig a static
@@ -119,6 +124,10 @@ func (cc *ChannelConfig) Check() error {
 		return fmt.Errorf("invalid number of frames %d", nf)
 	}
 
+	if cc.MaxConcurrentChannels < 0 {
+		return fmt.Errorf("invalid number of concurrent channels %d", cc.MaxConcurrentChannels)
+	}
+
 	return nil
 }
 
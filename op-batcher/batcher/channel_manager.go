@@ -47,8 +47,9 @@ type channelManager struct {
 	currentChannel *channel
 	// channels to read frame data from, for writing batches onchain
 	channelQueue []*channel
-	// used to lookup channels by tx ID upon tx success / failure
-	txChannels map[string]*channel
+	// used to lookup channels by tx ID upon tx success / failure.
+	// A tx has frames of multiple channels in multi-channel mode.
+	txChannels map[string][]*channel
 
 	// if set to true, prevents production of any new channel frames
 	closed bool
@@ -60,7 +61,7 @@ func NewChannelManager(log log.Logger, metr metrics.Metricer, cfgProvider Channe
 		metr:        metr,
 		cfgProvider: cfgProvider,
 		rollupCfg:   rollupCfg,
-		txChannels:  make(map[string]*channel),
+		txChannels:  make(map[string][]*channel),
 	}
 }
 
@@ -76,7 +77,19 @@ func (s *channelManager) Clear(l1OriginLastClosedChannel eth.BlockID) {
 	s.closed = false
 	s.currentChannel = nil
 	s.channelQueue = nil
-	s.txChannels = make(map[string]*channel)
+	s.txChannels = make(map[string][]*channel)
+}
+
+// HasChannel returns whether the channel with the given ID is pending submission.
+func (s *channelManager) HasChannel(id derive.ChannelID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ch := range s.channelQueue {
+		if ch.ID() == id {
+			return true
+		}
+	}
+	return false
 }
 
 // TxFailed records a transaction as failed. It will attempt to resubmit the data
@@ -85,12 +98,14 @@ func (s *channelManager) TxFailed(_id txID) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	id := _id.String()
-	if channel, ok := s.txChannels[id]; ok {
+	if channels, ok := s.txChannels[id]; ok {
 		delete(s.txChannels, id)
-		channel.TxFailed(id)
-		if s.closed && channel.NoneSubmitted() {
-			s.log.Info("Channel has no submitted transactions, clearing for shutdown", "chID", channel.ID())
-			s.removePendingChannel(channel)
+		for _, channel := range channels {
+			channel.TxFailed(id)
+			if s.closed && channel.NoneSubmitted() {
+				s.log.Info("Channel has no submitted transactions, clearing for shutdown", "chID", channel.ID())
+				s.removePendingChannel(channel)
+			}
 		}
 	} else {
 		s.log.Warn("transaction from unknown channel marked as failed", "id", id)
@@ -105,13 +120,18 @@ func (s *channelManager) TxConfirmed(_id txID, inclusionBlock eth.BlockID) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	id := _id.String()
-	if channel, ok := s.txChannels[id]; ok {
+	if channels, ok := s.txChannels[id]; ok {
 		delete(s.txChannels, id)
-		done, blocks := channel.TxConfirmed(id, inclusionBlock)
-		s.blocks = append(blocks, s.blocks...)
-		if done {
-			s.removePendingChannel(channel)
+		// blocks of timed out channels are requeued in the order of their channels
+		var requeued []*ChannelBlock
+		for _, channel := range channels {
+			done, blocks := channel.TxConfirmed(id, inclusionBlock)
+			requeued = append(requeued, blocks...)
+			if done {
+				s.removePendingChannel(channel)
+			}
 		}
+		s.blocks = append(requeued, s.blocks...)
 	} else {
 		s.log.Warn("transaction from unknown channel marked as confirmed", "id", id)
 	}
@@ -139,13 +159,13 @@ func (s *channelManager) removePendingChannel(channel *channel) {
 }
 
 // nextTxData pops off s.datas & handles updating the internal state
-func (s *channelManager) nextTxData(channel *channel) (txData, error) {
-	if channel == nil || !channel.HasTxData() {
+func (s *channelManager) nextTxData(ch *channel) (txData, error) {
+	if ch == nil || !ch.HasTxData() {
 		s.log.Trace("no next tx data")
 		return txData{}, io.EOF // TODO: not enough data error instead
 	}
-	tx := channel.NextTxData()
-	s.txChannels[tx.ID().String()] = channel
+	tx := ch.NextTxData()
+	s.txChannels[tx.ID().String()] = []*channel{ch}
 	return tx, nil
 }
 
@@ -157,6 +177,9 @@ func (s *channelManager) nextTxData(channel *channel) (txData, error) {
 func (s *channelManager) TxData(l1Head eth.BlockID) (txData, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if maxChannels := s.maxConcurrentChannels(); maxChannels > 1 {
+		return s.multiChannelTxData(l1Head, maxChannels)
+	}
 	var firstWithTxData *channel
 	for _, ch := range s.channelQueue {
 		if ch.HasTxData() {
@@ -200,6 +223,103 @@ func (s *channelManager) TxData(l1Head eth.BlockID) (txData, error) {
 	return s.nextTxData(s.currentChannel)
 }
 
+// maxConcurrentChannels returns ChannelConfig.MaxConcurrentChannels of the current channel config.
+func (s *channelManager) maxConcurrentChannels() int {
+	return s.cfgProvider.ChannelConfig().MaxConcurrentChannels
+}
+
+// multiChannelTxData returns the next tx data in multi-channel mode, see
+// ChannelConfig.MaxConcurrentChannels. It adds the pending blocks to channels,
+// opening new channels while fewer than maxChannels channels have frames left
+// to submit. The tx data is then packed with frames of the channels in the order
+// of the channel queue.
+func (s *channelManager) multiChannelTxData(l1Head eth.BlockID, maxChannels int) (txData, error) {
+	for !s.closed && len(s.blocks) > 0 {
+		if (s.currentChannel == nil || s.currentChannel.IsFull()) && s.unsubmittedChannels() >= maxChannels {
+			break
+		}
+		if err := s.ensureChannelWithSpace(l1Head); err != nil {
+			return txData{}, err
+		}
+		if err := s.processBlocks(); err != nil {
+			return txData{}, err
+		}
+		s.registerL1Block(l1Head)
+		if err := s.outputFrames(); err != nil {
+			return txData{}, err
+		}
+		if !s.currentChannel.IsFull() {
+			// all pending blocks fit into the current channel
+			break
+		}
+	}
+	s.metr.RecordConcurrentChannels(s.unsubmittedChannels())
+	return s.nextMultiChannelTxData()
+}
+
+// unsubmittedChannels returns the number of channels which are still filled or
+// have frames which are not submitted yet.
+func (s *channelManager) unsubmittedChannels() int {
+	n := 0
+	for _, ch := range s.channelQueue {
+		if !ch.IsFull() || ch.PendingFrames() > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// nextMultiChannelTxData packs the frames of the channels into the next tx data.
+// The frames are taken in the order of the channel queue, which is the order the
+// ChannelBank of the derivation pipeline reads channels in, as channels are queued
+// there by the L1 inclusion of their first frame. The closing frame of a channel is
+// only taken once no earlier channel has frames left, so that channels become ready
+// in the same order. The frames of a channel which is still being filled are only
+// taken if they complete the tx.
+func (s *channelManager) nextMultiChannelTxData() (txData, error) {
+	var (
+		txdata    txData
+		channels  []*channel
+		parts     []txData
+		maxFrames int
+		// whether an earlier channel has frames left
+		earlierPending bool
+	)
+	for _, ch := range s.channelQueue {
+		if ch.PendingFrames() == 0 {
+			continue
+		}
+		if len(channels) == 0 {
+			txdata.asBlob, maxFrames = ch.cfg.UseBlobs, ch.cfg.MaxFramesPerTx()
+		} else if ch.cfg.UseBlobs != txdata.asBlob {
+			break
+		}
+		free := maxFrames - len(txdata.frames)
+		if free == 0 || (!ch.IsFull() && ch.PendingFrames() < free) {
+			break
+		}
+		frames := ch.NextFrames(free, earlierPending)
+		earlierPending = earlierPending || ch.PendingFrames() > 0
+		if len(frames) == 0 {
+			continue
+		}
+		txdata.frames = append(txdata.frames, frames...)
+		channels = append(channels, ch)
+		parts = append(parts, txData{frames: frames, asBlob: txdata.asBlob})
+	}
+	if len(channels) == 0 {
+		s.log.Trace("no next tx data")
+		return txData{}, io.EOF
+	}
+
+	id := txdata.ID().String()
+	for i, ch := range channels {
+		ch.AddPendingTx(id, parts[i])
+	}
+	s.txChannels[id] = channels
+	return txdata, nil
+}
+
 // ensureChannelWithSpace ensures currentChannel is populated with a channel that has
 // space for more data (i.e. channel.IsFull returns false). If currentChannel is nil
 // or full, a new channel is created.
@@ -4,6 +4,7 @@ import (
 	"io"
 	"math/big"
 	"math/rand"
+	"slices"
 	"testing"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 	derivetest "github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive/test"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testlog"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testutils"
 )
 
 func channelManagerTestConfig(maxFrameSize uint64, batchType uint) ChannelConfig {
@@ -484,3 +486,109 @@ func TestChannelManager_ChannelCreation(t *testing.T) {
 		})
 	}
 }
+
+// TestChannelManager_MultiChannel tests that in multi-channel mode, blob txs are packed with
+// frames of multiple channels, while channels are opened and closed on L1 in order.
+func TestChannelManager_MultiChannel(t *testing.T) {
+	require := require.New(t)
+	rng := rand.New(rand.NewSource(4321))
+	log := testlog.Logger(t, log.LevelError)
+	const (
+		numBlocks = 3
+		maxFrames = 4
+	)
+	cfg := channelManagerTestConfig(derive.FrameV0OverHeadSize+100, derive.SingularBatchType)
+	cfg.UseBlobs = true
+	cfg.TargetNumFrames = maxFrames
+	cfg.MaxConcurrentChannels = 2
+	cfg.ChannelTimeout = 1000
+	cfg.InitNoneCompressor()
+	cfg.CompressorConfig.TargetOutputSize = 1 // full on first block
+	m := NewChannelManager(log, metrics.NoopMetrics, cfg, &defaultTestRollupConfig)
+	m.Clear(eth.BlockID{})
+
+	parent := common.Hash{}
+	for i := 0; i < numBlocks; i++ {
+		// a block with about 6 frames of data
+		block := newMiniL2BlockWithNumberParent(0, big.NewInt(int64(i)), parent)
+		tx := types.NewTx(&types.DynamicFeeTx{Data: testutils.RandomData(rng, 550)})
+		block = block.WithBody(append(block.Transactions(), tx), nil)
+		parent = block.Hash()
+		require.NoError(m.AddL2Block(block, derive.NewL1BlockInfo(&types.L1Info{})))
+	}
+
+	var txs []txData
+	nextTxData := func() {
+		txdata, err := m.TxData(eth.BlockID{})
+		require.NoError(err)
+		require.True(txdata.asBlob)
+		txs = append(txs, txdata)
+	}
+	// the first two channels are opened right away, the third once the first has no frames left
+	nextTxData()
+	require.Len(m.channelQueue, 2)
+	require.Len(m.blocks, 1, "only two channels with frames left at a time")
+	require.Greater(m.channelQueue[0].TotalFrames(), maxFrames, "test needs channels with more frames than fit in a tx")
+	require.Equal(1, txs[0].NumChannels())
+
+	nextTxData()
+	require.Len(m.channelQueue, 2)
+	require.Equal(2, txs[1].NumChannels())
+
+	// a failed tx with frames of multiple channels is resubmitted
+	m.TxFailed(txs[1].ID())
+	txs = txs[:1]
+	for {
+		txdata, err := m.TxData(eth.BlockID{})
+		if err == io.EOF {
+			break
+		}
+		require.NoError(err)
+		txs = append(txs, txdata)
+	}
+	require.Len(m.channelQueue, numBlocks)
+	require.Empty(m.blocks)
+
+	var (
+		numFrames   int
+		multi       bool
+		openOrder   []derive.ChannelID
+		closedOrder []derive.ChannelID
+	)
+	channelOrder := make([]derive.ChannelID, 0, numBlocks)
+	for _, ch := range m.channelQueue {
+		channelOrder = append(channelOrder, ch.ID())
+	}
+	for i, tx := range txs {
+		if i < len(txs)-1 {
+			require.Len(tx.frames, maxFrames, "only the last tx may not be full")
+		}
+		multi = multi || tx.NumChannels() > 1
+		for _, f := range tx.frames {
+			numFrames++
+			// the channel bank queues channels by their first included frame
+			if !slices.Contains(openOrder, f.id.chID) {
+				openOrder = append(openOrder, f.id.chID)
+			}
+			for _, ch := range m.channelQueue {
+				if ch.ID() == f.id.chID && ch.isClosingFrame(f) {
+					closedOrder = append(closedOrder, f.id.chID)
+				}
+			}
+		}
+	}
+	require.True(multi, "expected a tx with frames of multiple channels")
+	require.Equal(channelOrder, openOrder, "channels must be opened in order")
+	require.Equal(channelOrder, closedOrder, "channels must be closed in order")
+	totalFrames := 0
+	for _, ch := range m.channelQueue {
+		totalFrames += ch.TotalFrames()
+	}
+	require.Equal(totalFrames, numFrames)
+
+	for _, tx := range txs {
+		m.TxConfirmed(tx.ID(), eth.BlockID{Number: 1})
+	}
+	require.Empty(m.channelQueue, "all channels fully submitted")
+	require.Empty(m.txChannels)
+}
@@ -14,6 +14,7 @@ import (
 	oplog "github.com/zircuit-labs/zkr-monorepo-public/op-service/log"
 	opmetrics "github.com/zircuit-labs/zkr-monorepo-public/op-service/metrics"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/oppprof"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/optracing"
 	oprpc "github.com/zircuit-labs/zkr-monorepo-public/op-service/rpc"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/txmgr"
 )
@@ -59,6 +60,10 @@ type CLIConfig struct {
 	// per blob tx, if using Blob DA.
 	TargetNumFrames int
 
+	// The maximum number of channels to fill and submit frames from at the same time.
+	// If larger than 1, blob txs are packed with frames of multiple channels.
+	MaxConcurrentChannels int
+
 	// ApproxComprRatio to assume (only [compressor.RatioCompressor]).
 	// Should be slightly smaller than average from experiments to avoid the
 	// chances of creating a small additional leftover frame.
@@ -81,6 +86,9 @@ type CLIConfig struct {
 	// If 0, the batcher will just use the current head.
 	CheckRecentTxsDepth int
 
+	// Whether to force close channels of the batcher address which are stuck on L1.
+	ForceCloseChannels bool
+
 	BatchType uint
 
 	// DataAvailabilityType is one of the values defined in op-batcher/flags/types.go and dictates
@@ -99,6 +107,7 @@ type CLIConfig struct {
 	LogConfig     oplog.CLIConfig
 	MetricsConfig opmetrics.CLIConfig
 	PprofConfig   oppprof.CLIConfig
+	TracingConfig optracing.CLIConfig
 	RPC           oprpc.CLIConfig
 }
 
@@ -124,6 +133,9 @@ func (c *CLIConfig) Check() error {
 	if c.TargetNumFrames < 1 {
 		return errors.New("TargetNumFrames must be at least 1")
 	}
+	if c.MaxConcurrentChannels < 0 {
+		return errors.New("MaxConcurrentChannels must not be negative")
+	}
 	if c.Compressor == compressor.RatioKind && (c.ApproxComprRatio <= 0 || c.ApproxComprRatio > 1) {
 		return fmt.Errorf("invalid ApproxComprRatio %v for ratio compressor", c.ApproxComprRatio)
 	}
@@ -142,12 +154,18 @@ func (c *CLIConfig) Check() error {
 	if !flags.ValidDataAvailabilityType(c.DataAvailabilityType) {
 		return fmt.Errorf("unknown data availability type: %q", c.DataAvailabilityType)
 	}
+	if c.ForceCloseChannels && c.DataAvailabilityType != flags.CalldataType {
+		return fmt.Errorf("force closing channels is not supported with data availability type %q", c.DataAvailabilityType)
+	}
 	if err := c.MetricsConfig.Check(); err != nil {
 		return err
 	}
 	if err := c.PprofConfig.Check(); err != nil {
 		return err
 	}
+	if err := c.TracingConfig.Check(); err != nil {
+		return err
+	}
 	if err := c.TxMgrConfig.Check(); err != nil {
 		return err
 	}
@@ -172,12 +190,14 @@ func NewConfig(ctx *cli.Context) *CLIConfig {
 		MaxChannelDuration:           ctx.Uint64(flags.MaxChannelDurationFlag.Name),
 		MaxL1TxSize:                  ctx.Uint64(flags.MaxL1TxSizeBytesFlag.Name),
 		TargetNumFrames:              ctx.Int(flags.TargetNumFramesFlag.Name),
+		MaxConcurrentChannels:        ctx.Int(flags.MaxConcurrentChannelsFlag.Name),
 		ApproxComprRatio:             ctx.Float64(flags.ApproxComprRatioFlag.Name),
 		Compressor:                   ctx.String(flags.CompressorFlag.Name),
 		CompressionAlgo:              derive.CompressionAlgo(ctx.String(flags.CompressionAlgoFlag.Name)),
 		Stopped:                      ctx.Bool(flags.StoppedFlag.Name),
 		WaitNodeSync:                 ctx.Bool(flags.WaitNodeSyncFlag.Name),
 		CheckRecentTxsDepth:          ctx.Int(flags.CheckRecentTxsDepthFlag.Name),
+		ForceCloseChannels:           ctx.Bool(flags.ForceCloseChannelsFlag.Name),
 		BatchType:                    ctx.Uint(flags.BatchTypeFlag.Name),
 		DataAvailabilityType:         flags.DataAvailabilityType(ctx.String(flags.DataAvailabilityTypeFlag.Name)),
 		ActiveSequencerCheckDuration: ctx.Duration(flags.ActiveSequencerCheckDurationFlag.Name),
@@ -185,6 +205,7 @@ func NewConfig(ctx *cli.Context) *CLIConfig {
 		LogConfig:                    oplog.ReadCLIConfig(ctx),
 		MetricsConfig:                opmetrics.ReadCLIConfig(ctx),
 		PprofConfig:                  oppprof.ReadCLIConfig(ctx),
+		TracingConfig:                optracing.ReadCLIConfig(ctx),
 		RPC:                          oprpc.ReadCLIConfig(ctx),
 	}
 }
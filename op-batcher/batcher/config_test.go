@@ -97,6 +97,11 @@ func TestBatcherConfig(t *testing.T) {
 			override:  func(c *batcher.CLIConfig) { c.TargetNumFrames = 0 },
 			errString: "TargetNumFrames must be at least 1",
 		},
+		{
+			name:      "negative MaxConcurrentChannels",
+			override:  func(c *batcher.CLIConfig) { c.MaxConcurrentChannels = -1 },
+			errString: "MaxConcurrentChannels must not be negative",
+		},
 		{
 			name: "larger 6 TargetNumFrames for blobs",
 			override: func(c *batcher.CLIConfig) {
@@ -105,6 +110,14 @@ func TestBatcherConfig(t *testing.T) {
 			},
 			errString: "too many frames for blob transactions, max 6",
 		},
+		{
+			name: "force close channels with blobs",
+			override: func(c *batcher.CLIConfig) {
+				c.ForceCloseChannels = true
+				c.DataAvailabilityType = flags.BlobsType
+			},
+			errString: "force closing channels is not supported with data availability type \"blobs\"",
+		},
 		{
 			name: "invalid compr ratio for ratio compressor",
 			override: func(c *batcher.CLIConfig) {
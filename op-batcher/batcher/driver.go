@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"math/big"
 	_ "net/http/pprof"
 	"sync"
@@ -16,11 +17,15 @@ import (
 	"github.com/zircuit-labs/l2-geth-public/core"
 	"github.com/zircuit-labs/l2-geth-public/core/types"
 	"github.com/zircuit-labs/l2-geth-public/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/zircuit-labs/zkr-monorepo-public/op-batcher/metrics"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/dial"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/optracing"
 	l1eth "github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/l1/eth"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/txmgr"
 )
@@ -48,10 +53,13 @@ type txRef struct {
 	id       txID
 	isCancel bool
 	isBlob   bool
+	// forceClose is set for a transaction force closing a stuck channel, with its tx data
+	forceClose *stuckChannel
 }
 
 type L1Client interface {
 	HeaderByNumber(ctx context.Context, number *big.Int) (*l1types.Header, error)
+	BlockByNumber(ctx context.Context, number *big.Int) (*l1types.Block, error)
 	NonceAt(ctx context.Context, account l1common.Address, blockNumber *big.Int) (uint64, error)
 }
 
@@ -75,6 +83,8 @@ type DriverSetup struct {
 	ChannelConfig    ChannelConfigProvider
 }
 
+var tracer = optracing.Tracer("github.com/zircuit-labs/zkr-monorepo-public/op-batcher/batcher")
+
 // BatchSubmitter encapsulates a service responsible for submitting L2 tx
 // batches to L1 for availability.
 type BatchSubmitter struct {
@@ -95,13 +105,20 @@ type BatchSubmitter struct {
 	lastL1Tip       eth.L1BlockRef
 
 	state *channelManager
+	// channels tracks the channels of the batcher address on L1, to force close the stuck ones
+	channels *channelTracker
 }
 
 // NewBatchSubmitter initializes the BatchSubmitter driver from a preconfigured DriverSetup
 func NewBatchSubmitter(setup DriverSetup) *BatchSubmitter {
+	var from l1common.Address
+	if setup.Txmgr != nil {
+		from = l1common.Address(setup.Txmgr.From())
+	}
 	return &BatchSubmitter{
 		DriverSetup: setup,
 		state:       NewChannelManager(setup.Log, setup.Metr, setup.ChannelConfig, setup.RollupConfig),
+		channels:    newChannelTracker(setup.Log, setup.RollupConfig, from),
 	}
 }
 
@@ -211,7 +228,10 @@ func (l *BatchSubmitter) loadBlocksIntoState(ctx context.Context) error {
 }
 
 // loadBlockIntoState fetches & stores a single block into `state`. It returns the block it loaded.
-func (l *BatchSubmitter) loadBlockIntoState(ctx context.Context, blockNumber uint64) (*types.Block, *types.L1Info, error) {
+func (l *BatchSubmitter) loadBlockIntoState(ctx context.Context, blockNumber uint64) (_ *types.Block, _ *types.L1Info, err error) {
+	ctx, span := tracer.Start(ctx, "batcher.load-block", trace.WithAttributes(attribute.Int64("number", int64(blockNumber))))
+	defer func() { optracing.EndSpan(span, err) }()
+
 	l2Client, err := l.EndpointProvider.EthClient(ctx)
 	if err != nil {
 		return nil, nil, fmt.Errorf("getting L2 client: %w", err)
@@ -387,6 +407,9 @@ func (l *BatchSubmitter) loop() {
 				continue
 			}
 			l.publishStateToL1(queue, receiptsCh)
+			if l.Config.ForceCloseChannels {
+				l.forceCloseStuckChannels(queue, receiptsCh)
+			}
 		case <-l.shutdownCtx.Done():
 			if l.Txmgr.IsClosed() {
 				l.Log.Info("Txmgr is closed, remaining channel data won't be sent")
@@ -562,13 +585,19 @@ func (l *BatchSubmitter) cancelBlockingTx(queue *txmgr.Queue[txRef], receiptsCh
 		panic(err) // this error should not happen
 	}
 	l.Log.Warn("sending a cancellation transaction to unblock txpool", "blocked_blob", isBlockedBlob)
-	l.queueTx(txData{}, true, candidate, queue, receiptsCh)
+	l.queueTx(l.killCtx, txRef{isCancel: true}, candidate, queue, receiptsCh)
 }
 
 // sendTransaction creates & queues for sending a transaction to the batch inbox address with the given `txData`.
 // The method will block if the queue's MaxPendingTransactions is exceeded.
-func (l *BatchSubmitter) sendTransaction(ctx context.Context, txdata txData, queue *txmgr.Queue[txRef], receiptsCh chan txmgr.TxReceipt[txRef]) error {
-	var err error
+func (l *BatchSubmitter) sendTransaction(ctx context.Context, txdata txData, queue *txmgr.Queue[txRef], receiptsCh chan txmgr.TxReceipt[txRef]) (err error) {
+	ctx, span := tracer.Start(ctx, "batcher.send-transaction", trace.WithAttributes(
+		attribute.String("tx_id", txdata.ID().String()),
+		attribute.Int("frames", len(txdata.frames)),
+		attribute.Int("size", txdata.Len()),
+		attribute.Bool("blob", txdata.asBlob),
+	))
+	defer func() { optracing.EndSpan(span, err) }()
 	// Do the gas estimation offline. A value of 0 will cause the [txmgr] to estimate the gas limit.
 
 	var candidate *txmgr.TxCandidate
@@ -589,11 +618,13 @@ func (l *BatchSubmitter) sendTransaction(ctx context.Context, txdata txData, que
 		candidate = l.calldataTxCandidate(data)
 	}
 
-	l.queueTx(txdata, false, candidate, queue, receiptsCh)
+	l.Metr.RecordTxFrames(len(txdata.frames), txdata.NumChannels())
+	l.queueTx(ctx, txRef{id: txdata.ID(), isBlob: txdata.asBlob}, candidate, queue, receiptsCh)
 	return nil
 }
 
-func (l *BatchSubmitter) queueTx(txdata txData, isCancel bool, candidate *txmgr.TxCandidate, queue *txmgr.Queue[txRef], receiptsCh chan txmgr.TxReceipt[txRef]) {
+// queueTx queues the transaction for sending, continuing the trace of ctx, if any.
+func (l *BatchSubmitter) queueTx(ctx context.Context, ref txRef, candidate *txmgr.TxCandidate, queue *txmgr.Queue[txRef], receiptsCh chan txmgr.TxReceipt[txRef]) {
 	intrinsicGas, err := core.IntrinsicGas(candidate.TxData, nil, false, true, true, false)
 	if err != nil {
 		// we log instead of return an error here because txmgr can do its own gas estimation
@@ -602,7 +633,67 @@ func (l *BatchSubmitter) queueTx(txdata txData, isCancel bool, candidate *txmgr.
 		candidate.GasLimit = intrinsicGas
 	}
 
-	queue.Send(txRef{id: txdata.ID(), isCancel: isCancel, isBlob: txdata.asBlob}, *candidate, receiptsCh)
+	queue.Send(ctx, ref, *candidate, receiptsCh)
+}
+
+// stuckChannels scans at most maxBlocks L1 blocks towards the current tip for frames of the batcher
+// address. Once the scan reached the tip, it returns the channels which are stuck, see
+// channelTracker.Stuck. Before that, channels may look stuck because of frames not scanned yet.
+func (l *BatchSubmitter) stuckChannels(ctx context.Context, maxBlocks uint64) ([]stuckChannel, error) {
+	l1tip, err := l.l1Tip(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query L1 tip: %w", err)
+	}
+	done, err := l.channels.Update(ctx, l.L1Client, l1tip, maxBlocks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan L1 for batcher frames: %w", err)
+	}
+	if !done {
+		return nil, nil
+	}
+	return l.channels.Stuck(l1tip.Number, l.state.HasChannel), nil
+}
+
+// forceCloseStuckChannels queues a transaction force closing each stuck channel. It scans at most
+// forceCloseScanBlocks L1 blocks per call, so the first scan is spread over several ticks.
+func (l *BatchSubmitter) forceCloseStuckChannels(queue *txmgr.Queue[txRef], receiptsCh chan txmgr.TxReceipt[txRef]) {
+	ctx, cancel := context.WithTimeout(l.killCtx, l.Config.NetworkTimeout)
+	stuck, err := l.stuckChannels(ctx, forceCloseScanBlocks)
+	cancel()
+	if err != nil {
+		l.Log.Warn("Failed to find stuck channels", "err", err)
+		return
+	}
+	for i := range stuck {
+		l.Log.Warn("Force closing stuck channel", "channel", stuck[i].id)
+		l.queueTx(l.killCtx, txRef{forceClose: &stuck[i]}, l.calldataTxCandidate(stuck[i].data), queue, receiptsCh)
+	}
+}
+
+// ForceCloseChannels force closes the channels of the batcher address which are stuck on L1,
+// and returns the IDs of the channels once their force close transactions are confirmed.
+// Channels submitted in blobs are not tracked, so it fails if the batcher submits blobs.
+func (l *BatchSubmitter) ForceCloseChannels(ctx context.Context) ([]derive.ChannelID, error) {
+	if l.ChannelConfig.ChannelConfig().UseBlobs {
+		return nil, errors.New("force closing channels is not supported with blob data availability")
+	}
+	stuck, err := l.stuckChannels(ctx, math.MaxUint64)
+	if err != nil {
+		return nil, err
+	}
+	closed := make([]derive.ChannelID, 0, len(stuck))
+	var errs []error
+	for i := range stuck {
+		l.Log.Warn("Force closing stuck channel", "channel", stuck[i].id)
+		receipt, err := l.Txmgr.Send(ctx, *l.calldataTxCandidate(stuck[i].data))
+		l.recordForceClose(&stuck[i], receipt, err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to force close channel %s: %w", stuck[i].id, err))
+			continue
+		}
+		closed = append(closed, stuck[i].id)
+	}
+	return closed, errors.Join(errs...)
 }
 
 func (l *BatchSubmitter) blobTxCandidate(data txData) (*txmgr.TxCandidate, error) {
@@ -630,6 +721,10 @@ func (l *BatchSubmitter) calldataTxCandidate(data []byte) *txmgr.TxCandidate {
 }
 
 func (l *BatchSubmitter) handleReceipt(r txmgr.TxReceipt[txRef]) {
+	if r.ID.forceClose != nil {
+		l.recordForceClose(r.ID.forceClose, r.Receipt, r.Err)
+		return
+	}
 	// Record TX Status
 	if r.Err != nil {
 		l.recordFailedTx(r.ID.id, r.Err)
@@ -657,6 +752,18 @@ func (l *BatchSubmitter) recordConfirmedTx(id txID, receipt *types.Receipt) {
 	l.state.TxConfirmed(id, l1block)
 }
 
+func (l *BatchSubmitter) recordForceClose(sc *stuckChannel, receipt *types.Receipt, err error) {
+	if err != nil {
+		l.Log.Warn("Force close transaction failed to send", "channel", sc.id, "err", err)
+		l.channels.Closed(sc.id, sc.data, nil)
+		return
+	}
+	l1block := eth.ReceiptBlockID(receipt)
+	l.Log.Info("Force closed channel", "channel", sc.id, "tx", receipt.TxHash, "block", l1block)
+	l.Metr.RecordChannelForceClosed(sc.id)
+	l.channels.Closed(sc.id, sc.data, &eth.L1BlockRef{Hash: l1block.Hash, Number: l1block.Number})
+}
+
 // l1Tip gets the current L1 tip as a L1BlockRef. The passed context is assumed
 // to be a lifetime context, so it is internally wrapped with a network timeout.
 func (l *BatchSubmitter) l1Tip(ctx context.Context) (eth.L1BlockRef, error) {
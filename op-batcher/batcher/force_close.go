@@ -0,0 +1,214 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	l1common "github.com/ethereum/go-ethereum/common"
+	l1types "github.com/ethereum/go-ethereum/core/types"
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	l1eth "github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/l1/eth"
+)
+
+// forceCloseIdleBlocks is the number of L1 blocks without a new frame after which an incomplete
+// channel that is not submitted by this batcher anymore is considered stuck. It leaves time for
+// the remaining frames of a channel that is still being submitted to be included.
+const forceCloseIdleBlocks = 10
+
+// forceCloseScanBlocks is the maximum number of L1 blocks scanned for frames per batcher tick, so
+// that the first scan over the channel timeout window does not hold up the batch submission.
+const forceCloseScanBlocks = 32
+
+type trackedChannel struct {
+	ch *derive.Channel
+	// frames of the channel, in the order they were included on L1, without their data
+	frames []derive.Frame
+}
+
+// stuckChannel is an incomplete channel on L1, with the frames it needs to be closed.
+type stuckChannel struct {
+	id   derive.ChannelID
+	data []byte
+}
+
+// channelTracker follows the frames the batcher address submitted to the batch inbox on L1 within
+// the channel timeout, as the channel bank of the derivation pipeline sees them. It finds the
+// channels which will never be completed, e.g. because a frame got lost or a previous batcher
+// instance stopped in the middle of a channel. These channels occupy the channel bank until they
+// time out, unless they get force closed.
+// Frames in blob transactions are not tracked, as the batcher has no beacon endpoint. So channels
+// submitted in blobs are never found stuck, force closing is only supported with calldata.
+type channelTracker struct {
+	mu      sync.Mutex
+	log     log.Logger
+	timeout uint64
+	from    l1common.Address
+	inbox   l1common.Address
+	signer  l1types.Signer
+
+	channels map[derive.ChannelID]*trackedChannel
+	// channels with an in-flight force close transaction
+	closing map[derive.ChannelID]struct{}
+	// last scanned L1 block
+	scanned eth.L1BlockRef
+}
+
+func newChannelTracker(log log.Logger, rollupCfg *rollup.Config, from l1common.Address) *channelTracker {
+	return &channelTracker{
+		log:      log,
+		timeout:  rollupCfg.ChannelTimeoutBedrock,
+		from:     from,
+		inbox:    l1common.Address(rollupCfg.BatchInboxAddress),
+		signer:   l1types.LatestSignerForChainID(rollupCfg.L1ChainID),
+		channels: make(map[derive.ChannelID]*trackedChannel),
+		closing:  make(map[derive.ChannelID]struct{}),
+	}
+}
+
+// Update scans at most maxBlocks L1 blocks after the last scanned block towards the given head, and
+// returns whether the head is reached. On the first scan and after a L1 reorg, it starts at the
+// oldest block a channel can still be open at.
+func (t *channelTracker) Update(ctx context.Context, client L1Client, head eth.L1BlockRef, maxBlocks uint64) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.scanned != (eth.L1BlockRef{}) {
+		header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(t.scanned.Number))
+		if err != nil {
+			return false, fmt.Errorf("failed to fetch last scanned L1 block %d: %w", t.scanned.Number, err)
+		}
+		if header.Hash() != l1common.Hash(t.scanned.Hash) {
+			t.log.Warn("L1 reorg detected, rescanning batcher frames", "scanned", t.scanned)
+			t.channels = make(map[derive.ChannelID]*trackedChannel)
+			t.closing = make(map[derive.ChannelID]struct{})
+			t.scanned = eth.L1BlockRef{}
+		}
+	}
+
+	start := uint64(0)
+	if head.Number > t.timeout {
+		start = head.Number - t.timeout
+	}
+	if t.scanned != (eth.L1BlockRef{}) {
+		start = max(start, t.scanned.Number+1)
+	}
+	end := head.Number
+	if start <= end && end-start >= maxBlocks {
+		end = start + maxBlocks - 1
+	}
+	for num := start; num <= end; num++ {
+		block, err := client.BlockByNumber(ctx, new(big.Int).SetUint64(num))
+		if err != nil {
+			return false, fmt.Errorf("failed to fetch L1 block %d: %w", num, err)
+		}
+		t.addBlock(block)
+	}
+	return end == head.Number, nil
+}
+
+func (t *channelTracker) addBlock(block *l1types.Block) {
+	ref := l1eth.ConvertToL1BlockRef(l1eth.InfoToBlockRef(l1eth.BlockToInfo(block)))
+	for _, tx := range block.Transactions() {
+		if to := tx.To(); to == nil || *to != t.inbox {
+			continue
+		}
+		if sender, err := l1types.Sender(t.signer, tx); err != nil || sender != t.from {
+			continue
+		}
+		if tx.Type() == l1types.BlobTxType {
+			t.log.Debug("Skipping frames of blob transaction", "tx", tx.Hash(), "block", ref.ID())
+			continue
+		}
+		frames, err := derive.ParseFrames(tx.Data())
+		if err != nil {
+			t.log.Warn("Failed to parse frames of batcher transaction", "tx", tx.Hash(), "block", ref.ID(), "err", err)
+			continue
+		}
+		t.addFrames(frames, ref)
+	}
+	t.scanned = ref
+}
+
+// AddFrames adds frames which got included in the given L1 block.
+func (t *channelTracker) AddFrames(frames []derive.Frame, inclusion eth.L1BlockRef) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.addFrames(frames, inclusion)
+}
+
+func (t *channelTracker) addFrames(frames []derive.Frame, inclusion eth.L1BlockRef) {
+	for _, frame := range frames {
+		tc, ok := t.channels[frame.ID]
+		if !ok {
+			tc = &trackedChannel{ch: derive.NewChannel(frame.ID, inclusion)}
+			t.channels[frame.ID] = tc
+		}
+		if err := tc.ch.AddFrame(frame, inclusion); errors.Is(err, derive.ErrDuplicate) {
+			continue
+		} else if err != nil {
+			t.log.Warn("Dropping invalid frame", "channel", frame.ID, "frame", frame.FrameNumber, "err", err)
+			continue
+		}
+		tc.frames = append(tc.frames, derive.Frame{ID: frame.ID, FrameNumber: frame.FrameNumber, IsLast: frame.IsLast})
+	}
+}
+
+// Stuck returns the channels at the given L1 head which are incomplete, not active and idle for
+// forceCloseIdleBlocks blocks, and marks them as closing until Closed is called for them.
+// Channels which timed out are dropped, their frames would be ignored by the channel bank.
+func (t *channelTracker) Stuck(head uint64, active func(derive.ChannelID) bool) []stuckChannel {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var stuck []stuckChannel
+	for id, tc := range t.channels {
+		if tc.ch.OpenBlockNumber()+t.timeout < head {
+			delete(t.channels, id)
+			delete(t.closing, id)
+			continue
+		}
+		if _, ok := t.closing[id]; ok || tc.ch.IsReady() || active(id) {
+			continue
+		}
+		if tc.ch.HighestBlock().Number+forceCloseIdleBlocks > head {
+			continue
+		}
+		data, err := derive.ForceCloseTxData(tc.frames)
+		if err != nil {
+			t.log.Warn("Cannot force close channel", "channel", id, "err", err)
+			continue
+		}
+		if len(data) <= 1 {
+			// only the version byte, all frames are on L1 already
+			continue
+		}
+		t.closing[id] = struct{}{}
+		stuck = append(stuck, stuckChannel{id: id, data: data})
+	}
+	return stuck
+}
+
+// Closed records the result of the force close transaction of a channel. If it got included, its
+// frames are tracked right away, so that the channel is not considered stuck again.
+// Otherwise the channel can be returned by Stuck again.
+func (t *channelTracker) Closed(id derive.ChannelID, data []byte, inclusion *eth.L1BlockRef) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.closing, id)
+	if inclusion == nil {
+		return
+	}
+	frames, err := derive.ParseFrames(data)
+	if err != nil {
+		t.log.Error("Failed to parse frames of force close transaction", "channel", id, "err", err)
+		return
+	}
+	t.addFrames(frames, *inclusion)
+}
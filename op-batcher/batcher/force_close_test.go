@@ -0,0 +1,208 @@
+package batcher
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+
+	l1common "github.com/ethereum/go-ethereum/common"
+	l1types "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/require"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testlog"
+)
+
+// fakeL1 is an L1 chain of blocks with batcher transactions, serving the L1Client interface.
+type fakeL1 struct {
+	signer l1types.Signer
+	inbox  l1common.Address
+	blocks []*l1types.Block
+	nonce  uint64
+}
+
+func newFakeL1(cfg *rollup.Config) *fakeL1 {
+	l1 := &fakeL1{
+		signer: l1types.LatestSignerForChainID(cfg.L1ChainID),
+		inbox:  l1common.Address(cfg.BatchInboxAddress),
+	}
+	l1.blocks = append(l1.blocks, l1types.NewBlockWithHeader(&l1types.Header{Number: new(big.Int)}))
+	return l1
+}
+
+// addBlock adds a block with a calldata transaction to the inbox, sent by key, for every tx data.
+func (l1 *fakeL1) addBlock(t *testing.T, key *ecdsa.PrivateKey, txs ...[]byte) eth.L1BlockRef {
+	parent := l1.blocks[len(l1.blocks)-1]
+	var body l1types.Body
+	for _, data := range txs {
+		tx, err := l1types.SignNewTx(key, l1.signer, &l1types.DynamicFeeTx{
+			ChainID: l1.signer.ChainID(),
+			Nonce:   l1.nonce,
+			To:      &l1.inbox,
+			Data:    data,
+		})
+		require.NoError(t, err)
+		l1.nonce++
+		body.Transactions = append(body.Transactions, tx)
+	}
+	header := &l1types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		Time:       parent.Time() + 12,
+	}
+	block := l1types.NewBlock(header, &body, nil, trie.NewStackTrie(nil))
+	l1.blocks = append(l1.blocks, block)
+	return l1.head()
+}
+
+// reorg replaces the blocks after the given number with empty blocks.
+func (l1 *fakeL1) reorg(num uint64) {
+	for i := num + 1; i < uint64(len(l1.blocks)); i++ {
+		l1.blocks[i] = l1types.NewBlockWithHeader(&l1types.Header{
+			ParentHash: l1.blocks[i-1].Hash(),
+			Number:     new(big.Int).SetUint64(i),
+			Extra:      []byte("reorg"),
+		})
+	}
+}
+
+func (l1 *fakeL1) head() eth.L1BlockRef {
+	b := l1.blocks[len(l1.blocks)-1]
+	return eth.L1BlockRef{Hash: common.Hash(b.Hash()), Number: b.NumberU64(), ParentHash: common.Hash(b.ParentHash()), Time: b.Time()}
+}
+
+func (l1 *fakeL1) block(number *big.Int) (*l1types.Block, error) {
+	if number == nil {
+		return l1.blocks[len(l1.blocks)-1], nil
+	}
+	if n := number.Uint64(); n < uint64(len(l1.blocks)) {
+		return l1.blocks[n], nil
+	}
+	return nil, fmt.Errorf("unknown block %v", number)
+}
+
+func (l1 *fakeL1) HeaderByNumber(_ context.Context, number *big.Int) (*l1types.Header, error) {
+	b, err := l1.block(number)
+	if err != nil {
+		return nil, err
+	}
+	return b.Header(), nil
+}
+
+func (l1 *fakeL1) BlockByNumber(_ context.Context, number *big.Int) (*l1types.Block, error) {
+	return l1.block(number)
+}
+
+func (l1 *fakeL1) NonceAt(context.Context, l1common.Address, *big.Int) (uint64, error) {
+	return l1.nonce, nil
+}
+
+func frameTxData(t *testing.T, frames ...derive.Frame) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(derive.DerivationVersion0)
+	for _, f := range frames {
+		require.NoError(t, f.MarshalBinary(&buf))
+	}
+	return buf.Bytes()
+}
+
+func TestChannelTracker(t *testing.T) {
+	cfg := &rollup.Config{
+		L1ChainID:             big.NewInt(900),
+		BatchInboxAddress:     common.Address{0xff, 0x42},
+		ChannelTimeoutBedrock: 50,
+	}
+	batcherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	otherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	l1 := newFakeL1(cfg)
+	tracker := newChannelTracker(testlog.Logger(t, log.LevelDebug), cfg, crypto.PubkeyToAddress(batcherKey.PublicKey))
+	ctx := context.Background()
+	notActive := func(derive.ChannelID) bool { return false }
+
+	timedOut := derive.ChannelID{0x01}
+	incomplete := derive.ChannelID{0x02}
+	complete := derive.ChannelID{0x03}
+	other := derive.ChannelID{0x04}
+	data := []byte{0xde, 0xad}
+
+	l1.addBlock(t, batcherKey, frameTxData(t, derive.Frame{ID: timedOut, FrameNumber: 0, Data: data}))
+	for i := 0; i < int(cfg.ChannelTimeoutBedrock); i++ {
+		l1.addBlock(t, batcherKey)
+	}
+	l1.addBlock(t, batcherKey,
+		frameTxData(t, derive.Frame{ID: incomplete, FrameNumber: 0, Data: data}),
+		frameTxData(t, derive.Frame{ID: complete, FrameNumber: 0, Data: data}))
+	l1.addBlock(t, otherKey, frameTxData(t, derive.Frame{ID: other, FrameNumber: 0, Data: data}))
+	head := l1.addBlock(t, batcherKey,
+		frameTxData(t, derive.Frame{ID: incomplete, FrameNumber: 2, Data: data}),
+		frameTxData(t, derive.Frame{ID: complete, FrameNumber: 1, Data: data, IsLast: true}))
+
+	// the first scan over the channel timeout window is spread over several updates
+	done, err := tracker.Update(ctx, l1, head, forceCloseScanBlocks)
+	require.NoError(t, err)
+	require.False(t, done)
+	require.Equal(t, head.Number-cfg.ChannelTimeoutBedrock+forceCloseScanBlocks-1, tracker.scanned.Number)
+	require.NotContains(t, tracker.channels, incomplete, "not scanned yet")
+	done, err = tracker.Update(ctx, l1, head, forceCloseScanBlocks)
+	require.NoError(t, err)
+	require.True(t, done)
+	require.Equal(t, head, tracker.scanned)
+	require.NotContains(t, tracker.channels, timedOut, "channel opened before the channel timeout window")
+	require.NotContains(t, tracker.channels, other, "channel of another sender")
+	require.Contains(t, tracker.channels, complete)
+	require.Contains(t, tracker.channels, incomplete)
+	require.Empty(t, tracker.Stuck(head.Number, notActive), "incomplete channel is not idle yet")
+
+	for i := 0; i < forceCloseIdleBlocks; i++ {
+		head = l1.addBlock(t, batcherKey)
+	}
+	done, err = tracker.Update(ctx, l1, head, forceCloseScanBlocks)
+	require.NoError(t, err)
+	require.True(t, done)
+	require.Empty(t, tracker.Stuck(head.Number, func(id derive.ChannelID) bool { return id == incomplete }),
+		"active channel is not stuck")
+
+	stuck := tracker.Stuck(head.Number, notActive)
+	require.Len(t, stuck, 1)
+	require.Equal(t, incomplete, stuck[0].id)
+	closeFrames, err := derive.ParseFrames(stuck[0].data)
+	require.NoError(t, err)
+	require.Equal(t, []derive.Frame{
+		{ID: incomplete, FrameNumber: 1, Data: []byte{}},
+		{ID: incomplete, FrameNumber: 3, Data: []byte{}, IsLast: true},
+	}, closeFrames)
+	require.Empty(t, tracker.Stuck(head.Number, notActive), "channel is closing")
+
+	// a failed force close makes the channel stuck again
+	tracker.Closed(incomplete, stuck[0].data, nil)
+	stuck = tracker.Stuck(head.Number, notActive)
+	require.Len(t, stuck, 1)
+
+	// the reorged blocks are scanned again, the force close tx is included in a new block
+	l1.reorg(head.Number - 1)
+	head = l1.addBlock(t, batcherKey, stuck[0].data)
+	done, err = tracker.Update(ctx, l1, head, math.MaxUint64)
+	require.NoError(t, err)
+	require.True(t, done)
+	require.Empty(t, tracker.closing, "closing channels are reset with the reorg")
+	tracker.Closed(incomplete, stuck[0].data, &head)
+	require.True(t, tracker.channels[incomplete].ch.IsReady())
+	require.Empty(t, tracker.Stuck(head.Number+forceCloseIdleBlocks, notActive))
+
+	// channels are dropped once they time out
+	tracker.Stuck(head.Number+cfg.ChannelTimeoutBedrock, notActive)
+	require.Empty(t, tracker.channels)
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/httputil"
 	opmetrics "github.com/zircuit-labs/zkr-monorepo-public/op-service/metrics"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/oppprof"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/optracing"
 	oprpc "github.com/zircuit-labs/zkr-monorepo-public/op-service/rpc"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/txmgr"
 )
@@ -38,6 +39,7 @@ type BatcherConfig struct {
 
 	WaitNodeSync        bool
 	CheckRecentTxsDepth int
+	ForceCloseChannels  bool
 }
 
 // BatcherService represents a full batch-submitter instance and its resources,
@@ -58,9 +60,10 @@ type BatcherService struct {
 
 	Version string
 
-	pprofService *oppprof.Service
-	metricsSrv   *httputil.HTTPServer
-	rpcServer    *oprpc.Server
+	pprofService   *oppprof.Service
+	tracingService *optracing.Service
+	metricsSrv     *httputil.HTTPServer
+	rpcServer      *oprpc.Server
 
 	balanceMetricer io.Closer
 	stopped         atomic.Bool
@@ -85,12 +88,16 @@ func (bs *BatcherService) initFromCLIConfig(ctx context.Context, version string,
 	bs.NotSubmittingOnStart = cfg.Stopped
 
 	bs.initMetrics(cfg)
+	if err := bs.initTracing(ctx, cfg); err != nil {
+		return fmt.Errorf("failed to init tracing: %w", err)
+	}
 
 	bs.PollInterval = cfg.PollInterval
 	bs.MaxPendingTransactions = cfg.MaxPendingTransactions
 	bs.NetworkTimeout = cfg.TxMgrConfig.NetworkTimeout
 	bs.CheckRecentTxsDepth = cfg.CheckRecentTxsDepth
 	bs.WaitNodeSync = cfg.WaitNodeSync
+	bs.ForceCloseChannels = cfg.ForceCloseChannels
 	if err := bs.initRPCClients(ctx, cfg); err != nil {
 		return err
 	}
@@ -178,13 +185,14 @@ func (bs *BatcherService) initRollupConfig(ctx context.Context) error {
 
 func (bs *BatcherService) initChannelConfig(cfg *CLIConfig) error {
 	cc := ChannelConfig{
-		SeqWindowSize:      bs.RollupConfig.SeqWindowSize,
-		ChannelTimeout:     bs.RollupConfig.ChannelTimeoutBedrock,
-		MaxChannelDuration: cfg.MaxChannelDuration,
-		MaxFrameSize:       cfg.MaxL1TxSize - 1, // account for version byte prefix; reset for blobs
-		TargetNumFrames:    cfg.TargetNumFrames,
-		SubSafetyMargin:    cfg.SubSafetyMargin,
-		BatchType:          cfg.BatchType,
+		SeqWindowSize:         bs.RollupConfig.SeqWindowSize,
+		ChannelTimeout:        bs.RollupConfig.ChannelTimeoutBedrock,
+		MaxChannelDuration:    cfg.MaxChannelDuration,
+		MaxFrameSize:          cfg.MaxL1TxSize - 1, // account for version byte prefix; reset for blobs
+		TargetNumFrames:       cfg.TargetNumFrames,
+		SubSafetyMargin:       cfg.SubSafetyMargin,
+		BatchType:             cfg.BatchType,
+		MaxConcurrentChannels: cfg.MaxConcurrentChannels,
 	}
 
 	switch cfg.DataAvailabilityType {
@@ -215,6 +223,7 @@ func (bs *BatcherService) initChannelConfig(cfg *CLIConfig) error {
 		"da_type", cfg.DataAvailabilityType,
 		"max_frame_size", cc.MaxFrameSize,
 		"target_num_frames", cc.TargetNumFrames,
+		"max_concurrent_channels", cc.MaxConcurrentChannels,
 		"compressor", cc.CompressorConfig.Kind,
 		"compression_algo", cc.CompressorConfig.CompressionAlgo,
 		"batch_type", cc.BatchType,
@@ -246,6 +255,15 @@ func (bs *BatcherService) initTxManager(cfg *CLIConfig) error {
 	return nil
 }
 
+func (bs *BatcherService) initTracing(ctx context.Context, cfg *CLIConfig) error {
+	tracingService, err := optracing.Start(ctx, "op-batcher", bs.Version, cfg.TracingConfig)
+	if err != nil {
+		return err
+	}
+	bs.tracingService = tracingService
+	return nil
+}
+
 func (bs *BatcherService) initPProf(cfg *CLIConfig) error {
 	bs.pprofService = oppprof.New(
 		cfg.PprofConfig.ListenEnabled,
@@ -390,6 +408,10 @@ func (bs *BatcherService) Stop(ctx context.Context) error {
 		bs.EndpointProvider.Close()
 	}
 
+	if err := bs.tracingService.Stop(ctx); err != nil {
+		result = errors.Join(result, fmt.Errorf("failed to stop tracing: %w", err))
+	}
+
 	if result == nil {
 		bs.stopped.Store(true)
 		bs.Log.Info("Batch Submitter stopped")
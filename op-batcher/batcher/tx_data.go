@@ -10,9 +10,9 @@ import (
 
 // txData represents the data for a single transaction.
 //
-// Note: The batcher currently sends exactly one frame per transaction. This
-// might change in the future to allow for multiple frames from possibly
-// different channels.
+// A calldata transaction has exactly one frame, a blob transaction has one frame
+// per blob. In multi-channel mode, the frames of a blob transaction can be of
+// different channels, see ChannelConfig.MaxConcurrentChannels.
 type txData struct {
 	frames []frameData
 	asBlob bool // indicates whether this should be sent as blob
@@ -63,6 +63,15 @@ func (td *txData) Len() (l int) {
 	return l
 }
 
+// NumChannels returns the number of channels with frames in this tx data.
+func (td *txData) NumChannels() int {
+	channels := make(map[derive.ChannelID]struct{})
+	for _, f := range td.frames {
+		channels[f.id.chID] = struct{}{}
+	}
+	return len(channels)
+}
+
 // Frames returns the single frame of this tx data.
 func (td *txData) Frames() []frameData {
 	return td.frames
@@ -15,6 +15,7 @@ import (
 	oplog "github.com/zircuit-labs/zkr-monorepo-public/op-service/log"
 	opmetrics "github.com/zircuit-labs/zkr-monorepo-public/op-service/metrics"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/oppprof"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/optracing"
 	oprpc "github.com/zircuit-labs/zkr-monorepo-public/op-service/rpc"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/txmgr"
 )
@@ -81,6 +82,13 @@ var (
 		Value:   1,
 		EnvVars: prefixEnvVars("TARGET_NUM_FRAMES"),
 	}
+	MaxConcurrentChannelsFlag = &cli.IntFlag{
+		Name: "max-concurrent-channels",
+		Usage: "The maximum number of channels to fill and submit frames from at the same time. If larger than 1, " +
+			"blob txs are packed with frames of multiple channels. 0 and 1 submit frames of a single channel per tx.",
+		Value:   1,
+		EnvVars: prefixEnvVars("MAX_CONCURRENT_CHANNELS"),
+	}
 	ApproxComprRatioFlag = &cli.Float64Flag{
 		Name:    "approx-compr-ratio",
 		Usage:   "The approximate compression ratio (<= 1.0). Only relevant for ratio compressor.",
@@ -150,6 +158,15 @@ var (
 		Value:   false,
 		EnvVars: prefixEnvVars("WAIT_NODE_SYNC"),
 	}
+	ForceCloseChannelsFlag = &cli.BoolFlag{
+		Name: "force-close-channels",
+		Usage: "Indicates if the batcher should force close channels of the batcher address which are stuck on L1, " +
+			"e.g. because a frame got lost or a previous batcher stopped in the middle of a channel. " +
+			"Stuck channels can also be force closed via the admin RPC. " +
+			"Only supported with calldata data availability, frames in blobs are not tracked.",
+		Value:   false,
+		EnvVars: prefixEnvVars("FORCE_CLOSE_CHANNELS"),
+	}
 	// Legacy Flags
 	SequencerHDPathFlag = txmgr.SequencerHDPathFlag
 )
@@ -163,12 +180,14 @@ var requiredFlags = []cli.Flag{
 var optionalFlags = []cli.Flag{
 	WaitNodeSyncFlag,
 	CheckRecentTxsDepthFlag,
+	ForceCloseChannelsFlag,
 	SubSafetyMarginFlag,
 	PollIntervalFlag,
 	MaxPendingTransactionsFlag,
 	MaxChannelDurationFlag,
 	MaxL1TxSizeBytesFlag,
 	TargetNumFramesFlag,
+	MaxConcurrentChannelsFlag,
 	ApproxComprRatioFlag,
 	CompressorFlag,
 	StoppedFlag,
@@ -184,6 +203,7 @@ func init() {
 	optionalFlags = append(optionalFlags, oplog.CLIFlags(EnvVarPrefix)...)
 	optionalFlags = append(optionalFlags, opmetrics.CLIFlags(EnvVarPrefix)...)
 	optionalFlags = append(optionalFlags, oppprof.CLIFlags(EnvVarPrefix)...)
+	optionalFlags = append(optionalFlags, optracing.CLIFlags(EnvVarPrefix)...)
 	optionalFlags = append(optionalFlags, txmgr.CLIFlags(EnvVarPrefix)...)
 
 	Flags = append(requiredFlags, optionalFlags...)
@@ -42,6 +42,7 @@ type Metricer interface {
 	RecordChannelClosed(id derive.ChannelID, numPendingBlocks int, numFrames int, inputBytes int, outputComprBytes int, reason error)
 	RecordChannelFullySubmitted(id derive.ChannelID)
 	RecordChannelTimedOut(id derive.ChannelID)
+	RecordChannelForceClosed(id derive.ChannelID)
 
 	RecordBatchTxSubmitted()
 	RecordBatchTxSuccess()
@@ -49,6 +50,9 @@ type Metricer interface {
 
 	RecordBlobUsedBytes(num int)
 
+	RecordConcurrentChannels(num int)
+	RecordTxFrames(numFrames, numChannels int)
+
 	Document() []opmetrics.DocumentedMetric
 }
 
@@ -84,6 +88,10 @@ type Metrics struct {
 	batcherTxEvs opmetrics.EventVec
 
 	blobUsedBytes prometheus.Histogram
+
+	concurrentChannels prometheus.Gauge
+	txFrames           prometheus.Histogram
+	txChannels         prometheus.Histogram
 }
 
 var _ Metricer = (*Metrics)(nil)
@@ -193,6 +201,24 @@ func NewMetrics(procName string) *Metrics {
 			Buckets:   prometheus.LinearBuckets(0.0, eth.MaxBlobDataSize/13, 14),
 		}),
 
+		concurrentChannels: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "concurrent_channels",
+			Help:      "Number of channels which are filled or have frames left to submit, in multi-channel mode.",
+		}),
+		txFrames: factory.NewHistogram(prometheus.HistogramOpts{
+			Namespace: ns,
+			Name:      "tx_frames",
+			Help:      "Number of frames per batcher tx.",
+			Buckets:   prometheus.LinearBuckets(1, 1, 6),
+		}),
+		txChannels: factory.NewHistogram(prometheus.HistogramOpts{
+			Namespace: ns,
+			Name:      "tx_channels",
+			Help:      "Number of channels with frames per batcher tx.",
+			Buckets:   prometheus.LinearBuckets(1, 1, 6),
+		}),
+
 		batcherTxEvs: opmetrics.NewEventVec(factory, ns, "", "batcher_tx", "BatcherTx", []string{"stage"}),
 	}
 }
@@ -228,6 +254,7 @@ const (
 	StageClosed         = "closed"
 	StageFullySubmitted = "fully_submitted"
 	StageTimedOut       = "timed_out"
+	StageForceClosed    = "force_closed"
 
 	TxStageSubmitted = "submitted"
 	TxStageSuccess   = "success"
@@ -303,6 +330,10 @@ func (m *Metrics) RecordChannelTimedOut(id derive.ChannelID) {
 	m.channelEvs.Record(StageTimedOut)
 }
 
+func (m *Metrics) RecordChannelForceClosed(id derive.ChannelID) {
+	m.channelEvs.Record(StageForceClosed)
+}
+
 func (m *Metrics) RecordBatchTxSubmitted() {
 	m.batcherTxEvs.Record(TxStageSubmitted)
 }
@@ -319,6 +350,15 @@ func (m *Metrics) RecordBlobUsedBytes(num int) {
 	m.blobUsedBytes.Observe(float64(num))
 }
 
+func (m *Metrics) RecordConcurrentChannels(num int) {
+	m.concurrentChannels.Set(float64(num))
+}
+
+func (m *Metrics) RecordTxFrames(numFrames, numChannels int) {
+	m.txFrames.Observe(float64(numFrames))
+	m.txChannels.Observe(float64(numChannels))
+}
+
 // estimateBatchSize estimates the size of the batch
 func estimateBatchSize(block *types.Block) uint64 {
 	size := uint64(70) // estimated overhead of batch metadata
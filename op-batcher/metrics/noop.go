@@ -38,11 +38,14 @@ func (*noopMetrics) RecordChannelClosed(derive.ChannelID, int, int, int, int, er
 
 func (*noopMetrics) RecordChannelFullySubmitted(derive.ChannelID) {}
 func (*noopMetrics) RecordChannelTimedOut(derive.ChannelID)       {}
-
-func (*noopMetrics) RecordBatchTxSubmitted() {}
-func (*noopMetrics) RecordBatchTxSuccess()   {}
-func (*noopMetrics) RecordBatchTxFailed()    {}
-func (*noopMetrics) RecordBlobUsedBytes(int) {}
+func (*noopMetrics) RecordChannelForceClosed(derive.ChannelID)    {}
+
+func (*noopMetrics) RecordBatchTxSubmitted()      {}
+func (*noopMetrics) RecordBatchTxSuccess()        {}
+func (*noopMetrics) RecordBatchTxFailed()         {}
+func (*noopMetrics) RecordBlobUsedBytes(int)      {}
+func (*noopMetrics) RecordConcurrentChannels(int) {}
+func (*noopMetrics) RecordTxFrames(int, int)      {}
 func (*noopMetrics) StartBalanceMetrics(log.Logger, *l1ethclient.Client, l1common.Address) io.Closer {
 	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/zircuit-labs/l2-geth-public/log"
 	gethrpc "github.com/zircuit-labs/l2-geth-public/rpc"
 
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/metrics"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/rpc"
 )
@@ -13,6 +14,7 @@ import (
 type BatcherDriver interface {
 	StartBatchSubmitting() error
 	StopBatchSubmitting(ctx context.Context) error
+	ForceCloseChannels(ctx context.Context) ([]derive.ChannelID, error)
 }
 
 type adminAPI struct {
@@ -41,3 +43,9 @@ func (a *adminAPI) StartBatcher(_ context.Context) error {
 func (a *adminAPI) StopBatcher(ctx context.Context) error {
 	return a.b.StopBatchSubmitting(ctx)
 }
+
+// ForceCloseChannels force closes the channels of the batcher address which are stuck on L1,
+// and returns the IDs of the force closed channels.
+func (a *adminAPI) ForceCloseChannels(ctx context.Context) ([]derive.ChannelID, error) {
+	return a.b.ForceCloseChannels(ctx)
+}
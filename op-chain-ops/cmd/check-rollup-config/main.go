@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/mattn/go-isatty"
+	"github.com/urfave/cli/v2"
+
+	"github.com/zircuit-labs/l2-geth-public/ethclient"
+	"github.com/zircuit-labs/l2-geth-public/log"
+	"github.com/zircuit-labs/l2-geth-public/rpc"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-chain-ops/rollupcheck"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/client"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/jsonutil"
+	oplog "github.com/zircuit-labs/zkr-monorepo-public/op-service/log"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/sources"
+)
+
+func main() {
+	color := isatty.IsTerminal(os.Stderr.Fd())
+	oplog.SetGlobalLogHandler(log.NewTerminalHandler(os.Stderr, color))
+
+	app := &cli.App{
+		Name:  "check-rollup-config",
+		Usage: "Checks that the rollup config of running nodes matches a reference config, and the L1 contracts it refers to",
+		Flags: []cli.Flag{
+			&cli.PathFlag{
+				Name:     "rollup-config",
+				Required: true,
+				Usage:    "Path to the reference rollup config",
+				EnvVars:  []string{"ROLLUP_CONFIG"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "rollup-rpc-urls",
+				Usage:   "Rollup node RPC URLs to fetch the rollup config from with optimism_rollupConfig",
+				EnvVars: []string{"ROLLUP_RPC_URLS"},
+			},
+			&cli.StringFlag{
+				Name:    "l1-rpc-url",
+				Usage:   "L1 RPC URL. If set, the reference config is also checked against the SystemConfig and ProtocolVersions contracts.",
+				EnvVars: []string{"L1_RPC_URL"},
+			},
+		},
+		Action: entrypoint,
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Crit("error checking rollup config", "err", err)
+	}
+}
+
+// result lists the differences of a single source with the reference config.
+// For L1, it also lists the system config updates since genesis, which are not differences.
+type result struct {
+	Source      string                   `json:"source"`
+	Error       string                   `json:"error,omitempty"`
+	Differences []rollupcheck.Difference `json:"differences"`
+	Updates     []rollupcheck.Difference `json:"updates,omitempty"`
+}
+
+func entrypoint(ctx *cli.Context) error {
+	reference, err := jsonutil.LoadJSON[rollup.Config](ctx.Path("rollup-config"))
+	if err != nil {
+		return err
+	}
+
+	var results []result
+	if url := ctx.String("l1-rpc-url"); url != "" {
+		res := result{Source: "l1"}
+		diffs, updates, err := checkOnChain(ctx.Context, reference, url)
+		if err != nil {
+			res.Error = err.Error()
+		}
+		res.Differences = diffs
+		res.Updates = updates
+		results = append(results, res)
+	}
+	for _, url := range ctx.StringSlice("rollup-rpc-urls") {
+		res := result{Source: url}
+		diffs, err := checkNode(ctx.Context, reference, url)
+		if err != nil {
+			res.Error = err.Error()
+		}
+		res.Differences = diffs
+		results = append(results, res)
+	}
+
+	failed := 0
+	for i, res := range results {
+		if res.Differences == nil {
+			results[i].Differences = []rollupcheck.Difference{}
+		}
+		if res.Error != "" {
+			log.Error("Failed to check rollup config", "source", res.Source, "err", res.Error)
+			failed++
+			continue
+		}
+		for _, d := range res.Differences {
+			log.Error("Rollup config diverges", "source", res.Source, "field", d.Field, "expected", d.Expected, "actual", d.Actual)
+		}
+		for _, u := range res.Updates {
+			log.Info("System config updated since genesis", "source", res.Source, "field", u.Field, "genesis", u.Expected, "latest", u.Actual)
+		}
+		if len(res.Differences) > 0 {
+			failed++
+		}
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(results); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("rollup config diverges for %d of %d sources", failed, len(results))
+	}
+	log.Info("Rollup config matches", "sources", len(results))
+	return nil
+}
+
+func checkOnChain(ctx context.Context, reference *rollup.Config, url string) (diffs, updates []rollupcheck.Difference, err error) {
+	l1, err := ethclient.DialContext(ctx, url)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to dial L1 RPC: %w", err)
+	}
+	defer l1.Close()
+	diffs, err = rollupcheck.CompareOnChain(ctx, reference, l1)
+	if err != nil || len(diffs) > 0 {
+		return diffs, nil, err
+	}
+	updates, err = rollupcheck.SystemConfigUpdates(ctx, reference, l1)
+	return diffs, updates, err
+}
+
+func checkNode(ctx context.Context, reference *rollup.Config, url string) ([]rollupcheck.Difference, error) {
+	rpcCl, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial rollup node: %w", err)
+	}
+	rollupCl := sources.NewRollupClient(client.NewBaseRPCClient(rpcCl))
+	defer rollupCl.Close()
+	actual, err := rollupCl.RollupConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rollup config: %w", err)
+	}
+	return rollupcheck.CompareConfigs(reference, actual)
+}
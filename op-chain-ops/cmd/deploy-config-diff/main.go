@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mattn/go-isatty"
+	"github.com/urfave/cli/v2"
+
+	"github.com/zircuit-labs/l2-geth-public/ethclient"
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-chain-ops/genesis"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-chain-ops/safe"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-chain-ops/upgrades"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/jsonutil"
+	oplog "github.com/zircuit-labs/zkr-monorepo-public/op-service/log"
+)
+
+func main() {
+	color := isatty.IsTerminal(os.Stderr.Fd())
+	oplog.SetGlobalLogHandler(log.NewTerminalHandler(os.Stderr, color))
+
+	app := &cli.App{
+		Name:  "deploy-config-diff",
+		Usage: "Diff a deploy config against the deployed L1 contracts, or against another deploy config, and plan the upgrade",
+		Flags: []cli.Flag{
+			&cli.PathFlag{
+				Name:     "deploy-config",
+				Required: true,
+				Usage:    "Path to the target deploy config",
+				EnvVars:  []string{"DEPLOY_CONFIG"},
+			},
+			&cli.PathFlag{
+				Name:     "l1-deployments",
+				Required: true,
+				Usage:    "Path to the L1 deployments of the target, with the proxies and implementation addresses",
+				EnvVars:  []string{"L1_DEPLOYMENTS"},
+			},
+			&cli.StringFlag{
+				Name:    "l1-rpc-url",
+				Usage:   "L1 RPC URL, to diff against the deployed contracts",
+				EnvVars: []string{"L1_RPC_URL"},
+			},
+			&cli.PathFlag{
+				Name:  "from-deploy-config",
+				Usage: "Path to a deploy config to diff against, instead of the deployed contracts",
+			},
+			&cli.PathFlag{
+				Name:  "from-l1-deployments",
+				Usage: "Path to the L1 deployments of --from-deploy-config. Defaults to --l1-deployments",
+			},
+			&cli.PathFlag{
+				Name:  "outfile",
+				Usage: "The file to write the diff to. If not specified, the diff is written to stdout",
+			},
+			&cli.PathFlag{
+				Name:  "batch-outfile",
+				Usage: "The file to write the Safe batch to, that reconciles the deployed contracts with the target",
+			},
+		},
+		Action: entrypoint,
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Crit("error diffing deploy config", "err", err)
+	}
+}
+
+type output struct {
+	From    *upgrades.State   `json:"from"`
+	To      *upgrades.State   `json:"to"`
+	Changes []upgrades.Change `json:"changes"`
+}
+
+func entrypoint(ctx *cli.Context) error {
+	config, err := genesis.NewDeployConfig(ctx.Path("deploy-config"))
+	if err != nil {
+		return err
+	}
+	if err := config.Check(); err != nil {
+		return fmt.Errorf("invalid deploy config: %w", err)
+	}
+	deployments, err := genesis.NewL1Deployments(ctx.Path("l1-deployments"))
+	if err != nil {
+		return err
+	}
+	to := upgrades.StateFromConfig(config, deployments)
+
+	var from *upgrades.State
+	onChain := false
+	switch {
+	case ctx.IsSet("from-deploy-config") && ctx.IsSet("l1-rpc-url"):
+		return errors.New("cannot diff against both a deploy config and the deployed contracts")
+	case ctx.IsSet("from-deploy-config"):
+		fromConfig, err := genesis.NewDeployConfig(ctx.Path("from-deploy-config"))
+		if err != nil {
+			return err
+		}
+		fromDeployments := deployments
+		if ctx.IsSet("from-l1-deployments") {
+			if fromDeployments, err = genesis.NewL1Deployments(ctx.Path("from-l1-deployments")); err != nil {
+				return err
+			}
+		}
+		from = upgrades.StateFromConfig(fromConfig, fromDeployments)
+	case ctx.IsSet("l1-rpc-url"):
+		client, err := ethclient.Dial(ctx.String("l1-rpc-url"))
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+		log.Info("Reading deployed contracts", "system-config", deployments.SystemConfigProxy, "proxy-admin", deployments.ProxyAdmin)
+		if from, err = upgrades.StateFromChain(ctx.Context, deployments, client); err != nil {
+			return err
+		}
+		onChain = true
+	default:
+		return errors.New("either --l1-rpc-url or --from-deploy-config is required")
+	}
+
+	changes := upgrades.Diff(from, to)
+	for _, change := range changes {
+		log.Info("Change", "kind", change.Kind, "name", change.Name, "from", change.From, "to", change.To)
+	}
+	log.Info("Diffed deploy config", "changes", len(changes))
+
+	if outfile := ctx.Path("batch-outfile"); outfile != "" {
+		if !onChain {
+			return errors.New("a batch can only be planned against the deployed contracts, with --l1-rpc-url")
+		}
+		batch := safe.Batch{}
+		if err := upgrades.Reconcile(&batch, deployments, to, changes); err != nil {
+			return err
+		}
+		log.Info("Writing batch", "transactions", len(batch.Transactions), "path", outfile)
+		if err := jsonutil.WriteJSON(outfile, batch, 0o666); err != nil {
+			return err
+		}
+	}
+
+	out := output{From: from, To: to, Changes: changes}
+	if outfile := ctx.Path("outfile"); outfile != "" {
+		return jsonutil.WriteJSON(outfile, out, 0o666)
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/mattn/go-isatty"
+	"github.com/urfave/cli/v2"
+
+	l1common "github.com/ethereum/go-ethereum/common"
+	l1ethclient "github.com/ethereum/go-ethereum/ethclient"
+	l1rpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/core/types"
+	"github.com/zircuit-labs/l2-geth-public/ethclient"
+	"github.com/zircuit-labs/l2-geth-public/log"
+	"github.com/zircuit-labs/l2-geth-public/rpc"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/jsonutil"
+	oplog "github.com/zircuit-labs/zkr-monorepo-public/op-service/log"
+)
+
+func main() {
+	color := isatty.IsTerminal(os.Stderr.Fd())
+	oplog.SetGlobalLogHandler(log.NewTerminalHandler(os.Stderr, color))
+
+	app := &cli.App{
+		Name:      "trace-deposit",
+		Usage:     "Reports whether the deposits of an L1 transaction are included in L2, excluded, or still pending",
+		ArgsUsage: "<l1-tx-hash>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "l1-rpc-url",
+				Required: true,
+				Usage:    "L1 RPC URL",
+				EnvVars:  []string{"L1_RPC_URL"},
+			},
+			&cli.StringFlag{
+				Name:     "l2-rpc-url",
+				Required: true,
+				Usage:    "L2 RPC URL",
+				EnvVars:  []string{"L2_RPC_URL"},
+			},
+			&cli.PathFlag{
+				Name:     "rollup-config",
+				Required: true,
+				Usage:    "Path to the rollup config of the L2 chain",
+				EnvVars:  []string{"ROLLUP_CONFIG"},
+			},
+		},
+		Action: entrypoint,
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Crit("error tracing deposit", "err", err)
+	}
+}
+
+type report struct {
+	L1TxHash l1common.Hash `json:"l1TxHash"`
+	L1Block  eth.BlockID   `json:"l1Block"`
+	// L2Block is the first L2 block of the epoch of the L1 block, which includes its deposits.
+	// It is not set while the deposits are pending, until the L2 block is safe.
+	L2Block  *eth.BlockID `json:"l2Block,omitempty"`
+	Deposits []deposit    `json:"deposits"`
+}
+
+type deposit struct {
+	derive.TracedDeposit
+	// L2Success is whether the included deposit executed successfully on L2.
+	// Failed deposits still mint their ETH on L2.
+	L2Success *bool `json:"l2Success,omitempty"`
+}
+
+func entrypoint(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("expected the L1 transaction hash as the only argument")
+	}
+	txHash := l1common.HexToHash(ctx.Args().First())
+	rollupCfg, err := jsonutil.LoadJSON[rollup.Config](ctx.Path("rollup-config"))
+	if err != nil {
+		return err
+	}
+	l1, err := l1ethclient.DialContext(ctx.Context, ctx.String("l1-rpc-url"))
+	if err != nil {
+		return fmt.Errorf("failed to dial L1 RPC: %w", err)
+	}
+	defer l1.Close()
+	l2, err := ethclient.DialContext(ctx.Context, ctx.String("l2-rpc-url"))
+	if err != nil {
+		return fmt.Errorf("failed to dial L2 RPC: %w", err)
+	}
+	defer l2.Close()
+
+	rep, err := traceDeposit(ctx.Context, rollupCfg, l1, l2, txHash)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rep)
+}
+
+func traceDeposit(ctx context.Context, rollupCfg *rollup.Config, l1 *l1ethclient.Client, l2 *ethclient.Client, txHash l1common.Hash) (*report, error) {
+	receipt, err := l1.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch L1 receipt of %s: %w", txHash, err)
+	}
+	receipts, err := l1.BlockReceipts(ctx, l1rpc.BlockNumberOrHashWithHash(receipt.BlockHash, false))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch receipts of L1 block %s: %w", receipt.BlockHash, err)
+	}
+	l1Header, err := l1.HeaderByHash(ctx, receipt.BlockHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch L1 block %s: %w", receipt.BlockHash, err)
+	}
+	rep := &report{
+		L1TxHash: txHash,
+		L1Block:  eth.BlockID{Hash: common.Hash(receipt.BlockHash), Number: receipt.BlockNumber.Uint64()},
+		Deposits: []deposit{},
+	}
+
+	epochStart, l1Info, err := findEpochStart(ctx, rollupCfg, l2, rep.L1Block, l1Header.Time)
+	if err != nil {
+		return nil, err
+	}
+	traced, err := derive.TraceDeposits(receipts, txHash, rollupCfg.DepositContractAddress, l1Info)
+	if err != nil {
+		return nil, err
+	}
+	if epochStart != nil {
+		id := eth.BlockID{Hash: epochStart.Hash(), Number: epochStart.NumberU64()}
+		rep.L2Block = &id
+	}
+	for _, dep := range traced {
+		d := deposit{TracedDeposit: dep}
+		if dep.Status == derive.DepositIncluded {
+			if epochStart.Transaction(dep.L2TxHash) == nil {
+				return nil, fmt.Errorf("included deposit %s is missing from L2 block %s", dep.L2TxHash, rep.L2Block)
+			}
+			l2Receipt, err := l2.TransactionReceipt(ctx, dep.L2TxHash)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch L2 receipt of deposit %s: %w", dep.L2TxHash, err)
+			}
+			success := l2Receipt.Status == types.ReceiptStatusSuccessful
+			d.L2Success = &success
+		}
+		rep.Deposits = append(rep.Deposits, d)
+	}
+	return rep, nil
+}
+
+// findEpochStart returns the first L2 block with the given L1 origin, and its L1 info.
+// It searches the safe L2 chain, since the unsafe blocks may still be reorged.
+// It returns no block, and no error, if there is no safe L2 block with this or a later L1 origin yet.
+func findEpochStart(ctx context.Context, rollupCfg *rollup.Config, l2 *ethclient.Client, origin eth.BlockID, originTime uint64) (*types.Block, *derive.L1BlockInfo, error) {
+	head, err := l2.BlockByNumber(ctx, big.NewInt(int64(rpc.SafeBlockNumber)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch safe L2 head: %w", err)
+	}
+	headInfo, err := derive.L1InfoFromBlock(rollupCfg, head)
+	if err != nil {
+		return nil, nil, err
+	}
+	if headInfo.Number < origin.Number {
+		return nil, nil, nil
+	}
+	// L2 blocks are never older than their L1 origin, so the epoch cannot start before the L1 block time
+	lo := rollupCfg.Genesis.L2.Number
+	if originTime > rollupCfg.Genesis.L2Time {
+		if lo, err = rollupCfg.TargetBlockNumber(originTime); err != nil {
+			return nil, nil, err
+		}
+	}
+	hi := head.NumberU64()
+	if lo > hi {
+		return nil, nil, fmt.Errorf("safe L2 head %d is before the earliest possible epoch start %d", hi, lo)
+	}
+	// binary search for the first block with an L1 origin at or after the L1 block,
+	// block is always the one at hi, which has such an origin
+	block, info := head, headInfo
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		b, err := l2.BlockByNumber(ctx, new(big.Int).SetUint64(mid))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch L2 block %d: %w", mid, err)
+		}
+		i, err := derive.L1InfoFromBlock(rollupCfg, b)
+		if err != nil {
+			return nil, nil, err
+		}
+		if i.Number >= origin.Number {
+			hi = mid
+			block, info = b, i
+		} else {
+			lo = mid + 1
+		}
+	}
+	if info.Number != origin.Number || info.BlockHash != origin.Hash {
+		return nil, nil, fmt.Errorf("L2 block %d starts epoch %d (%s) instead of %s, the L1 block may have been reorged",
+			block.NumberU64(), info.Number, info.BlockHash, origin)
+	}
+	return block, info, nil
+}
@@ -122,6 +122,9 @@ type DeployConfig struct {
 	// L2GenesisInteropTimeOffset is the number of seconds after genesis block that the Interop hard fork activates.
 	// Set it to 0 to activate at genesis. Nil to disable Interop.
 	L2GenesisInteropTimeOffset *hexutil.Uint64 `json:"l2GenesisInteropTimeOffset,omitempty"`
+	// L2GenesisSpanBatchExclusionsTimeOffset is the number of seconds after genesis block that span batches
+	// with deposit exclusions activate. Set it to 0 to activate at genesis. Nil to disable them.
+	L2GenesisSpanBatchExclusionsTimeOffset *hexutil.Uint64 `json:"l2GenesisSpanBatchExclusionsTimeOffset,omitempty"`
 	// L2GenesisBlockExtraData is configurable extradata. Will default to []byte("BEDROCK") if left unspecified.
 	L2GenesisBlockExtraData []byte `json:"l2GenesisBlockExtraData"`
 	// ProxyAdminOwner represents the owner of the ProxyAdmin predeploy on L2.
@@ -367,6 +370,9 @@ func (d *DeployConfig) Check() error {
 	if err := checkFork(d.L2GenesisEcotoneTimeOffset, d.L2GenesisHyraxTimeOffset, "ecotone", "hyrax"); err != nil {
 		return err
 	}
+	if err := checkFork(d.L2GenesisDeltaTimeOffset, d.L2GenesisSpanBatchExclusionsTimeOffset, "delta", "span batch exclusions"); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -506,6 +512,17 @@ func (d *DeployConfig) InteropTime(genesisTime uint64) *uint64 {
 	return &v
 }
 
+func (d *DeployConfig) SpanBatchExclusionsTime(genesisTime uint64) *uint64 {
+	if d.L2GenesisSpanBatchExclusionsTimeOffset == nil {
+		return nil
+	}
+	v := uint64(0)
+	if offset := *d.L2GenesisSpanBatchExclusionsTimeOffset; offset > 0 {
+		v = genesisTime + uint64(offset)
+	}
+	return &v
+}
+
 // RollupConfig converts a DeployConfig to a rollup.Config. If Ecotone is active at genesis, the
 // Overhead value is considered a noop.
 func (d *DeployConfig) RollupConfig(l1StartBlock *types.Block, l2GenesisBlockHash common.Hash, l2GenesisBlockNumber uint64) (*rollup.Config, error) {
@@ -534,23 +551,24 @@ func (d *DeployConfig) RollupConfig(l1StartBlock *types.Block, l2GenesisBlockHas
 				GasLimit:    uint64(d.L2GenesisBlockGasLimit),
 			},
 		},
-		BlockTime:              d.L2BlockTime,
-		MaxSequencerDrift:      d.MaxSequencerDrift,
-		SeqWindowSize:          d.SequencerWindowSize,
-		ChannelTimeoutBedrock:  d.ChannelTimeout,
-		L1ChainID:              new(big.Int).SetUint64(d.L1ChainID),
-		L2ChainID:              new(big.Int).SetUint64(d.L2ChainID),
-		BatchInboxAddress:      d.BatchInboxAddress,
-		DepositContractAddress: d.OptimismPortalProxy,
-		L1SystemConfigAddress:  d.SystemConfigProxy,
-		RegolithTime:           d.RegolithTime(l1StartBlock.Time()),
-		CanyonTime:             d.CanyonTime(l1StartBlock.Time()),
-		DeltaTime:              d.DeltaTime(l1StartBlock.Time()),
-		EcotoneTime:            d.EcotoneTime(l1StartBlock.Time()),
-		L2CancunTime:           d.L2CancunTime(l1StartBlock.Time()),
-		FjordTime:              d.FjordTime(l1StartBlock.Time()),
-		InteropTime:            d.InteropTime(l1StartBlock.Time()),
-		HyraxTime:              d.HyraxTime(l1StartBlock.Time()),
+		BlockTime:               d.L2BlockTime,
+		MaxSequencerDrift:       d.MaxSequencerDrift,
+		SeqWindowSize:           d.SequencerWindowSize,
+		ChannelTimeoutBedrock:   d.ChannelTimeout,
+		L1ChainID:               new(big.Int).SetUint64(d.L1ChainID),
+		L2ChainID:               new(big.Int).SetUint64(d.L2ChainID),
+		BatchInboxAddress:       d.BatchInboxAddress,
+		DepositContractAddress:  d.OptimismPortalProxy,
+		L1SystemConfigAddress:   d.SystemConfigProxy,
+		RegolithTime:            d.RegolithTime(l1StartBlock.Time()),
+		CanyonTime:              d.CanyonTime(l1StartBlock.Time()),
+		DeltaTime:               d.DeltaTime(l1StartBlock.Time()),
+		EcotoneTime:             d.EcotoneTime(l1StartBlock.Time()),
+		L2CancunTime:            d.L2CancunTime(l1StartBlock.Time()),
+		FjordTime:               d.FjordTime(l1StartBlock.Time()),
+		InteropTime:             d.InteropTime(l1StartBlock.Time()),
+		HyraxTime:               d.HyraxTime(l1StartBlock.Time()),
+		SpanBatchExclusionsTime: d.SpanBatchExclusionsTime(l1StartBlock.Time()),
 	}, nil
 }
 
@@ -0,0 +1,240 @@
+// Package rollupcheck compares rollup configs with each other, and with the L1 contracts they refer to.
+package rollupcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/zircuit-labs/l2-geth-public/accounts/abi/bind"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/params"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-bindings/bindings"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/node"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+)
+
+// unset is reported for fields that are not present in one of the compared values.
+const unset = "<unset>"
+
+// Difference is a field with a different value than expected.
+// Fields are named by their JSON path in the rollup config, e.g. "genesis.l1.hash".
+type Difference struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%s: expected %s, got %s", d.Field, d.Expected, d.Actual)
+}
+
+// CompareConfigs compares the actual rollup config field by field against the expected one.
+// The differences are ordered by field.
+func CompareConfigs(expected, actual *rollup.Config) ([]Difference, error) {
+	return compareValues("", expected, actual)
+}
+
+// compareValues compares the JSON encodings of the values field by field, with fields named relative to path.
+// The differences are ordered by field.
+func compareValues(path string, expected, actual any) ([]Difference, error) {
+	exp, err := toJSONValue(expected)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode expected value: %w", err)
+	}
+	act, err := toJSONValue(actual)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode actual value: %w", err)
+	}
+	var diffs []Difference
+	diffJSON(path, exp, act, &diffs)
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Field < diffs[j].Field })
+	return diffs, nil
+}
+
+// toJSONValue encodes the value to its generic JSON form, with numbers kept exact.
+func toJSONValue(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out any
+	err = dec.Decode(&out)
+	return out, err
+}
+
+func diffJSON(path string, expected, actual any, diffs *[]Difference) {
+	expMap, expIsMap := expected.(map[string]any)
+	actMap, actIsMap := actual.(map[string]any)
+	if expIsMap && actIsMap {
+		keys := make(map[string]struct{})
+		for k := range expMap {
+			keys[k] = struct{}{}
+		}
+		for k := range actMap {
+			keys[k] = struct{}{}
+		}
+		for k := range keys {
+			field := k
+			if path != "" {
+				field = path + "." + k
+			}
+			// missing keys are nil, and reported as unset
+			diffJSON(field, expMap[k], actMap[k], diffs)
+		}
+		return
+	}
+	expStr, actStr := jsonString(expected), jsonString(actual)
+	if expStr != actStr {
+		*diffs = append(*diffs, Difference{Field: path, Expected: expStr, Actual: actStr})
+	}
+}
+
+func jsonString(v any) string {
+	if v == nil {
+		return unset
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+// L1Client reads the L1 contracts that a rollup config refers to.
+type L1Client interface {
+	bind.ContractCaller
+	StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error)
+}
+
+// CompareOnChain compares the rollup config against the L1 contracts it refers to.
+// The rollup config is the reference: its values are the expected ones, the on-chain values the actual ones.
+//
+// The SystemConfig must be deployed, and agree on the batch inbox, the deposit contract, the L1 start block,
+// and the system config values. The latter are read at the L1 genesis block and compared with the genesis
+// system config of the rollup config, which requires the L1 RPC to serve the state of that block.
+// Later updates of the system config are legitimate, see SystemConfigUpdates.
+// If the config sets a ProtocolVersions contract, the required and recommended protocol versions must be
+// supported by this build.
+func CompareOnChain(ctx context.Context, cfg *rollup.Config, l1 L1Client) ([]Difference, error) {
+	opts := &bind.CallOpts{Context: ctx}
+	var diffs []Difference
+	code, err := l1.CodeAt(ctx, cfg.L1SystemConfigAddress, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch SystemConfig code: %w", err)
+	}
+	if len(code) == 0 {
+		return []Difference{{Field: "l1_system_config_address", Expected: "contract", Actual: "no code"}}, nil
+	}
+	sysCfg, err := bindings.NewSystemConfigCaller(cfg.L1SystemConfigAddress, l1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bind SystemConfig: %w", err)
+	}
+	batchInbox, err := sysCfg.BatchInbox(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch batch inbox: %w", err)
+	}
+	if batchInbox != cfg.BatchInboxAddress {
+		diffs = append(diffs, Difference{Field: "batch_inbox_address", Expected: cfg.BatchInboxAddress.String(), Actual: batchInbox.String()})
+	}
+	portal, err := sysCfg.OptimismPortal(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch OptimismPortal: %w", err)
+	}
+	if portal != cfg.DepositContractAddress {
+		diffs = append(diffs, Difference{Field: "deposit_contract_address", Expected: cfg.DepositContractAddress.String(), Actual: portal.String()})
+	}
+	startBlock, err := sysCfg.StartBlock(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch start block: %w", err)
+	}
+	if !startBlock.IsUint64() || startBlock.Uint64() != cfg.Genesis.L1.Number {
+		diffs = append(diffs, Difference{Field: "genesis.l1.number", Expected: fmt.Sprint(cfg.Genesis.L1.Number), Actual: startBlock.String()})
+	}
+	genesisOpts := &bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(cfg.Genesis.L1.Number)}
+	onChainSysCfg, err := fetchSystemConfig(genesisOpts, sysCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch system config at L1 genesis block %d: %w", cfg.Genesis.L1.Number, err)
+	}
+	sysCfgDiffs, err := compareValues("genesis.system_config", cfg.Genesis.SystemConfig, onChainSysCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare system config: %w", err)
+	}
+	diffs = append(diffs, sysCfgDiffs...)
+
+	if cfg.ProtocolVersionsAddress != (common.Address{}) {
+		// There is no ProtocolVersions binding, the versions are read from the storage slots the op-node reads.
+		for _, v := range []struct {
+			field string
+			slot  common.Hash
+		}{
+			{"required_protocol_version", node.RequiredProtocolVersionStorageSlot},
+			{"recommended_protocol_version", node.RecommendedProtocolVersionStorageSlot},
+		} {
+			value, err := l1.StorageAt(ctx, cfg.ProtocolVersionsAddress, v.slot, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch %s: %w", v.field, err)
+			}
+			version := params.ProtocolVersion(common.BytesToHash(value))
+			if params.OPStackSupport.Compare(version) < 0 {
+				diffs = append(diffs, Difference{Field: v.field, Expected: "at most " + params.OPStackSupport.String(), Actual: version.String()})
+			}
+		}
+	}
+	return diffs, nil
+}
+
+// SystemConfigUpdates returns the system config values that were updated on L1 since genesis, with the
+// value at the L1 genesis block as expected and the latest value as actual one. These are not divergences
+// of the rollup config, the L2 chain follows the updates.
+func SystemConfigUpdates(ctx context.Context, cfg *rollup.Config, l1 L1Client) ([]Difference, error) {
+	sysCfg, err := bindings.NewSystemConfigCaller(cfg.L1SystemConfigAddress, l1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bind SystemConfig: %w", err)
+	}
+	genesis, err := fetchSystemConfig(&bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(cfg.Genesis.L1.Number)}, sysCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch system config at L1 genesis block %d: %w", cfg.Genesis.L1.Number, err)
+	}
+	latest, err := fetchSystemConfig(&bind.CallOpts{Context: ctx}, sysCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch latest system config: %w", err)
+	}
+	return compareValues("system_config", genesis, latest)
+}
+
+// fetchSystemConfig reads the system config values from the SystemConfig contract, at the block of the call options.
+func fetchSystemConfig(opts *bind.CallOpts, sysCfg *bindings.SystemConfigCaller) (eth.SystemConfig, error) {
+	batcherHash, err := sysCfg.BatcherHash(opts)
+	if err != nil {
+		return eth.SystemConfig{}, fmt.Errorf("failed to fetch batcher hash: %w", err)
+	}
+	gasLimit, err := sysCfg.GasLimit(opts)
+	if err != nil {
+		return eth.SystemConfig{}, fmt.Errorf("failed to fetch gas limit: %w", err)
+	}
+	overhead, err := sysCfg.Overhead(opts)
+	if err != nil {
+		return eth.SystemConfig{}, fmt.Errorf("failed to fetch overhead: %w", err)
+	}
+	scalar, err := sysCfg.Scalar(opts)
+	if err != nil {
+		return eth.SystemConfig{}, fmt.Errorf("failed to fetch scalar: %w", err)
+	}
+	return eth.SystemConfig{
+		BatcherAddr: common.BytesToAddress(batcherHash[:]),
+		Overhead:    eth.Bytes32(common.BigToHash(overhead)),
+		Scalar:      eth.Bytes32(common.BigToHash(scalar)),
+		GasLimit:    gasLimit,
+	}, nil
+}
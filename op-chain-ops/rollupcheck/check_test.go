@@ -0,0 +1,229 @@
+package rollupcheck
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	ethereum "github.com/zircuit-labs/l2-geth-public"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/params"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-bindings/bindings"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/node"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+)
+
+var (
+	sysCfgAddr    = common.Address{0x01}
+	protocolAddr  = common.Address{0x02}
+	batchInbox    = common.Address{0x03}
+	portalAddr    = common.Address{0x04}
+	l1StartNumber = uint64(42)
+	systemConfig  = eth.SystemConfig{
+		BatcherAddr: common.Address{0x05},
+		Overhead:    eth.Bytes32{31: 0xbc},
+		Scalar:      eth.Bytes32{31: 0x0a},
+		GasLimit:    30_000_000,
+	}
+)
+
+func testConfig() *rollup.Config {
+	u64 := func(v uint64) *uint64 { return &v }
+	return &rollup.Config{
+		Genesis: rollup.Genesis{
+			L1:           eth.BlockID{Hash: common.Hash{0xaa}, Number: l1StartNumber},
+			L2:           eth.BlockID{Hash: common.Hash{0xbb}, Number: 0},
+			L2Time:       1000,
+			SystemConfig: systemConfig,
+		},
+		BlockTime:               2,
+		SeqWindowSize:           3600,
+		L1ChainID:               big.NewInt(1),
+		L2ChainID:               big.NewInt(10),
+		RegolithTime:            u64(0),
+		CanyonTime:              u64(2000),
+		BatchInboxAddress:       batchInbox,
+		DepositContractAddress:  portalAddr,
+		L1SystemConfigAddress:   sysCfgAddr,
+		ProtocolVersionsAddress: protocolAddr,
+	}
+}
+
+func TestCompareConfigs(t *testing.T) {
+	expected := testConfig()
+
+	diffs, err := CompareConfigs(expected, testConfig())
+	require.NoError(t, err)
+	require.Empty(t, diffs)
+
+	actual := testConfig()
+	actual.Genesis.L1.Hash = common.Hash{0xcc}
+	actual.CanyonTime = nil
+	fork := uint64(3000)
+	actual.DeltaTime = &fork
+	actual.L2ChainID = big.NewInt(11)
+	diffs, err = CompareConfigs(expected, actual)
+	require.NoError(t, err)
+	require.Equal(t, []Difference{
+		{Field: "canyon_time", Expected: "2000", Actual: unset},
+		{Field: "delta_time", Expected: unset, Actual: "3000"},
+		{Field: "genesis.l1.hash", Expected: common.Hash{0xaa}.String(), Actual: common.Hash{0xcc}.String()},
+		{Field: "l2_chain_id", Expected: "10", Actual: "11"},
+	}, diffs)
+}
+
+// fakeL1 answers the SystemConfig calls and the ProtocolVersions storage reads from its fields.
+type fakeL1 struct {
+	code        []byte
+	batchInbox  common.Address
+	portal      common.Address
+	startBlock  uint64
+	sysCfg      eth.SystemConfig  // at the L1 genesis block
+	latest      *eth.SystemConfig // the latest system config if updated since genesis
+	required    params.ProtocolVersion
+	recommended params.ProtocolVersion
+}
+
+func (f *fakeL1) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return f.code, nil
+}
+
+func (f *fakeL1) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if *call.To != sysCfgAddr {
+		return nil, fmt.Errorf("unexpected call to %s", call.To)
+	}
+	sysCfgValues := f.sysCfg
+	if blockNumber == nil && f.latest != nil {
+		sysCfgValues = *f.latest
+	} else if blockNumber != nil && blockNumber.Uint64() != l1StartNumber {
+		return nil, fmt.Errorf("unexpected call at block %d", blockNumber)
+	}
+	sysCfg, err := bindings.SystemConfigMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	method, err := sysCfg.MethodById(call.Data[:4])
+	if err != nil {
+		return nil, err
+	}
+	switch method.Name {
+	case "batchInbox":
+		return method.Outputs.Pack(f.batchInbox)
+	case "optimismPortal":
+		return method.Outputs.Pack(f.portal)
+	case "startBlock":
+		return method.Outputs.Pack(new(big.Int).SetUint64(f.startBlock))
+	case "batcherHash":
+		return method.Outputs.Pack(common.BytesToHash(sysCfgValues.BatcherAddr[:]))
+	case "gasLimit":
+		return method.Outputs.Pack(sysCfgValues.GasLimit)
+	case "overhead":
+		return method.Outputs.Pack(new(big.Int).SetBytes(sysCfgValues.Overhead[:]))
+	case "scalar":
+		return method.Outputs.Pack(new(big.Int).SetBytes(sysCfgValues.Scalar[:]))
+	default:
+		return nil, fmt.Errorf("unexpected call of %s", method.Name)
+	}
+}
+
+func (f *fakeL1) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	if account != protocolAddr {
+		return nil, fmt.Errorf("unexpected storage read of %s at %s", key, account)
+	}
+	switch key {
+	case node.RequiredProtocolVersionStorageSlot:
+		return f.required[:], nil
+	case node.RecommendedProtocolVersionStorageSlot:
+		return f.recommended[:], nil
+	default:
+		return nil, fmt.Errorf("unexpected storage read of %s at %s", key, account)
+	}
+}
+
+func TestCompareOnChain(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	newL1 := func() *fakeL1 {
+		return &fakeL1{
+			code:        []byte{0x01},
+			batchInbox:  batchInbox,
+			portal:      portalAddr,
+			startBlock:  l1StartNumber,
+			sysCfg:      systemConfig,
+			required:    params.OPStackSupport,
+			recommended: params.OPStackSupport,
+		}
+	}
+
+	diffs, err := CompareOnChain(ctx, cfg, newL1())
+	require.NoError(t, err)
+	require.Empty(t, diffs)
+
+	l1 := newL1()
+	l1.code = nil
+	diffs, err = CompareOnChain(ctx, cfg, l1)
+	require.NoError(t, err)
+	require.Len(t, diffs, 1)
+	require.Equal(t, "l1_system_config_address", diffs[0].Field)
+
+	l1 = newL1()
+	l1.batchInbox = common.Address{0x05}
+	l1.startBlock = l1StartNumber + 1
+	diffs, err = CompareOnChain(ctx, cfg, l1)
+	require.NoError(t, err)
+	require.Equal(t, []Difference{
+		{Field: "batch_inbox_address", Expected: batchInbox.String(), Actual: l1.batchInbox.String()},
+		{Field: "genesis.l1.number", Expected: "42", Actual: "43"},
+	}, diffs)
+
+	// system config values are compared with the genesis system config of the reference
+	l1 = newL1()
+	l1.sysCfg.BatcherAddr = common.Address{0x06}
+	l1.sysCfg.GasLimit = 60_000_000
+	l1.sysCfg.Overhead = eth.Bytes32{}
+	l1.sysCfg.Scalar = eth.Bytes32{31: 0x0b}
+	diffs, err = CompareOnChain(ctx, cfg, l1)
+	require.NoError(t, err)
+	require.Equal(t, []Difference{
+		{Field: "genesis.system_config.batcherAddr", Expected: systemConfig.BatcherAddr.String(), Actual: l1.sysCfg.BatcherAddr.String()},
+		{Field: "genesis.system_config.gasLimit", Expected: "30000000", Actual: "60000000"},
+		{Field: "genesis.system_config.overhead", Expected: systemConfig.Overhead.String(), Actual: l1.sysCfg.Overhead.String()},
+		{Field: "genesis.system_config.scalar", Expected: systemConfig.Scalar.String(), Actual: l1.sysCfg.Scalar.String()},
+	}, diffs)
+
+	// updates after genesis are no divergence, but reported as updates
+	l1 = newL1()
+	l1.latest = &eth.SystemConfig{BatcherAddr: systemConfig.BatcherAddr, GasLimit: 60_000_000, Overhead: systemConfig.Overhead, Scalar: systemConfig.Scalar}
+	diffs, err = CompareOnChain(ctx, cfg, l1)
+	require.NoError(t, err)
+	require.Empty(t, diffs)
+	updates, err := SystemConfigUpdates(ctx, cfg, l1)
+	require.NoError(t, err)
+	require.Equal(t, []Difference{{Field: "system_config.gasLimit", Expected: "30000000", Actual: "60000000"}}, updates)
+
+	// a required protocol version newer than the supported one is a divergence
+	_, build, major, minor, patch, preRelease := params.OPStackSupport.Parse()
+	l1 = newL1()
+	l1.required = params.ProtocolVersionV0{Build: build, Major: major + 1, Minor: minor, Patch: patch, PreRelease: preRelease}.Encode()
+	diffs, err = CompareOnChain(ctx, cfg, l1)
+	require.NoError(t, err)
+	require.Len(t, diffs, 1)
+	require.Equal(t, "required_protocol_version", diffs[0].Field)
+
+	l1 = newL1()
+	l1.recommended = params.ProtocolVersionV0{Build: build, Major: major + 1, Minor: minor, Patch: patch, PreRelease: preRelease}.Encode()
+	diffs, err = CompareOnChain(ctx, cfg, l1)
+	require.NoError(t, err)
+	require.Len(t, diffs, 1)
+	require.Equal(t, "recommended_protocol_version", diffs[0].Field)
+
+	// without a ProtocolVersions contract, the protocol version is not checked
+	cfg.ProtocolVersionsAddress = common.Address{}
+	diffs, err = CompareOnChain(ctx, cfg, l1)
+	require.NoError(t, err)
+	require.Empty(t, diffs)
+}
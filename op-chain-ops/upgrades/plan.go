@@ -0,0 +1,342 @@
+package upgrades
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/zircuit-labs/l2-geth-public/accounts/abi"
+	"github.com/zircuit-labs/l2-geth-public/accounts/abi/bind"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/common/hexutil"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-bindings/bindings"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-chain-ops/genesis"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-chain-ops/safe"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+)
+
+// ChangeKind groups the changes of a Diff.
+type ChangeKind string
+
+const (
+	// ForkTimeChange is a change of an L2 fork activation time offset.
+	// Fork times are part of the L2 chain config, and cannot be reconciled with an L1 transaction.
+	ForkTimeChange ChangeKind = "fork-time"
+	// SystemConfigChange is a change of a value of the SystemConfig contract.
+	SystemConfigChange ChangeKind = "system-config"
+	// ImplementationChange is a change of the implementation behind a proxy.
+	ImplementationChange ChangeKind = "implementation"
+)
+
+// Change is a single difference between two states. From or To is empty if the value is unset.
+type Change struct {
+	Kind ChangeKind `json:"kind"`
+	Name string     `json:"name"`
+	From string     `json:"from"`
+	To   string     `json:"to"`
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s %s: %q -> %q", c.Kind, c.Name, c.From, c.To)
+}
+
+// SystemConfigValues are the values of the SystemConfig contract that are set from the deploy config.
+type SystemConfigValues struct {
+	Owner             common.Address `json:"owner"`
+	BatcherHash       common.Hash    `json:"batcherHash"`
+	GasLimit          uint64         `json:"gasLimit"`
+	Overhead          common.Hash    `json:"overhead"`
+	Scalar            common.Hash    `json:"scalar"`
+	UnsafeBlockSigner common.Address `json:"unsafeBlockSigner"`
+}
+
+// State is the comparable state of a chain deployment,
+// either as described by a deploy config or as deployed on L1.
+type State struct {
+	// ForkTimes are the L2 fork activation time offsets by fork name.
+	// Nil if the fork times are not known, like for on-chain state, in which case they are not compared.
+	ForkTimes map[string]*uint64 `json:"forkTimes,omitempty"`
+	// SystemConfig values, nil if not known.
+	SystemConfig *SystemConfigValues `json:"systemConfig,omitempty"`
+	// Implementations by proxy name.
+	Implementations map[string]common.Address `json:"implementations"`
+}
+
+// proxy ties the names of a proxy and its implementation in the L1Deployments.
+type proxy struct {
+	name           string
+	proxy          func(d *genesis.L1Deployments) common.Address
+	implementation func(d *genesis.L1Deployments) common.Address
+}
+
+var proxies = []proxy{
+	{"L1CrossDomainMessengerProxy",
+		func(d *genesis.L1Deployments) common.Address { return d.L1CrossDomainMessengerProxy },
+		func(d *genesis.L1Deployments) common.Address { return d.L1CrossDomainMessenger }},
+	{"L1ERC721BridgeProxy",
+		func(d *genesis.L1Deployments) common.Address { return d.L1ERC721BridgeProxy },
+		func(d *genesis.L1Deployments) common.Address { return d.L1ERC721Bridge }},
+	{"L1StandardBridgeProxy",
+		func(d *genesis.L1Deployments) common.Address { return d.L1StandardBridgeProxy },
+		func(d *genesis.L1Deployments) common.Address { return d.L1StandardBridge }},
+	{"L2OutputOracleProxy",
+		func(d *genesis.L1Deployments) common.Address { return d.L2OutputOracleProxy },
+		func(d *genesis.L1Deployments) common.Address { return d.L2OutputOracle }},
+	{"OptimismMintableERC20FactoryProxy",
+		func(d *genesis.L1Deployments) common.Address { return d.OptimismMintableERC20FactoryProxy },
+		func(d *genesis.L1Deployments) common.Address { return d.OptimismMintableERC20Factory }},
+	{"OptimismPortalProxy",
+		func(d *genesis.L1Deployments) common.Address { return d.OptimismPortalProxy },
+		func(d *genesis.L1Deployments) common.Address { return d.OptimismPortal }},
+	{"SystemConfigProxy",
+		func(d *genesis.L1Deployments) common.Address { return d.SystemConfigProxy },
+		func(d *genesis.L1Deployments) common.Address { return d.SystemConfig }},
+}
+
+// StateFromConfig returns the state described by the deploy config and the deployments.
+func StateFromConfig(config *genesis.DeployConfig, deployments *genesis.L1Deployments) *State {
+	state := &State{
+		ForkTimes: forkTimes(config),
+		SystemConfig: &SystemConfigValues{
+			Owner:             config.FinalSystemOwner,
+			BatcherHash:       common.BytesToHash(config.BatchSenderAddress.Bytes()),
+			GasLimit:          uint64(config.L2GenesisBlockGasLimit),
+			Overhead:          common.BigToHash(new(big.Int).SetUint64(config.GasPriceOracleOverhead)),
+			Scalar:            config.FeeScalar(),
+			UnsafeBlockSigner: config.P2PSequencerAddress,
+		},
+		Implementations: make(map[string]common.Address, len(proxies)),
+	}
+	for _, p := range proxies {
+		state.Implementations[p.name] = p.implementation(deployments)
+	}
+	return state
+}
+
+// forkTimes collects the L2Genesis<Fork>TimeOffset fields of the deploy config.
+func forkTimes(config *genesis.DeployConfig) map[string]*uint64 {
+	out := make(map[string]*uint64)
+	val := reflect.ValueOf(config).Elem()
+	for i := 0; i < val.NumField(); i++ {
+		name := val.Type().Field(i).Name
+		if !strings.HasPrefix(name, "L2Genesis") || !strings.HasSuffix(name, "TimeOffset") {
+			continue
+		}
+		fork := strings.TrimSuffix(strings.TrimPrefix(name, "L2Genesis"), "TimeOffset")
+		if offset, ok := val.Field(i).Interface().(*hexutil.Uint64); ok && offset != nil {
+			v := uint64(*offset)
+			out[fork] = &v
+		} else {
+			out[fork] = nil
+		}
+	}
+	return out
+}
+
+// StateFromChain reads the state of the deployed contracts.
+// The proxies are read from the deployments, the implementations of the deployments are ignored.
+func StateFromChain(ctx context.Context, deployments *genesis.L1Deployments, backend bind.ContractCaller) (*State, error) {
+	opts := &bind.CallOpts{Context: ctx}
+	proxyAdmin, err := bindings.NewProxyAdminCaller(deployments.ProxyAdmin, backend)
+	if err != nil {
+		return nil, err
+	}
+	state := &State{Implementations: make(map[string]common.Address, len(proxies))}
+	for _, p := range proxies {
+		impl, err := proxyAdmin.GetProxyImplementation(opts, p.proxy(deployments))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read implementation of %s: %w", p.name, err)
+		}
+		state.Implementations[p.name] = impl
+	}
+
+	systemConfig, err := bindings.NewSystemConfigCaller(deployments.SystemConfigProxy, backend)
+	if err != nil {
+		return nil, err
+	}
+	var values SystemConfigValues
+	if values.Owner, err = systemConfig.Owner(opts); err != nil {
+		return nil, fmt.Errorf("failed to read SystemConfig owner: %w", err)
+	}
+	if values.BatcherHash, err = systemConfig.BatcherHash(opts); err != nil {
+		return nil, fmt.Errorf("failed to read SystemConfig batcher hash: %w", err)
+	}
+	if values.GasLimit, err = systemConfig.GasLimit(opts); err != nil {
+		return nil, fmt.Errorf("failed to read SystemConfig gas limit: %w", err)
+	}
+	overhead, err := systemConfig.Overhead(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SystemConfig overhead: %w", err)
+	}
+	values.Overhead = common.BigToHash(overhead)
+	scalar, err := systemConfig.Scalar(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SystemConfig scalar: %w", err)
+	}
+	values.Scalar = common.BigToHash(scalar)
+	if values.UnsafeBlockSigner, err = systemConfig.UnsafeBlockSigner(opts); err != nil {
+		return nil, fmt.Errorf("failed to read SystemConfig unsafe block signer: %w", err)
+	}
+	state.SystemConfig = &values
+	return state, nil
+}
+
+// Diff returns the changes to go from one state to the other, ordered by kind and name.
+// Fork times and system config values are only compared if both states have them.
+func Diff(from, to *State) []Change {
+	var changes []Change
+	if from.ForkTimes != nil && to.ForkTimes != nil {
+		for fork := range union(from.ForkTimes, to.ForkTimes) {
+			if a, b := fmtOffset(from.ForkTimes[fork]), fmtOffset(to.ForkTimes[fork]); a != b {
+				changes = append(changes, Change{Kind: ForkTimeChange, Name: fork, From: a, To: b})
+			}
+		}
+	}
+	if from.SystemConfig != nil && to.SystemConfig != nil {
+		a, b := from.SystemConfig, to.SystemConfig
+		add := func(name string, from, to any) {
+			if x, y := fmt.Sprint(from), fmt.Sprint(to); x != y {
+				changes = append(changes, Change{Kind: SystemConfigChange, Name: name, From: x, To: y})
+			}
+		}
+		add("owner", a.Owner, b.Owner)
+		add("batcherHash", a.BatcherHash, b.BatcherHash)
+		add("gasLimit", a.GasLimit, b.GasLimit)
+		add("overhead", a.Overhead, b.Overhead)
+		add("scalar", a.Scalar, b.Scalar)
+		add("unsafeBlockSigner", a.UnsafeBlockSigner, b.UnsafeBlockSigner)
+	}
+	for name := range union(from.Implementations, to.Implementations) {
+		a, aok := from.Implementations[name]
+		b, bok := to.Implementations[name]
+		if a != b || aok != bok {
+			changes = append(changes, Change{Kind: ImplementationChange, Name: name, From: fmtAddress(a, aok), To: fmtAddress(b, bok)})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return changes[i].Kind < changes[j].Kind
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+func union[V any](a, b map[string]V) map[string]struct{} {
+	out := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		out[k] = struct{}{}
+	}
+	for k := range b {
+		out[k] = struct{}{}
+	}
+	return out
+}
+
+func fmtOffset(v *uint64) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(*v)
+}
+
+func fmtAddress(addr common.Address, ok bool) string {
+	if !ok {
+		return ""
+	}
+	return addr.String()
+}
+
+// Reconcile adds the calls to the batch that change the on-chain state of the deployment into the target state,
+// for the given changes. Changes that cannot be made on L1, like fork times, are skipped.
+// The calls must be executed by the owner of the ProxyAdmin and of the SystemConfig.
+func Reconcile(batch *safe.Batch, deployments *genesis.L1Deployments, target *State, changes []Change) error {
+	proxyAdminABI, err := bindings.ProxyAdminMetaData.GetAbi()
+	if err != nil {
+		return err
+	}
+	systemConfigABI, err := bindings.SystemConfigMetaData.GetAbi()
+	if err != nil {
+		return err
+	}
+	systemConfig := deployments.SystemConfigProxy
+
+	var gasConfigSet, ownerChanged bool
+	for _, change := range changes {
+		switch change.Kind {
+		case ImplementationChange:
+			var proxyAddr common.Address
+			for _, p := range proxies {
+				if p.name == change.Name {
+					proxyAddr = p.proxy(deployments)
+				}
+			}
+			if proxyAddr == (common.Address{}) {
+				return fmt.Errorf("unknown proxy %s", change.Name)
+			}
+			args := []any{proxyAddr, target.Implementations[change.Name]}
+			if err := batch.AddCall(deployments.ProxyAdmin, common.Big0, "upgrade(address,address)", args, proxyAdminABI); err != nil {
+				return fmt.Errorf("upgrading %s: %w", change.Name, err)
+			}
+		case SystemConfigChange:
+			values := target.SystemConfig
+			switch change.Name {
+			case "owner":
+				// transferred last, so the other calls are still made by the current owner
+				ownerChanged = true
+			case "batcherHash":
+				err = batch.AddCall(systemConfig, common.Big0, "setBatcherHash(bytes32)", []any{values.BatcherHash}, systemConfigABI)
+			case "gasLimit":
+				err = batch.AddCall(systemConfig, common.Big0, "setGasLimit(uint64)", []any{values.GasLimit}, systemConfigABI)
+			case "overhead", "scalar":
+				// both are set by the same call
+				if !gasConfigSet {
+					gasConfigSet = true
+					err = addGasConfigCall(batch, systemConfig, values, systemConfigABI)
+				}
+			case "unsafeBlockSigner":
+				err = batch.AddCall(systemConfig, common.Big0, "setUnsafeBlockSigner(address)", []any{values.UnsafeBlockSigner}, systemConfigABI)
+			default:
+				err = fmt.Errorf("unknown value %s", change.Name)
+			}
+			if err != nil {
+				return fmt.Errorf("setting SystemConfig %s: %w", change.Name, err)
+			}
+		}
+	}
+	if ownerChanged {
+		args := []any{target.SystemConfig.Owner}
+		if err := batch.AddCall(systemConfig, common.Big0, "transferOwnership(address)", args, systemConfigABI); err != nil {
+			return fmt.Errorf("transferring SystemConfig ownership: %w", err)
+		}
+	}
+	return nil
+}
+
+// addGasConfigCall adds the call that sets the gas config of the SystemConfig to the given values.
+// A version 0 scalar is set together with the overhead by setGasConfig, which rejects scalars with
+// a version byte. A version 1 scalar is set by setGasConfigEcotone from its base fee and blob base
+// fee scalars. The overhead is unused since Ecotone and left unchanged in that case.
+func addGasConfigCall(batch *safe.Batch, systemConfig common.Address, values *SystemConfigValues, iface *abi.ABI) error {
+	switch values.Scalar[0] {
+	case eth.L1ScalarBedrock:
+		args := []any{values.Overhead.Big(), values.Scalar.Big()}
+		return batch.AddCall(systemConfig, common.Big0, "setGasConfig(uint256,uint256)", args, iface)
+	case eth.L1ScalarEcotone:
+		if err := eth.CheckEcotoneL1SystemConfigScalar(values.Scalar); err != nil {
+			return err
+		}
+		scalars, err := eth.DecodeScalar(values.Scalar)
+		if err != nil {
+			return err
+		}
+		args := []any{scalars.BaseFeeScalar, scalars.BlobBaseFeeScalar}
+		return batch.AddCall(systemConfig, common.Big0, "setGasConfigEcotone(uint32,uint32)", args, iface)
+	default:
+		return fmt.Errorf("unrecognized scalar version: %d", values.Scalar[0])
+	}
+}
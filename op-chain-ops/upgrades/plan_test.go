@@ -0,0 +1,134 @@
+package upgrades
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/common/hexutil"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-bindings/bindings"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-chain-ops/genesis"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-chain-ops/safe"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+)
+
+func loadTestConfig(t *testing.T) (*genesis.DeployConfig, *genesis.L1Deployments) {
+	config, err := genesis.NewDeployConfig("../genesis/testdata/test-deploy-config-full.json")
+	require.NoError(t, err)
+	deployments, err := genesis.NewL1Deployments("../genesis/testdata/l1-deployments.json")
+	require.NoError(t, err)
+	return config, deployments
+}
+
+func TestDiff_Configs(t *testing.T) {
+	config, deployments := loadTestConfig(t)
+	from := StateFromConfig(config, deployments)
+	require.Empty(t, Diff(from, from))
+
+	offset := hexutil.Uint64(1000)
+	config.L2GenesisEcotoneTimeOffset = &offset
+	config.L2GenesisBlockGasLimit = 60_000_000
+	newPortal := common.Address{0xaa}
+	deployments.OptimismPortal = newPortal
+	to := StateFromConfig(config, deployments)
+
+	changes := Diff(from, to)
+	require.Equal(t, []Change{
+		{Kind: ForkTimeChange, Name: "Ecotone", From: "", To: "1000"},
+		{Kind: ImplementationChange, Name: "OptimismPortalProxy", From: from.Implementations["OptimismPortalProxy"].String(), To: newPortal.String()},
+		{Kind: SystemConfigChange, Name: "gasLimit", From: "30000000", To: "60000000"},
+	}, changes)
+}
+
+func TestDiff_OnChain(t *testing.T) {
+	config, deployments := loadTestConfig(t)
+	to := StateFromConfig(config, deployments)
+	// on-chain state has no fork times, which are then not compared
+	onChain := &State{
+		SystemConfig:    &SystemConfigValues{},
+		Implementations: map[string]common.Address{},
+	}
+	*onChain.SystemConfig = *to.SystemConfig
+	for k, v := range to.Implementations {
+		onChain.Implementations[k] = v
+	}
+	require.Empty(t, Diff(onChain, to))
+
+	onChain.SystemConfig.Owner = common.Address{0x01}
+	onChain.SystemConfig.Scalar = common.Hash{0x02}
+	onChain.SystemConfig.Overhead = common.Hash{0x03}
+	onChain.Implementations["L1StandardBridgeProxy"] = common.Address{0x04}
+	changes := Diff(onChain, to)
+	require.Len(t, changes, 4)
+
+	batch := safe.Batch{}
+	require.NoError(t, Reconcile(&batch, deployments, to, changes))
+	require.Len(t, batch.Transactions, 3)
+
+	upgrade := batch.Transactions[0]
+	require.Equal(t, deployments.ProxyAdmin, upgrade.To)
+	require.Equal(t, "upgrade(address,address)", upgrade.Signature())
+	require.Equal(t, deployments.L1StandardBridgeProxy.String(), upgrade.InputValues["_proxy"])
+	require.Equal(t, deployments.L1StandardBridge.String(), upgrade.InputValues["_implementation"])
+
+	// overhead and scalar are set with a single call
+	gasConfig := batch.Transactions[1]
+	require.Equal(t, deployments.SystemConfigProxy, gasConfig.To)
+	require.Equal(t, "setGasConfig(uint256,uint256)", gasConfig.Signature())
+	require.Equal(t, "2100", gasConfig.InputValues["_overhead"])
+	require.Equal(t, "1000000", gasConfig.InputValues["_scalar"])
+	systemConfigABI, err := bindings.SystemConfigMetaData.GetAbi()
+	require.NoError(t, err)
+	data, err := systemConfigABI.Pack("setGasConfig", big.NewInt(2100), big.NewInt(1_000_000))
+	require.NoError(t, err)
+	require.Equal(t, data, gasConfig.Data)
+
+	// the owner is transferred last, after the calls that require the current owner
+	require.Equal(t, "transferOwnership(address)", batch.Transactions[2].Signature())
+	require.NoError(t, batch.Check())
+}
+
+func TestReconcile_GasConfig(t *testing.T) {
+	config, deployments := loadTestConfig(t)
+	systemConfigABI, err := bindings.SystemConfigMetaData.GetAbi()
+	require.NoError(t, err)
+	changes := []Change{
+		{Kind: SystemConfigChange, Name: "overhead"},
+		{Kind: SystemConfigChange, Name: "scalar"},
+	}
+
+	t.Run("Ecotone", func(t *testing.T) {
+		config.GasPriceOracleScalar = 0
+		config.GasPriceOracleBaseFeeScalar = 1368
+		config.GasPriceOracleBlobBaseFeeScalar = 810949
+		target := StateFromConfig(config, deployments)
+		require.Equal(t, eth.L1ScalarEcotone, target.SystemConfig.Scalar[0])
+
+		batch := safe.Batch{}
+		require.NoError(t, Reconcile(&batch, deployments, target, changes))
+		require.Len(t, batch.Transactions, 1, "the overhead is unused since Ecotone")
+		gasConfig := batch.Transactions[0]
+		require.Equal(t, deployments.SystemConfigProxy, gasConfig.To)
+		require.Equal(t, "setGasConfigEcotone(uint32,uint32)", gasConfig.Signature())
+		require.Equal(t, "1368", gasConfig.InputValues["_basefeeScalar"])
+		require.Equal(t, "810949", gasConfig.InputValues["_blobbasefeeScalar"])
+		data, err := systemConfigABI.Pack("setGasConfigEcotone", uint32(1368), uint32(810949))
+		require.NoError(t, err)
+		require.Equal(t, data, gasConfig.Data)
+		require.NoError(t, batch.Check())
+	})
+
+	t.Run("InvalidEcotonePadding", func(t *testing.T) {
+		target := StateFromConfig(config, deployments)
+		target.SystemConfig.Scalar = common.Hash{eth.L1ScalarEcotone, 0x01}
+		require.ErrorContains(t, Reconcile(&safe.Batch{}, deployments, target, changes), "invalid version 1 scalar padding")
+	})
+
+	t.Run("UnknownVersion", func(t *testing.T) {
+		target := StateFromConfig(config, deployments)
+		target.SystemConfig.Scalar = common.Hash{0x02}
+		require.ErrorContains(t, Reconcile(&safe.Batch{}, deployments, target, changes), "unrecognized scalar version: 2")
+	})
+}
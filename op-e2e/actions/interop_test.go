@@ -0,0 +1,168 @@
+package actions
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	gosync "sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	ethereum "github.com/zircuit-labs/l2-geth-public"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/common/hexutil"
+	"github.com/zircuit-labs/l2-geth-public/core/types"
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/engine"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/interop"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/sync"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testlog"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testutils"
+)
+
+// interopChain is an in-process L2 chain of blocks and their logs. It serves the blocks and receipts
+// to the verifier of the chain, and as interop peer chain to the verifiers of other chains.
+type interopChain struct {
+	testutils.MockEngine
+
+	mu       gosync.Mutex
+	blocks   []eth.L2BlockRef
+	receipts map[common.Hash]types.Receipts
+}
+
+var _ L2API = (*interopChain)(nil)
+
+func newInteropChain(rng *rand.Rand) *interopChain {
+	genesis := testutils.RandomL2BlockRef(rng)
+	genesis.Number = 0
+	return &interopChain{blocks: []eth.L2BlockRef{genesis}, receipts: make(map[common.Hash]types.Receipts)}
+}
+
+func (c *interopChain) genesis() eth.L2BlockRef {
+	return c.blocks[0]
+}
+
+func (c *interopChain) addBlock(rng *rand.Rand, logs ...*types.Log) eth.L2BlockRef {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ref := testutils.NextRandomL2Ref(rng, 2, c.blocks[len(c.blocks)-1], eth.BlockID{})
+	for i, l := range logs {
+		l.Index = uint(i)
+	}
+	c.blocks = append(c.blocks, ref)
+	c.receipts[ref.Hash] = types.Receipts{{Logs: logs}}
+	return ref
+}
+
+func (c *interopChain) info(ref eth.L2BlockRef) eth.BlockInfo {
+	return &testutils.MockBlockInfo{InfoHash: ref.Hash, InfoParentHash: ref.ParentHash, InfoNum: ref.Number, InfoTime: ref.Time}
+}
+
+func (c *interopChain) L2BlockRefByNumber(ctx context.Context, num uint64) (eth.L2BlockRef, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if num >= uint64(len(c.blocks)) {
+		return eth.L2BlockRef{}, ethereum.NotFound
+	}
+	return c.blocks[num], nil
+}
+
+func (c *interopChain) InfoByNumber(ctx context.Context, num uint64) (eth.BlockInfo, error) {
+	ref, err := c.L2BlockRefByNumber(ctx, num)
+	if err != nil {
+		return nil, err
+	}
+	return c.info(ref), nil
+}
+
+func (c *interopChain) FetchReceipts(ctx context.Context, blockHash common.Hash) (eth.BlockInfo, types.Receipts, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ref := range c.blocks {
+		if ref.Hash == blockHash {
+			return c.info(ref), c.receipts[blockHash], nil
+		}
+	}
+	return nil, nil, ethereum.NotFound
+}
+
+func setupInteropVerifier(t Testing, chain *interopChain, chainID uint64) *L2Verifier {
+	interopTime := uint64(0)
+	cfg := &rollup.Config{
+		Genesis:     rollup.Genesis{L2: chain.genesis().ID()},
+		BlockTime:   2,
+		L2ChainID:   new(big.Int).SetUint64(chainID),
+		InteropTime: &interopTime,
+	}
+	// the verifier does not derive from L1, it only follows the unsafe chain
+	verifier := NewL2Verifier(t, testlog.Logger(t, log.LevelDebug), nil, nil, chain, cfg, &sync.Config{}, nil)
+	// the chain starts at genesis, like after an engine reset
+	genesis := chain.genesis()
+	verifier.synchronousEvents.Emit(engine.EngineResetConfirmedEvent{Unsafe: genesis, Safe: genesis, Finalized: genesis})
+	require.NoError(t, verifier.drainer.Drain())
+	return verifier
+}
+
+// actInteropUnsafe signals the new unsafe head of the chain to the verifier,
+// like the engine does once the block is inserted.
+func actInteropUnsafe(t Testing, verifier *L2Verifier, unsafe eth.L2BlockRef) {
+	status := verifier.SyncStatus()
+	verifier.synchronousEvents.Emit(engine.ForkchoiceUpdateEvent{UnsafeL2Head: unsafe, SafeL2Head: status.SafeL2, FinalizedL2Head: status.FinalizedL2})
+	require.NoError(t, verifier.drainer.Drain())
+}
+
+// TestInteropCrossUnsafe runs the verifiers of two chains, A and B. An executing message on chain A
+// holds the cross-unsafe head of chain A until chain B includes the initiating message.
+func TestInteropCrossUnsafe(gt *testing.T) {
+	t := NewDefaultTesting(gt)
+	rng := rand.New(rand.NewSource(1234))
+	const chainIDA, chainIDB = 901, 902
+
+	chainA, chainB := newInteropChain(rng), newInteropChain(rng)
+	verifierA := setupInteropVerifier(t, chainA, chainIDA)
+	verifierA.AddInteropPeer(t, chainIDB, chainB)
+	verifierB := setupInteropVerifier(t, chainB, chainIDB)
+	verifierB.AddInteropPeer(t, chainIDA, chainA)
+	require.Equal(t, chainA.genesis(), verifierA.L2CrossUnsafe())
+	require.Equal(t, chainB.genesis(), verifierB.L2CrossUnsafe())
+
+	// chain A executes a message that chain B initiates in its next block
+	initiating := &types.Log{Address: common.Address{0xaa}, Topics: []common.Hash{{0x01}}, Data: []byte("hello")}
+	nextB := testutils.NextRandomL2Ref(rng, 2, chainB.genesis(), eth.BlockID{})
+	msg := interop.ExecutingMessage{
+		Identifier: interop.Identifier{
+			Origin:      initiating.Address,
+			BlockNumber: hexutil.Uint64(nextB.Number),
+			LogIndex:    0,
+			Timestamp:   hexutil.Uint64(nextB.Time),
+			ChainID:     chainIDB,
+		},
+		PayloadHash: interop.LogPayloadHash(initiating),
+	}
+	a1 := chainA.addBlock(rng, interop.ExecutingMessageLog(msg))
+	actInteropUnsafe(t, verifierA, a1)
+	verifierA.ActL2CrossUnsafeCheck(t)
+	require.Equal(t, chainA.genesis(), verifierA.L2CrossUnsafe(), "held until chain B has the initiating block")
+
+	// chain B includes the initiating message, and becomes cross-unsafe without dependencies
+	b1 := chainB.addBlock(rng, initiating)
+	require.Equal(t, nextB.Number, b1.Number)
+	require.Equal(t, nextB.Time, b1.Time)
+	actInteropUnsafe(t, verifierB, b1)
+	verifierB.ActL2CrossUnsafeCheck(t)
+	require.Equal(t, b1, verifierB.L2CrossUnsafe())
+
+	// on the next forkchoice update, chain A checks the dependency again, and advances
+	actInteropUnsafe(t, verifierA, a1)
+	verifierA.ActL2CrossUnsafeCheck(t)
+	require.Equal(t, a1, verifierA.L2CrossUnsafe())
+
+	// blocks without dependencies follow right away
+	a2 := chainA.addBlock(rng)
+	actInteropUnsafe(t, verifierA, a2)
+	verifierA.ActL2CrossUnsafeCheck(t)
+	require.Equal(t, a2, verifierA.L2CrossUnsafe())
+}
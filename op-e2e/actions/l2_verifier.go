@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"golang.org/x/time/rate"
 
 	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/core/types"
 	"github.com/zircuit-labs/l2-geth-public/log"
 	gnode "github.com/zircuit-labs/l2-geth-public/node"
 	"github.com/zircuit-labs/l2-geth-public/rpc"
@@ -23,6 +25,7 @@ import (
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/engine"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/event"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/finality"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/interop"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/status"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/sync"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/client"
@@ -61,6 +64,9 @@ type L2Verifier struct {
 
 	rollupCfg *rollup.Config
 
+	// checks executing messages if interop is scheduled, nil otherwise
+	interopChecker *interop.MessageChecker
+
 	rpc *rpc.Server
 
 	failRPC func(call []rpc.BatchElem) error // mock error
@@ -77,6 +83,8 @@ type L2API interface {
 	// GetProof returns a proof of the account, it may return a nil result without error if the address was not found.
 	GetProof(ctx context.Context, address common.Address, storage []common.Hash, blockTag string) (*eth.AccountResult, error)
 	OutputV0AtBlock(ctx context.Context, blockHash common.Hash) (*eth.OutputV0, error)
+	InfoByNumber(ctx context.Context, number uint64) (eth.BlockInfo, error)
+	FetchReceipts(ctx context.Context, blockHash common.Hash) (eth.BlockInfo, types.Receipts, error)
 }
 
 type safeDB interface {
@@ -144,6 +152,14 @@ func NewL2Verifier(t Testing, log log.Logger, l1 derive.L1Fetcher, blobsSrc deri
 
 	sys.Register("engine", engine.NewEngDeriver(log, ctx, cfg, metrics, ec), opts)
 
+	var interopChecker *interop.MessageChecker
+	if cfg.InteropTime != nil {
+		interopChecker = interop.NewMessageChecker()
+		interopChecker.AddChain(cfg.L2ChainID.Uint64(), eng)
+		checks := sys.Register("cross-unsafe-checks", nil, opts)
+		sys.Register("cross-unsafe", interop.NewCrossUnsafeTracker(ctx, log, cfg, eng, interopChecker, checks), opts)
+	}
+
 	rollupNode := &L2Verifier{
 		eventSys:          sys,
 		log:               log,
@@ -158,6 +174,7 @@ func NewL2Verifier(t Testing, log log.Logger, l1 derive.L1Fetcher, blobsSrc deri
 		l2PipelineIdle:    true,
 		l2Building:        false,
 		rollupCfg:         cfg,
+		interopChecker:    interopChecker,
 		rpc:               rpc.NewServer(),
 		synchronousEvents: testActionEmitter,
 	}
@@ -183,6 +200,14 @@ func NewL2Verifier(t Testing, log log.Logger, l1 derive.L1Fetcher, blobsSrc deri
 			Authenticated: false,
 		},
 	}
+	if interopChecker != nil {
+		apis = append(apis, rpc.API{
+			Namespace:     "interop",
+			Service:       node.NewInteropAPI(interopChecker, m),
+			Public:        true,
+			Authenticated: false,
+		})
+	}
 	require.NoError(t, gnode.RegisterApis(apis, nil, rollupNode.rpc), "failed to set up APIs")
 	return rollupNode
 }
@@ -245,6 +270,29 @@ func (s *L2Verifier) L2BackupUnsafe() eth.L2BlockRef {
 	return s.engine.BackupUnsafeL2Head()
 }
 
+// AddInteropPeer registers the L2 chain of another actor as interop peer chain,
+// to check the executing messages of this chain against.
+func (s *L2Verifier) AddInteropPeer(t Testing, chainID uint64, peer interop.ChainSource) {
+	require.NotNil(t, s.interopChecker, "interop must be scheduled to add an interop peer")
+	s.interopChecker.AddChain(chainID, peer)
+}
+
+func (s *L2Verifier) L2CrossUnsafe() eth.L2BlockRef {
+	return s.SyncStatus().CrossUnsafeL2
+}
+
+// ActL2CrossUnsafeCheck processes events until the dependencies of the block after the cross-unsafe head
+// are checked. The checks run outside of the event processing, the result is awaited.
+func (s *L2Verifier) ActL2CrossUnsafeCheck(t Testing) {
+	require.Eventually(t, func() bool {
+		return s.drainer.DrainUntil(func(ev event.Event) bool {
+			_, ok := ev.(interop.CrossUnsafeCheckEvent)
+			return ok
+		}, false) == nil
+	}, 10*time.Second, 10*time.Millisecond, "dependencies of the next block were not checked")
+	require.NoError(t, s.drainer.Drain(), "complete all event processing triggered by the check")
+}
+
 func (s *L2Verifier) SyncStatus() *eth.SyncStatus {
 	return s.syncStatus.SyncStatus()
 }
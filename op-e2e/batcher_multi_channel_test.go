@@ -0,0 +1,118 @@
+package op_e2e
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/common/hexutil"
+	"github.com/zircuit-labs/l2-geth-public/core"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-batcher/batcher"
+	batcherFlags "github.com/zircuit-labs/zkr-monorepo-public/op-batcher/flags"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-batcher/metrics"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-e2e/e2eutils/wait"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testutils"
+)
+
+// batcherTxStats are the frames and channels of all batcher transactions, as recorded in the
+// batcher metrics.
+type batcherTxStats struct {
+	txs      uint64
+	frames   float64
+	channels float64
+}
+
+func (s batcherTxStats) framesPerTx() float64 {
+	return s.frames / float64(s.txs)
+}
+
+// TestBatcherMultiChannelBurst submits a burst of L2 blocks which take several channels each, once
+// with single-channel submission and once with multi-channel submission. Filling blob transactions
+// with the frames of multiple channels needs fewer transactions for the same frames.
+func TestBatcherMultiChannelBurst(t *testing.T) {
+	single := testBatcherBurst(t, 1)
+	multi := testBatcherBurst(t, 4)
+	t.Logf("single-channel: %+v, multi-channel: %+v", single, multi)
+
+	require.Equal(t, float64(single.txs), single.channels, "single-channel txs contain frames of one channel")
+	require.Greater(t, multi.channels, float64(multi.txs), "multi-channel txs contain frames of multiple channels")
+	require.Greater(t, multi.framesPerTx(), single.framesPerTx(), "multi-channel txs contain more frames")
+}
+
+func testBatcherBurst(t *testing.T, maxConcurrentChannels int) batcherTxStats {
+	cfg := DefaultSystemConfig(t)
+	cfg.DataAvailabilityType = batcherFlags.BlobsType
+	cfg.BatcherTargetNumFrames = 6
+	cfg.BatcherUseMaxTxSizeForBlobs = true
+	// every L2 block with a user tx with 400 random bytes takes 5 frames of a small channel
+	cfg.BatcherMaxL1TxSizeBytes = derive.FrameV0OverHeadSize + 100
+	cfg.BatcherMaxConcurrentChannels = maxConcurrentChannels
+	cfg.EnableBatcherMetrics = true
+	// the burst of L2 blocks is submitted at once, when the batcher gets started
+	cfg.DisableBatcher = true
+
+	genesisActivation := hexutil.Uint64(0)
+	cfg.DeployConfig.L1CancunTimeOffset = &genesisActivation
+	cfg.DeployConfig.L2GenesisDeltaTimeOffset = &genesisActivation
+	cfg.DeployConfig.L2GenesisEcotoneTimeOffset = &genesisActivation
+
+	sys, err := cfg.Start(t)
+	require.NoError(t, err, "Error starting up system")
+	defer sys.Close()
+
+	l2Seq := sys.Clients["sequencer"]
+	rng := rand.New(rand.NewSource(1234))
+	const burstSize = 10
+	var lastBlock uint64
+	for i := 0; i < burstSize; i++ {
+		receipt := SendL2Tx(t, cfg, l2Seq, cfg.Secrets.Alice, func(opts *TxOpts) {
+			opts.Nonce = uint64(i)
+			opts.Value = big.NewInt(1_000_000_000)
+			opts.ToAddr = &common.Address{0xff, 0xff}
+			opts.Data = testutils.RandomData(rng, 400)
+			opts.Gas, err = core.IntrinsicGas(opts.Data, nil, false, true, true, false)
+			require.NoError(t, err)
+		})
+		lastBlock = receipt.BlockNumber.Uint64()
+	}
+
+	driver := sys.BatchSubmitter.TestDriver()
+	require.NoError(t, driver.StartBatchSubmitting())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	require.NoError(t, wait.ForSafeBlock(ctx, sys.RollupClient("verifier"), lastBlock))
+
+	return gatherBatcherTxStats(t, sys.BatchSubmitter)
+}
+
+func gatherBatcherTxStats(t *testing.T, bs *batcher.BatcherService) batcherTxStats {
+	m, ok := bs.Metrics.(*metrics.Metrics)
+	require.True(t, ok, "batcher metrics must be enabled")
+	families, err := m.Registry().Gather()
+	require.NoError(t, err)
+
+	var stats batcherTxStats
+	for _, family := range families {
+		if len(family.GetMetric()) == 0 {
+			continue
+		}
+		histogram := family.GetMetric()[0].GetHistogram()
+		switch family.GetName() {
+		case metrics.Namespace + "_default_tx_frames":
+			stats.txs = histogram.GetSampleCount()
+			stats.frames = histogram.GetSampleSum()
+		case metrics.Namespace + "_default_tx_channels":
+			stats.channels = histogram.GetSampleSum()
+		}
+	}
+	require.NotZero(t, stats.txs, "no batcher txs recorded")
+	return stats
+}
@@ -153,21 +153,22 @@ func Setup(t require.TestingT, deployParams *DeployParams, alloc *AllocParams) *
 			L2Time:       uint64(deployConf.L1GenesisBlockTimestamp),
 			SystemConfig: SystemConfigFromDeployConfig(deployConf),
 		},
-		BlockTime:              deployConf.L2BlockTime,
-		MaxSequencerDrift:      deployConf.MaxSequencerDrift,
-		SeqWindowSize:          deployConf.SequencerWindowSize,
-		ChannelTimeoutBedrock:  deployConf.ChannelTimeout,
-		L1ChainID:              new(big.Int).SetUint64(deployConf.L1ChainID),
-		L2ChainID:              new(big.Int).SetUint64(deployConf.L2ChainID),
-		BatchInboxAddress:      deployConf.BatchInboxAddress,
-		DepositContractAddress: deployConf.OptimismPortalProxy,
-		L1SystemConfigAddress:  deployConf.SystemConfigProxy,
-		RegolithTime:           deployConf.RegolithTime(uint64(deployConf.L1GenesisBlockTimestamp)),
-		CanyonTime:             deployConf.CanyonTime(uint64(deployConf.L1GenesisBlockTimestamp)),
-		DeltaTime:              deployConf.DeltaTime(uint64(deployConf.L1GenesisBlockTimestamp)),
-		EcotoneTime:            deployConf.EcotoneTime(uint64(deployConf.L1GenesisBlockTimestamp)),
-		FjordTime:              deployConf.FjordTime(uint64(deployConf.L1GenesisBlockTimestamp)),
-		InteropTime:            deployConf.InteropTime(uint64(deployConf.L1GenesisBlockTimestamp)),
+		BlockTime:               deployConf.L2BlockTime,
+		MaxSequencerDrift:       deployConf.MaxSequencerDrift,
+		SeqWindowSize:           deployConf.SequencerWindowSize,
+		ChannelTimeoutBedrock:   deployConf.ChannelTimeout,
+		L1ChainID:               new(big.Int).SetUint64(deployConf.L1ChainID),
+		L2ChainID:               new(big.Int).SetUint64(deployConf.L2ChainID),
+		BatchInboxAddress:       deployConf.BatchInboxAddress,
+		DepositContractAddress:  deployConf.OptimismPortalProxy,
+		L1SystemConfigAddress:   deployConf.SystemConfigProxy,
+		RegolithTime:            deployConf.RegolithTime(uint64(deployConf.L1GenesisBlockTimestamp)),
+		CanyonTime:              deployConf.CanyonTime(uint64(deployConf.L1GenesisBlockTimestamp)),
+		DeltaTime:               deployConf.DeltaTime(uint64(deployConf.L1GenesisBlockTimestamp)),
+		EcotoneTime:             deployConf.EcotoneTime(uint64(deployConf.L1GenesisBlockTimestamp)),
+		FjordTime:               deployConf.FjordTime(uint64(deployConf.L1GenesisBlockTimestamp)),
+		InteropTime:             deployConf.InteropTime(uint64(deployConf.L1GenesisBlockTimestamp)),
+		SpanBatchExclusionsTime: deployConf.SpanBatchExclusionsTime(uint64(deployConf.L1GenesisBlockTimestamp)),
 	}
 
 	require.NoError(t, rollupCfg.Check())
@@ -17,6 +17,7 @@ import (
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/cmd/batch_decoder/reassemble"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive"
 	oplog "github.com/zircuit-labs/zkr-monorepo-public/op-service/log"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/caching"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/l1"
 	l1client "github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/l1/client"
 )
@@ -72,6 +73,16 @@ func main() {
 					Value: 10,
 					Usage: "Concurrency level when fetching L1",
 				},
+				&cli.StringFlag{
+					Name:    "l1.cache.dir",
+					Usage:   "Directory to persist fetched blobs in, so they are not fetched again. Disabled if empty.",
+					EnvVars: []string{"L1_CACHE_DIR"},
+				},
+				&cli.Uint64Flag{
+					Name:  "l1.cache.size",
+					Value: 4096,
+					Usage: "Size budget in MiB of the persisted blobs, the least recently used blobs are evicted first.",
+				},
 			},
 			Action: func(cliCtx *cli.Context) error {
 				l1Client, err := l1ethclient.Dial(cliCtx.String("l1"))
@@ -89,6 +100,12 @@ func main() {
 				if beaconAddr != "" {
 					beaconClient := l1.NewBeaconHTTPClient(l1client.NewBasicHTTPClient(beaconAddr, nil))
 					beaconCfg := l1.L1BeaconClientConfig{FetchAllSidecars: false}
+					if dir := cliCtx.String("l1.cache.dir"); dir != "" {
+						beaconCfg.PersistentCache, err = caching.OpenDiskStore(dir, int64(cliCtx.Uint64("l1.cache.size")<<20))
+						if err != nil {
+							log.Fatal(fmt.Errorf("failed to open L1 cache: %w", err))
+						}
+					}
 					beacon = l1.NewL1BeaconClient(beaconClient, beaconCfg)
 					_, err := beacon.GetVersion(ctx)
 					if err != nil {
@@ -135,7 +135,7 @@ func processFrames(cfg Config, rollupCfg *rollup.Config, id derive.ChannelID, fr
 						}
 						// singularBatch will be nil when errored
 						batches = append(batches, singularBatch)
-					case derive.SpanBatchType:
+					case derive.SpanBatchType, derive.SpanBatchWithExclusionsType:
 						spanBatch, err := derive.DeriveSpanBatch(batchData, cfg.L2BlockTime, cfg.L2GenesisTime, cfg.L2ChainID)
 						if err != nil {
 							invalidBatches = true
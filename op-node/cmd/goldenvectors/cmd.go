@@ -0,0 +1,97 @@
+package goldenvectors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/urfave/cli/v2"
+)
+
+var dirFlag = &cli.PathFlag{
+	Name:     "dir",
+	Usage:    "Directory of the golden vectors. The vectors are in a sub-directory per version, e.g. v1/frames.json",
+	Required: true,
+}
+
+var Subcommands = []*cli.Command{
+	{
+		Name:  "generate",
+		Usage: "Writes the golden vectors of frames, channels, batches and L1 info deposits, as encoded by this version",
+		Flags: []cli.Flag{dirFlag},
+		Action: func(cliCtx *cli.Context) error {
+			return WriteCorpus(cliCtx.Path(dirFlag.Name))
+		},
+	},
+	{
+		Name:  "verify",
+		Usage: "Checks that the golden vectors of the current version match the encoding of this version",
+		Flags: []cli.Flag{dirFlag},
+		Action: func(cliCtx *cli.Context) error {
+			return VerifyCorpus(cliCtx.Path(dirFlag.Name))
+		},
+	},
+}
+
+// VersionDir returns the directory of the vectors of the current version.
+func VersionDir(dir string) string {
+	return filepath.Join(dir, fmt.Sprintf("v%d", Version))
+}
+
+func encodeFile(v any) ([]byte, error) {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// WriteCorpus generates the golden vectors and writes them to the directory of the current version.
+func WriteCorpus(dir string) error {
+	corpus, err := Generate()
+	if err != nil {
+		return fmt.Errorf("failed to generate golden vectors: %w", err)
+	}
+	dir = VersionDir(dir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create golden vectors dir: %w", err)
+	}
+	files := corpus.Files()
+	for _, kind := range Kinds {
+		data, err := encodeFile(files[kind])
+		if err != nil {
+			return fmt.Errorf("failed to encode %s vectors: %w", kind, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, kind+".json"), data, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s vectors: %w", kind, err)
+		}
+	}
+	return nil
+}
+
+// VerifyCorpus checks that the golden vectors of the current version in the directory
+// are exactly the vectors generated with the current encoding.
+func VerifyCorpus(dir string) error {
+	corpus, err := Generate()
+	if err != nil {
+		return fmt.Errorf("failed to generate golden vectors: %w", err)
+	}
+	dir = VersionDir(dir)
+	files := corpus.Files()
+	for _, kind := range Kinds {
+		expected, err := encodeFile(files[kind])
+		if err != nil {
+			return fmt.Errorf("failed to encode %s vectors: %w", kind, err)
+		}
+		actual, err := os.ReadFile(filepath.Join(dir, kind+".json"))
+		if err != nil {
+			return fmt.Errorf("failed to read %s vectors: %w", kind, err)
+		}
+		if !bytes.Equal(expected, actual) {
+			return fmt.Errorf("%s vectors of version %d do not match the current encoding", kind, Version)
+		}
+	}
+	return nil
+}
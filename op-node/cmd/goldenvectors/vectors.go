@@ -0,0 +1,547 @@
+package goldenvectors
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/common/hexutil"
+	"github.com/zircuit-labs/l2-geth-public/core/types"
+	"github.com/zircuit-labs/l2-geth-public/crypto"
+	"github.com/zircuit-labs/l2-geth-public/rlp"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+)
+
+// Version of the golden vectors. It is bumped whenever the vectors of an existing kind change,
+// so that implementations can tell which revision of the encoding they are tested against.
+const Version = 1
+
+const (
+	KindFrames          = "frames"
+	KindChannels        = "channels"
+	KindSingularBatches = "singular_batches"
+	KindSpanBatches     = "span_batches"
+	KindL1Info          = "l1_info"
+)
+
+// Kinds lists the kinds of golden vectors, each is written to its own file.
+var Kinds = []string{KindFrames, KindChannels, KindSingularBatches, KindSpanBatches, KindL1Info}
+
+// File is the content of a golden vector file.
+type File[V any] struct {
+	Version int    `json:"version"`
+	Kind    string `json:"kind"`
+	Vectors []V    `json:"vectors"`
+}
+
+// FrameVector is a single frame and its encoding.
+type FrameVector struct {
+	Name        string           `json:"name"`
+	ChannelID   derive.ChannelID `json:"channel_id"`
+	FrameNumber uint16           `json:"frame_number"`
+	Data        hexutil.Bytes    `json:"data"`
+	IsLast      bool             `json:"is_last"`
+
+	Encoded hexutil.Bytes `json:"encoded"`
+}
+
+// ChannelVector is a channel of batches, and the frames it is split in.
+// The batcher submits a frame as DerivationVersion0 ++ frame.
+type ChannelVector struct {
+	Name string `json:"name"`
+	// Type is either "singular" for a channel of singular batches, or "span" for a channel of a single span batch.
+	Type             string                  `json:"type"`
+	CompressionAlgo  derive.CompressionAlgo  `json:"compression_algo"`
+	ChannelID        derive.ChannelID        `json:"channel_id"`
+	MaxFrameSize     uint64                  `json:"max_frame_size"`
+	GenesisTimestamp uint64                  `json:"genesis_timestamp"`
+	ChainID          *hexutil.Big            `json:"chain_id"`
+	WithExclusions   bool                    `json:"with_exclusions"`
+	Batches          []*derive.SingularBatch `json:"batches"`
+	SequenceNumbers  []uint64                `json:"sequence_numbers"`
+
+	// Uncompressed is the RLP stream of the batches in the channel
+	Uncompressed hexutil.Bytes `json:"uncompressed"`
+	// Compressed is the channel data, the concatenation of the data of all frames
+	Compressed hexutil.Bytes   `json:"compressed"`
+	Frames     []hexutil.Bytes `json:"frames"`
+}
+
+// SingularBatchVector is a singular batch and its typed encoding.
+type SingularBatchVector struct {
+	Name  string                `json:"name"`
+	Batch *derive.SingularBatch `json:"batch"`
+
+	Encoded hexutil.Bytes `json:"encoded"`
+}
+
+// SpanBatchVector is a list of singular batches, and the typed encoding of the raw span batch of them.
+type SpanBatchVector struct {
+	Name             string                  `json:"name"`
+	GenesisTimestamp uint64                  `json:"genesis_timestamp"`
+	ChainID          *hexutil.Big            `json:"chain_id"`
+	WithExclusions   bool                    `json:"with_exclusions"`
+	Batches          []*derive.SingularBatch `json:"batches"`
+	SequenceNumbers  []uint64                `json:"sequence_numbers"`
+
+	Encoded hexutil.Bytes `json:"encoded"`
+}
+
+// L1InfoVector is the L1 info deposit of a L2 block, with its calldata and its encoded transaction.
+type L1InfoVector struct {
+	Name string `json:"name"`
+	// Ecotone is whether the L2 block is an Ecotone block, after the Ecotone activation block.
+	// All vectors are Regolith blocks.
+	Ecotone           bool           `json:"ecotone"`
+	L2BlockTime       uint64         `json:"l2_block_time"`
+	SequenceNumber    uint64         `json:"sequence_number"`
+	L1Number          uint64         `json:"l1_number"`
+	L1Time            uint64         `json:"l1_time"`
+	L1Hash            common.Hash    `json:"l1_hash"`
+	L1BaseFee         *hexutil.Big   `json:"l1_base_fee"`
+	L1BlobBaseFee     *hexutil.Big   `json:"l1_blob_base_fee"`
+	BatcherAddr       common.Address `json:"batcher_addr"`
+	Overhead          eth.Bytes32    `json:"overhead"`
+	Scalar            eth.Bytes32    `json:"scalar"`
+	DepositExclusions hexutil.Bytes  `json:"deposit_exclusions"`
+
+	Function  string        `json:"function"`
+	Calldata  hexutil.Bytes `json:"calldata"`
+	DepositTx hexutil.Bytes `json:"deposit_tx"`
+}
+
+// Corpus holds the golden vectors of all kinds.
+type Corpus struct {
+	Frames          File[FrameVector]
+	Channels        File[ChannelVector]
+	SingularBatches File[SingularBatchVector]
+	SpanBatches     File[SpanBatchVector]
+	L1Info          File[L1InfoVector]
+}
+
+// Files returns the files of the corpus by kind.
+func (c *Corpus) Files() map[string]any {
+	return map[string]any{
+		KindFrames:          &c.Frames,
+		KindChannels:        &c.Channels,
+		KindSingularBatches: &c.SingularBatches,
+		KindSpanBatches:     &c.SpanBatches,
+		KindL1Info:          &c.L1Info,
+	}
+}
+
+var (
+	chainID          = big.NewInt(901)
+	genesisTimestamp = uint64(1_700_000_000)
+	blockTime        = uint64(2)
+	channelID        = derive.ChannelID{0xc0, 0xff, 0xee, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d}
+
+	// the key signing the transactions of the batches. Signatures are deterministic (RFC 6979).
+	txKeyHex = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+)
+
+// Generate creates all golden vectors from the fixed inputs, with the current encoding.
+func Generate() (*Corpus, error) {
+	batches, err := inputBatches()
+	if err != nil {
+		return nil, err
+	}
+	frames, err := frameVectors()
+	if err != nil {
+		return nil, fmt.Errorf("frames: %w", err)
+	}
+	channels, err := channelVectors(batches)
+	if err != nil {
+		return nil, fmt.Errorf("channels: %w", err)
+	}
+	singular, err := singularBatchVectors(batches)
+	if err != nil {
+		return nil, fmt.Errorf("singular batches: %w", err)
+	}
+	span, err := spanBatchVectors(batches)
+	if err != nil {
+		return nil, fmt.Errorf("span batches: %w", err)
+	}
+	l1Info, err := l1InfoVectors()
+	if err != nil {
+		return nil, fmt.Errorf("L1 info: %w", err)
+	}
+	return &Corpus{
+		Frames:          File[FrameVector]{Version: Version, Kind: KindFrames, Vectors: frames},
+		Channels:        File[ChannelVector]{Version: Version, Kind: KindChannels, Vectors: channels},
+		SingularBatches: File[SingularBatchVector]{Version: Version, Kind: KindSingularBatches, Vectors: singular},
+		SpanBatches:     File[SpanBatchVector]{Version: Version, Kind: KindSpanBatches, Vectors: span},
+		L1Info:          File[L1InfoVector]{Version: Version, Kind: KindL1Info, Vectors: l1Info},
+	}, nil
+}
+
+// inputBatches returns three consecutive batches, spanning two epochs.
+// The first batch starts an epoch and excludes a deposit, the last batch starts the next epoch.
+func inputBatches() ([]*derive.SingularBatch, error) {
+	key, err := crypto.HexToECDSA(txKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	signer := types.LatestSignerForChainID(chainID)
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	txs := []types.TxData{
+		&types.LegacyTx{Nonce: 0, GasPrice: big.NewInt(1_000_000_000), Gas: 21_000, To: &to, Value: big.NewInt(1_000_000_000_000_000)},
+		&types.AccessListTx{
+			ChainID: chainID, Nonce: 1, GasPrice: big.NewInt(1_000_000_000), Gas: 50_000, To: &to,
+			Value: big.NewInt(0), Data: []byte{0xde, 0xad, 0xbe, 0xef},
+			AccessList: types.AccessList{{Address: to, StorageKeys: []common.Hash{{0x01}}}},
+		},
+		&types.DynamicFeeTx{
+			ChainID: chainID, Nonce: 2, GasTipCap: big.NewInt(1_000), GasFeeCap: big.NewInt(2_000_000_000), Gas: 60_000, To: &to,
+			Value: big.NewInt(42), Data: []byte("golden"),
+		},
+		// contract creation
+		&types.DynamicFeeTx{
+			ChainID: chainID, Nonce: 3, GasTipCap: big.NewInt(1_000), GasFeeCap: big.NewInt(2_000_000_000), Gas: 100_000,
+			Value: big.NewInt(0), Data: []byte{0x60, 0x00, 0x60, 0x00, 0xf3},
+		},
+	}
+	opaque := make([]hexutil.Bytes, len(txs))
+	for i, data := range txs {
+		tx, err := types.SignNewTx(key, signer, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign tx %d: %w", i, err)
+		}
+		if opaque[i], err = tx.MarshalBinary(); err != nil {
+			return nil, fmt.Errorf("failed to encode tx %d: %w", i, err)
+		}
+	}
+
+	// exclude the deposit at index 2 of the first block, index 0 is the L1 info deposit
+	exclusions := derive.EmptyBitmap(4)
+	exclusions.Set(2)
+
+	timestamp := genesisTimestamp + 1000*blockTime
+	return []*derive.SingularBatch{
+		{
+			ParentHash:        common.Hash{0xaa, 0x01},
+			EpochNum:          100,
+			EpochHash:         common.Hash{0xbb, 0x01},
+			Timestamp:         timestamp,
+			Transactions:      opaque[0:2],
+			DepositExclusions: exclusions.MustBytes(),
+		},
+		{
+			ParentHash:   common.Hash{0xaa, 0x02},
+			EpochNum:     100,
+			EpochHash:    common.Hash{0xbb, 0x01},
+			Timestamp:    timestamp + blockTime,
+			Transactions: opaque[2:3],
+		},
+		{
+			ParentHash:   common.Hash{0xaa, 0x03},
+			EpochNum:     101,
+			EpochHash:    common.Hash{0xbb, 0x02},
+			Timestamp:    timestamp + 2*blockTime,
+			Transactions: opaque[3:4],
+		},
+	}, nil
+}
+
+// batchSequenceNumbers are the sequence numbers of the input batches
+var batchSequenceNumbers = []uint64{0, 1, 0}
+
+func withoutExclusions(batches []*derive.SingularBatch) []*derive.SingularBatch {
+	out := make([]*derive.SingularBatch, len(batches))
+	for i, b := range batches {
+		cpy := *b
+		cpy.DepositExclusions = nil
+		out[i] = &cpy
+	}
+	return out
+}
+
+func encodeFrame(f *derive.Frame) (hexutil.Bytes, error) {
+	var buf bytes.Buffer
+	if err := f.MarshalBinary(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func frameVectors() ([]FrameVector, error) {
+	frames := []struct {
+		name  string
+		frame derive.Frame
+	}{
+		{"empty", derive.Frame{ID: channelID}},
+		{"empty-last", derive.Frame{ID: channelID, IsLast: true}},
+		{"data", derive.Frame{ID: channelID, FrameNumber: 1, Data: []byte("golden vector frame data")}},
+		{"data-last", derive.Frame{ID: channelID, FrameNumber: 0xabcd, Data: bytes.Repeat([]byte{0x5a}, 300), IsLast: true}},
+	}
+	out := make([]FrameVector, 0, len(frames))
+	for _, f := range frames {
+		enc, err := encodeFrame(&f.frame)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.name, err)
+		}
+		out = append(out, FrameVector{
+			Name:        f.name,
+			ChannelID:   f.frame.ID,
+			FrameNumber: f.frame.FrameNumber,
+			Data:        f.frame.Data,
+			IsLast:      f.frame.IsLast,
+			Encoded:     enc,
+		})
+	}
+	return out, nil
+}
+
+// channelCompressor adapts a channel compressor to the compressor of a singular channel.
+// It is never full, the vectors are small.
+type channelCompressor struct {
+	derive.ChannelCompressor
+}
+
+func (c channelCompressor) FullErr() error {
+	return nil
+}
+
+func channelVectors(batches []*derive.SingularBatch) ([]ChannelVector, error) {
+	var out []ChannelVector
+	for _, typ := range []string{"singular", "span"} {
+		for _, algo := range derive.CompressionAlgos {
+			v := ChannelVector{
+				Name:             fmt.Sprintf("%s-%s", typ, algo),
+				Type:             typ,
+				CompressionAlgo:  algo,
+				ChannelID:        channelID,
+				MaxFrameSize:     200,
+				GenesisTimestamp: genesisTimestamp,
+				ChainID:          (*hexutil.Big)(chainID),
+				WithExclusions:   true,
+				Batches:          batches,
+				SequenceNumbers:  batchSequenceNumbers,
+			}
+			if err := buildChannel(&v); err != nil {
+				return nil, fmt.Errorf("%s: %w", v.Name, err)
+			}
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+// buildChannel fills in the encoded fields of the channel vector.
+func buildChannel(v *ChannelVector) error {
+	exclusionsTime := uint64(0)
+	cfg := &rollup.Config{BlockTime: blockTime}
+	if v.WithExclusions {
+		cfg.SpanBatchExclusionsTime = &exclusionsTime
+	}
+	spec := rollup.NewChainSpec(cfg)
+
+	var co derive.ChannelOut
+	var uncompressed bytes.Buffer
+	switch v.Type {
+	case "singular":
+		compressor, err := derive.NewChannelCompressor(v.CompressionAlgo)
+		if err != nil {
+			return err
+		}
+		if co, err = derive.NewSingularChannelOut(channelCompressor{compressor}, spec); err != nil {
+			return err
+		}
+		for _, b := range v.Batches {
+			if err := rlp.Encode(&uncompressed, derive.NewBatchData(b)); err != nil {
+				return err
+			}
+		}
+	case "span":
+		var err error
+		if co, err = derive.NewSpanChannelOut(v.GenesisTimestamp, v.ChainID.ToInt(), derive.MaxFrameLen, v.CompressionAlgo, spec); err != nil {
+			return err
+		}
+		raw, err := rawSpanBatch(v.GenesisTimestamp, v.ChainID.ToInt(), v.WithExclusions, v.Batches, v.SequenceNumbers)
+		if err != nil {
+			return err
+		}
+		if err := rlp.Encode(&uncompressed, derive.NewBatchData(raw)); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown channel type %q", v.Type)
+	}
+	for i, b := range v.Batches {
+		if err := co.AddSingularBatch(b, v.SequenceNumbers[i]); err != nil {
+			return fmt.Errorf("failed to add batch %d: %w", i, err)
+		}
+	}
+	if err := co.Close(); err != nil {
+		return err
+	}
+
+	v.Uncompressed = uncompressed.Bytes()
+	v.Compressed = nil
+	v.Frames = nil
+	for {
+		var buf bytes.Buffer
+		_, err := co.OutputFrame(&buf, v.MaxFrameSize)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		// the channel out picks a random channel ID, replace it to keep the vectors stable
+		var f derive.Frame
+		if err := f.UnmarshalBinary(&buf); err != nil {
+			return err
+		}
+		f.ID = v.ChannelID
+		enc, err2 := encodeFrame(&f)
+		if err2 != nil {
+			return err2
+		}
+		v.Frames = append(v.Frames, enc)
+		v.Compressed = append(v.Compressed, f.Data...)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+	}
+}
+
+func rawSpanBatch(genesisTimestamp uint64, chainID *big.Int, withExclusions bool, batches []*derive.SingularBatch, seqNums []uint64) (*derive.RawSpanBatch, error) {
+	span := derive.NewSpanBatch(genesisTimestamp, chainID)
+	span.WithExclusions = withExclusions
+	for i, b := range batches {
+		if err := span.AppendSingularBatch(b, seqNums[i]); err != nil {
+			return nil, fmt.Errorf("failed to add batch %d: %w", i, err)
+		}
+	}
+	return span.ToRawSpanBatch()
+}
+
+func singularBatchVectors(batches []*derive.SingularBatch) ([]SingularBatchVector, error) {
+	inputs := []struct {
+		name  string
+		batch *derive.SingularBatch
+	}{
+		{"exclusions", batches[0]},
+		{"no-exclusions", batches[1]},
+		{"contract-creation", batches[2]},
+		{"empty", &derive.SingularBatch{EpochNum: 7, Timestamp: genesisTimestamp}},
+	}
+	out := make([]SingularBatchVector, 0, len(inputs))
+	for _, in := range inputs {
+		enc, err := derive.NewBatchData(in.batch).MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", in.name, err)
+		}
+		out = append(out, SingularBatchVector{Name: in.name, Batch: in.batch, Encoded: enc})
+	}
+	return out, nil
+}
+
+func spanBatchVectors(batches []*derive.SingularBatch) ([]SpanBatchVector, error) {
+	inputs := []SpanBatchVector{
+		{Name: "span", Batches: withoutExclusions(batches), SequenceNumbers: batchSequenceNumbers},
+		{Name: "span-exclusions", WithExclusions: true, Batches: batches, SequenceNumbers: batchSequenceNumbers},
+		{Name: "span-exclusions-mid-epoch", WithExclusions: true, Batches: batches[1:], SequenceNumbers: batchSequenceNumbers[1:]},
+	}
+	for i := range inputs {
+		v := &inputs[i]
+		v.GenesisTimestamp = genesisTimestamp
+		v.ChainID = (*hexutil.Big)(chainID)
+		raw, err := rawSpanBatch(v.GenesisTimestamp, v.ChainID.ToInt(), v.WithExclusions, v.Batches, v.SequenceNumbers)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", v.Name, err)
+		}
+		if v.Encoded, err = derive.NewBatchData(raw).MarshalBinary(); err != nil {
+			return nil, fmt.Errorf("%s: %w", v.Name, err)
+		}
+	}
+	return inputs, nil
+}
+
+// l1Block serves the L1 block fields used by the L1 info deposit. Any other field is not available.
+type l1Block struct {
+	eth.BlockInfo
+	v *L1InfoVector
+}
+
+func (b l1Block) Hash() common.Hash     { return b.v.L1Hash }
+func (b l1Block) NumberU64() uint64     { return b.v.L1Number }
+func (b l1Block) Time() uint64          { return b.v.L1Time }
+func (b l1Block) BaseFee() *big.Int     { return b.v.L1BaseFee.ToInt() }
+func (b l1Block) BlobBaseFee() *big.Int { return (*big.Int)(b.v.L1BlobBaseFee) }
+
+func l1InfoVectors() ([]L1InfoVector, error) {
+	exclusions := derive.EmptyBitmap(10)
+	exclusions.Set(3)
+	exclusions.Set(9)
+
+	base := L1InfoVector{
+		L2BlockTime:    genesisTimestamp + 1000*blockTime,
+		SequenceNumber: 3,
+		L1Number:       19_000_000,
+		L1Time:         genesisTimestamp + 1000*blockTime - 12,
+		L1Hash:         common.HexToHash("0x8ee9f1e1a2c8b9c3f5d4e6a7b8c9d0e1f2a3b4c5d6e7f8091a2b3c4d5e6f7081"),
+		L1BaseFee:      (*hexutil.Big)(big.NewInt(7_000_000_000)),
+		L1BlobBaseFee:  (*hexutil.Big)(big.NewInt(3)),
+		BatcherAddr:    common.HexToAddress("0x2222222222222222222222222222222222222222"),
+		Overhead:       eth.Bytes32{31: 188},
+		Scalar:         eth.EncodeScalar(eth.EcotoneScalars{BlobBaseFeeScalar: 810_949, BaseFeeScalar: 1_368}),
+	}
+	var out []L1InfoVector
+	for _, ecotone := range []bool{false, true} {
+		for _, excl := range []bool{false, true} {
+			v := base
+			v.Ecotone = ecotone
+			v.Name = "bedrock"
+			if ecotone {
+				v.Name = "ecotone"
+			}
+			var bm *types.Bitmap
+			if excl {
+				v.Name += "-exclusions"
+				v.DepositExclusions = exclusions.MustBytes()
+				bm = exclusions
+			}
+			if err := buildL1Info(&v, bm); err != nil {
+				return nil, fmt.Errorf("%s: %w", v.Name, err)
+			}
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+// buildL1Info fills in the encoded fields of the L1 info vector.
+func buildL1Info(v *L1InfoVector, exclusions *types.Bitmap) error {
+	zero := uint64(0)
+	cfg := &rollup.Config{BlockTime: blockTime, RegolithTime: &zero}
+	if v.Ecotone {
+		cfg.EcotoneTime = &zero
+	}
+	sysCfg := eth.SystemConfig{BatcherAddr: v.BatcherAddr, Overhead: v.Overhead, Scalar: v.Scalar}
+	dep, err := derive.L1InfoDeposit(cfg, sysCfg, v.SequenceNumber, l1Block{v: v}, v.L2BlockTime, exclusions)
+	if err != nil {
+		return err
+	}
+	v.Calldata = dep.Data
+	if v.DepositTx, err = types.NewTx(dep).MarshalBinary(); err != nil {
+		return err
+	}
+	switch selector := v.Calldata[:4]; {
+	case bytes.Equal(selector, derive.L1InfoFuncBedrockBytes4):
+		v.Function = derive.L1InfoFuncBedrockSignature
+	case bytes.Equal(selector, derive.L1InfoExclusionsFuncBytes4):
+		v.Function = derive.L1InfoExclusionsFuncSignature
+	case bytes.Equal(selector, derive.L1InfoFuncEcotoneBytes4):
+		v.Function = derive.L1InfoFuncEcotoneSignature
+	case bytes.Equal(selector, derive.L1InfoExclusionsFuncEcotoneBytes4):
+		v.Function = derive.L1InfoExclusionsFuncEcotoneSignature
+	default:
+		return fmt.Errorf("unknown L1 info selector %x", selector)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package goldenvectors
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zircuit-labs/l2-geth-public/core/types"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/jsonutil"
+)
+
+// TestCommittedVectors checks that the committed vectors match the current encoding.
+// If an encoding changes on purpose, bump Version and run:
+//
+//	go run ./op-node/cmd golden-vectors generate --dir op-node/cmd/goldenvectors/testdata
+func TestCommittedVectors(t *testing.T) {
+	require.NoError(t, VerifyCorpus("testdata"))
+}
+
+func loadFile[V any](t *testing.T, kind string) []V {
+	f, err := jsonutil.LoadJSON[File[V]](filepath.Join(VersionDir("testdata"), kind+".json"))
+	require.NoError(t, err)
+	require.Equal(t, Version, f.Version)
+	require.Equal(t, kind, f.Kind)
+	require.NotEmpty(t, f.Vectors)
+	return f.Vectors
+}
+
+// The tests below decode the committed vectors, to check they describe what the derivation pipeline reads.
+
+func TestDecodeFrames(t *testing.T) {
+	for _, v := range loadFile[FrameVector](t, KindFrames) {
+		t.Run(v.Name, func(t *testing.T) {
+			var f derive.Frame
+			require.NoError(t, f.UnmarshalBinary(bytes.NewReader(v.Encoded)))
+			require.Equal(t, v.ChannelID, f.ID)
+			require.Equal(t, v.FrameNumber, f.FrameNumber)
+			require.Equal(t, []byte(v.Data), f.Data)
+			require.Equal(t, v.IsLast, f.IsLast)
+		})
+	}
+}
+
+func TestDecodeChannels(t *testing.T) {
+	for _, v := range loadFile[ChannelVector](t, KindChannels) {
+		t.Run(v.Name, func(t *testing.T) {
+			var data []byte
+			for i, enc := range v.Frames {
+				frames, err := derive.ParseFrames(append([]byte{derive.DerivationVersion0}, enc...))
+				require.NoError(t, err)
+				require.Len(t, frames, 1)
+				require.Equal(t, v.ChannelID, frames[0].ID)
+				require.Equal(t, uint16(i), frames[0].FrameNumber)
+				require.Equal(t, i == len(v.Frames)-1, frames[0].IsLast)
+				data = append(data, frames[0].Data...)
+			}
+			require.Equal(t, []byte(v.Compressed), data)
+
+			read, err := derive.BatchReader(bytes.NewReader(data), derive.MaxFrameLen*10, true)
+			require.NoError(t, err)
+			var uncompressed bytes.Buffer
+			for {
+				batch, err := read()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				require.NoError(t, err)
+				require.NoError(t, batch.EncodeRLP(&uncompressed))
+			}
+			require.Equal(t, []byte(v.Uncompressed), uncompressed.Bytes())
+		})
+	}
+}
+
+func TestDecodeSingularBatches(t *testing.T) {
+	for _, v := range loadFile[SingularBatchVector](t, KindSingularBatches) {
+		t.Run(v.Name, func(t *testing.T) {
+			var data derive.BatchData
+			require.NoError(t, data.UnmarshalBinary(v.Encoded))
+			batch, err := derive.GetSingularBatch(&data)
+			require.NoError(t, err)
+			enc, err := derive.NewBatchData(batch).MarshalBinary()
+			require.NoError(t, err)
+			require.Equal(t, []byte(v.Encoded), enc)
+			require.Equal(t, v.Batch.Epoch(), batch.Epoch())
+			require.Equal(t, v.Batch.Timestamp, batch.Timestamp)
+			require.Equal(t, len(v.Batch.Transactions), len(batch.Transactions))
+			require.Equal(t, v.Batch.DepositExclusions.String(), batch.DepositExclusions.String())
+		})
+	}
+}
+
+func TestDecodeSpanBatches(t *testing.T) {
+	for _, v := range loadFile[SpanBatchVector](t, KindSpanBatches) {
+		t.Run(v.Name, func(t *testing.T) {
+			var data derive.BatchData
+			require.NoError(t, data.UnmarshalBinary(v.Encoded))
+			span, err := derive.DeriveSpanBatch(&data, blockTime, v.GenesisTimestamp, v.ChainID.ToInt())
+			require.NoError(t, err)
+			require.Equal(t, v.WithExclusions, span.WithExclusions)
+			require.Equal(t, len(v.Batches), span.GetBlockCount())
+			for i, b := range v.Batches {
+				require.Equal(t, b.Timestamp, span.GetBlockTimestamp(i))
+				require.Equal(t, uint64(b.EpochNum), span.GetBlockEpochNum(i))
+				require.Equal(t, b.Transactions, span.GetBlockTransactions(i))
+			}
+		})
+	}
+}
+
+func TestDecodeL1Info(t *testing.T) {
+	for _, v := range loadFile[L1InfoVector](t, KindL1Info) {
+		t.Run(v.Name, func(t *testing.T) {
+			var tx types.Transaction
+			require.NoError(t, tx.UnmarshalBinary(v.DepositTx))
+			require.Equal(t, []byte(v.Calldata), tx.Data())
+
+			zero := uint64(0)
+			cfg := &rollup.Config{BlockTime: blockTime, RegolithTime: &zero}
+			if v.Ecotone {
+				cfg.EcotoneTime = &zero
+			}
+			info, err := derive.L1InfoFromSystemTx(cfg, v.L2BlockTime, &tx)
+			require.NoError(t, err)
+			require.Equal(t, v.L1Number, info.Number)
+			require.Equal(t, v.L1Time, info.Time)
+			require.Equal(t, v.L1Hash, info.BlockHash)
+			require.Equal(t, v.SequenceNumber, info.SequenceNumber)
+			require.Equal(t, v.BatcherAddr, info.BatcherAddr)
+			if len(v.DepositExclusions) == 0 {
+				require.True(t, info.DepositExclusions == nil || info.DepositExclusions.Count() == 0)
+			} else {
+				require.Equal(t, derive.MustBitmap(v.DepositExclusions).Indices(), info.DepositExclusions.Indices())
+			}
+		})
+	}
+}
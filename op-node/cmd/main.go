@@ -12,8 +12,11 @@ import (
 	opnode "github.com/zircuit-labs/zkr-monorepo-public/op-node"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/chaincfg"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/cmd/genesis"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/cmd/goldenvectors"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/cmd/networks"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/cmd/p2p"
+	safedbcmd "github.com/zircuit-labs/zkr-monorepo-public/op-node/cmd/safedb"
+	withdrawalscmd "github.com/zircuit-labs/zkr-monorepo-public/op-node/cmd/withdrawals"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/flags"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/metrics"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/node"
@@ -62,6 +65,19 @@ func main() {
 			Name:        "networks",
 			Subcommands: networks.Subcommands,
 		},
+		{
+			Name:        "safedb",
+			Subcommands: safedbcmd.Subcommands,
+		},
+		{
+			Name:        "withdrawals",
+			Subcommands: withdrawalscmd.Subcommands,
+		},
+		{
+			Name:        "golden-vectors",
+			Usage:       "Golden vectors of the frame, channel, batch and L1 info encodings, for other implementations to test against",
+			Subcommands: goldenvectors.Subcommands,
+		},
 	}
 
 	ctx := opio.WithInterruptBlocker(context.Background())
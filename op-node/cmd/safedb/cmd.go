@@ -0,0 +1,203 @@
+package safedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	opnode "github.com/zircuit-labs/zkr-monorepo-public/op-node"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/flags"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/metrics"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/node/safedb"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/client"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	opflags "github.com/zircuit-labs/zkr-monorepo-public/op-service/flags"
+	oplog "github.com/zircuit-labs/zkr-monorepo-public/op-service/log"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/sources"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/caching"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/l1"
+	l1client "github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/l1/client"
+)
+
+var (
+	l1RPCFlag = &cli.StringFlag{
+		Name:     "l1",
+		Usage:    "Address of L1 User JSON-RPC endpoint to use (eth namespace required)",
+		Required: true,
+	}
+	l1BeaconFlag = &cli.StringFlag{
+		Name:  "l1.beacon",
+		Usage: "Address of L1 Beacon-node HTTP endpoint to use. Required to derive blocks from blob data.",
+	}
+	l2RPCFlag = &cli.StringFlag{
+		Name:     "l2",
+		Usage:    "Address of L2 JSON-RPC endpoint to use (eth namespace required). The engine API is not used.",
+		Required: true,
+	}
+	safeDBPathFlag = &cli.PathFlag{
+		Name:     "safedb.path",
+		Usage:    "Path of the safe head database to backfill. Must not be in use by a running op-node.",
+		Required: true,
+	}
+	l2StartFlag = &cli.Uint64Flag{
+		Name:  "l2-start",
+		Usage: "L2 block number to start deriving from. Defaults to the L2 genesis block.",
+	}
+	l1EndFlag = &cli.Uint64Flag{
+		Name:  "l1-end",
+		Usage: "Last L1 block number (inclusive) to record safe heads for. Defaults to the finalized L1 block.",
+	}
+	resumeFlag = &cli.BoolFlag{
+		Name:  "resume",
+		Usage: "Continue from the newest existing entry in the range, instead of from --l2-start.",
+	}
+	verifyFlag = &cli.BoolFlag{
+		Name:  "verify",
+		Usage: "Compare derived safe heads against the existing entries instead of writing them. Fails if any entry is missing or different.",
+	}
+)
+
+var Subcommands = []*cli.Command{
+	{
+		Name:  "backfill",
+		Usage: "Rebuilds the safe head database by running the derivation pipeline against L1 and L2 RPCs",
+		Flags: append([]cli.Flag{
+			l1RPCFlag,
+			l1BeaconFlag,
+			l2RPCFlag,
+			safeDBPathFlag,
+			l2StartFlag,
+			l1EndFlag,
+			resumeFlag,
+			verifyFlag,
+			flags.L1CacheDir,
+			flags.L1CacheSize,
+			opflags.CLINetworkFlag(flags.EnvVarPrefix, ""),
+			opflags.CLIRollupConfigFlag(flags.EnvVarPrefix, ""),
+		}, oplog.CLIFlags(flags.EnvVarPrefix)...),
+		Action: backfill,
+	},
+}
+
+func backfill(cliCtx *cli.Context) error {
+	ctx := cliCtx.Context
+	logger := oplog.NewLogger(oplog.AppOut(cliCtx), oplog.ReadCLIConfig(cliCtx))
+	if cliCtx.Bool(resumeFlag.Name) && cliCtx.Bool(verifyFlag.Name) {
+		return errors.New("cannot resume and verify at the same time")
+	}
+
+	rollupCfg, err := opnode.NewRollupConfigFromCLI(logger, cliCtx)
+	if err != nil {
+		return err
+	}
+
+	l1RPC, err := l1client.NewRPC(ctx, logger, cliCtx.String(l1RPCFlag.Name), l1client.WithDialBackoff(10))
+	if err != nil {
+		return fmt.Errorf("failed to dial L1 RPC: %w", err)
+	}
+	defer l1RPC.Close()
+	// L1 data persisted by a previous run, or by an op-node sharing the same cache dir, is not fetched again
+	var l1Cache *caching.DiskStore
+	if dir := cliCtx.String(flags.L1CacheDir.Name); dir != "" {
+		l1Cache, err = caching.OpenDiskStore(dir, int64(cliCtx.Uint64(flags.L1CacheSize.Name)<<20))
+		if err != nil {
+			return fmt.Errorf("failed to open L1 cache: %w", err)
+		}
+	}
+	l1Cfg := l1.L1ClientDefaultConfig(rollupCfg, false, l1.RPCKindStandard)
+	l1Cfg.PersistentCache = l1Cache
+	l1Client, err := l1.NewL1Client(l1RPC, logger, nil, l1Cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create L1 client: %w", err)
+	}
+	l1Source := l1.NewL1DataReader(l1Client, logger)
+
+	l2RPC, err := client.NewRPC(ctx, logger, cliCtx.String(l2RPCFlag.Name), client.WithDialBackoff(10))
+	if err != nil {
+		return fmt.Errorf("failed to dial L2 RPC: %w", err)
+	}
+	defer l2RPC.Close()
+	l2Source, err := sources.NewL2Client(l2RPC, logger, nil, sources.L2ClientDefaultConfig(rollupCfg, false))
+	if err != nil {
+		return fmt.Errorf("failed to create L2 client: %w", err)
+	}
+
+	var blobs derive.L1BlobsFetcher
+	if addr := cliCtx.String(l1BeaconFlag.Name); addr != "" {
+		blobs = l1.NewL1BeaconClient(l1.NewBeaconHTTPClient(l1client.NewBasicHTTPClient(addr, logger)), l1.L1BeaconClientConfig{PersistentCache: l1Cache})
+	} else if rollupCfg.EcotoneTime != nil {
+		logger.Warn("L1 Beacon endpoint not set, derivation fails on blob data")
+	}
+
+	endL1 := cliCtx.Uint64(l1EndFlag.Name)
+	if !cliCtx.IsSet(l1EndFlag.Name) {
+		finalized, err := l1Source.L1BlockRefByLabel(ctx, eth.Finalized)
+		if err != nil {
+			return fmt.Errorf("failed to fetch finalized L1 block: %w", err)
+		}
+		endL1 = finalized.Number
+	}
+
+	db, err := safedb.NewSafeDB(logger, cliCtx.Path(safeDBPathFlag.Name))
+	if err != nil {
+		return fmt.Errorf("failed to open safe head database: %w", err)
+	}
+	defer db.Close()
+
+	start, err := startingPoint(ctx, logger, cliCtx, rollupCfg, db, l2Source, endL1)
+	if err != nil {
+		return err
+	}
+
+	verify := cliCtx.Bool(verifyFlag.Name)
+	logger.Info("Starting safe head backfill", "start", start, "endL1", endL1, "verify", verify)
+	pipeline := derive.NewDerivationPipeline(logger, rollupCfg, l1Source, blobs, l2Source, metrics.NoopMetrics)
+	res, err := safedb.NewBackfiller(logger, rollupCfg, db, pipeline, l1Source, l2Source, verify).Run(ctx, start, endL1)
+	logger.Info("Safe head backfill stopped", "safe", res.SafeHead, "l1", res.L1Origin,
+		"written", res.Written, "verified", res.Verified, "missing", res.Missing, "mismatched", res.Mismatched)
+	if err != nil {
+		return err
+	}
+	if res.Missing > 0 || res.Mismatched > 0 {
+		return fmt.Errorf("%w: %d missing, %d mismatched", safedb.ErrEntryMismatch, res.Missing, res.Mismatched)
+	}
+	return nil
+}
+
+// startingPoint returns the L2 block to start deriving from.
+// When resuming, this is the safe head of the newest entry in the range, if it is beyond the configured start.
+func startingPoint(ctx context.Context, logger log.Logger, cliCtx *cli.Context, rollupCfg *rollup.Config, db *safedb.SafeDB, l2Source *sources.L2Client, endL1 uint64) (eth.L2BlockRef, error) {
+	startNum := rollupCfg.Genesis.L2.Number
+	if cliCtx.IsSet(l2StartFlag.Name) {
+		startNum = cliCtx.Uint64(l2StartFlag.Name)
+	}
+	start, err := l2Source.L2BlockRefByNumber(ctx, startNum)
+	if err != nil {
+		return eth.L2BlockRef{}, fmt.Errorf("failed to fetch L2 start block %d: %w", startNum, err)
+	}
+	if !cliCtx.Bool(resumeFlag.Name) {
+		return start, nil
+	}
+	l1Block, safeHead, err := db.ResumePoint(ctx, start.L1Origin.Number, endL1)
+	if errors.Is(err, safedb.ErrNotFound) {
+		logger.Info("No existing entries in range, nothing to resume from", "start", start)
+		return start, nil
+	} else if err != nil {
+		return eth.L2BlockRef{}, fmt.Errorf("failed to find resume point: %w", err)
+	}
+	if safeHead.Number <= start.Number {
+		return start, nil
+	}
+	resume, err := l2Source.L2BlockRefByHash(ctx, safeHead.Hash)
+	if err != nil {
+		return eth.L2BlockRef{}, fmt.Errorf("failed to fetch L2 block %s to resume from: %w", safeHead, err)
+	}
+	logger.Info("Resuming from existing entry", "l1", l1Block, "safe", resume)
+	return resume, nil
+}
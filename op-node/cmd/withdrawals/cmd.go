@@ -0,0 +1,217 @@
+package withdrawals
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/zircuit-labs/l2-geth-public/accounts/abi/bind"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/ethclient"
+	"github.com/zircuit-labs/l2-geth-public/ethclient/gethclient"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-bindings/bindings"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/flags"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/withdrawals/tracker"
+	oplog "github.com/zircuit-labs/zkr-monorepo-public/op-service/log"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/txmgr"
+	txmetrics "github.com/zircuit-labs/zkr-monorepo-public/op-service/txmgr/metrics"
+)
+
+var (
+	l1RPCFlag = &cli.StringFlag{
+		Name:     txmgr.L1RPCFlagName,
+		Usage:    "Address of L1 User JSON-RPC endpoint to use (eth namespace required)",
+		Required: true,
+	}
+	l2RPCFlag = &cli.StringFlag{
+		Name:     "l2",
+		Usage:    "Address of L2 JSON-RPC endpoint to use (eth namespace required, with eth_getProof)",
+		Required: true,
+	}
+	dbPathFlag = &cli.PathFlag{
+		Name:     "db.path",
+		Usage:    "Path of the database that keeps the state of the tracked withdrawals",
+		Required: true,
+	}
+	portalFlag = &cli.StringFlag{
+		Name:     "portal.address",
+		Usage:    "Address of the OptimismPortal proxy on L1. The L2OutputOracle is looked up from it.",
+		Required: true,
+	}
+	sendersFlag = &cli.StringSliceFlag{
+		Name:  "sender",
+		Usage: "Only track withdrawals initiated by these L2 addresses. All withdrawals are tracked if not set.",
+	}
+	l2StartFlag = &cli.Uint64Flag{
+		Name:  "l2-start",
+		Usage: "First L2 block to scan for withdrawals, when the database is new. Later runs resume after the last scanned block.",
+	}
+	scanRangeFlag = &cli.Uint64Flag{
+		Name:  "scan-range",
+		Usage: "Maximum number of L2 blocks to fetch withdrawal logs for in a single request",
+		Value: 1000,
+	}
+	pollIntervalFlag = &cli.DurationFlag{
+		Name:  "poll-interval",
+		Usage: "Time between checks for new withdrawals and withdrawals ready to be proven or finalized",
+		Value: tracker.DefaultPollInterval,
+	}
+	submitFlag = &cli.BoolFlag{
+		Name:  "submit",
+		Usage: "Send the prove and finalize transactions with the configured signer. Otherwise they are only logged.",
+	}
+	onceFlag = &cli.BoolFlag{
+		Name:  "once",
+		Usage: "Scan and advance the withdrawals once, then exit",
+	}
+	statusFlag = &cli.StringFlag{
+		Name:  "status",
+		Usage: "Only list withdrawals with this status: initiated, proven or finalized",
+	}
+)
+
+var Subcommands = []*cli.Command{
+	{
+		Name:  "track",
+		Usage: "Tracks withdrawals from L2 to L1, and proves and finalizes them when they are ready",
+		Flags: append(append([]cli.Flag{
+			l1RPCFlag,
+			l2RPCFlag,
+			dbPathFlag,
+			portalFlag,
+			sendersFlag,
+			l2StartFlag,
+			scanRangeFlag,
+			pollIntervalFlag,
+			submitFlag,
+			onceFlag,
+		}, txmgr.CLIFlagsWithDefaults(flags.EnvVarPrefix, txmgr.DefaultChallengerFlagValues)...), oplog.CLIFlags(flags.EnvVarPrefix)...),
+		Action: track,
+	},
+	{
+		Name:  "list",
+		Usage: "Prints the tracked withdrawals as JSON",
+		Flags: []cli.Flag{
+			dbPathFlag,
+			statusFlag,
+		},
+		Action: list,
+	},
+}
+
+func track(cliCtx *cli.Context) error {
+	ctx := cliCtx.Context
+	logger := oplog.NewLogger(oplog.AppOut(cliCtx), oplog.ReadCLIConfig(cliCtx))
+
+	portal := cliCtx.String(portalFlag.Name)
+	if !common.IsHexAddress(portal) {
+		return fmt.Errorf("invalid OptimismPortal address: %q", portal)
+	}
+	var senders []common.Address
+	for _, s := range cliCtx.StringSlice(sendersFlag.Name) {
+		if !common.IsHexAddress(s) {
+			return fmt.Errorf("invalid sender address: %q", s)
+		}
+		senders = append(senders, common.HexToAddress(s))
+	}
+
+	l1, err := ethclient.DialContext(ctx, cliCtx.String(l1RPCFlag.Name))
+	if err != nil {
+		return fmt.Errorf("failed to dial L1 RPC: %w", err)
+	}
+	defer l1.Close()
+	l2, err := ethclient.DialContext(ctx, cliCtx.String(l2RPCFlag.Name))
+	if err != nil {
+		return fmt.Errorf("failed to dial L2 RPC: %w", err)
+	}
+	defer l2.Close()
+
+	portalCaller, err := bindings.NewOptimismPortalCaller(common.HexToAddress(portal), l1)
+	if err != nil {
+		return fmt.Errorf("failed to bind OptimismPortal: %w", err)
+	}
+	oracle, err := portalCaller.L2Oracle(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return fmt.Errorf("failed to fetch L2OutputOracle address: %w", err)
+	}
+
+	var txMgr txmgr.TxManager
+	if cliCtx.Bool(submitFlag.Name) {
+		txMgr, err = txmgr.NewSimpleTxManager("withdrawals", logger, &txmetrics.NoopTxMetrics{}, txmgr.ReadCLIConfig(cliCtx))
+		if err != nil {
+			return fmt.Errorf("failed to create transaction manager: %w", err)
+		}
+		defer txMgr.Close()
+		logger.Info("Submitting withdrawal transactions", "from", txMgr.From())
+	}
+
+	db, err := tracker.OpenDB(cliCtx.Path(dbPathFlag.Name))
+	if err != nil {
+		return fmt.Errorf("failed to open withdrawals database: %w", err)
+	}
+	defer db.Close()
+
+	cfg := tracker.Config{
+		OptimismPortal: common.HexToAddress(portal),
+		L2OutputOracle: oracle,
+		StartBlock:     cliCtx.Uint64(l2StartFlag.Name),
+		ScanRange:      cliCtx.Uint64(scanRangeFlag.Name),
+		Senders:        senders,
+		PollInterval:   cliCtx.Duration(pollIntervalFlag.Name),
+	}
+	l2Proofs := &l2Client{Client: l2, proofs: gethclient.New(l2.Client())}
+	t, err := tracker.NewTracker(logger, cfg, db, l1, l2Proofs, txMgr)
+	if err != nil {
+		return err
+	}
+	logger.Info("Tracking withdrawals", "portal", cfg.OptimismPortal, "oracle", cfg.L2OutputOracle, "senders", len(senders))
+	if cliCtx.Bool(onceFlag.Name) {
+		return t.Step(ctx)
+	}
+	if err := t.Run(ctx); err != nil && !errors.Is(err, ctx.Err()) {
+		return err
+	}
+	return nil
+}
+
+func list(cliCtx *cli.Context) error {
+	var statuses []tracker.Status
+	if s := cliCtx.String(statusFlag.Name); s != "" {
+		var status tracker.Status
+		if err := status.UnmarshalText([]byte(s)); err != nil {
+			return err
+		}
+		statuses = append(statuses, status)
+	}
+	db, err := tracker.OpenDB(cliCtx.Path(dbPathFlag.Name))
+	if err != nil {
+		return fmt.Errorf("failed to open withdrawals database: %w", err)
+	}
+	defer db.Close()
+	tracked, err := db.All(statuses...)
+	if err != nil {
+		return err
+	}
+	if tracked == nil {
+		tracked = []tracker.Withdrawal{}
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(tracked)
+}
+
+// l2Client adds the eth_getProof support of gethclient to an ethclient.
+type l2Client struct {
+	*ethclient.Client
+	proofs *gethclient.Client
+}
+
+func (c *l2Client) GetProof(ctx context.Context, account common.Address, keys []string, blockNumber *big.Int) (*gethclient.AccountResult, error) {
+	return c.proofs.GetProof(ctx, account, keys, blockNumber)
+}
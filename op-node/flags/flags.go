@@ -12,6 +12,7 @@ import (
 	opflags "github.com/zircuit-labs/zkr-monorepo-public/op-service/flags"
 	oplog "github.com/zircuit-labs/zkr-monorepo-public/op-service/log"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/oppprof"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/optracing"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/sources"
 )
 
@@ -140,12 +141,66 @@ var (
 		EnvVars:  prefixEnvVars("RPC_ADMIN_STATE"),
 		Category: OperationsCategory,
 	}
+	RPCAdminPersistenceBackend = &cli.StringFlag{
+		Name:     "rpc.admin-state.backend",
+		Usage:    "Backend to persist the admin API state changes in. Options: file (the rpc.admin-state path), nats (a JetStream key-value bucket shared by a fleet of sequencers, requires NATS to be enabled)",
+		EnvVars:  prefixEnvVars("RPC_ADMIN_STATE_BACKEND"),
+		Value:    "file",
+		Category: OperationsCategory,
+	}
+	RPCAdminPersistenceNATSBucket = &cli.StringFlag{
+		Name:     "rpc.admin-state.nats.bucket",
+		Usage:    "JetStream key-value bucket to persist the admin API state changes in, with the nats backend",
+		EnvVars:  prefixEnvVars("RPC_ADMIN_STATE_NATS_BUCKET"),
+		Value:    "op_node_admin_state",
+		Category: OperationsCategory,
+	}
+	RPCAdminPersistenceNATSKey = &cli.StringFlag{
+		Name:     "rpc.admin-state.nats.key",
+		Usage:    "Key of the sequencer state in the bucket, with the nats backend. Sequencers sharing the key are fenced: at most one of them is started.",
+		EnvVars:  prefixEnvVars("RPC_ADMIN_STATE_NATS_KEY"),
+		Value:    "sequencer",
+		Category: OperationsCategory,
+	}
+	RPCAdminPersistenceNATSDomain = &cli.StringFlag{
+		Name:     "rpc.admin-state.nats.domain",
+		Usage:    "JetStream domain of the bucket, with the nats backend. Required: it has to be shared by the fenced sequencers, e.g. the domain of the NATS server the embedded one is a leaf node of.",
+		EnvVars:  prefixEnvVars("RPC_ADMIN_STATE_NATS_DOMAIN"),
+		Category: OperationsCategory,
+	}
+	RPCAdminPersistenceNATSLease = &cli.DurationFlag{
+		Name:     "rpc.admin-state.nats.lease",
+		Usage:    "Duration a started sequencer holds the shared state without renewing it, with the nats backend. Once it expires, e.g. because the sequencer crashed, another sequencer can be started. Has to be much larger than the clock skew of the sequencers.",
+		EnvVars:  prefixEnvVars("RPC_ADMIN_STATE_NATS_LEASE"),
+		Value:    30 * time.Second,
+		Category: OperationsCategory,
+	}
+	RPCAdminPersistenceNATSOwner = &cli.StringFlag{
+		Name:     "rpc.admin-state.nats.owner",
+		Usage:    "Unique name of this sequencer in the shared state, with the nats backend. Defaults to the hostname.",
+		EnvVars:  prefixEnvVars("RPC_ADMIN_STATE_NATS_OWNER"),
+		Category: OperationsCategory,
+	}
 	L1TrustRPC = &cli.BoolFlag{
 		Name:     "l1.trustrpc",
 		Usage:    "Trust the L1 RPC, sync faster at risk of malicious/buggy RPC providing bad or inconsistent L1 data",
 		EnvVars:  prefixEnvVars("L1_TRUST_RPC"),
 		Category: L1RPCCategory,
 	}
+	L1QuorumAddrs = &cli.StringSliceFlag{
+		Name: "l1.quorum.addrs",
+		Usage: "Addresses of additional L1 User JSON-RPC endpoints to cross-check the l1 endpoint with. " +
+			"Blocks by number and label are only accepted when l1.quorum.threshold endpoints agree on the block hash, other requests fail over between endpoints.",
+		EnvVars:  prefixEnvVars("L1_QUORUM_ADDRS"),
+		Category: L1RPCCategory,
+	}
+	L1QuorumThreshold = &cli.IntFlag{
+		Name:     "l1.quorum.threshold",
+		Usage:    "Number of L1 endpoints, including the l1 endpoint, that must agree on a block when l1.quorum.addrs is set. Defaults to a majority of the endpoints if 0.",
+		EnvVars:  prefixEnvVars("L1_QUORUM_THRESHOLD"),
+		Value:    0,
+		Category: L1RPCCategory,
+	}
 	L1RPCProviderKind = &cli.GenericFlag{
 		Name: "l1.rpckind",
 		Usage: "The kind of RPC provider, used to inform optimal transactions receipts fetching, and thus reduce costs. Valid options: " +
@@ -164,6 +219,26 @@ var (
 		Hidden:   true,
 		Category: L1RPCCategory,
 	}
+	L1CacheDir = &cli.StringFlag{
+		Name:     "l1.cache.dir",
+		Usage:    "Directory to persist immutable L1 data in, like receipts, transactions and blobs, so it is not fetched again after a restart. Disabled if empty.",
+		EnvVars:  prefixEnvVars("L1_CACHE_DIR"),
+		Category: L1RPCCategory,
+	}
+	L1CacheSize = &cli.Uint64Flag{
+		Name:     "l1.cache.size",
+		Usage:    "Size budget in MiB of the persisted L1 data, the least recently used data is evicted first.",
+		EnvVars:  prefixEnvVars("L1_CACHE_SIZE"),
+		Value:    4096,
+		Category: L1RPCCategory,
+	}
+	L1PrefetchDepth = &cli.Uint64Flag{
+		Name:     "l1.prefetch-depth",
+		Usage:    "Number of L1 blocks after the current derivation L1 origin to concurrently prefetch receipts, transactions and blobs of. Disabled if 0.",
+		EnvVars:  prefixEnvVars("L1_PREFETCH_DEPTH"),
+		Value:    0,
+		Category: L1RPCCategory,
+	}
 	L1RPCMaxConcurrency = &cli.IntFlag{
 		Name:     "l1.max-concurrency",
 		Usage:    "Maximum number of concurrent RPC requests to make to the L1 RPC provider.",
@@ -203,6 +278,26 @@ var (
 		}(),
 		Category: RollupCategory,
 	}
+	L2EngineReplicas = &cli.StringSliceFlag{
+		Name: "l2.replicas",
+		Usage: "Addresses of additional L2 Engine JSON-RPC endpoints, authenticated with the same JWT secret. " +
+			"Engine state changes are replicated to these endpoints, and reads fail over to them if the primary l2 endpoint is unavailable.",
+		EnvVars:  prefixEnvVars("L2_REPLICAS"),
+		Category: RollupCategory,
+	}
+	L2EngineReplicasHealthCheckInterval = &cli.DurationFlag{
+		Name:     "l2.replicas.health-check-interval",
+		Usage:    "Interval between health checks of the L2 Engine endpoints, when l2.replicas is set.",
+		EnvVars:  prefixEnvVars("L2_REPLICAS_HEALTH_CHECK_INTERVAL"),
+		Value:    10 * time.Second,
+		Category: RollupCategory,
+	}
+	InteropPeerRPC = &cli.StringFlag{
+		Name:     "interop.peer-rpc",
+		Usage:    "RPC endpoint of an execution client of the interop peer chain, to check executing messages of the peer chain against. Only used if interop is scheduled.",
+		EnvVars:  prefixEnvVars("INTEROP_PEER_RPC"),
+		Category: RollupCategory,
+	}
 	VerifierL1Confs = &cli.Uint64Flag{
 		Name:     "verifier.l1-confs",
 		Usage:    "Number of L1 blocks to keep distance from the L1 head before deriving L2 data from. Reorgs are supported, but may be slow to perform.",
@@ -229,6 +324,12 @@ var (
 		Value:    0,
 		Category: SequencerCategory,
 	}
+	SequencerDepositPreSimulationFlag = &cli.BoolFlag{
+		Name:     "sequencer.deposit-pre-simulation",
+		Usage:    "Dry-run the deposits of the first block of an epoch against the circuit capacity checker of the engine, to exclude rejected deposits before building the block, instead of rebuilding the block after the engine rejected them. Requires an engine that serves engine_simulateDepositsV1, the node does not start otherwise.",
+		EnvVars:  prefixEnvVars("SEQUENCER_DEPOSIT_PRE_SIMULATION"),
+		Category: SequencerCategory,
+	}
 	SequencerL1Confs = &cli.Uint64Flag{
 		Name:     "sequencer.l1-confs",
 		Usage:    "Number of L1 blocks to keep distance from the L1 head as a sequencer for picking an L1 origin.",
@@ -236,6 +337,37 @@ var (
 		Value:    4,
 		Category: SequencerCategory,
 	}
+	SequencerL1OriginPolicy = &cli.StringFlag{
+		Name: "sequencer.l1-origin-policy",
+		Usage: "Policy for adopting the next L1 origin as a sequencer, on top of sequencer.l1-confs. " +
+			"'immediate' adopts it as soon as possible, 'hold' holds on to the current origin until the next origin has sequencer.l1-origin-confs confirmations, " +
+			"'adaptive' does the same with a number of confirmations that follows the depth of recent L1 reorgs. " +
+			"The current origin is never held beyond the max sequencer drift.",
+		EnvVars:  prefixEnvVars("SEQUENCER_L1_ORIGIN_POLICY"),
+		Value:    "immediate",
+		Category: SequencerCategory,
+	}
+	SequencerL1OriginConfs = &cli.Uint64Flag{
+		Name:     "sequencer.l1-origin-confs",
+		Usage:    "Number of confirmations of the next L1 origin to hold on to the current origin for. Minimum number of confirmations with the adaptive L1 origin policy.",
+		EnvVars:  prefixEnvVars("SEQUENCER_L1_ORIGIN_CONFS"),
+		Value:    0,
+		Category: SequencerCategory,
+	}
+	SequencerL1OriginMaxConfs = &cli.Uint64Flag{
+		Name:     "sequencer.l1-origin-max-confs",
+		Usage:    "Maximum number of confirmations of the next L1 origin the adaptive L1 origin policy requires.",
+		EnvVars:  prefixEnvVars("SEQUENCER_L1_ORIGIN_MAX_CONFS"),
+		Value:    12,
+		Category: SequencerCategory,
+	}
+	SequencerL1OriginReorgWindow = &cli.Uint64Flag{
+		Name:     "sequencer.l1-origin-reorg-window",
+		Usage:    "Number of L1 blocks the adaptive L1 origin policy takes an observed L1 reorg into account for.",
+		EnvVars:  prefixEnvVars("SEQUENCER_L1_ORIGIN_REORG_WINDOW"),
+		Value:    256,
+		Category: SequencerCategory,
+	}
 	L1EpochPollIntervalFlag = &cli.DurationFlag{
 		Name:     "l1.epoch-poll-interval",
 		Usage:    "Poll interval for retrieving new L1 epoch updates such as safe and finalized block changes. Disabled if 0 or negative.",
@@ -243,6 +375,13 @@ var (
 		Value:    time.Second * 12 * 32,
 		Category: L1RPCCategory,
 	}
+	L1HeadsPollIntervalFlag = &cli.DurationFlag{
+		Name:     "l1.heads-poll-interval",
+		Usage:    "Poll interval for retrieving the L1 head while the new-heads subscription of the L1 endpoint is unavailable. The subscription is retried after every poll.",
+		EnvVars:  prefixEnvVars("L1_HEADS_POLL_INTERVAL"),
+		Value:    time.Second * 12,
+		Category: L1RPCCategory,
+	}
 	RuntimeConfigReloadIntervalFlag = &cli.DurationFlag{
 		Name:     "l1.runtime-config-reload-interval",
 		Usage:    "Poll interval for reloading the runtime config, useful when config events are not being picked up. Disabled if 0 or negative.",
@@ -314,6 +453,51 @@ var (
 		EnvVars:  prefixEnvVars("SAFEDB_PATH"),
 		Category: OperationsCategory,
 	}
+	SafeDBRetentionBlocks = &cli.Uint64Flag{
+		Name:     "safedb.retention.blocks",
+		Usage:    "Number of L1 blocks before the finalized L1 block to retain safe head entries for. Retained forever if 0 and no retention age is set.",
+		EnvVars:  prefixEnvVars("SAFEDB_RETENTION_BLOCKS"),
+		Category: OperationsCategory,
+	}
+	SafeDBRetentionAge = &cli.DurationFlag{
+		Name:     "safedb.retention.age",
+		Usage:    "Duration before the finalized L1 block to retain safe head entries for. Retained forever if 0 and no retention block count is set.",
+		EnvVars:  prefixEnvVars("SAFEDB_RETENTION_AGE"),
+		Category: OperationsCategory,
+	}
+	SafeDBPruneInterval = &cli.DurationFlag{
+		Name:     "safedb.prune-interval",
+		Usage:    "Interval between pruning safe head entries outside of the retention limits.",
+		EnvVars:  prefixEnvVars("SAFEDB_PRUNE_INTERVAL"),
+		Value:    time.Minute * 10,
+		Category: OperationsCategory,
+	}
+	PipelineCheckpointPath = &cli.StringFlag{
+		Name:     "derivation.checkpoint.path",
+		Usage:    "File path used to persist a checkpoint of the derivation pipeline at safe head updates, to continue derivation from it after a restart. Disabled if not set.",
+		EnvVars:  prefixEnvVars("DERIVATION_CHECKPOINT_PATH"),
+		Category: OperationsCategory,
+	}
+	PipelineCheckpointInterval = &cli.DurationFlag{
+		Name:     "derivation.checkpoint.interval",
+		Usage:    "Minimum interval between two derivation pipeline checkpoints.",
+		EnvVars:  prefixEnvVars("DERIVATION_CHECKPOINT_INTERVAL"),
+		Value:    time.Second * 30,
+		Category: OperationsCategory,
+	}
+	DerivationAuditDir = &cli.StringFlag{
+		Name:     "derivation.audit.dir",
+		Usage:    "Directory used to persist the audit trail of the batcher data, channels and batches seen by derivation per L1 block. Disabled if not set.",
+		EnvVars:  prefixEnvVars("DERIVATION_AUDIT_DIR"),
+		Category: OperationsCategory,
+	}
+	DerivationAuditRetention = &cli.Uint64Flag{
+		Name:     "derivation.audit.retention",
+		Usage:    "Number of most recent L1 blocks to retain the derivation audit trail of. Retained forever if 0.",
+		EnvVars:  prefixEnvVars("DERIVATION_AUDIT_RETENTION"),
+		Value:    10_000,
+		Category: OperationsCategory,
+	}
 	/* Deprecated Flags */
 	L2EngineSyncEnabled = &cli.BoolFlag{
 		Name:    "l2.engine-sync",
@@ -397,6 +581,8 @@ var optionalFlags = []cli.Flag{
 	RPCListenAddr,
 	RPCListenPort,
 	L1TrustRPC,
+	L1QuorumAddrs,
+	L1QuorumThreshold,
 	L1RPCProviderKind,
 	L1RPCRateLimit,
 	L1RPCMaxBatchSize,
@@ -406,11 +592,23 @@ var optionalFlags = []cli.Flag{
 	SequencerEnabledFlag,
 	SequencerStoppedFlag,
 	SequencerMaxSafeLagFlag,
+	SequencerDepositPreSimulationFlag,
 	SequencerL1Confs,
+	SequencerL1OriginPolicy,
+	SequencerL1OriginConfs,
+	SequencerL1OriginMaxConfs,
+	SequencerL1OriginReorgWindow,
 	L1EpochPollIntervalFlag,
+	L1HeadsPollIntervalFlag,
 	RuntimeConfigReloadIntervalFlag,
 	RPCEnableAdmin,
 	RPCAdminPersistence,
+	RPCAdminPersistenceBackend,
+	RPCAdminPersistenceNATSBucket,
+	RPCAdminPersistenceNATSKey,
+	RPCAdminPersistenceNATSDomain,
+	RPCAdminPersistenceNATSLease,
+	RPCAdminPersistenceNATSOwner,
 	MetricsEnabledFlag,
 	MetricsAddrFlag,
 	MetricsPortFlag,
@@ -421,11 +619,24 @@ var optionalFlags = []cli.Flag{
 	RollupHalt,
 	RollupLoadProtocolVersions,
 	L1RethDBPath,
+	L1CacheDir,
+	L1CacheSize,
+	L1PrefetchDepth,
 	ConductorEnabledFlag,
 	ConductorRpcFlag,
 	ConductorRpcTimeoutFlag,
 	SafeDBPath,
+	SafeDBRetentionBlocks,
+	SafeDBRetentionAge,
+	SafeDBPruneInterval,
+	PipelineCheckpointPath,
+	PipelineCheckpointInterval,
+	DerivationAuditDir,
+	DerivationAuditRetention,
 	L2EngineKind,
+	L2EngineReplicas,
+	L2EngineReplicasHealthCheckInterval,
+	InteropPeerRPC,
 	NATSEnabledFlag,
 	NATSStoreDirFlag,
 }
@@ -446,6 +657,7 @@ func init() {
 	optionalFlags = append(optionalFlags, P2PFlags(EnvVarPrefix)...)
 	optionalFlags = append(optionalFlags, oplog.CLIFlagsWithCategory(EnvVarPrefix, OperationsCategory)...)
 	optionalFlags = append(optionalFlags, oppprof.CLIFlagsWithCategory(EnvVarPrefix, OperationsCategory)...)
+	optionalFlags = append(optionalFlags, optracing.CLIFlagsWithCategory(EnvVarPrefix, OperationsCategory)...)
 	optionalFlags = append(optionalFlags, DeprecatedFlags...)
 	optionalFlags = append(optionalFlags, opflags.CLIFlags(EnvVarPrefix, RollupCategory)...)
 	Flags = append(requiredFlags, optionalFlags...)
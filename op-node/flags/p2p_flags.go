@@ -22,6 +22,7 @@ var (
 	BanningName             = "p2p.ban.peers"
 	BanningThresholdName    = "p2p.ban.threshold"
 	BanningDurationName     = "p2p.ban.duration"
+	BanningAuditLogName     = "p2p.ban.audit-log"
 	TopicScoringName        = "p2p.scoring.topics"
 	P2PPrivPathName         = "p2p.priv.path"
 	P2PPrivRawName          = "p2p.priv.raw"
@@ -34,6 +35,7 @@ var (
 	BootnodesName           = "p2p.bootnodes"
 	StaticPeersName         = "p2p.static"
 	NetRestrictName         = "p2p.netrestrict"
+	AllowlistPathName       = "p2p.allowlist.path"
 	HostMuxName             = "p2p.mux"
 	HostSecurityName        = "p2p.security"
 	PeersLoName             = "p2p.peers.lo"
@@ -135,6 +137,14 @@ func P2PFlags(envPrefix string) []cli.Flag {
 			EnvVars:  p2pEnv(envPrefix, "PEER_BANNING_DURATION"),
 			Category: P2PCategory,
 		},
+		&cli.StringFlag{
+			Name:      BanningAuditLogName,
+			Usage:     "Optional path of a JSON-lines file that every peer ban decision is appended to.",
+			Required:  false,
+			EnvVars:   p2pEnv(envPrefix, "BAN_AUDIT_LOG"),
+			TakesFile: true,
+			Category:  P2PCategory,
+		},
 		&cli.StringFlag{
 			Name: P2PPrivPathName,
 			Usage: "Read the hex-encoded 32-byte private key for the peer ID from this txt file. Created if not already exists." +
@@ -222,6 +232,15 @@ func P2PFlags(envPrefix string) []cli.Flag {
 			EnvVars:  p2pEnv(envPrefix, "STATIC"),
 			Category: P2PCategory,
 		},
+		&cli.StringFlag{
+			Name: AllowlistPathName,
+			Usage: "Path of a file with the libp2p peer IDs that are allowed to connect, one per line. " +
+				"Connections to and from any other peer are rejected. The file is reloaded when it changes. Requires discovery to be disabled.",
+			Required:  false,
+			EnvVars:   p2pEnv(envPrefix, "ALLOWLIST_PATH"),
+			TakesFile: true,
+			Category:  P2PCategory,
+		},
 		&cli.StringFlag{
 			Name:     NetRestrictName,
 			Usage:    "Comma-separated list of CIDR masks. P2P will only try to connect on these networks",
@@ -51,6 +51,8 @@ type Metricer interface {
 	RecordL1ReorgDepth(d uint64)
 	RecordSequencerInconsistentL1Origin(from eth.BlockID, to eth.BlockID)
 	RecordSequencerReset()
+	RecordSequencerL1OriginConfs(required uint64)
+	RecordSequencerL1OriginHeld()
 	RecordGossipEvent(evType int32)
 	IncPeerCount()
 	DecPeerCount()
@@ -73,6 +75,12 @@ type Metricer interface {
 	RecordIPUnban()
 	RecordDial(allow bool)
 	RecordAccept(allow bool)
+	RecordAllowlistCheck(allow bool)
+	RecordAllowlistSize(size int)
+	RecordSafeDBSize(bytes uint64)
+	RecordSafeDBOldestEntry(l1BlockNum uint64)
+	RecordL1ProviderDisagreement(method string)
+	RecordL1Prefetch(kind string, hit bool)
 	ReportProtocolVersions(local, engine, recommended, required params.ProtocolVersion)
 }
 
@@ -117,6 +125,9 @@ type Metrics struct {
 	SequencerInconsistentL1Origin *metrics.Event
 	SequencerResets               *metrics.Event
 
+	SequencerL1OriginRequiredConfs prometheus.Gauge
+	SequencerL1OriginHeld          *metrics.Event
+
 	L1RequestDurationSeconds *prometheus.HistogramVec
 
 	SequencerBuildingDiffDurationSeconds prometheus.Histogram
@@ -148,8 +159,16 @@ type Metrics struct {
 	IPUnbans          prometheus.Counter
 	Dials             *prometheus.CounterVec
 	Accepts           *prometheus.CounterVec
+	AllowlistChecks   *prometheus.CounterVec
+	AllowlistSize     prometheus.Gauge
 	PeerScores        *prometheus.HistogramVec
 
+	SafeDBSize        prometheus.Gauge
+	SafeDBOldestEntry prometheus.Gauge
+
+	L1ProviderDisagreements *prometheus.CounterVec
+	L1Prefetches            *prometheus.CounterVec
+
 	ChannelInputBytes prometheus.Counter
 
 	// Protocol version reporting
@@ -238,6 +257,13 @@ func NewMetrics(procName string) *Metrics {
 		SequencerInconsistentL1Origin: metrics.NewEvent(factory, ns, "", "sequencer_inconsistent_l1_origin", "events when the sequencer selects an inconsistent L1 origin"),
 		SequencerResets:               metrics.NewEvent(factory, ns, "", "sequencer_resets", "sequencer resets"),
 
+		SequencerL1OriginRequiredConfs: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "sequencer_l1_origin_required_confs",
+			Help:      "Number of L1 confirmations the L1 origin policy requires of the next L1 origin",
+		}),
+		SequencerL1OriginHeld: metrics.NewEvent(factory, ns, "", "sequencer_l1_origin_held", "events when the sequencer holds on to the current L1 origin until the next L1 origin is confirmed"),
+
 		UnsafePayloadsBufferLen: factory.NewGauge(prometheus.GaugeOpts{
 			Namespace: ns,
 			Name:      "unsafe_payloads_buffer_len",
@@ -255,7 +281,7 @@ func NewMetrics(procName string) *Metrics {
 			Namespace: ns,
 			Name:      "l1_reorg_depth",
 			Buckets:   []float64{0.5, 1.5, 2.5, 3.5, 4.5, 5.5, 6.5, 7.5, 8.5, 9.5, 10.5, 20.5, 50.5, 100.5},
-			Help:      "Histogram of L1 Reorg Depths",
+			Help:      "Histogram of L1 Reorg Depths, the number of blocks of the previous L1 chain that a reorg replaced",
 		}),
 
 		TransactionsSequencedTotal: factory.NewGauge(prometheus.GaugeOpts{
@@ -322,6 +348,44 @@ func NewMetrics(procName string) *Metrics {
 			Name:      "accepts",
 			Help:      "Count of incoming dial attempts to accept, with label to filter to allowed attempts",
 		}, []string{"allow"}),
+		AllowlistChecks: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Subsystem: "p2p",
+			Name:      "allowlist_checks",
+			Help:      "Count of connections checked against the peer allowlist, with label to filter to allowed peers",
+		}, []string{"allow"}),
+		AllowlistSize: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: ns,
+			Subsystem: "p2p",
+			Name:      "allowlist_size",
+			Help:      "Number of peer identities in the peer allowlist",
+		}),
+
+		SafeDBSize: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: ns,
+			Subsystem: "safedb",
+			Name:      "size_bytes",
+			Help:      "Disk space used by the safe head database",
+		}),
+		SafeDBOldestEntry: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: ns,
+			Subsystem: "safedb",
+			Name:      "oldest_entry",
+			Help:      "L1 block number of the oldest entry in the safe head database",
+		}),
+
+		L1ProviderDisagreements: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Subsystem: "l1",
+			Name:      "provider_disagreements",
+			Help:      "Number of times L1 providers returned different blocks for the same block number",
+		}, []string{"method"}),
+		L1Prefetches: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Subsystem: "l1",
+			Name:      "prefetches",
+			Help:      "Number of L1 data requests of the derivation pipeline, by kind of data and whether it was prefetched",
+		}, []string{"kind", "result"}),
 
 		headChannelOpenedEvent: metrics.NewEvent(factory, ns, "", "head_channel", "New channel at the front of the channel bank"),
 		channelTimedOutEvent:   metrics.NewEvent(factory, ns, "", "channel_timeout", "Channel has timed out"),
@@ -531,6 +595,14 @@ func (m *Metrics) RecordSequencerReset() {
 	m.SequencerResets.Record()
 }
 
+func (m *Metrics) RecordSequencerL1OriginConfs(required uint64) {
+	m.SequencerL1OriginRequiredConfs.Set(float64(required))
+}
+
+func (m *Metrics) RecordSequencerL1OriginHeld() {
+	m.SequencerL1OriginHeld.Record()
+}
+
 func (m *Metrics) RecordGossipEvent(evType int32) {
 	m.GossipEventsTotal.WithLabelValues(pb.TraceEvent_Type_name[evType]).Inc()
 }
@@ -661,6 +733,38 @@ func (m *Metrics) RecordAccept(allow bool) {
 	}
 }
 
+func (m *Metrics) RecordAllowlistCheck(allow bool) {
+	if allow {
+		m.AllowlistChecks.WithLabelValues("true").Inc()
+	} else {
+		m.AllowlistChecks.WithLabelValues("false").Inc()
+	}
+}
+
+func (m *Metrics) RecordAllowlistSize(size int) {
+	m.AllowlistSize.Set(float64(size))
+}
+
+func (m *Metrics) RecordSafeDBSize(bytes uint64) {
+	m.SafeDBSize.Set(float64(bytes))
+}
+
+func (m *Metrics) RecordSafeDBOldestEntry(l1BlockNum uint64) {
+	m.SafeDBOldestEntry.Set(float64(l1BlockNum))
+}
+
+func (m *Metrics) RecordL1ProviderDisagreement(method string) {
+	m.L1ProviderDisagreements.WithLabelValues(method).Inc()
+}
+
+func (m *Metrics) RecordL1Prefetch(kind string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.L1Prefetches.WithLabelValues(kind, result).Inc()
+}
+
 func (m *Metrics) ReportProtocolVersions(local, engine, recommended, required params.ProtocolVersion) {
 	m.ProtocolVersionDelta.WithLabelValues("local_recommended").Set(float64(local.Compare(recommended)))
 	m.ProtocolVersionDelta.WithLabelValues("local_required").Set(float64(local.Compare(required)))
@@ -735,6 +839,12 @@ func (n *noopMetricer) RecordSequencerInconsistentL1Origin(from eth.BlockID, to
 func (n *noopMetricer) RecordSequencerReset() {
 }
 
+func (n *noopMetricer) RecordSequencerL1OriginConfs(required uint64) {
+}
+
+func (n *noopMetricer) RecordSequencerL1OriginHeld() {
+}
+
 func (n *noopMetricer) RecordGossipEvent(evType int32) {
 }
 
@@ -799,5 +909,23 @@ func (n *noopMetricer) RecordDial(allow bool) {
 func (n *noopMetricer) RecordAccept(allow bool) {
 }
 
+func (n *noopMetricer) RecordAllowlistCheck(allow bool) {
+}
+
+func (n *noopMetricer) RecordAllowlistSize(size int) {
+}
+
+func (n *noopMetricer) RecordSafeDBSize(bytes uint64) {
+}
+
+func (n *noopMetricer) RecordSafeDBOldestEntry(l1BlockNum uint64) {
+}
+
+func (n *noopMetricer) RecordL1ProviderDisagreement(method string) {
+}
+
+func (n *noopMetricer) RecordL1Prefetch(kind string, hit bool) {
+}
+
 func (n *noopMetricer) ReportProtocolVersions(local, engine, recommended, required params.ProtocolVersion) {
 }
@@ -7,10 +7,14 @@ import (
 
 	"github.com/zircuit-labs/l2-geth-public/common"
 	"github.com/zircuit-labs/l2-geth-public/common/hexutil"
+	"github.com/zircuit-labs/l2-geth-public/event"
 	"github.com/zircuit-labs/l2-geth-public/log"
+	gethrpc "github.com/zircuit-labs/l2-geth-public/rpc"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/node/safedb"
 
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/interop"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/version"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/metrics"
@@ -145,7 +149,7 @@ func (n *nodeAPI) SafeHeadAtL1Block(ctx context.Context, number hexutil.Uint64)
 	recordDur := n.m.RecordRPCServerRequest("optimism_safeHeadAtL1Block")
 	defer recordDur()
 	l1Block, safeHead, err := n.safeDB.SafeHeadAtL1(ctx, uint64(number))
-	if errors.Is(err, safedb.ErrNotFound) {
+	if errors.Is(err, safedb.ErrNotFound) || errors.Is(err, safedb.ErrPruned) {
 		return nil, err
 	} else if err != nil {
 		return nil, fmt.Errorf("failed to get safe head at l1 block %s: %w", number, err)
@@ -173,3 +177,130 @@ func (n *nodeAPI) Version(ctx context.Context) (string, error) {
 	defer recordDur()
 	return version.Version + "-" + version.Meta, nil
 }
+
+type runtimeConfigSource interface {
+	Snapshot() eth.RuntimeConfig
+	SubscribeChanges(ch chan<- eth.RuntimeConfig) event.Subscription
+	UpdateSettings(ctx context.Context, update eth.RuntimeSettings) (eth.RuntimeSettings, error)
+}
+
+type runtimeConfigAPI struct {
+	runCfg runtimeConfigSource
+	log    log.Logger
+	m      metrics.RPCMetricer
+}
+
+func NewRuntimeConfigAPI(runCfg runtimeConfigSource, log log.Logger, m metrics.RPCMetricer) *runtimeConfigAPI {
+	return &runtimeConfigAPI{
+		runCfg: runCfg,
+		log:    log,
+		m:      m,
+	}
+}
+
+// RuntimeConfig is the "runtimeConfig" subscription of optimism_subscribe,
+// i.e. it is served as optimism_subscribe with the params ["runtimeConfig"].
+// It notifies the current runtime config, and then its changes.
+func (n *runtimeConfigAPI) RuntimeConfig(ctx context.Context) (*gethrpc.Subscription, error) {
+	recordDur := n.m.RecordRPCServerRequest("optimism_subscribe_runtimeConfig")
+	defer recordDur()
+	notifier, supported := gethrpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, gethrpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	changes := make(chan eth.RuntimeConfig, 10)
+	// subscribe before taking the snapshot, so no change is missed
+	changesSub := n.runCfg.SubscribeChanges(changes)
+	current := n.runCfg.Snapshot()
+	go func() {
+		defer changesSub.Unsubscribe()
+		if err := notifier.Notify(sub.ID, current); err != nil {
+			n.log.Debug("failed to notify runtime config", "err", err)
+			return
+		}
+		for {
+			select {
+			case cfg := <-changes:
+				if err := notifier.Notify(sub.ID, cfg); err != nil {
+					n.log.Debug("failed to notify runtime config", "err", err)
+					return
+				}
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+type runtimeConfigAdminAPI struct {
+	runCfg runtimeConfigSource
+	m      metrics.RPCMetricer
+}
+
+func NewRuntimeConfigAdminAPI(runCfg runtimeConfigSource, m metrics.RPCMetricer) *runtimeConfigAdminAPI {
+	return &runtimeConfigAdminAPI{
+		runCfg: runCfg,
+		m:      m,
+	}
+}
+
+// SetRuntimeSettings changes the runtime settings that are set, and returns all the current settings.
+func (n *runtimeConfigAdminAPI) SetRuntimeSettings(ctx context.Context, settings eth.RuntimeSettings) (eth.RuntimeSettings, error) {
+	recordDur := n.m.RecordRPCServerRequest("admin_setRuntimeSettings")
+	defer recordDur()
+	return n.runCfg.UpdateSettings(ctx, settings)
+}
+
+type derivationAuditSource interface {
+	AuditAtL1(num uint64) (*derive.DerivationAudit, error)
+}
+
+type derivationAuditAPI struct {
+	audit derivationAuditSource
+	m     metrics.RPCMetricer
+}
+
+func NewDerivationAuditAPI(audit derivationAuditSource, m metrics.RPCMetricer) *derivationAuditAPI {
+	return &derivationAuditAPI{
+		audit: audit,
+		m:     m,
+	}
+}
+
+// DerivationAudit returns the batcher data, channels and batches that derivation saw in the given L1 block.
+func (n *derivationAuditAPI) DerivationAudit(ctx context.Context, number hexutil.Uint64) (*derive.DerivationAudit, error) {
+	recordDur := n.m.RecordRPCServerRequest("optimism_derivationAudit")
+	defer recordDur()
+	audit, err := n.audit.AuditAtL1(uint64(number))
+	if errors.Is(err, derive.ErrAuditNotFound) {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get derivation audit at l1 block %s: %w", number, err)
+	}
+	return audit, nil
+}
+
+type messageChecker interface {
+	CheckMessage(ctx context.Context, id interop.Identifier, payloadHash common.Hash) (interop.MessageStatus, error)
+}
+
+type interopAPI struct {
+	checker messageChecker
+	m       metrics.RPCMetricer
+}
+
+func NewInteropAPI(checker messageChecker, m metrics.RPCMetricer) *interopAPI {
+	return &interopAPI{
+		checker: checker,
+		m:       m,
+	}
+}
+
+// CheckMessage checks if the identified initiating message exists, with the given payload hash.
+func (n *interopAPI) CheckMessage(ctx context.Context, identifier interop.Identifier, payloadHash common.Hash) (interop.MessageStatus, error) {
+	recordDur := n.m.RecordRPCServerRequest("interop_checkMessage")
+	defer recordDur()
+	return n.checker.CheckMessage(ctx, identifier, payloadHash)
+}
@@ -49,6 +49,13 @@ type L2EndpointConfig struct {
 	// JWT secrets for L2 Engine API authentication during HTTP or initial Websocket communication.
 	// Any value for an IPC connection.
 	L2EngineJWTSecret [32]byte
+
+	// L2EngineReplicaAddrs are the addresses of additional L2 Engine JSON-RPC endpoints, kept in lockstep with
+	// the primary endpoint and used for failover. Authenticated with the same JWT secret.
+	L2EngineReplicaAddrs []string
+
+	// ReplicaHealthCheckInterval is the interval between health checks of the endpoints, if there are replicas.
+	ReplicaHealthCheckInterval time.Duration
 }
 
 var _ L2EndpointSetup = (*L2EndpointConfig)(nil)
@@ -57,6 +64,9 @@ func (cfg *L2EndpointConfig) Check() error {
 	if cfg.L2EngineAddr == "" {
 		return errors.New("empty L2 Engine Address")
 	}
+	if len(cfg.L2EngineReplicaAddrs) > 0 && cfg.ReplicaHealthCheckInterval <= 0 {
+		return errors.New("L2 Engine replica health check interval must be positive")
+	}
 
 	return nil
 }
@@ -74,8 +84,26 @@ func (cfg *L2EndpointConfig) Setup(ctx context.Context, log log.Logger, rollupCf
 	if err != nil {
 		return nil, nil, err
 	}
+	if len(cfg.L2EngineReplicaAddrs) == 0 {
+		return l2Node, sources.EngineClientDefaultConfig(rollupCfg), nil
+	}
 
-	return l2Node, sources.EngineClientDefaultConfig(rollupCfg), nil
+	endpoints := []client.RPC{l2Node}
+	for i, addr := range cfg.L2EngineReplicaAddrs {
+		replica, err := client.NewRPC(ctx, log.New("replica", i+1), addr, opts...)
+		if err != nil {
+			for _, e := range endpoints {
+				e.Close()
+			}
+			return nil, nil, fmt.Errorf("failed to dial L2 Engine replica %d: %w", i+1, err)
+		}
+		endpoints = append(endpoints, replica)
+	}
+	replicated, err := sources.NewReplicatedEngineRPC(log, endpoints, cfg.ReplicaHealthCheckInterval)
+	if err != nil {
+		return nil, nil, err
+	}
+	return replicated, sources.EngineClientDefaultConfig(rollupCfg), nil
 }
 
 // PreparedL2Endpoints enables testing with in-process pre-setup RPC connections to L2 engines
@@ -122,6 +150,13 @@ type L1EndpointConfig struct {
 	// It is recommended to use websockets or IPC for efficient following of the changing block.
 	// Setting this to 0 disables polling.
 	HttpPollInterval time.Duration
+
+	// QuorumAddrs are the addresses of additional L1 User JSON-RPC endpoints, to cross-check L1NodeAddr with.
+	QuorumAddrs []string
+
+	// QuorumThreshold is the number of endpoints that must agree on a block, if there are QuorumAddrs.
+	// A majority of the endpoints is required if 0.
+	QuorumThreshold int
 }
 
 var _ L1EndpointSetup = (*L1EndpointConfig)(nil)
@@ -136,6 +171,9 @@ func (cfg *L1EndpointConfig) Check() error {
 	if cfg.MaxConcurrency < 1 {
 		return fmt.Errorf("max concurrent requests cannot be less than 1, was %d", cfg.MaxConcurrency)
 	}
+	if cfg.QuorumThreshold < 0 || cfg.QuorumThreshold > len(cfg.QuorumAddrs)+1 {
+		return fmt.Errorf("L1 quorum threshold must be between 0 and the number of L1 endpoints %d, was %d", len(cfg.QuorumAddrs)+1, cfg.QuorumThreshold)
+	}
 	return nil
 }
 
@@ -152,6 +190,29 @@ func (cfg *L1EndpointConfig) Setup(ctx context.Context, log log.Logger, rollupCf
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to dial L1 address (%s): %w", cfg.L1NodeAddr, err)
 	}
+	if len(cfg.QuorumAddrs) > 0 {
+		endpoints := []l1client.RPC{l1Node}
+		for _, addr := range cfg.QuorumAddrs {
+			endpoint, err := l1client.NewRPC(ctx, log, addr, opts...)
+			if err != nil {
+				for _, e := range endpoints {
+					e.Close()
+				}
+				return nil, nil, fmt.Errorf("failed to dial L1 quorum address (%s): %w", addr, err)
+			}
+			endpoints = append(endpoints, endpoint)
+		}
+		threshold := cfg.QuorumThreshold
+		if threshold == 0 {
+			threshold = len(endpoints)/2 + 1
+		}
+		log.Info("Cross-checking L1 blocks across endpoints", "endpoints", len(endpoints), "threshold", threshold)
+		quorum, err := l1client.NewQuorumRPC(log, endpoints, threshold)
+		if err != nil {
+			return nil, nil, err
+		}
+		l1Node = quorum
+	}
 	rpcCfg := l1.L1ClientDefaultConfig(rollupCfg, cfg.L1TrustRPC, cfg.L1RPCKind)
 	rpcCfg.MaxRequestsPerBatch = cfg.BatchSize
 	rpcCfg.MaxConcurrentRequests = cfg.MaxConcurrency
@@ -10,11 +10,13 @@ import (
 	"github.com/zircuit-labs/l2-geth-public/log"
 
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/flags"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/node/safedb"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/p2p"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/driver"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/sync"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/oppprof"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/optracing"
 )
 
 type Config struct {
@@ -39,14 +41,22 @@ type Config struct {
 
 	Pprof oppprof.CLIConfig
 
+	Tracing optracing.CLIConfig
+
 	// Used to poll the L1 for new finalized or safe blocks
 	L1EpochPollInterval time.Duration
 
+	// Used to poll the L1 for new heads, while the new-heads subscription is unavailable
+	L1HeadsPollInterval time.Duration
+
 	ConfigPersistence ConfigPersistence
 
 	// Path to store safe head database. Disabled when set to empty string
 	SafeDBPath string
 
+	// SafeDBRetention configures pruning of old safe head database entries. Retained forever if not enabled.
+	SafeDBRetention safedb.RetentionConfig
+
 	// RuntimeConfigReloadInterval defines the interval between runtime config reloads.
 	// Disabled if <= 0.
 	// Runtime config changes should be picked up from log-events,
@@ -69,6 +79,19 @@ type Config struct {
 	// [OPTIONAL] The reth DB path to read receipts from
 	RethDBPath string
 
+	// [OPTIONAL] The directory to persist immutable L1 data in, and its size budget in bytes
+	L1CacheDir  string
+	L1CacheSize uint64
+
+	// [OPTIONAL] The directory to persist the derivation audit trail in, and the number of L1 blocks to retain it for.
+	// Disabled if the directory is empty.
+	DerivationAuditDir       string
+	DerivationAuditRetention uint64
+
+	// [OPTIONAL] The RPC endpoint of the interop peer chain, to check executing messages against.
+	// Messages of the peer chain are unknown if empty.
+	InteropPeerRPC string
+
 	// Conductor is used to determine this node is the leader sequencer.
 	ConductorEnabled    bool
 	ConductorRpc        string
@@ -123,7 +146,10 @@ func (cfg *Config) LoadPersisted(log log.Logger) error {
 	if !cfg.Driver.SequencerEnabled {
 		return nil
 	}
-	if state, err := cfg.ConfigPersistence.SequencerState(); err != nil {
+	if state, err := cfg.ConfigPersistence.SequencerState(); errors.Is(err, ErrConfigPersistenceNotConnected) {
+		log.Info("Persisted sequencer state is loaded once the config persistence is connected")
+		return nil
+	} else if err != nil {
 		return err
 	} else if state != StateUnset {
 		stopped := state == StateStopped
@@ -162,6 +188,12 @@ func (cfg *Config) Check() error {
 	if err := cfg.Pprof.Check(); err != nil {
 		return fmt.Errorf("pprof config error: %w", err)
 	}
+	if cfg.L1CacheDir != "" && cfg.L1CacheSize == 0 {
+		return errors.New("L1 cache size must be positive when the L1 cache dir is set")
+	}
+	if err := cfg.Tracing.Check(); err != nil {
+		return fmt.Errorf("tracing config error: %w", err)
+	}
 	if cfg.P2P != nil {
 		if err := cfg.P2P.Check(); err != nil {
 			return fmt.Errorf("p2p config error: %w", err)
@@ -170,7 +202,30 @@ func (cfg *Config) Check() error {
 	if !(cfg.RollupHalt == "" || cfg.RollupHalt == "major" || cfg.RollupHalt == "minor" || cfg.RollupHalt == "patch") {
 		return fmt.Errorf("invalid rollup halting option: %q", cfg.RollupHalt)
 	}
+	if err := cfg.SafeDBRetention.Check(); err != nil {
+		return fmt.Errorf("safe head db retention config error: %w", err)
+	}
+	if cfg.Driver.SequencerEnabled {
+		if err := cfg.Driver.SequencerOriginPolicy.Check(); err != nil {
+			return fmt.Errorf("sequencer L1 origin policy config error: %w", err)
+		}
+	}
+	if kv, ok := cfg.ConfigPersistence.(*KVConfigPersistence); ok {
+		if cfg.NatsConfig == nil {
+			return errors.New("NATS must be enabled to persist the sequencer state in NATS")
+		}
+		// the JetStream of the embedded NATS server is local to each node, and cannot fence other sequencers
+		if kv.domain == "" || kv.domain == embeddedJetStreamDomain {
+			return errors.New("the sequencer state in NATS must be kept in a JetStream domain shared by the sequencers")
+		}
+		if kv.lease <= 0 {
+			return errors.New("the sequencer lease in NATS must be positive")
+		}
+	}
 	if cfg.ConductorEnabled {
+		if _, ok := cfg.ConfigPersistence.(*KVConfigPersistence); ok {
+			return fmt.Errorf("config persistence must be disabled when conductor is enabled")
+		}
 		if state, _ := cfg.ConfigPersistence.SequencerState(); state != StateUnset {
 			return fmt.Errorf("config persistence must be disabled when conductor is enabled")
 		}
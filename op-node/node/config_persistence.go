@@ -22,6 +22,9 @@ type persistedState struct {
 	SequencerStarted *bool `json:"sequencerStarted,omitempty"`
 }
 
+// ConfigPersistence keeps the sequencer state changes made via the admin API across restarts.
+// The state is kept in a local file (ActiveConfigPersistence), or in a NATS JetStream key-value bucket
+// that can be shared by a fleet of sequencers (KVConfigPersistence).
 type ConfigPersistence interface {
 	SequencerStarted() error
 	SequencerStopped() error
@@ -0,0 +1,325 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/zircuit-labs/l2-geth-public/log"
+)
+
+const (
+	// kvRequestTimeout bounds each request to the key-value store.
+	kvRequestTimeout = 10 * time.Second
+	// kvLeaseRenewals is the number of times the lease is renewed within the lease duration,
+	// so that a few failed renewals do not lose it.
+	kvLeaseRenewals = 3
+)
+
+var (
+	// ErrConfigPersistenceNotConnected is returned by config persistence backends
+	// that are only available once the node connected to them.
+	ErrConfigPersistenceNotConnected = errors.New("config persistence is not connected")
+	// ErrSequencerFenced is returned when the sequencer cannot be started,
+	// because another sequencer is started, or the shared state changed concurrently.
+	ErrSequencerFenced = errors.New("sequencer is fenced by the shared state")
+)
+
+// kvStore is the subset of a JetStream key-value bucket that the config persistence uses.
+type kvStore interface {
+	Get(ctx context.Context, key string) (jetstream.KeyValueEntry, error)
+	Create(ctx context.Context, key string, value []byte) (uint64, error)
+	Update(ctx context.Context, key string, value []byte, revision uint64) (uint64, error)
+}
+
+// sharedState is the sequencer state kept in the key-value store.
+type sharedState struct {
+	SequencerStarted bool `json:"sequencerStarted"`
+	// Owner identifies the node that last changed the state.
+	Owner string `json:"owner"`
+	// LeaseExpiry is the time in unix milliseconds until which the owner holds the started sequencer,
+	// unless it renews the lease. A started state without lease is held until it is stopped.
+	LeaseExpiry int64 `json:"leaseExpiry,omitempty"`
+}
+
+// heldByOther returns whether the sequencer is started by another owner, which still holds its lease.
+func (s *sharedState) heldByOther(owner string, now time.Time) bool {
+	if !s.SequencerStarted || s.Owner == owner {
+		return false
+	}
+	return s.LeaseExpiry == 0 || now.UnixMilli() < s.LeaseExpiry
+}
+
+var _ ConfigPersistence = (*KVConfigPersistence)(nil)
+
+// KVConfigPersistence persists the sequencer state in a NATS JetStream key-value bucket,
+// so that it can be shared by a fleet of sequencers.
+//
+// Every change is a compare-and-swap on the last read revision of the key, and at most one owner
+// can have the sequencer started: starting fails with ErrSequencerFenced while another owner has it started,
+// until that owner stops it, or its lease expires.
+// Other owners see the sequencer as stopped.
+//
+// The owner that started the sequencer holds it for the lease duration, and renews the lease in the
+// background. A crashed owner stops renewing, and another owner can take over once the lease expired.
+// An owner that cannot renew its lease before it expires, or finds the sequencer taken over, loses it
+// and calls the lease-lost handler, which has to stop the sequencer. Leases are compared with the clocks
+// of the nodes, so the lease duration has to be much larger than their clock skew.
+type KVConfigPersistence struct {
+	lock   sync.Mutex
+	log    log.Logger
+	domain string
+	bucket string
+	key    string
+	owner  string
+	lease  time.Duration
+	kv     kvStore
+	now    func() time.Time
+
+	// expiry of the lease this owner holds, zero if it does not hold the sequencer
+	expiry time.Time
+	// lost is set once the lease is lost, until the sequencer is started again
+	lost   bool
+	onLost func()
+
+	closing chan struct{}
+	done    chan struct{}
+}
+
+// NewKVConfigPersistence creates a config persistence that keeps the state under the key of the bucket,
+// on behalf of the owner, with a lease of the given duration. The bucket is in the given JetStream domain,
+// or in the JetStream of the connected server if the domain is empty. It has to be connected before use.
+func NewKVConfigPersistence(domain, bucket, key, owner string, lease time.Duration) *KVConfigPersistence {
+	return &KVConfigPersistence{
+		log:     log.Root(),
+		domain:  domain,
+		bucket:  bucket,
+		key:     key,
+		owner:   owner,
+		lease:   lease,
+		now:     time.Now,
+		closing: make(chan struct{}),
+	}
+}
+
+// OnLeaseLost sets the handler that is called when this owner loses the lease of the started sequencer.
+func (p *KVConfigPersistence) OnLeaseLost(fn func()) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.onLost = fn
+}
+
+// Connect creates the bucket if it does not exist yet, and uses it from now on.
+// It starts renewing the lease of the sequencer once this owner starts it, until Close is called.
+func (p *KVConfigPersistence) Connect(ctx context.Context, logger log.Logger, nc *nats.Conn) error {
+	var js jetstream.JetStream
+	var err error
+	if p.domain != "" {
+		js, err = jetstream.NewWithDomain(nc, p.domain)
+	} else {
+		js, err = jetstream.New(nc)
+	}
+	if err != nil {
+		return fmt.Errorf("create JetStream context: %w", err)
+	}
+	kv, err := js.CreateOrUpdateKeyValue(ctx, jetstream.KeyValueConfig{
+		Bucket:      p.bucket,
+		Description: "op-node sequencer admin state",
+	})
+	if err != nil {
+		return fmt.Errorf("create key-value bucket %q: %w", p.bucket, err)
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.log = logger
+	p.kv = kv
+	if p.done == nil {
+		p.done = make(chan struct{})
+		go p.renewLoop()
+	}
+	return nil
+}
+
+// Close stops renewing the lease. The lease expires unless the sequencer was stopped before.
+func (p *KVConfigPersistence) Close() {
+	p.lock.Lock()
+	done := p.done
+	p.lock.Unlock()
+	close(p.closing)
+	if done != nil {
+		<-done
+	}
+}
+
+func (p *KVConfigPersistence) renewLoop() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.lease / kvLeaseRenewals)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.renew()
+		case <-p.closing:
+			return
+		}
+	}
+}
+
+// renew extends the lease of the started sequencer, if this owner holds it. If the sequencer was
+// taken over, or the lease expired before it could be renewed, the lease is lost.
+func (p *KVConfigPersistence) renew() {
+	p.lock.Lock()
+	if p.expiry.IsZero() {
+		p.lock.Unlock()
+		return
+	}
+	// a renewal after the expiry would be too late, another owner may have taken over already
+	ctx, cancel := context.WithDeadline(context.Background(), p.expiry)
+	defer cancel()
+	err := p.renewLease(ctx)
+	if err == nil {
+		p.lock.Unlock()
+		return
+	}
+	if !errors.Is(err, ErrSequencerFenced) && p.now().Before(p.expiry) {
+		p.log.Warn("Failed to renew the sequencer lease, retrying", "expiry", p.expiry, "err", err)
+		p.lock.Unlock()
+		return
+	}
+	p.log.Error("Lost the sequencer lease", "owner", p.owner, "err", err)
+	p.lost = true
+	p.expiry = time.Time{}
+	onLost := p.onLost
+	p.lock.Unlock()
+	if onLost != nil {
+		onLost()
+	}
+}
+
+func (p *KVConfigPersistence) renewLease(ctx context.Context) error {
+	state, revision, err := p.read(ctx)
+	if err != nil {
+		return err
+	}
+	if state == nil || !state.SequencerStarted || state.Owner != p.owner {
+		return fmt.Errorf("%w: sequencer state changed by another owner", ErrSequencerFenced)
+	}
+	expiry := p.now().Add(p.lease)
+	if err := p.write(ctx, sharedState{SequencerStarted: true, Owner: p.owner, LeaseExpiry: expiry.UnixMilli()}, revision); err != nil {
+		return err
+	}
+	p.expiry = expiry
+	return nil
+}
+
+func (p *KVConfigPersistence) SequencerStarted() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), kvRequestTimeout)
+	defer cancel()
+	state, revision, err := p.read(ctx)
+	if err != nil {
+		return err
+	}
+	if state != nil && state.heldByOther(p.owner, p.now()) {
+		return fmt.Errorf("%w: started by %q", ErrSequencerFenced, state.Owner)
+	}
+	if state != nil && state.SequencerStarted && state.Owner != p.owner {
+		p.log.Warn("Taking over the sequencer after its lease expired", "owner", state.Owner)
+	}
+	expiry := p.now().Add(p.lease)
+	if err := p.write(ctx, sharedState{SequencerStarted: true, Owner: p.owner, LeaseExpiry: expiry.UnixMilli()}, revision); err != nil {
+		return err
+	}
+	p.expiry = expiry
+	p.lost = false
+	return nil
+}
+
+func (p *KVConfigPersistence) SequencerStopped() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.lost {
+		// the lease is lost, the state is not ours to change anymore
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), kvRequestTimeout)
+	defer cancel()
+	state, revision, err := p.read(ctx)
+	if err != nil {
+		return err
+	}
+	// Stopping never releases the sequencer of another owner, nor rewrites an already stopped state.
+	if state != nil && (!state.SequencerStarted || state.Owner != p.owner) {
+		p.expiry = time.Time{}
+		return nil
+	}
+	if err := p.write(ctx, sharedState{SequencerStarted: false, Owner: p.owner}, revision); err != nil {
+		return err
+	}
+	p.expiry = time.Time{}
+	return nil
+}
+
+func (p *KVConfigPersistence) SequencerState() (RunningState, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.lost {
+		return StateStopped, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), kvRequestTimeout)
+	defer cancel()
+	state, _, err := p.read(ctx)
+	if err != nil {
+		return StateUnset, err
+	}
+	if state == nil {
+		return StateUnset, nil
+	} else if state.SequencerStarted && state.Owner == p.owner {
+		return StateStarted, nil
+	} else {
+		return StateStopped, nil
+	}
+}
+
+// read returns the shared state and its revision, or no state and revision 0 if the key does not exist.
+func (p *KVConfigPersistence) read(ctx context.Context) (*sharedState, uint64, error) {
+	if p.kv == nil {
+		return nil, 0, ErrConfigPersistenceNotConnected
+	}
+	entry, err := p.kv.Get(ctx, p.key)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil, 0, nil
+	} else if err != nil {
+		return nil, 0, fmt.Errorf("read sequencer state (%v/%v): %w", p.bucket, p.key, err)
+	}
+	var state sharedState
+	if err := json.Unmarshal(entry.Value(), &state); err != nil {
+		return nil, 0, fmt.Errorf("invalid sequencer state (%v/%v): %w", p.bucket, p.key, err)
+	}
+	return &state, entry.Revision(), nil
+}
+
+// write replaces the shared state if it is still at the given revision, or creates it if the revision is 0.
+func (p *KVConfigPersistence) write(ctx context.Context, state sharedState, revision uint64) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("marshall new state: %w", err)
+	}
+	if revision == 0 {
+		_, err = p.kv.Create(ctx, p.key, data)
+	} else {
+		_, err = p.kv.Update(ctx, p.key, data, revision)
+	}
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		return fmt.Errorf("%w: state changed concurrently", ErrSequencerFenced)
+	} else if err != nil {
+		return fmt.Errorf("write sequencer state (%v/%v): %w", p.bucket, p.key, err)
+	}
+	return nil
+}
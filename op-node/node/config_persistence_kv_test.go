@@ -0,0 +1,199 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKV is an in-memory key-value bucket with the revision semantics of JetStream.
+type fakeKV struct {
+	revision uint64
+	entries  map[string]fakeEntry
+	// beforeWrite is called before every write, to simulate concurrent writers
+	beforeWrite func()
+	// err is returned by every request, to simulate an unreachable key-value store
+	err error
+}
+
+type fakeEntry struct {
+	key      string
+	value    []byte
+	revision uint64
+}
+
+func (e fakeEntry) Bucket() string                  { return "test" }
+func (e fakeEntry) Key() string                     { return e.key }
+func (e fakeEntry) Value() []byte                   { return e.value }
+func (e fakeEntry) Revision() uint64                { return e.revision }
+func (e fakeEntry) Created() time.Time              { return time.Time{} }
+func (e fakeEntry) Delta() uint64                   { return 0 }
+func (e fakeEntry) Operation() jetstream.KeyValueOp { return jetstream.KeyValuePut }
+
+func newFakeKV() *fakeKV {
+	return &fakeKV{entries: make(map[string]fakeEntry)}
+}
+
+func (f *fakeKV) Get(ctx context.Context, key string) (jetstream.KeyValueEntry, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	e, ok := f.entries[key]
+	if !ok {
+		return nil, jetstream.ErrKeyNotFound
+	}
+	return e, nil
+}
+
+func (f *fakeKV) Create(ctx context.Context, key string, value []byte) (uint64, error) {
+	return f.Update(ctx, key, value, 0)
+}
+
+func (f *fakeKV) Update(ctx context.Context, key string, value []byte, revision uint64) (uint64, error) {
+	if f.beforeWrite != nil {
+		f.beforeWrite()
+	}
+	if f.entries[key].revision != revision {
+		return 0, jetstream.ErrKeyExists
+	}
+	f.revision++
+	f.entries[key] = fakeEntry{key: key, value: value, revision: f.revision}
+	return f.revision, nil
+}
+
+func TestKVConfigPersistence(t *testing.T) {
+	const lease = 30 * time.Second
+	now := time.Now()
+	create := func(kv *fakeKV, owner string) *KVConfigPersistence {
+		p := NewKVConfigPersistence("shared", "bucket", "sequencer", owner, lease)
+		p.kv = kv
+		p.now = func() time.Time { return now }
+		return p
+	}
+	requireState := func(t *testing.T, p *KVConfigPersistence, expected RunningState) {
+		state, err := p.SequencerState()
+		require.NoError(t, err)
+		require.Equal(t, expected, state)
+	}
+
+	t.Run("NotConnected", func(t *testing.T) {
+		p := NewKVConfigPersistence("shared", "bucket", "sequencer", "a", lease)
+		_, err := p.SequencerState()
+		require.ErrorIs(t, err, ErrConfigPersistenceNotConnected)
+		require.ErrorIs(t, p.SequencerStarted(), ErrConfigPersistenceNotConnected)
+	})
+
+	t.Run("SequencerStateUnsetWhenKeyDoesNotExist", func(t *testing.T) {
+		requireState(t, create(newFakeKV(), "a"), StateUnset)
+	})
+
+	t.Run("PersistSequencerStartedAndStopped", func(t *testing.T) {
+		kv := newFakeKV()
+		p := create(kv, "a")
+		require.NoError(t, p.SequencerStarted())
+		requireState(t, p, StateStarted)
+		// restarting the same owner keeps the state
+		requireState(t, create(kv, "a"), StateStarted)
+		require.NoError(t, p.SequencerStopped())
+		requireState(t, create(kv, "a"), StateStopped)
+	})
+
+	t.Run("FenceOtherOwners", func(t *testing.T) {
+		kv := newFakeKV()
+		a, b := create(kv, "a"), create(kv, "b")
+		require.NoError(t, a.SequencerStarted())
+		requireState(t, b, StateStopped)
+		require.ErrorIs(t, b.SequencerStarted(), ErrSequencerFenced)
+		// stopping b does not release the sequencer of a
+		require.NoError(t, b.SequencerStopped())
+		requireState(t, a, StateStarted)
+
+		require.NoError(t, a.SequencerStopped())
+		require.NoError(t, b.SequencerStarted())
+		requireState(t, b, StateStarted)
+		requireState(t, a, StateStopped)
+	})
+
+	t.Run("RenewLease", func(t *testing.T) {
+		kv := newFakeKV()
+		a, b := create(kv, "a"), create(kv, "b")
+		require.NoError(t, a.SequencerStarted())
+		now = now.Add(lease / 2)
+		a.renew()
+		now = now.Add(lease / 2)
+		require.ErrorIs(t, b.SequencerStarted(), ErrSequencerFenced, "the renewed lease did not expire yet")
+		requireState(t, a, StateStarted)
+	})
+
+	t.Run("TakeOverExpiredLease", func(t *testing.T) {
+		kv := newFakeKV()
+		a, b := create(kv, "a"), create(kv, "b")
+		lost := false
+		a.OnLeaseLost(func() {
+			lost = true
+			// the handler stops the sequencer, which leaves the state of the new owner alone
+			require.NoError(t, a.SequencerStopped())
+		})
+		require.NoError(t, a.SequencerStarted())
+		// a crashed, or cannot reach the key-value store
+		now = now.Add(lease)
+		require.NoError(t, b.SequencerStarted())
+		requireState(t, b, StateStarted)
+
+		a.renew()
+		require.True(t, lost)
+		requireState(t, a, StateStopped)
+		requireState(t, b, StateStarted)
+		// a does not renew a lease it lost
+		lost = false
+		a.renew()
+		require.False(t, lost)
+
+		// a can start again once b stopped
+		require.NoError(t, b.SequencerStopped())
+		require.NoError(t, a.SequencerStarted())
+		requireState(t, a, StateStarted)
+	})
+
+	t.Run("LoseLeaseWhenRenewalFails", func(t *testing.T) {
+		kv := newFakeKV()
+		a := create(kv, "a")
+		lost := false
+		a.OnLeaseLost(func() { lost = true })
+		require.NoError(t, a.SequencerStarted())
+		kv.err = errors.New("unreachable")
+		now = now.Add(lease / 2)
+		a.renew()
+		require.False(t, lost, "retried until the lease expires")
+		now = now.Add(lease / 2)
+		a.renew()
+		require.True(t, lost)
+		requireState(t, a, StateStopped)
+		require.NoError(t, a.SequencerStopped(), "stopping does not need the key-value store after the lease is lost")
+	})
+
+	t.Run("ConcurrentStart", func(t *testing.T) {
+		kv := newFakeKV()
+		a, b := create(kv, "a"), create(kv, "b")
+		// b starts between the read and the write of a
+		kv.beforeWrite = func() {
+			kv.beforeWrite = nil
+			require.NoError(t, b.SequencerStarted())
+		}
+		require.ErrorIs(t, a.SequencerStarted(), ErrSequencerFenced)
+		requireState(t, b, StateStarted)
+		requireState(t, a, StateStopped)
+	})
+
+	t.Run("ReadError", func(t *testing.T) {
+		kv := newFakeKV()
+		kv.entries["sequencer"] = fakeEntry{key: "sequencer", value: []byte("not json"), revision: 1}
+		_, err := create(kv, "a").SequencerState()
+		require.Error(t, err)
+		require.False(t, errors.Is(err, ErrConfigPersistenceNotConnected))
+	})
+}
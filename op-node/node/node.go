@@ -8,7 +8,6 @@ import (
 	"sync/atomic"
 	"time"
 
-	l1event "github.com/ethereum/go-ethereum/event"
 	"github.com/hashicorp/go-multierror"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/nats-io/nats.go"
@@ -26,7 +25,10 @@ import (
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/p2p"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/conductor"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/driver"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/interop"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/sequencing"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/status"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/sync"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/version"
@@ -34,8 +36,10 @@ import (
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/httputil"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/oppprof"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/optracing"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/retry"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/sources"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/caching"
 	l1 "github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/l1"
 	l1client "github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/l1/client"
 	l1eth "github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/l1/eth"
@@ -60,6 +64,8 @@ type OpNode struct {
 	l1FinalizedSub ethereum.Subscription // Subscription to get L1 safe blocks, a.k.a. justified data (polling)
 
 	l1Source  l1.L1Reader           // L1 Client to fetch data from
+	l1Cache   *caching.DiskStore    // Persisted immutable L1 data, nil if disabled
+	l1Quorum  *l1client.QuorumRPC   // Cross-checks the L1 providers, nil if a single provider is configured
 	l2Driver  *driver.Driver        // L2 Engine to Sync
 	l2Source  *sources.EngineClient // L2 Execution Engine RPC bindings
 	server    *rpcServer            // RPC server hosting the rollup-node API
@@ -68,12 +74,19 @@ type OpNode struct {
 	tracer    Tracer                // tracer to get events for testing/debugging
 	runCfg    *RuntimeConfig        // runtime configurables
 
-	safeDB closableSafeDB
+	derivationAudit *derive.DerivationAuditLog // Audit trail of the derivation per L1 block, nil if disabled
+
+	interopChecker *interop.MessageChecker // Checks executing messages, nil if interop is not scheduled
+	interopPeer    client.RPC              // RPC of the interop peer chain, nil if not configured
+
+	safeDB       closableSafeDB
+	safeDBPruner *safedb.Pruner // prunes old safe head entries, nil if retention is not configured
 
 	rollupHalt string // when to halt the rollup, disabled if empty
 
-	pprofService *oppprof.Service
-	metricsSrv   *httputil.HTTPServer
+	pprofService   *oppprof.Service
+	tracingService *optracing.Service
+	metricsSrv     *httputil.HTTPServer
 
 	beacon *l1.L1BeaconClient
 
@@ -97,6 +110,8 @@ type OpNode struct {
 	embeddedNatsServer *messagebus.NatsEmbeddedServer
 	natsConnection     *nats.Conn
 	natsConfig         *config.Configuration
+	// sequencer state shared in NATS, nil if kept elsewhere
+	kvPersistence *KVConfigPersistence
 }
 
 // The OpNode handles incoming gossip
@@ -138,6 +153,9 @@ func New(ctx context.Context, cfg *Config, log log.Logger, appVersion string, m
 	return n, nil
 }
 
+// embeddedJetStreamDomain is the JetStream domain of the embedded NATS server.
+const embeddedJetStreamDomain = "op_node_embedded"
+
 func (n *OpNode) setupNats(cfg *Config) error {
 	if cfg.NatsConfig == nil {
 		log.Info("No embedded nats server configured")
@@ -147,7 +165,7 @@ func (n *OpNode) setupNats(cfg *Config) error {
 	natsConfig, err := config.NewConfigurationFromMap(
 		map[string]any{
 			"servername":            "op_node_embedded",
-			"jetstreamdomain":       "op_node_embedded",
+			"jetstreamdomain":       embeddedJetStreamDomain,
 			"leafnodeurl":           cfg.NatsConfig.LeafNodeURL,
 			"storedir":              cfg.NatsConfig.StoreDir,
 			"remotecredentialspath": cfg.NatsConfig.RemoteCredentialsPath,
@@ -194,11 +212,34 @@ func (n *OpNode) setupNats(cfg *Config) error {
 	return nil
 }
 
+// initConfigPersistence connects the config persistence backends that depend on the node,
+// and loads the persisted sequencer state they hold.
+func (n *OpNode) initConfigPersistence(ctx context.Context, cfg *Config) error {
+	kv, ok := cfg.ConfigPersistence.(*KVConfigPersistence)
+	if !ok {
+		return nil
+	}
+	if n.natsConnection == nil {
+		return errors.New("no NATS connection to persist the sequencer state in")
+	}
+	if err := kv.Connect(ctx, n.log, n.natsConnection); err != nil {
+		return err
+	}
+	n.kvPersistence = kv
+	return cfg.LoadPersisted(n.log)
+}
+
 func (n *OpNode) init(ctx context.Context, cfg *Config) error {
 	n.log.Info("Initializing rollup node", "version", n.appVersion)
+	if err := n.initConfigPersistence(ctx, cfg); err != nil {
+		return fmt.Errorf("failed to init the config persistence: %w", err)
+	}
 	if err := n.initTracer(ctx, cfg); err != nil {
 		return fmt.Errorf("failed to init the trace: %w", err)
 	}
+	if err := n.initTracing(ctx, cfg); err != nil {
+		return fmt.Errorf("failed to init tracing: %w", err)
+	}
 	if err := n.initL1(ctx, cfg); err != nil {
 		return fmt.Errorf("failed to init L1: %w", err)
 	}
@@ -243,6 +284,15 @@ func (n *OpNode) initTracer(ctx context.Context, cfg *Config) error {
 	return nil
 }
 
+func (n *OpNode) initTracing(ctx context.Context, cfg *Config) error {
+	tracingService, err := optracing.Start(ctx, "op-node", n.appVersion, cfg.Tracing)
+	if err != nil {
+		return err
+	}
+	n.tracingService = tracingService
+	return nil
+}
+
 func (n *OpNode) initL1(ctx context.Context, cfg *Config) error {
 	l1Node, rpcCfg, err := cfg.L1.Setup(ctx, n.log, &cfg.Rollup)
 	if err != nil {
@@ -252,6 +302,23 @@ func (n *OpNode) initL1(ctx context.Context, cfg *Config) error {
 	// Set the RethDB path in the EthClientConfig, if there is one configured.
 	rpcCfg.L1EthClientConfig.RethDBPath = cfg.RethDBPath
 
+	if cfg.L1CacheDir != "" {
+		n.l1Cache, err = caching.OpenDiskStore(cfg.L1CacheDir, int64(cfg.L1CacheSize))
+		if err != nil {
+			return fmt.Errorf("failed to open L1 cache: %w", err)
+		}
+		n.log.Info("Persisting L1 data", "dir", cfg.L1CacheDir, "entries", n.l1Cache.Len(), "size", n.l1Cache.Size())
+		rpcCfg.L1EthClientConfig.PersistentCache = n.l1Cache
+	}
+
+	if quorum, ok := l1Node.(*l1client.QuorumRPC); ok {
+		n.l1Quorum = quorum
+		// The driver is only available once the L2 side is initialized, initL2 replaces this callback.
+		quorum.OnDisagreement(func(err *l1client.ProviderDisagreementError) {
+			n.metrics.RecordL1ProviderDisagreement(err.Method)
+		})
+	}
+
 	l1Client, err := l1.NewL1Client(
 		l1client.NewInstrumentedRPC(l1Node, &n.metrics.RPCMetrics.RPCClientMetrics), n.log, n.metrics.L1SourceCache, rpcCfg)
 	if err != nil {
@@ -263,13 +330,9 @@ func (n *OpNode) initL1(ctx context.Context, cfg *Config) error {
 		return fmt.Errorf("failed to validate the L1 config: %w", err)
 	}
 
-	// Keep subscribed to the L1 heads, which keeps the L1 maintainer pointing to the best headers to sync
-	n.l1HeadsSub = l1event.ResubscribeErr(time.Second*10, func(ctx context.Context, err error) (l1event.Subscription, error) {
-		if err != nil {
-			n.log.Warn("resubscribing after failed L1 subscription", "err", err)
-		}
-		return l1eth.WatchHeadChanges(ctx, n.l1Source, n.OnNewL1Head)
-	})
+	// Keep tracking the L1 heads, which keeps the L1 maintainer pointing to the best headers to sync.
+	// Polls the L1 head while the subscription is unavailable, and backfills skipped heads.
+	n.l1HeadsSub = l1eth.TrackHeads(n.log, n.l1Source, n.OnNewL1Head, cfg.L1HeadsPollInterval, time.Second*10)
 	go func() {
 		err, ok := <-n.l1HeadsSub.Err()
 		if !ok {
@@ -290,6 +353,9 @@ func (n *OpNode) initL1(ctx context.Context, cfg *Config) error {
 func (n *OpNode) initRuntimeConfig(ctx context.Context, cfg *Config) error {
 	// attempt to load runtime config, repeat N times
 	n.runCfg = NewRuntimeConfig(n.log, n.l1Source, &cfg.Rollup)
+	if cfg.Driver.SequencerEnabled {
+		n.runCfg.EnableSequencerSettings(n.l2Driver, cfg.Driver.SequencerMaxSafeLag)
+	}
 
 	confDepth := cfg.Driver.VerifierConfDepth
 	reload := func(ctx context.Context) (eth.L1BlockRef, error) {
@@ -385,6 +451,8 @@ func (n *OpNode) initL1BeaconAPI(ctx context.Context, cfg *Config) error {
 	}
 	beaconCfg := l1.L1BeaconClientConfig{
 		FetchAllSidecars: cfg.Beacon.ShouldFetchAllSidecars(),
+		PersistentCache:  n.l1Cache,
+		Metrics:          n.metrics.L1SourceCache,
 	}
 	n.beacon = l1.NewL1BeaconClient(beaconClient, beaconCfg, fallbacks...)
 
@@ -436,7 +504,7 @@ func (n *OpNode) initL2(ctx context.Context, cfg *Config) error {
 	}
 
 	n.l2Source, err = sources.NewEngineClient(
-		client.NewInstrumentedRPC(rpcClient, &n.metrics.RPCClientMetrics), n.log, n.metrics.L2SourceCache, rpcCfg,
+		client.NewInstrumentedRPC(client.NewTracingRPC(rpcClient), &n.metrics.RPCClientMetrics), n.log, n.metrics.L2SourceCache, rpcCfg,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create Engine client: %w", err)
@@ -446,6 +514,16 @@ func (n *OpNode) initL2(ctx context.Context, cfg *Config) error {
 		return err
 	}
 
+	if cfg.Driver.SequencerEnabled && cfg.Driver.SequencerDepositPreSimulation {
+		supported, err := n.l2Source.SupportsMethod(ctx, eth.SimulateDepositsV1)
+		if err != nil {
+			return fmt.Errorf("failed to check engine support of deposit pre-simulation: %w", err)
+		}
+		if !supported {
+			return fmt.Errorf("deposit pre-simulation is enabled, but the engine does not support %s", eth.SimulateDepositsV1)
+		}
+	}
+
 	var sequencerConductor conductor.SequencerConductor = &conductor.NoOpConductor{}
 	if cfg.ConductorEnabled {
 		// TODO: conductor support
@@ -459,6 +537,11 @@ func (n *OpNode) initL2(ctx context.Context, cfg *Config) error {
 			return fmt.Errorf("failed to create safe head database at %v: %w", cfg.SafeDBPath, err)
 		}
 		n.safeDB = safeDB
+		if cfg.SafeDBRetention.Enabled() {
+			n.log.Info("Safe head database pruning enabled", "blocks", cfg.SafeDBRetention.Blocks, "age", cfg.SafeDBRetention.Age)
+			n.safeDBPruner = safedb.NewPruner(n.log, cfg.SafeDBRetention, safeDB, n.l1Source, n.metrics)
+			n.safeDBPruner.Start()
+		}
 	} else {
 		n.safeDB = safedb.Disabled
 	}
@@ -485,6 +568,24 @@ func (n *OpNode) initL2(ctx context.Context, cfg *Config) error {
 		l2BlockProducer = status.NilL2BlockProducer{}
 	}
 
+	var derivationAuditor derive.DerivationAuditor
+	if cfg.DerivationAuditDir != "" {
+		n.log.Info("Derivation audit enabled", "dir", cfg.DerivationAuditDir, "retention", cfg.DerivationAuditRetention)
+		n.derivationAudit, err = derive.NewDerivationAuditLog(n.log, cfg.DerivationAuditDir, cfg.DerivationAuditRetention)
+		if err != nil {
+			return fmt.Errorf("failed to open derivation audit log at %v: %w", cfg.DerivationAuditDir, err)
+		}
+		derivationAuditor = n.derivationAudit
+	}
+
+	var interopChecker interop.Checker
+	if cfg.Rollup.InteropTime != nil {
+		if err := n.initInterop(ctx, cfg); err != nil {
+			return err
+		}
+		interopChecker = n.interopChecker
+	}
+
 	n.l2Driver = driver.NewDriver(
 		&cfg.Driver,
 		&cfg.Rollup,
@@ -497,10 +598,61 @@ func (n *OpNode) initL2(ctx context.Context, cfg *Config) error {
 		n.metrics,
 		cfg.ConfigPersistence,
 		n.safeDB,
+		derivationAuditor,
+		interopChecker,
 		&cfg.Sync,
 		sequencerConductor,
 		l2BlockProducer,
 	)
+	if n.l1Quorum != nil {
+		n.l1Quorum.OnDisagreement(func(err *l1client.ProviderDisagreementError) {
+			n.metrics.RecordL1ProviderDisagreement(err.Method)
+			n.l2Driver.OnL1ProviderDisagreement(err)
+		})
+	}
+	if replicated, ok := rpcClient.(*sources.ReplicatedEngineRPC); ok {
+		replicated.OnDivergence(func(err *sources.ReplicaDivergenceError) {
+			n.l2Driver.OnEngineReplicaDivergence(err)
+		})
+	}
+	if n.kvPersistence != nil {
+		n.kvPersistence.OnLeaseLost(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if _, err := n.l2Driver.StopSequencer(ctx); err != nil && !errors.Is(err, sequencing.ErrSequencerAlreadyStopped) {
+				n.log.Error("Failed to stop the sequencer after losing its lease", "err", err)
+			}
+		})
+	}
+	return nil
+}
+
+// initInterop sets up the checker of executing messages, against this chain and the interop peer chain.
+func (n *OpNode) initInterop(ctx context.Context, cfg *Config) error {
+	n.interopChecker = interop.NewMessageChecker()
+	n.interopChecker.AddChain(cfg.Rollup.L2ChainID.Uint64(), n.l2Source)
+	if cfg.InteropPeerRPC == "" {
+		n.log.Warn("Interop is scheduled, but no interop peer chain is configured")
+		return nil
+	}
+	peerRPC, err := client.NewRPC(ctx, n.log.New("interop", "peer"), cfg.InteropPeerRPC, client.WithDialBackoff(10))
+	if err != nil {
+		return fmt.Errorf("failed to dial interop peer chain: %w", err)
+	}
+	n.interopPeer = peerRPC
+	peer, err := sources.NewEthClient(peerRPC, n.log.New("interop", "peer"), nil, &sources.L2ClientDefaultConfig(&cfg.Rollup, false).EthClientConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create interop peer chain client: %w", err)
+	}
+	peerChainID, err := peer.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch chain ID of interop peer chain: %w", err)
+	}
+	if !peerChainID.IsUint64() || peerChainID.Cmp(cfg.Rollup.L2ChainID) == 0 {
+		return fmt.Errorf("invalid interop peer chain ID %s", peerChainID)
+	}
+	n.interopChecker.AddChain(peerChainID.Uint64(), peer)
+	n.log.Info("Interop peer chain configured", "chain_id", peerChainID)
 	return nil
 }
 
@@ -512,10 +664,19 @@ func (n *OpNode) initRPCServer(cfg *Config) error {
 	if n.p2pNode != nil {
 		server.EnableP2P(p2p.NewP2PAPIBackend(n.p2pNode, n.log, n.metrics))
 	}
+	var runCfgAdmin *runtimeConfigAdminAPI
 	if cfg.RPC.EnableAdmin {
 		server.EnableAdminAPI(NewAdminAPI(n.l2Driver, n.metrics, n.log))
+		runCfgAdmin = NewRuntimeConfigAdminAPI(n.runCfg, n.metrics)
 		n.log.Info("Admin RPC enabled")
 	}
+	server.EnableRuntimeConfig(NewRuntimeConfigAPI(n.runCfg, n.log.New("rpc", "runtime-config"), n.metrics), runCfgAdmin)
+	if n.interopChecker != nil {
+		server.EnableInterop(NewInteropAPI(n.interopChecker, n.metrics))
+	}
+	if n.derivationAudit != nil {
+		server.EnableDerivationAudit(NewDerivationAuditAPI(n.derivationAudit, n.metrics))
+	}
 	n.log.Info("Starting JSON-RPC server")
 	if err := server.Start(); err != nil {
 		return fmt.Errorf("unable to start RPC server: %w", err)
@@ -590,6 +751,9 @@ func (n *OpNode) initP2P(ctx context.Context, cfg *Config) error {
 			return err
 		}
 		n.p2pNode = p2pNode
+		if lo, hi, ok := p2pNode.PeerLimits(); ok {
+			n.runCfg.EnablePeerLimitSettings(p2pNode, lo, hi)
+		}
 		if n.p2pNode.Dv5Udp() != nil {
 			go n.p2pNode.DiscoveryProcess(n.resourcesCtx, n.log, &cfg.Rollup, cfg.P2P.TargetPeers())
 		}
@@ -655,6 +819,9 @@ func (n *OpNode) OnNewL1Finalized(ctx context.Context, sig eth.L1BlockRef) {
 	if err := n.l2Driver.OnL1Finalized(ctx, sig); err != nil {
 		n.log.Warn("failed to notify engine driver of L1 finalized block change", "err", err)
 	}
+	if n.safeDBPruner != nil {
+		n.safeDBPruner.OnNewL1Finalized(sig)
+	}
 }
 
 func (n *OpNode) PublishL2Payload(ctx context.Context, envelope *eth.ExecutionPayloadEnvelope) error {
@@ -771,7 +938,20 @@ func (n *OpNode) Stop(ctx context.Context) error {
 			result = multierror.Append(result, fmt.Errorf("failed to close L2 engine driver cleanly: %w", err))
 		}
 	}
+	if n.interopPeer != nil {
+		n.interopPeer.Close()
+	}
+	if n.derivationAudit != nil {
+		if err := n.derivationAudit.Close(); err != nil {
+			result = multierror.Append(result, fmt.Errorf("failed to close derivation audit log: %w", err))
+		}
+	}
 
+	if n.safeDBPruner != nil {
+		if err := n.safeDBPruner.Close(); err != nil {
+			result = multierror.Append(result, fmt.Errorf("failed to close safe head db pruner: %w", err))
+		}
+	}
 	if n.safeDB != nil {
 		if err := n.safeDB.Close(); err != nil {
 			result = multierror.Append(result, fmt.Errorf("failed to close safe head db: %w", err))
@@ -788,6 +968,10 @@ func (n *OpNode) Stop(ctx context.Context) error {
 		n.l2Source.Close()
 	}
 
+	if n.kvPersistence != nil {
+		n.kvPersistence.Close()
+	}
+
 	// Close the embedded nats server and connection to it
 	if n.natsConnection != nil {
 		n.natsConnection.Close()
@@ -827,6 +1011,10 @@ func (n *OpNode) Stop(ctx context.Context) error {
 			result = multierror.Append(result, fmt.Errorf("failed to close metrics server: %w", err))
 		}
 	}
+	// Flush the remaining spans last, after all components that create them are stopped
+	if err := n.tracingService.Stop(ctx); err != nil {
+		result = multierror.Append(result, fmt.Errorf("failed to stop tracing: %w", err))
+	}
 
 	return result.ErrorOrNil()
 }
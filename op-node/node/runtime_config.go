@@ -2,10 +2,12 @@ package node
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 
 	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/event"
 	"github.com/zircuit-labs/l2-geth-public/log"
 	"github.com/zircuit-labs/l2-geth-public/params"
 
@@ -39,10 +41,28 @@ type ReadonlyRuntimeConfig interface {
 	RecommendedProtocolVersion() params.ProtocolVersion
 }
 
+// ErrUnsupportedSetting is returned when updating a runtime setting of a feature that is not enabled.
+var ErrUnsupportedSetting = errors.New("runtime setting is not supported")
+
+// SequencerSettings applies the sequencer runtime settings.
+type SequencerSettings interface {
+	SetSequencerMaxSafeLag(ctx context.Context, v uint64) error
+}
+
+// PeerLimitSettings applies the P2P peer limit runtime settings.
+type PeerLimitSettings interface {
+	SetPeerLimits(lo, hi uint) error
+}
+
 // RuntimeConfig maintains runtime-configurable options.
 // These options are loaded based on initial loading + updates for every subsequent L1 block.
 // Only the *latest* values are maintained however, the runtime config has no concept of chain history,
 // does not require any archive data, and may be out of sync with the rollup derivation process.
+//
+// Next to the L1 values, the runtime config maintains the settings that can be changed with the admin API.
+// Subscribers are notified of changes of the L1 values or the settings in the background,
+// so a slow subscriber does not block reloads: changes that happen while subscribers are being notified
+// are coalesced, and only the latest snapshot is sent.
 type RuntimeConfig struct {
 	mu sync.RWMutex
 
@@ -56,6 +76,19 @@ type RuntimeConfig struct {
 	l1Ref eth.L1BlockRef
 
 	runtimeConfigData
+
+	// settingsLock serializes settings updates, which are applied without holding mu
+	settingsLock sync.Mutex
+	settings     eth.RuntimeSettings
+	sequencer    SequencerSettings
+	peers        PeerLimitSettings
+
+	feed event.FeedOf[eth.RuntimeConfig]
+
+	notifyLock sync.Mutex
+	// notifyNext is the latest snapshot that subscribers were not notified of yet
+	notifyNext *eth.RuntimeConfig
+	notifying  bool
 }
 
 // runtimeConfigData is a flat bundle of configurable data, easy and light to copy around.
@@ -117,12 +150,154 @@ func (r *RuntimeConfig) Load(ctx context.Context, l1Ref eth.L1BlockRef) error {
 		}
 		recommendedProtoVersion = params.ProtocolVersion(recommendedVal)
 	}
+	data := runtimeConfigData{
+		p2pBlockSignerAddr: common.BytesToAddress(p2pSignerVal[:]),
+		required:           requiredProtVersion,
+		recommended:        recommendedProtoVersion,
+	}
 	r.mu.Lock()
-	defer r.mu.Unlock()
+	changed := data != r.runtimeConfigData
 	r.l1Ref = l1Ref
-	r.p2pBlockSignerAddr = common.BytesToAddress(p2pSignerVal[:])
-	r.required = requiredProtVersion
-	r.recommended = recommendedProtoVersion
-	r.log.Info("loaded new runtime config values!", "p2p_seq_address", r.p2pBlockSignerAddr)
+	r.runtimeConfigData = data
+	if changed {
+		r.notify(r.snapshot())
+	}
+	r.mu.Unlock()
+	r.log.Info("loaded new runtime config values!", "p2p_seq_address", data.p2pBlockSignerAddr)
 	return nil
 }
+
+// notify sends the snapshot to the subscribers without blocking.
+// It must be called while holding mu, so snapshots are sent in order.
+func (r *RuntimeConfig) notify(snapshot eth.RuntimeConfig) {
+	r.notifyLock.Lock()
+	defer r.notifyLock.Unlock()
+	r.notifyNext = &snapshot
+	if !r.notifying {
+		r.notifying = true
+		go r.sendChanges()
+	}
+}
+
+// sendChanges sends the latest snapshot to the subscribers, until there is no newer snapshot.
+func (r *RuntimeConfig) sendChanges() {
+	for {
+		r.notifyLock.Lock()
+		next := r.notifyNext
+		r.notifyNext = nil
+		if next == nil {
+			r.notifying = false
+			r.notifyLock.Unlock()
+			return
+		}
+		r.notifyLock.Unlock()
+		r.feed.Send(*next)
+	}
+}
+
+// Snapshot returns the current runtime config values and settings.
+func (r *RuntimeConfig) Snapshot() eth.RuntimeConfig {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.snapshot()
+}
+
+func (r *RuntimeConfig) snapshot() eth.RuntimeConfig {
+	return eth.RuntimeConfig{
+		L1Origin:                   r.l1Ref.ID(),
+		P2PSequencerAddress:        r.p2pBlockSignerAddr,
+		RequiredProtocolVersion:    r.required,
+		RecommendedProtocolVersion: r.recommended,
+		Settings:                   r.settings,
+	}
+}
+
+// SubscribeChanges sends a snapshot of the runtime config to the channel on changes,
+// until the subscription is unsubscribed. Changes are not sent if the L1 values are reloaded unchanged.
+// A subscriber that is slow to receive may only get the latest of several changes.
+func (r *RuntimeConfig) SubscribeChanges(ch chan<- eth.RuntimeConfig) event.Subscription {
+	return r.feed.Subscribe(ch)
+}
+
+// EnableSequencerSettings makes the sequencer settings changeable, starting from the given values.
+func (r *RuntimeConfig) EnableSequencerSettings(s SequencerSettings, maxSafeLag uint64) {
+	r.settingsLock.Lock()
+	defer r.settingsLock.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sequencer = s
+	r.settings.SequencerMaxSafeLag = &maxSafeLag
+}
+
+// EnablePeerLimitSettings makes the P2P peer limit settings changeable, starting from the given values.
+func (r *RuntimeConfig) EnablePeerLimitSettings(p PeerLimitSettings, lo, hi uint) {
+	r.settingsLock.Lock()
+	defer r.settingsLock.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.peers = p
+	r.settings.P2PPeersLo = &lo
+	r.settings.P2PPeersHi = &hi
+}
+
+// UpdateSettings applies the settings that are set in the update, and returns all the current settings.
+// The update is checked as a whole before applying it. If applying a setting fails,
+// the settings applied before it remain changed, and subscribers are notified of them.
+func (r *RuntimeConfig) UpdateSettings(ctx context.Context, update eth.RuntimeSettings) (eth.RuntimeSettings, error) {
+	r.settingsLock.Lock()
+	defer r.settingsLock.Unlock()
+	r.mu.RLock()
+	current := r.settings
+	r.mu.RUnlock()
+
+	if update.SequencerMaxSafeLag != nil && r.sequencer == nil {
+		return current, fmt.Errorf("%w: sequencer is not enabled", ErrUnsupportedSetting)
+	}
+	updatePeers := update.P2PPeersLo != nil || update.P2PPeersHi != nil
+	var lo, hi uint
+	if updatePeers {
+		if r.peers == nil {
+			return current, fmt.Errorf("%w: P2P peer limits cannot be changed", ErrUnsupportedSetting)
+		}
+		lo, hi = *current.P2PPeersLo, *current.P2PPeersHi
+		if update.P2PPeersLo != nil {
+			lo = *update.P2PPeersLo
+		}
+		if update.P2PPeersHi != nil {
+			hi = *update.P2PPeersHi
+		}
+		if lo == 0 || lo > hi {
+			return current, fmt.Errorf("invalid P2P peer limits: lo %d, hi %d", lo, hi)
+		}
+	}
+
+	changed := false
+	var err error
+	if update.SequencerMaxSafeLag != nil {
+		if err = r.sequencer.SetSequencerMaxSafeLag(ctx, *update.SequencerMaxSafeLag); err != nil {
+			err = fmt.Errorf("failed to set sequencer max safe lag: %w", err)
+		} else {
+			v := *update.SequencerMaxSafeLag
+			current.SequencerMaxSafeLag = &v
+			changed = true
+			r.log.Info("changed sequencer max safe lag", "max_safe_lag", v)
+		}
+	}
+	if err == nil && updatePeers {
+		if err = r.peers.SetPeerLimits(lo, hi); err != nil {
+			err = fmt.Errorf("failed to set P2P peer limits: %w", err)
+		} else {
+			current.P2PPeersLo, current.P2PPeersHi = &lo, &hi
+			changed = true
+			r.log.Info("changed P2P peer limits", "lo", lo, "hi", hi)
+		}
+	}
+	if !changed {
+		return current, err
+	}
+	r.mu.Lock()
+	r.settings = current
+	r.notify(r.snapshot())
+	r.mu.Unlock()
+	return current, err
+}
@@ -0,0 +1,190 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	l1common "github.com/ethereum/go-ethereum/common"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testlog"
+)
+
+type fakeRuntimeCfgL1Source struct {
+	signer common.Address
+}
+
+func (f *fakeRuntimeCfgL1Source) ReadStorageAt(ctx context.Context, address common.Address, storageSlot common.Hash, blockHash common.Hash) (l1common.Hash, error) {
+	return l1common.BytesToHash(f.signer[:]), nil
+}
+
+type fakeSequencerSettings struct {
+	maxSafeLag uint64
+	err        error
+}
+
+func (f *fakeSequencerSettings) SetSequencerMaxSafeLag(ctx context.Context, v uint64) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.maxSafeLag = v
+	return nil
+}
+
+type fakePeerLimitSettings struct {
+	lo, hi uint
+}
+
+func (f *fakePeerLimitSettings) SetPeerLimits(lo, hi uint) error {
+	f.lo, f.hi = lo, hi
+	return nil
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// requireChange returns the next change sent to the subscriber.
+func requireChange(t *testing.T, ch chan eth.RuntimeConfig) eth.RuntimeConfig {
+	select {
+	case cfg := <-ch:
+		return cfg
+	case <-time.After(5 * time.Second):
+		t.Fatal("runtime config change was not sent")
+		return eth.RuntimeConfig{}
+	}
+}
+
+// requireNoChange checks that no change is sent to the subscriber.
+func requireNoChange(t *testing.T, ch chan eth.RuntimeConfig) {
+	select {
+	case cfg := <-ch:
+		t.Fatalf("unexpected runtime config change: %v", cfg)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRuntimeConfigLoadNotifiesChanges(t *testing.T) {
+	l1 := &fakeRuntimeCfgL1Source{signer: common.Address{0xaa}}
+	r := NewRuntimeConfig(testlog.Logger(t, log.LevelError), l1, &rollup.Config{})
+	ch := make(chan eth.RuntimeConfig, 10)
+	sub := r.SubscribeChanges(ch)
+	defer sub.Unsubscribe()
+
+	ref := eth.L1BlockRef{Hash: common.Hash{0x01}, Number: 1}
+	require.NoError(t, r.Load(context.Background(), ref))
+	snapshot := requireChange(t, ch)
+	require.Equal(t, common.Address{0xaa}, snapshot.P2PSequencerAddress)
+	require.Equal(t, ref.ID(), snapshot.L1Origin)
+
+	// reloading the same values at a later block does not notify
+	require.NoError(t, r.Load(context.Background(), eth.L1BlockRef{Hash: common.Hash{0x02}, Number: 2}))
+	requireNoChange(t, ch)
+	require.Equal(t, uint64(2), r.Snapshot().L1Origin.Number)
+
+	l1.signer = common.Address{0xbb}
+	require.NoError(t, r.Load(context.Background(), eth.L1BlockRef{Hash: common.Hash{0x03}, Number: 3}))
+	require.Equal(t, common.Address{0xbb}, requireChange(t, ch).P2PSequencerAddress)
+}
+
+func TestRuntimeConfigSlowSubscriber(t *testing.T) {
+	l1 := &fakeRuntimeCfgL1Source{}
+	r := NewRuntimeConfig(testlog.Logger(t, log.LevelError), l1, &rollup.Config{})
+	ch := make(chan eth.RuntimeConfig)
+	sub := r.SubscribeChanges(ch)
+	defer sub.Unsubscribe()
+
+	// reloads do not wait for the subscriber
+	for i := byte(1); i <= 3; i++ {
+		l1.signer = common.Address{i}
+		require.NoError(t, r.Load(context.Background(), eth.L1BlockRef{Hash: common.Hash{i}, Number: uint64(i)}))
+	}
+	// the subscriber eventually gets the latest change, intermediate changes may be skipped
+	require.Eventually(t, func() bool {
+		return requireChange(t, ch).P2PSequencerAddress == common.Address{3}
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestRuntimeConfigUpdateSettings(t *testing.T) {
+	ctx := context.Background()
+	newConfig := func(t *testing.T) (*RuntimeConfig, chan eth.RuntimeConfig) {
+		r := NewRuntimeConfig(testlog.Logger(t, log.LevelError), &fakeRuntimeCfgL1Source{}, &rollup.Config{})
+		ch := make(chan eth.RuntimeConfig, 10)
+		sub := r.SubscribeChanges(ch)
+		t.Cleanup(sub.Unsubscribe)
+		return r, ch
+	}
+
+	t.Run("Unsupported", func(t *testing.T) {
+		r, ch := newConfig(t)
+		_, err := r.UpdateSettings(ctx, eth.RuntimeSettings{SequencerMaxSafeLag: ptr(uint64(10))})
+		require.ErrorIs(t, err, ErrUnsupportedSetting)
+		_, err = r.UpdateSettings(ctx, eth.RuntimeSettings{P2PPeersHi: ptr(uint(10))})
+		require.ErrorIs(t, err, ErrUnsupportedSetting)
+		requireNoChange(t, ch)
+		require.Equal(t, eth.RuntimeSettings{}, r.Snapshot().Settings)
+	})
+
+	t.Run("Apply", func(t *testing.T) {
+		r, ch := newConfig(t)
+		seq := &fakeSequencerSettings{}
+		peers := &fakePeerLimitSettings{}
+		r.EnableSequencerSettings(seq, 5)
+		r.EnablePeerLimitSettings(peers, 20, 30)
+		require.Equal(t, eth.RuntimeSettings{
+			SequencerMaxSafeLag: ptr(uint64(5)),
+			P2PPeersLo:          ptr(uint(20)),
+			P2PPeersHi:          ptr(uint(30)),
+		}, r.Snapshot().Settings)
+
+		settings, err := r.UpdateSettings(ctx, eth.RuntimeSettings{SequencerMaxSafeLag: ptr(uint64(100)), P2PPeersHi: ptr(uint(40))})
+		require.NoError(t, err)
+		expected := eth.RuntimeSettings{
+			SequencerMaxSafeLag: ptr(uint64(100)),
+			P2PPeersLo:          ptr(uint(20)),
+			P2PPeersHi:          ptr(uint(40)),
+		}
+		require.Equal(t, expected, settings)
+		require.Equal(t, uint64(100), seq.maxSafeLag)
+		require.Equal(t, uint(20), peers.lo)
+		require.Equal(t, uint(40), peers.hi)
+		require.Equal(t, expected, requireChange(t, ch).Settings)
+
+		// an empty update changes nothing
+		settings, err = r.UpdateSettings(ctx, eth.RuntimeSettings{})
+		require.NoError(t, err)
+		require.Equal(t, expected, settings)
+		requireNoChange(t, ch)
+	})
+
+	t.Run("InvalidPeerLimits", func(t *testing.T) {
+		r, ch := newConfig(t)
+		seq := &fakeSequencerSettings{}
+		peers := &fakePeerLimitSettings{}
+		r.EnableSequencerSettings(seq, 5)
+		r.EnablePeerLimitSettings(peers, 20, 30)
+		// the update is checked as a whole, the max safe lag is not applied either
+		_, err := r.UpdateSettings(ctx, eth.RuntimeSettings{SequencerMaxSafeLag: ptr(uint64(100)), P2PPeersLo: ptr(uint(31))})
+		require.Error(t, err)
+		_, err = r.UpdateSettings(ctx, eth.RuntimeSettings{P2PPeersLo: ptr(uint(0))})
+		require.Error(t, err)
+		require.Equal(t, uint64(0), seq.maxSafeLag)
+		requireNoChange(t, ch)
+	})
+
+	t.Run("ApplyError", func(t *testing.T) {
+		r, ch := newConfig(t)
+		seq := &fakeSequencerSettings{err: errors.New("boom")}
+		r.EnableSequencerSettings(seq, 5)
+		settings, err := r.UpdateSettings(ctx, eth.RuntimeSettings{SequencerMaxSafeLag: ptr(uint64(100))})
+		require.ErrorIs(t, err, seq.err)
+		require.Equal(t, ptr(uint64(5)), settings.SequencerMaxSafeLag)
+		requireNoChange(t, ch)
+	})
+}
@@ -0,0 +1,214 @@
+package safedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/attributes"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+)
+
+// maxTemporaryErrors is the number of consecutive temporary derivation errors tolerated before the backfill aborts.
+const maxTemporaryErrors = 10
+
+// ErrEntryMismatch is returned by the backfill command when verification finds missing or different entries.
+var ErrEntryMismatch = errors.New("safe head entry mismatch")
+
+// BackfillPipeline is the subset of the derivation pipeline used to derive safe heads offline.
+type BackfillPipeline interface {
+	Step(ctx context.Context, pendingSafeHead eth.L2BlockRef) (*derive.AttributesWithParent, error)
+	Origin() eth.L1BlockRef
+	ConfirmEngineReset()
+}
+
+type BackfillL1Source interface {
+	L1BlockRefByNumber(ctx context.Context, num uint64) (eth.L1BlockRef, error)
+}
+
+type BackfillL2Source interface {
+	PayloadByNumber(ctx context.Context, num uint64) (*eth.ExecutionPayloadEnvelope, error)
+}
+
+// BackfillResult summarizes a backfill run.
+type BackfillResult struct {
+	// Written is the number of entries written. Always 0 when verifying.
+	Written uint64
+	// Verified is the number of entries that matched the existing entry.
+	Verified uint64
+	// Missing is the number of derived entries that had no existing entry. Only set when verifying.
+	Missing uint64
+	// Mismatched is the number of derived entries that differ from the existing entry. Only set when verifying.
+	Mismatched uint64
+	// SafeHead is the last safe head that was derived.
+	SafeHead eth.L2BlockRef
+	// L1Origin is the L1 block the derivation stopped at.
+	L1Origin eth.L1BlockRef
+}
+
+// Backfiller rebuilds the safe-by-L1 index by running the derivation pipeline offline,
+// checking every derived block against the canonical L2 chain.
+// The canonical chain is not modified: the L2 source only needs to serve blocks.
+type Backfiller struct {
+	log      log.Logger
+	cfg      *rollup.Config
+	db       *SafeDB
+	pipeline BackfillPipeline
+	l1       BackfillL1Source
+	l2       BackfillL2Source
+	// if verify is set, derived entries are compared against the existing entries instead of being written.
+	verify bool
+}
+
+func NewBackfiller(logger log.Logger, cfg *rollup.Config, db *SafeDB, pipeline BackfillPipeline, l1 BackfillL1Source, l2 BackfillL2Source, verify bool) *Backfiller {
+	return &Backfiller{
+		log:      logger,
+		cfg:      cfg,
+		db:       db,
+		pipeline: pipeline,
+		l1:       l1,
+		l2:       l2,
+		verify:   verify,
+	}
+}
+
+// Run derives the chain starting from the given safe head, until the pipeline moves past the end L1 block,
+// or until L1 runs out of blocks. Entries for L1 blocks after endL1 are not recorded.
+// The pipeline must not have been used before.
+func (b *Backfiller) Run(ctx context.Context, start eth.L2BlockRef, endL1 uint64) (BackfillResult, error) {
+	res := BackfillResult{SafeHead: start}
+	b.pipeline.ConfirmEngineReset()
+	// Multiple safe head updates within the same L1 block overwrite each other,
+	// so an entry is only final once the safe head is updated in a later L1 block.
+	var pending *backfillEntry
+	record := func(safeHead eth.L2BlockRef, l1Block eth.BlockID) error {
+		if pending != nil && pending.l1 != l1Block {
+			if err := b.flush(ctx, &res, pending); err != nil {
+				return err
+			}
+		}
+		pending = &backfillEntry{safe: safeHead, l1: l1Block}
+		return nil
+	}
+	done := func() (BackfillResult, error) {
+		if pending != nil {
+			if err := b.flush(ctx, &res, pending); err != nil {
+				return res, err
+			}
+		}
+		return res, nil
+	}
+
+	if start.ID() == b.cfg.Genesis.L2 {
+		// Same as the node: genesis is safe from L1 genesis onwards.
+		l1Genesis, err := b.l1.L1BlockRefByNumber(ctx, 0)
+		if err != nil {
+			return res, fmt.Errorf("failed to retrieve L1 genesis: %w", err)
+		}
+		if err := record(start, l1Genesis.ID()); err != nil {
+			return res, err
+		}
+	}
+
+	temporaryErrs := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		attrs, err := b.pipeline.Step(ctx, res.SafeHead)
+		res.L1Origin = b.pipeline.Origin()
+		if res.L1Origin.Number > endL1 {
+			b.log.Info("Reached end of backfill range", "l1", res.L1Origin, "safe", res.SafeHead)
+			return done()
+		}
+		if errors.Is(err, io.EOF) {
+			b.log.Warn("Reached L1 head before end of backfill range", "l1", res.L1Origin, "end", endL1)
+			return done()
+		} else if errors.Is(err, derive.ErrTemporary) {
+			temporaryErrs++
+			if temporaryErrs > maxTemporaryErrors {
+				return res, fmt.Errorf("too many temporary errors: %w", err)
+			}
+			b.log.Warn("Temporary derivation error, retrying", "err", err)
+			select {
+			case <-ctx.Done():
+				return res, ctx.Err()
+			case <-time.After(time.Second):
+			}
+			continue
+		} else if err != nil {
+			return res, fmt.Errorf("derivation failed at L1 origin %s: %w", res.L1Origin, err)
+		}
+		temporaryErrs = 0
+		if attrs == nil {
+			continue
+		}
+
+		envelope, err := b.l2.PayloadByNumber(ctx, res.SafeHead.Number+1)
+		if err != nil {
+			return res, fmt.Errorf("failed to fetch L2 block %d: %w", res.SafeHead.Number+1, err)
+		}
+		if err := attributes.AttributesMatchBlock(b.cfg, attrs.Attributes, res.SafeHead.Hash, envelope, b.log); err != nil {
+			return res, fmt.Errorf("derived attributes do not match canonical L2 block %d: %w", res.SafeHead.Number+1, err)
+		}
+		ref, err := derive.PayloadToBlockRef(b.cfg, envelope.ExecutionPayload, envelope.L1Info)
+		if err != nil {
+			return res, fmt.Errorf("invalid L2 block %d: %w", res.SafeHead.Number+1, err)
+		}
+		res.SafeHead = ref
+		if attrs.IsLastInSpan {
+			if err := record(ref, attrs.DerivedFrom.ID()); err != nil {
+				return res, err
+			}
+		}
+	}
+}
+
+type backfillEntry struct {
+	safe eth.L2BlockRef
+	l1   eth.BlockID
+}
+
+func (b *Backfiller) flush(ctx context.Context, res *BackfillResult, e *backfillEntry) error {
+	if !b.verify {
+		if err := b.db.SafeHeadUpdated(e.safe, e.l1); err != nil {
+			return err
+		}
+		res.Written++
+		return nil
+	}
+	actualL1, actualL2, err := b.db.SafeHeadAtL1(ctx, e.l1.Number)
+	if errors.Is(err, ErrNotFound) || (err == nil && actualL1.Number != e.l1.Number) {
+		b.log.Warn("Missing safe head entry", "l1", e.l1, "safe", e.safe.ID())
+		res.Missing++
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read entry at L1 block %d: %w", e.l1.Number, err)
+	}
+	if actualL1 != e.l1 || actualL2 != e.safe.ID() {
+		b.log.Error("Safe head entry mismatch", "l1", e.l1, "safe", e.safe.ID(), "storedL1", actualL1, "storedSafe", actualL2)
+		res.Mismatched++
+		return nil
+	}
+	res.Verified++
+	return nil
+}
+
+// ResumePoint returns the newest safe head entry recorded for an L1 block in the given range,
+// or ErrNotFound if there is none.
+func (d *SafeDB) ResumePoint(ctx context.Context, startL1 uint64, endL1 uint64) (l1Block eth.BlockID, safeHead eth.BlockID, err error) {
+	l1Block, safeHead, err = d.SafeHeadAtL1(ctx, endL1)
+	if err != nil {
+		return
+	}
+	if l1Block.Number < startL1 {
+		err = ErrNotFound
+	}
+	return
+}
@@ -0,0 +1,241 @@
+package safedb
+
+import (
+	"context"
+	"io"
+	"math/rand" // nosemgrep
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zircuit-labs/l2-geth-public/core/types"
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testlog"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testutils"
+)
+
+type backfillStep struct {
+	attrs  *derive.AttributesWithParent
+	origin eth.L1BlockRef
+}
+
+type fakePipeline struct {
+	steps  []backfillStep
+	origin eth.L1BlockRef
+	reset  bool
+}
+
+func (p *fakePipeline) Step(ctx context.Context, pendingSafeHead eth.L2BlockRef) (*derive.AttributesWithParent, error) {
+	if !p.reset {
+		return nil, derive.NewResetError(io.ErrUnexpectedEOF)
+	}
+	if len(p.steps) == 0 {
+		return nil, io.EOF
+	}
+	step := p.steps[0]
+	p.steps = p.steps[1:]
+	p.origin = step.origin
+	return step.attrs, nil
+}
+
+func (p *fakePipeline) Origin() eth.L1BlockRef {
+	return p.origin
+}
+
+func (p *fakePipeline) ConfirmEngineReset() {
+	p.reset = true
+}
+
+type fakeL1 map[uint64]eth.L1BlockRef
+
+func (f fakeL1) L1BlockRefByNumber(ctx context.Context, num uint64) (eth.L1BlockRef, error) {
+	ref, ok := f[num]
+	if !ok {
+		return eth.L1BlockRef{}, ErrNotFound
+	}
+	return ref, nil
+}
+
+type fakeL2 map[uint64]*eth.ExecutionPayloadEnvelope
+
+func (f fakeL2) PayloadByNumber(ctx context.Context, num uint64) (*eth.ExecutionPayloadEnvelope, error) {
+	envelope, ok := f[num]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return envelope, nil
+}
+
+type backfillChain struct {
+	cfg     *rollup.Config
+	l1      fakeL1
+	l2      fakeL2
+	genesis eth.L2BlockRef
+	refs    []eth.L2BlockRef
+	attrs   []*derive.AttributesWithParent
+}
+
+// newBackfillChain creates an L2 chain with one block per L1 block, on top of genesis anchored at L1 block 0.
+func newBackfillChain(t *testing.T, rng *rand.Rand, l2Blocks int) *backfillChain {
+	l1 := fakeL1{}
+	var parent eth.L1BlockRef
+	for i := uint64(0); i <= uint64(l2Blocks)+1; i++ {
+		ref := eth.L1BlockRef{Hash: testutils.RandomHash(rng), Number: i, ParentHash: parent.Hash, Time: 1000 + i*12}
+		l1[i] = ref
+		parent = ref
+	}
+	genesis := eth.L2BlockRef{Hash: testutils.RandomHash(rng), Number: 0, Time: l1[0].Time, L1Origin: l1[0].ID()}
+	cfg := &rollup.Config{
+		Genesis: rollup.Genesis{
+			L1:     l1[0].ID(),
+			L2:     genesis.ID(),
+			L2Time: genesis.Time,
+		},
+		BlockTime: 2,
+	}
+	chain := &backfillChain{cfg: cfg, l1: l1, l2: fakeL2{}, genesis: genesis}
+	prev := genesis
+	for i := uint64(1); i <= uint64(l2Blocks); i++ {
+		origin := l1[i-1]
+		gasLimit := eth.Uint64Quantity(30_000_000)
+		envelope := &eth.ExecutionPayloadEnvelope{
+			ExecutionPayload: &eth.ExecutionPayload{
+				ParentHash:  prev.Hash,
+				BlockNumber: eth.Uint64Quantity(i),
+				Timestamp:   eth.Uint64Quantity(prev.Time + cfg.BlockTime),
+				GasLimit:    gasLimit,
+				BlockHash:   testutils.RandomHash(rng),
+			},
+			L1Info: &types.L1Info{Number: origin.Number, BlockHash: origin.Hash},
+		}
+		ref, err := derive.PayloadToBlockRef(cfg, envelope.ExecutionPayload, envelope.L1Info)
+		require.NoError(t, err)
+		chain.l2[i] = envelope
+		chain.refs = append(chain.refs, ref)
+		chain.attrs = append(chain.attrs, &derive.AttributesWithParent{
+			Attributes: &eth.PayloadAttributes{
+				Timestamp: envelope.ExecutionPayload.Timestamp,
+				GasLimit:  &gasLimit,
+			},
+			Parent:       prev,
+			IsLastInSpan: true,
+		})
+		prev = ref
+	}
+	return chain
+}
+
+// derivedFrom returns the attributes of the given L2 block (1-indexed), as derived from the given L1 block.
+func (c *backfillChain) derivedFrom(l2Num uint64, l1Num uint64) backfillStep {
+	attrs := *c.attrs[l2Num-1]
+	attrs.DerivedFrom = c.l1[l1Num]
+	return backfillStep{attrs: &attrs, origin: c.l1[l1Num]}
+}
+
+func (c *backfillChain) steps() []backfillStep {
+	return []backfillStep{
+		c.derivedFrom(1, 1),
+		c.derivedFrom(2, 1),
+		{origin: c.l1[2]},
+		c.derivedFrom(3, 2),
+		{origin: c.l1[3]},
+		c.derivedFrom(4, 4),
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	logger := testlog.Logger(t, log.LvlInfo)
+	chain := newBackfillChain(t, rng, 4)
+
+	db, err := NewSafeDB(logger, t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+
+	run := func(verify bool, endL1 uint64) (BackfillResult, error) {
+		pipeline := &fakePipeline{steps: chain.steps()}
+		return NewBackfiller(logger, chain.cfg, db, pipeline, chain.l1, chain.l2, verify).Run(context.Background(), chain.genesis, endL1)
+	}
+
+	t.Run("verify empty", func(t *testing.T) {
+		res, err := run(true, 3)
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), res.Written)
+		require.Equal(t, uint64(3), res.Missing)
+		_, _, err = db.SafeHeadAtL1(context.Background(), 3)
+		require.ErrorIs(t, err, ErrNotFound, "verify must not write entries")
+	})
+
+	t.Run("write", func(t *testing.T) {
+		res, err := run(false, 3)
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), res.Written)
+		require.Equal(t, chain.refs[2], res.SafeHead)
+		require.Equal(t, chain.l1[4], res.L1Origin)
+
+		l1, l2, err := db.SafeHeadAtL1(context.Background(), 0)
+		require.NoError(t, err)
+		require.Equal(t, chain.l1[0].ID(), l1)
+		require.Equal(t, chain.genesis.ID(), l2)
+
+		// Only the last safe head of each L1 block is retained
+		l1, l2, err = db.SafeHeadAtL1(context.Background(), 1)
+		require.NoError(t, err)
+		require.Equal(t, chain.l1[1].ID(), l1)
+		require.Equal(t, chain.refs[1].ID(), l2)
+
+		l1, l2, err = db.SafeHeadAtL1(context.Background(), 4)
+		require.NoError(t, err)
+		require.Equal(t, chain.l1[2].ID(), l1, "entries after the end of the range are not written")
+		require.Equal(t, chain.refs[2].ID(), l2)
+	})
+
+	t.Run("verify", func(t *testing.T) {
+		res, err := run(true, 3)
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), res.Verified)
+		require.Zero(t, res.Missing)
+		require.Zero(t, res.Mismatched)
+	})
+
+	t.Run("verify mismatch", func(t *testing.T) {
+		require.NoError(t, db.SafeHeadUpdated(chain.refs[0], chain.l1[1].ID()))
+		res, err := run(true, 3)
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), res.Verified)
+		require.Equal(t, uint64(1), res.Mismatched)
+	})
+
+	t.Run("resume point", func(t *testing.T) {
+		l1, l2, err := db.ResumePoint(context.Background(), 1, 3)
+		require.NoError(t, err)
+		require.Equal(t, chain.l1[2].ID(), l1)
+		require.Equal(t, chain.refs[2].ID(), l2)
+
+		_, _, err = db.ResumePoint(context.Background(), 3, 10)
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestBackfill_NonCanonical(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	logger := testlog.Logger(t, log.LvlInfo)
+	chain := newBackfillChain(t, rng, 4)
+
+	db, err := NewSafeDB(logger, t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+
+	steps := chain.steps()
+	attrs := *steps[1].attrs.Attributes
+	attrs.Timestamp++
+	steps[1].attrs.Attributes = &attrs
+	pipeline := &fakePipeline{steps: steps}
+	res, err := NewBackfiller(logger, chain.cfg, db, pipeline, chain.l1, chain.l2, false).Run(context.Background(), chain.genesis, 10)
+	require.ErrorContains(t, err, "do not match canonical L2 block 2")
+	require.Equal(t, chain.refs[0], res.SafeHead)
+}
@@ -0,0 +1,203 @@
+package safedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+)
+
+// RetentionConfig configures how long safe head entries are kept.
+// Only entries for finalized L1 blocks are pruned.
+type RetentionConfig struct {
+	// Blocks is the number of L1 blocks before the finalized L1 block to retain entries for. Disabled if 0.
+	Blocks uint64
+	// Age is the duration before the finalized L1 block to retain entries for. Disabled if 0.
+	Age time.Duration
+	// Interval is the time between pruning attempts.
+	Interval time.Duration
+}
+
+// Enabled returns true if any retention limit is configured.
+// When both limits are configured, an entry is retained as long as it is within either limit.
+func (c RetentionConfig) Enabled() bool {
+	return c.Blocks > 0 || c.Age > 0
+}
+
+func (c RetentionConfig) Check() error {
+	if c.Enabled() && c.Interval <= 0 {
+		return errors.New("safe head db prune interval must be positive")
+	}
+	return nil
+}
+
+type PrunerMetrics interface {
+	RecordSafeDBSize(bytes uint64)
+	RecordSafeDBOldestEntry(l1BlockNum uint64)
+}
+
+type PrunerL1Source interface {
+	L1BlockRefByNumber(ctx context.Context, num uint64) (eth.L1BlockRef, error)
+}
+
+// Pruner periodically deletes safe head entries that are outside the retention limits.
+type Pruner struct {
+	log log.Logger
+	cfg RetentionConfig
+	db  *SafeDB
+	l1  PrunerL1Source
+	m   PrunerMetrics
+
+	finalized atomic.Pointer[eth.L1BlockRef]
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	startOnce sync.Once
+	started   atomic.Bool
+	done      chan struct{}
+}
+
+func NewPruner(logger log.Logger, cfg RetentionConfig, db *SafeDB, l1 PrunerL1Source, m PrunerMetrics) *Pruner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Pruner{
+		log:    logger,
+		cfg:    cfg,
+		db:     db,
+		l1:     l1,
+		m:      m,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+}
+
+// OnNewL1Finalized updates the finalized L1 block that retention is relative to.
+func (p *Pruner) OnNewL1Finalized(ref eth.L1BlockRef) {
+	p.finalized.Store(&ref)
+}
+
+func (p *Pruner) Start() {
+	p.startOnce.Do(func() {
+		p.started.Store(true)
+		go p.loop()
+	})
+}
+
+func (p *Pruner) loop() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.Prune(p.ctx); err != nil {
+				p.log.Warn("Failed to prune safe head db", "err", err)
+			}
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// Prune deletes the entries outside the retention limits and updates the metrics.
+func (p *Pruner) Prune(ctx context.Context) error {
+	defer p.recordMetrics()
+	finalized := p.finalized.Load()
+	if finalized == nil {
+		p.log.Debug("Finalized L1 block unknown, not pruning safe head db")
+		return nil
+	}
+	cutoff, ok, err := p.cutoff(ctx, *finalized)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	return p.db.Prune(cutoff)
+}
+
+// cutoff returns the L1 block number before which entries are no longer retained.
+func (p *Pruner) cutoff(ctx context.Context, finalized eth.L1BlockRef) (uint64, bool, error) {
+	var cutoff uint64
+	ok := false
+	if p.cfg.Blocks > 0 && finalized.Number > p.cfg.Blocks {
+		cutoff = finalized.Number - p.cfg.Blocks
+		ok = true
+	} else if p.cfg.Blocks > 0 {
+		return 0, false, nil
+	}
+	if p.cfg.Age > 0 {
+		byAge, found, err := p.ageCutoff(ctx, finalized)
+		if err != nil || !found {
+			return 0, false, err
+		}
+		if !ok || byAge < cutoff {
+			cutoff = byAge
+		}
+		ok = true
+	}
+	return cutoff, ok, nil
+}
+
+// ageCutoff finds the newest L1 block that is older than the retention age, relative to the finalized L1 block.
+func (p *Pruner) ageCutoff(ctx context.Context, finalized eth.L1BlockRef) (uint64, bool, error) {
+	age := uint64(p.cfg.Age / time.Second)
+	if finalized.Time <= age {
+		return 0, false, nil
+	}
+	maxTime := finalized.Time - age
+	lo, err := p.db.OldestEntry()
+	if errors.Is(err, ErrNotFound) {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, fmt.Errorf("failed to read oldest entry: %w", err)
+	}
+	if lo >= finalized.Number {
+		return 0, false, nil
+	}
+	ref, err := p.l1.L1BlockRefByNumber(ctx, lo)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to fetch L1 block %d: %w", lo, err)
+	}
+	if ref.Time > maxTime {
+		return 0, false, nil
+	}
+	// Binary search for the newest block with a time at or before maxTime, lo always satisfies this.
+	hi := finalized.Number
+	for lo < hi {
+		mid := lo + (hi-lo+1)/2
+		ref, err := p.l1.L1BlockRefByNumber(ctx, mid)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to fetch L1 block %d: %w", mid, err)
+		}
+		if ref.Time <= maxTime {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return lo, true, nil
+}
+
+func (p *Pruner) recordMetrics() {
+	p.m.RecordSafeDBSize(p.db.DiskUsage())
+	if oldest, err := p.db.OldestEntry(); err == nil {
+		p.m.RecordSafeDBOldestEntry(oldest)
+	}
+}
+
+// Close stops the background pruning. The database is not closed.
+func (p *Pruner) Close() error {
+	p.cancel()
+	if p.started.Load() {
+		<-p.done
+	}
+	return nil
+}
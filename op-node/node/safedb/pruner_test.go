@@ -0,0 +1,134 @@
+package safedb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/log"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testlog"
+)
+
+type testPrunerMetrics struct {
+	size   uint64
+	oldest uint64
+}
+
+func (m *testPrunerMetrics) RecordSafeDBSize(bytes uint64) {
+	m.size = bytes
+}
+
+func (m *testPrunerMetrics) RecordSafeDBOldestEntry(l1BlockNum uint64) {
+	m.oldest = l1BlockNum
+}
+
+// populatePruneTestDB records a safe head update every 10 L1 blocks, from L1 block 10 to 100.
+func populatePruneTestDB(t *testing.T, db *SafeDB) {
+	for i := uint64(1); i <= 10; i++ {
+		l1 := eth.BlockID{Hash: common.Hash{0x01, byte(i)}, Number: i * 10}
+		l2 := eth.L2BlockRef{Hash: common.Hash{0x02, byte(i)}, Number: i * 20}
+		require.NoError(t, db.SafeHeadUpdated(l2, l1))
+	}
+}
+
+func TestPrune(t *testing.T) {
+	logger := testlog.Logger(t, log.LvlInfo)
+	dir := t.TempDir()
+	db, err := NewSafeDB(logger, dir)
+	require.NoError(t, err)
+	defer db.Close()
+	populatePruneTestDB(t, db)
+
+	require.NoError(t, db.Prune(45))
+	verifyPruned := func(db *SafeDB) {
+		oldest, err := db.OldestEntry()
+		require.NoError(t, err)
+		require.Equal(t, uint64(40), oldest, "newest entry before the cutoff is retained")
+
+		_, _, err = db.SafeHeadAtL1(context.Background(), 39)
+		require.ErrorIs(t, err, ErrPruned)
+		var pruned *PrunedError
+		require.ErrorAs(t, err, &pruned)
+		require.Equal(t, uint64(39), pruned.L1BlockNum)
+		require.Equal(t, uint64(40), pruned.Oldest)
+
+		l1, l2, err := db.SafeHeadAtL1(context.Background(), 45)
+		require.NoError(t, err)
+		require.Equal(t, uint64(40), l1.Number)
+		require.Equal(t, uint64(80), l2.Number)
+	}
+	verifyPruned(db)
+
+	// Pruning to an earlier block has no effect
+	require.NoError(t, db.Prune(20))
+	verifyPruned(db)
+
+	// The pruned range is persisted
+	require.NoError(t, db.Close())
+	newDB, err := NewSafeDB(logger, dir)
+	require.NoError(t, err)
+	defer newDB.Close()
+	verifyPruned(newDB)
+}
+
+func TestPrune_EmptyDatabase(t *testing.T) {
+	logger := testlog.Logger(t, log.LvlInfo)
+	db, err := NewSafeDB(logger, t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.Prune(100))
+	_, _, err = db.SafeHeadAtL1(context.Background(), 50)
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = db.OldestEntry()
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestPruner(t *testing.T) {
+	l1 := fakeL1{}
+	for i := uint64(0); i <= 200; i++ {
+		l1[i] = eth.L1BlockRef{Number: i, Time: 1000 + i*12}
+	}
+
+	tests := []struct {
+		name     string
+		cfg      RetentionConfig
+		expected uint64
+	}{
+		{name: "blocks", cfg: RetentionConfig{Blocks: 50}, expected: 40},
+		{name: "blocks exceeding chain", cfg: RetentionConfig{Blocks: 200}, expected: 10},
+		{name: "age", cfg: RetentionConfig{Age: 12 * 65 * time.Second}, expected: 30},
+		{name: "age exceeding chain", cfg: RetentionConfig{Age: 24 * time.Hour}, expected: 10},
+		{name: "blocks and age retain most", cfg: RetentionConfig{Blocks: 50, Age: 12 * 65 * time.Second}, expected: 30},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			logger := testlog.Logger(t, log.LvlInfo)
+			db, err := NewSafeDB(logger, t.TempDir())
+			require.NoError(t, err)
+			defer db.Close()
+			populatePruneTestDB(t, db)
+
+			m := &testPrunerMetrics{}
+			pruner := NewPruner(logger, test.cfg, db, l1, m)
+			// Nothing is pruned until the finalized L1 block is known
+			require.NoError(t, pruner.Prune(context.Background()))
+			require.Equal(t, uint64(10), m.oldest)
+
+			pruner.OnNewL1Finalized(l1[95])
+			require.NoError(t, pruner.Prune(context.Background()))
+			require.Equal(t, test.expected, m.oldest)
+			require.NotZero(t, m.size)
+			require.NoError(t, pruner.Close())
+		})
+	}
+}
+
+func TestRetentionConfig_Check(t *testing.T) {
+	require.NoError(t, RetentionConfig{}.Check())
+	require.NoError(t, RetentionConfig{Blocks: 10, Interval: time.Minute}.Check())
+	require.Error(t, RetentionConfig{Blocks: 10}.Check())
+}
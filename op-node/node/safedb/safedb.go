@@ -17,15 +17,35 @@ import (
 var (
 	ErrNotFound     = errors.New("not found")
 	ErrInvalidEntry = errors.New("invalid db entry")
+	ErrPruned       = errors.New("pruned")
 )
 
+// PrunedError is returned when the requested L1 block is before the oldest entry that is retained.
+type PrunedError struct {
+	L1BlockNum uint64
+	// Oldest is the oldest L1 block number that can still be queried.
+	Oldest uint64
+}
+
+func (e *PrunedError) Error() string {
+	return fmt.Sprintf("safe head at L1 block %d is pruned, oldest available L1 block is %d", e.L1BlockNum, e.Oldest)
+}
+
+func (e *PrunedError) Is(target error) bool {
+	return target == ErrPruned
+}
+
 const (
 	// Keys are prefixed with a constant byte to allow us to differentiate different "columns" within the data
 	keyPrefixSafeByL1BlockNum byte = 0
+	keyPrefixMeta             byte = 1
 )
 
 var safeByL1BlockNumKey = uint64Key{prefix: keyPrefixSafeByL1BlockNum}
 
+// prunedBeforeKey stores the L1 block number of the oldest entry that was retained by pruning.
+var prunedBeforeKey = []byte{keyPrefixMeta, 0}
+
 type uint64Key struct {
 	prefix byte
 }
@@ -57,6 +77,9 @@ type SafeDB struct {
 
 	writeOpts *pebble.WriteOptions
 
+	// prunedBefore is the L1 block number of the oldest retained entry, or 0 if never pruned.
+	prunedBefore uint64
+
 	closed bool
 }
 
@@ -85,10 +108,25 @@ func NewSafeDB(logger log.Logger, path string) (*SafeDB, error) {
 	if err != nil {
 		return nil, err
 	}
+	var prunedBefore uint64
+	val, closer, err := db.Get(prunedBeforeKey)
+	if err == nil {
+		if len(val) != 8 {
+			_ = closer.Close()
+			_ = db.Close()
+			return nil, fmt.Errorf("%w: pruned marker of length %d", ErrInvalidEntry, len(val))
+		}
+		prunedBefore = binary.BigEndian.Uint64(val)
+		_ = closer.Close()
+	} else if !errors.Is(err, pebble.ErrNotFound) {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to read pruned marker: %w", err)
+	}
 	return &SafeDB{
-		log:       logger,
-		db:        db,
-		writeOpts: &pebble.WriteOptions{Sync: true},
+		log:          logger,
+		db:           db,
+		writeOpts:    &pebble.WriteOptions{Sync: true},
+		prunedBefore: prunedBefore,
 	}, nil
 }
 
@@ -165,6 +203,10 @@ func (d *SafeDB) SafeHeadReset(safeHead eth.L2BlockRef) error {
 func (d *SafeDB) SafeHeadAtL1(ctx context.Context, l1BlockNum uint64) (l1Block eth.BlockID, safeHead eth.BlockID, err error) {
 	d.m.RLock()
 	defer d.m.RUnlock()
+	if l1BlockNum < d.prunedBefore {
+		err = &PrunedError{L1BlockNum: l1BlockNum, Oldest: d.prunedBefore}
+		return
+	}
 	iter, err := d.db.NewIterWithContext(ctx, safeByL1BlockNumKey.IterRange())
 	if err != nil {
 		return
@@ -183,6 +225,88 @@ func (d *SafeDB) SafeHeadAtL1(ctx context.Context, l1BlockNum uint64) (l1Block e
 	return
 }
 
+// Prune deletes the entries before the given L1 block number.
+// The newest entry at or before the L1 block is retained, so that queries for the L1 block and after are unaffected.
+// Queries before the oldest retained entry return a PrunedError.
+func (d *SafeDB) Prune(l1BlockNum uint64) error {
+	d.m.Lock()
+	oldestKey, err := d.prune(l1BlockNum)
+	d.m.Unlock()
+	if err != nil || oldestKey == nil {
+		return err
+	}
+	// Range deletes only leave tombstones, compact to reclaim the disk space.
+	d.m.RLock()
+	defer d.m.RUnlock()
+	if d.closed {
+		return nil
+	}
+	if err := d.db.Compact(safeByL1BlockNumKey.Of(0), oldestKey, true); err != nil {
+		return fmt.Errorf("failed to compact pruned entries: %w", err)
+	}
+	return nil
+}
+
+// prune deletes the entries before the newest entry at or before l1BlockNum,
+// and returns the key of that entry, or nil if there was nothing to prune.
+func (d *SafeDB) prune(l1BlockNum uint64) ([]byte, error) {
+	if d.closed {
+		return nil, pebble.ErrClosed
+	}
+	iter, err := d.db.NewIter(safeByL1BlockNumKey.IterRange())
+	if err != nil {
+		return nil, fmt.Errorf("prune failed to create iterator: %w", err)
+	}
+	defer iter.Close()
+	if valid := iter.SeekLT(safeByL1BlockNumKey.Of(l1BlockNum + 1)); !valid {
+		return nil, nil
+	}
+	oldestKey := slices.Clone(iter.Key())
+	oldest := binary.BigEndian.Uint64(oldestKey[1:])
+	if oldest <= d.prunedBefore {
+		return nil, nil
+	}
+	batch := d.db.NewBatch()
+	defer batch.Close()
+	if err := batch.DeleteRange(safeByL1BlockNumKey.Of(0), oldestKey, d.writeOpts); err != nil {
+		return nil, fmt.Errorf("failed to delete entries before %d: %w", oldest, err)
+	}
+	if err := batch.Set(prunedBeforeKey, binary.BigEndian.AppendUint64(nil, oldest), d.writeOpts); err != nil {
+		return nil, fmt.Errorf("failed to record pruned marker: %w", err)
+	}
+	if err := batch.Commit(d.writeOpts); err != nil {
+		return nil, fmt.Errorf("failed to commit pruned entries: %w", err)
+	}
+	d.log.Info("Pruned safe head entries", "before", oldest)
+	d.prunedBefore = oldest
+	return oldestKey, nil
+}
+
+// OldestEntry returns the L1 block number of the oldest entry, or ErrNotFound if there are no entries.
+func (d *SafeDB) OldestEntry() (uint64, error) {
+	d.m.RLock()
+	defer d.m.RUnlock()
+	iter, err := d.db.NewIter(safeByL1BlockNumKey.IterRange())
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Close()
+	if valid := iter.First(); !valid {
+		return 0, ErrNotFound
+	}
+	return binary.BigEndian.Uint64(iter.Key()[1:]), nil
+}
+
+// DiskUsage returns the total disk space used by the database, in bytes.
+func (d *SafeDB) DiskUsage() uint64 {
+	d.m.RLock()
+	defer d.m.RUnlock()
+	if d.closed {
+		return 0
+	}
+	return d.db.Metrics().DiskSpaceUsage()
+}
+
 func (d *SafeDB) Close() error {
 	d.m.Lock()
 	defer d.m.Unlock()
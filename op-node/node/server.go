@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/zircuit-labs/l2-geth-public/log"
 	"github.com/zircuit-labs/l2-geth-public/node"
@@ -15,6 +16,7 @@ import (
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/metrics"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/p2p"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/optracing"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/sources"
 	ddhttp "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
 )
@@ -54,6 +56,40 @@ func (s *rpcServer) EnableAdminAPI(api *adminAPI) {
 	})
 }
 
+// EnableRuntimeConfig serves the runtime config subscription, and the runtime settings admin method if enabled.
+func (s *rpcServer) EnableRuntimeConfig(api *runtimeConfigAPI, adminAPI *runtimeConfigAdminAPI) {
+	s.apis = append(s.apis, rpc.API{
+		Namespace:     "optimism",
+		Service:       api,
+		Authenticated: false,
+	})
+	if adminAPI != nil {
+		s.apis = append(s.apis, rpc.API{
+			Namespace:     "admin",
+			Service:       adminAPI,
+			Authenticated: false,
+		})
+	}
+}
+
+// EnableInterop serves the checks of cross-chain messages.
+func (s *rpcServer) EnableInterop(api *interopAPI) {
+	s.apis = append(s.apis, rpc.API{
+		Namespace:     "interop",
+		Service:       api,
+		Authenticated: false,
+	})
+}
+
+// EnableDerivationAudit serves the audit trail of the derivation per L1 block.
+func (s *rpcServer) EnableDerivationAudit(api *derivationAuditAPI) {
+	s.apis = append(s.apis, rpc.API{
+		Namespace:     "optimism",
+		Service:       api,
+		Authenticated: false,
+	})
+}
+
 func (s *rpcServer) EnableP2P(backend *p2p.APIBackend) {
 	s.apis = append(s.apis, rpc.API{
 		Namespace:     p2p.NamespaceRPC,
@@ -74,9 +110,11 @@ func (s *rpcServer) Start() error {
 	// defaults to localhost, which will prevent containers from
 	// calling into the opnode without an "invalid host" error.
 	nodeHandler := node.NewHTTPHandlerStack(srv, []string{"*"}, []string{"*"}, nil)
+	// Websocket connections are served on the same endpoint, for subscriptions
+	wsHandler := node.NewWSHandlerStack(srv.WebsocketHandler([]string{"*"}), nil)
 
 	mux := ddhttp.NewServeMux(ddhttp.WithServiceName("op-node"))
-	mux.Handle("/", nodeHandler)
+	mux.Handle("/", websocketOr(wsHandler, optracing.NewHTTPMiddleware(nodeHandler)))
 	mux.HandleFunc("/healthz", healthzHandler(s.appVersion))
 
 	hs, err := ophttp.StartHTTPServer(s.endpoint, mux)
@@ -95,6 +133,18 @@ func (r *rpcServer) Addr() net.Addr {
 	return r.httpServer.Addr()
 }
 
+// websocketOr serves websocket upgrade requests with ws, and other requests with next.
+func websocketOr(ws http.Handler, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") &&
+			strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade") {
+			ws.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func healthzHandler(appVersion string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		_, _ = w.Write([]byte(appVersion))
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -13,6 +14,7 @@ import (
 	"github.com/zircuit-labs/l2-geth-public/common/hexutil"
 	"github.com/zircuit-labs/l2-geth-public/core/types"
 	"github.com/zircuit-labs/l2-geth-public/log"
+	gethrpc "github.com/zircuit-labs/l2-geth-public/rpc"
 
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/metrics"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
@@ -299,3 +301,58 @@ func (m *mockSafeDBReader) SafeHeadAtL1(ctx context.Context, l1BlockNum uint64)
 func (m *mockSafeDBReader) ExpectSafeHeadAtL1(l1BlockNum uint64, l1 eth.BlockID, safeHead eth.BlockID, err error) {
 	m.Mock.On("SafeHeadAtL1", l1BlockNum).Return(l1, safeHead, &err)
 }
+
+func TestRuntimeConfigSubscription(t *testing.T) {
+	log := testlog.Logger(t, log.LevelError)
+	l2Client := &testutils.MockL2Client{}
+	drClient := &mockDriverClient{}
+	safeReader := &mockSafeDBReader{}
+	rpcCfg := &RPCConfig{
+		ListenAddr: "localhost",
+		ListenPort: 0,
+	}
+	rollupCfg := &rollup.Config{
+		// ignore other rollup config info in this test
+	}
+	runCfg := NewRuntimeConfig(log, &fakeRuntimeCfgL1Source{}, rollupCfg)
+	runCfg.EnableSequencerSettings(&fakeSequencerSettings{}, 5)
+
+	server, err := newRPCServer(rpcCfg, rollupCfg, l2Client, drClient, safeReader, log, "0.0", metrics.NoopMetrics)
+	require.NoError(t, err)
+	server.EnableRuntimeConfig(NewRuntimeConfigAPI(runCfg, log, metrics.NoopMetrics), NewRuntimeConfigAdminAPI(runCfg, metrics.NoopMetrics))
+	require.NoError(t, server.Start())
+	defer func() {
+		require.NoError(t, server.Stop(context.Background()))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := gethrpc.DialContext(ctx, "ws://"+server.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	ch := make(chan eth.RuntimeConfig, 10)
+	sub, err := client.Subscribe(ctx, "optimism", ch, "runtimeConfig")
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	receive := func() eth.RuntimeConfig {
+		select {
+		case cfg := <-ch:
+			return cfg
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for runtime config")
+		}
+		return eth.RuntimeConfig{}
+	}
+	require.Equal(t, uint64(5), *receive().Settings.SequencerMaxSafeLag)
+
+	var settings eth.RuntimeSettings
+	maxSafeLag := uint64(100)
+	err = client.CallContext(ctx, &settings, "admin_setRuntimeSettings", eth.RuntimeSettings{SequencerMaxSafeLag: &maxSafeLag})
+	require.NoError(t, err)
+	require.Equal(t, maxSafeLag, *settings.SequencerMaxSafeLag)
+	require.Equal(t, maxSafeLag, *receive().Settings.SequencerMaxSafeLag)
+}
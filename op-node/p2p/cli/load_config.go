@@ -110,6 +110,7 @@ func loadBanningOptions(conf *p2p.Config, ctx *cli.Context) error {
 	conf.BanningEnabled = ctx.Bool(flags.BanningName)
 	conf.BanningThreshold = ctx.Float64(flags.BanningThresholdName)
 	conf.BanningDuration = ctx.Duration(flags.BanningDurationName)
+	conf.BanningAuditLogPath = ctx.String(flags.BanningAuditLogName)
 	return nil
 }
 
@@ -220,6 +221,7 @@ func loadLibp2pOpts(conf *p2p.Config, ctx *cli.Context) error {
 		}
 		conf.StaticPeers = append(conf.StaticPeers, a)
 	}
+	conf.AllowlistPath = ctx.String(flags.AllowlistPathName)
 
 	for _, v := range strings.Split(ctx.String(flags.HostMuxName), ",") {
 		v = strings.ToLower(strings.TrimSpace(v))
@@ -16,7 +16,6 @@ import (
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/metrics"
-	cmgr "github.com/libp2p/go-libp2p/p2p/net/connmgr"
 	"github.com/zircuit-labs/l2-geth-public/log"
 	"github.com/zircuit-labs/l2-geth-public/p2p/discover"
 	"github.com/zircuit-labs/l2-geth-public/p2p/enode"
@@ -31,6 +30,7 @@ var DefaultBootnodes = []*enode.Node{}
 type HostMetrics interface {
 	gating.UnbanMetrics
 	gating.ConnectionGaterMetrics
+	gating.AllowlistMetrics
 }
 
 // SetupP2P provides a host and discovery service for usage in the rollup node.
@@ -45,6 +45,8 @@ type SetupP2P interface {
 	BanPeers() bool
 	BanThreshold() float64
 	BanDuration() time.Duration
+	// BanAuditLogPath is the path of the JSON-lines log of ban decisions. Empty if disabled.
+	BanAuditLogPath() string
 	GossipSetupConfigurables
 	ReqRespSyncEnabled() bool
 }
@@ -70,6 +72,8 @@ type Config struct {
 	// Minimum score before peers are disconnected and banned
 	BanningThreshold float64
 	BanningDuration  time.Duration
+	// Optional path of a JSON-lines file to which every ban decision is appended
+	BanningAuditLogPath string
 
 	ListenIP      net.IP
 	ListenTCPPort uint16
@@ -85,6 +89,9 @@ type Config struct {
 	NetRestrict      *netutil.Netlist
 
 	StaticPeers []core.Multiaddr
+	// Optional path of a file with the libp2p peer IDs that are allowed to connect, one per line.
+	// If set, connections to and from any other peer are rejected.
+	AllowlistPath string
 
 	HostMux             []libp2p.Option
 	HostSecurity        []libp2p.Option
@@ -121,12 +128,7 @@ type Config struct {
 }
 
 func DefaultConnManager(conf *Config) (connmgr.ConnManager, error) {
-	return cmgr.NewConnManager(
-		int(conf.PeersLo),
-		int(conf.PeersHi),
-		cmgr.WithGracePeriod(conf.PeersGrace),
-		cmgr.WithSilencePeriod(time.Minute),
-		cmgr.WithEmergencyTrim(true))
+	return NewResizableConnManager(conf.PeersLo, conf.PeersHi, conf.PeersGrace, time.Minute)
 }
 
 func (conf *Config) TargetPeers() uint {
@@ -156,6 +158,10 @@ func (conf *Config) BanDuration() time.Duration {
 	return conf.BanningDuration
 }
 
+func (conf *Config) BanAuditLogPath() string {
+	return conf.BanningAuditLogPath
+}
+
 func (conf *Config) ReqRespSyncEnabled() bool {
 	return conf.EnableReqRespSync
 }
@@ -177,6 +183,9 @@ func (conf *Config) Check() error {
 			return errors.New("discovery requires a persistent or in-memory discv5 db, but found none")
 		}
 	}
+	if conf.AllowlistPath != "" && !conf.NoDiscovery {
+		return errors.New("the p2p peer allowlist requires discovery to be disabled")
+	}
 	if conf.PeersLo == 0 || conf.PeersHi == 0 || conf.PeersLo > conf.PeersHi {
 		return fmt.Errorf("peers lo/hi tides are invalid: %d, %d", conf.PeersLo, conf.PeersHi)
 	}
@@ -0,0 +1,203 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/connmgr"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	cmgr "github.com/libp2p/go-libp2p/p2p/net/connmgr"
+)
+
+// ResizableConnManager is a connection manager with peer watermarks that can be changed at runtime.
+//
+// It keeps the tags, protections and decaying tags in a libp2p basic connection manager,
+// but trims the peers itself, since the watermarks of the basic connection manager cannot be changed.
+// The basic connection manager only trims in memory emergencies, down to the initial low watermark.
+type ResizableConnManager struct {
+	*cmgr.BasicConnMgr
+
+	grace   time.Duration
+	silence time.Duration
+
+	mu      sync.Mutex
+	lo, hi  uint
+	nw      network.Network        // nil until the first connection
+	limiter connmgr.GetConnLimiter // nil until checked by the host
+
+	// trimMu ensures only a single trim runs at a time
+	trimMu sync.Mutex
+
+	closeOnce sync.Once
+	closing   chan struct{}
+	wg        sync.WaitGroup
+}
+
+var (
+	_ connmgr.ConnManager = (*ResizableConnManager)(nil)
+	_ connmgr.Decayer     = (*ResizableConnManager)(nil)
+)
+
+// NewResizableConnManager creates a connection manager that trims the peers down to lo when there are more than hi,
+// sparing peers connected within the grace period. It checks the peer count every silence period.
+func NewResizableConnManager(lo, hi uint, grace, silence time.Duration) (*ResizableConnManager, error) {
+	if err := checkPeerLimits(lo, hi); err != nil {
+		return nil, err
+	}
+	basic, err := cmgr.NewConnManager(
+		int(lo),
+		math.MaxInt32,
+		cmgr.WithGracePeriod(grace),
+		cmgr.WithSilencePeriod(silence),
+		cmgr.WithEmergencyTrim(true))
+	if err != nil {
+		return nil, err
+	}
+	m := &ResizableConnManager{
+		BasicConnMgr: basic,
+		grace:        grace,
+		silence:      silence,
+		lo:           lo,
+		hi:           hi,
+		closing:      make(chan struct{}),
+	}
+	m.wg.Add(1)
+	go m.background()
+	return m, nil
+}
+
+func checkPeerLimits(lo, hi uint) error {
+	if lo == 0 {
+		return errors.New("peers low watermark must be positive")
+	}
+	if lo > hi {
+		return fmt.Errorf("peers low watermark %d exceeds the high watermark %d", lo, hi)
+	}
+	return nil
+}
+
+// Limits returns the current low and high peer watermarks.
+func (m *ResizableConnManager) Limits() (lo, hi uint) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lo, m.hi
+}
+
+// SetLimits changes the peer watermarks, and trims the peers right away if there are more than hi.
+func (m *ResizableConnManager) SetLimits(lo, hi uint) error {
+	if err := checkPeerLimits(lo, hi); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	if m.limiter != nil && int(hi) > m.limiter.GetConnLimit() {
+		m.mu.Unlock()
+		return fmt.Errorf("peers high watermark %d exceeds the system connection limit of %d", hi, m.limiter.GetConnLimit())
+	}
+	m.lo, m.hi = lo, hi
+	m.mu.Unlock()
+	m.trim()
+	return nil
+}
+
+func (m *ResizableConnManager) CheckLimit(systemLimit connmgr.GetConnLimiter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if int(m.hi) > systemLimit.GetConnLimit() {
+		return fmt.Errorf("peers high watermark %d exceeds the system connection limit of %d", m.hi, systemLimit.GetConnLimit())
+	}
+	m.limiter = systemLimit
+	return nil
+}
+
+func (m *ResizableConnManager) TrimOpenConns(_ context.Context) {
+	m.trim()
+}
+
+// Notifee tracks the connections in the basic connection manager,
+// and keeps the network to trim the peers of.
+func (m *ResizableConnManager) Notifee() network.Notifiee {
+	return &resizableNotifee{Notifiee: m.BasicConnMgr.Notifee(), m: m}
+}
+
+func (m *ResizableConnManager) Close() error {
+	m.closeOnce.Do(func() { close(m.closing) })
+	m.wg.Wait()
+	return m.BasicConnMgr.Close()
+}
+
+func (m *ResizableConnManager) background() {
+	defer m.wg.Done()
+	ticker := time.NewTicker(m.silence)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.trim()
+		case <-m.closing:
+			return
+		}
+	}
+}
+
+// trim closes the connections to the least valuable peers, down to the low watermark,
+// if there are more peers than the high watermark.
+// Protected peers, and peers connected within the grace period, are never trimmed.
+func (m *ResizableConnManager) trim() {
+	m.trimMu.Lock()
+	defer m.trimMu.Unlock()
+	m.mu.Lock()
+	lo, hi, nw := m.lo, m.hi, m.nw
+	m.mu.Unlock()
+	if nw == nil {
+		return
+	}
+	peers := nw.Peers()
+	if uint(len(peers)) <= hi {
+		return
+	}
+
+	type candidate struct {
+		id    peer.ID
+		value int
+	}
+	now := time.Now()
+	candidates := make([]candidate, 0, len(peers))
+	for _, id := range peers {
+		if m.IsProtected(id, "") {
+			continue
+		}
+		var value int
+		if info := m.GetTagInfo(id); info != nil {
+			if now.Sub(info.FirstSeen) < m.grace {
+				continue
+			}
+			value = info.Value
+		}
+		candidates = append(candidates, candidate{id: id, value: value})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].value < candidates[j].value })
+	excess := len(peers) - int(lo)
+	for i := 0; i < excess && i < len(candidates); i++ {
+		_ = nw.ClosePeer(candidates[i].id)
+	}
+}
+
+// resizableNotifee forwards the connection notifications to the basic connection manager,
+// and keeps the network for the resizable connection manager to trim.
+type resizableNotifee struct {
+	network.Notifiee
+	m *ResizableConnManager
+}
+
+func (n *resizableNotifee) Connected(nw network.Network, c network.Conn) {
+	n.m.mu.Lock()
+	n.m.nw = nw
+	n.m.mu.Unlock()
+	n.Notifiee.Connected(nw, c)
+}
@@ -0,0 +1,67 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResizableConnManager(t *testing.T) {
+	mnet, err := mocknet.FullMeshConnected(6)
+	require.NoError(t, err)
+	defer mnet.Close()
+	hosts := mnet.Hosts()
+	local := hosts[0]
+
+	m, err := NewResizableConnManager(4, 5, 0, time.Hour)
+	require.NoError(t, err)
+	defer m.Close()
+	notifee := m.Notifee()
+	for _, c := range local.Network().Conns() {
+		notifee.Connected(local.Network(), c)
+	}
+	require.Len(t, local.Network().Peers(), 5)
+
+	// peers are tagged by value, the most valuable peers are kept
+	for i, h := range hosts[1:] {
+		m.TagPeer(h.ID(), "test", i)
+	}
+	protected := hosts[1].ID()
+	m.Protect(protected, "test")
+
+	lo, hi := m.Limits()
+	require.Equal(t, uint(4), lo)
+	require.Equal(t, uint(5), hi)
+
+	require.Error(t, m.SetLimits(3, 2))
+	require.Error(t, m.SetLimits(0, 2))
+
+	require.NoError(t, m.SetLimits(2, 3))
+	require.Eventually(t, func() bool {
+		return len(local.Network().Peers()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	peers := local.Network().Peers()
+	require.ElementsMatch(t, []peer.ID{protected, hosts[5].ID()}, peers)
+}
+
+func TestResizableConnManagerGracePeriod(t *testing.T) {
+	mnet, err := mocknet.FullMeshConnected(4)
+	require.NoError(t, err)
+	defer mnet.Close()
+	local := mnet.Hosts()[0]
+
+	m, err := NewResizableConnManager(3, 3, time.Hour, time.Hour)
+	require.NoError(t, err)
+	defer m.Close()
+	notifee := m.Notifee()
+	for _, c := range local.Network().Conns() {
+		notifee.Connected(local.Network(), c)
+	}
+
+	// all peers are new, so none of them is trimmed
+	require.NoError(t, m.SetLimits(1, 1))
+	require.Len(t, local.Network().Peers(), 3)
+}
@@ -383,7 +383,12 @@ func (n *NodeP2P) DiscoveryProcess(ctx context.Context, log log.Logger, cfg *rol
 				"advertised_udp", n.dv5Local.Node().UDP(),
 				"advertised_tcp", n.dv5Local.Node().TCP(),
 				"advertised_ip", n.dv5Local.Node().IP())
-			if uint(len(connected)) < connectGoal {
+			goal := connectGoal
+			// follow the low watermark when the peer limits are changed at runtime
+			if lo, _, ok := n.PeerLimits(); ok {
+				goal = lo
+			}
+			if uint(len(connected)) < goal {
 				// Start looking for more peers more actively again
 				faster()
 
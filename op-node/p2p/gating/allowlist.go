@@ -0,0 +1,148 @@
+package gating
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/clock"
+)
+
+// maxAllowlistDecisions is the number of recent rejections that are kept for inspection through the admin RPC.
+const maxAllowlistDecisions = 64
+
+type AllowlistMetrics interface {
+	RecordAllowlistCheck(allow bool)
+	RecordAllowlistSize(size int)
+}
+
+// AllowlistDecision is a connection that was rejected because the peer identity is not in the allowlist.
+type AllowlistDecision struct {
+	Time      time.Time `json:"time"`
+	Peer      peer.ID   `json:"peer"`
+	Method    string    `json:"method"`
+	Direction string    `json:"direction"`
+}
+
+// AllowlistConnectionGater enhances a BlockingConnectionGater by only allowing connections
+// to and from peers whose libp2p identity is in the allowlist.
+// Peer identities are authenticated by the libp2p security transport before InterceptSecured is called,
+// so with the allowlist enabled on both ends the connection is mutually authenticated.
+type AllowlistConnectionGater struct {
+	BlockingConnectionGater
+	log   log.Logger
+	clock clock.Clock
+	m     AllowlistMetrics
+
+	mu         sync.RWMutex
+	allowed    map[peer.ID]struct{}
+	lastUpdate time.Time
+	rejections []AllowlistDecision
+}
+
+func AddAllowlist(gater BlockingConnectionGater, log log.Logger, clock clock.Clock, m AllowlistMetrics) *AllowlistConnectionGater {
+	return &AllowlistConnectionGater{
+		BlockingConnectionGater: gater,
+		log:                     log,
+		clock:                   clock,
+		m:                       m,
+		allowed:                 make(map[peer.ID]struct{}),
+	}
+}
+
+// SetAllowedPeers replaces the allowlist.
+// Note: active connections to peers that are no longer allowed are not automatically closed.
+func (g *AllowlistConnectionGater) SetAllowedPeers(ids []peer.ID) {
+	allowed := make(map[peer.ID]struct{}, len(ids))
+	for _, id := range ids {
+		allowed[id] = struct{}{}
+	}
+	g.mu.Lock()
+	g.allowed = allowed
+	g.lastUpdate = g.clock.Now()
+	g.mu.Unlock()
+	g.m.RecordAllowlistSize(len(allowed))
+}
+
+// AllowedPeers returns the current allowlist, sorted by peer ID.
+func (g *AllowlistConnectionGater) AllowedPeers() []peer.ID {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	out := make([]peer.ID, 0, len(g.allowed))
+	for id := range g.allowed {
+		out = append(out, id)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// LastUpdate returns the time the allowlist was last replaced.
+func (g *AllowlistConnectionGater) LastUpdate() time.Time {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.lastUpdate
+}
+
+// RecentRejections returns the most recent rejected connections, oldest first.
+func (g *AllowlistConnectionGater) RecentRejections() []AllowlistDecision {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return append([]AllowlistDecision(nil), g.rejections...)
+}
+
+// IsAllowed returns whether the peer is in the allowlist.
+func (g *AllowlistConnectionGater) IsAllowed(p peer.ID) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	_, ok := g.allowed[p]
+	return ok
+}
+
+func (g *AllowlistConnectionGater) check(p peer.ID, method string, dir network.Direction) (allow bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	_, allow = g.allowed[p]
+	g.m.RecordAllowlistCheck(allow)
+	if !allow {
+		g.log.Warn("peer is not in the allowlist", "method", method, "peer_id", p, "direction", dir)
+		g.rejections = append(g.rejections, AllowlistDecision{
+			Time:      g.clock.Now(),
+			Peer:      p,
+			Method:    method,
+			Direction: dir.String(),
+		})
+		if len(g.rejections) > maxAllowlistDecisions {
+			g.rejections = g.rejections[len(g.rejections)-maxAllowlistDecisions:]
+		}
+	}
+	return allow
+}
+
+func (g *AllowlistConnectionGater) InterceptPeerDial(p peer.ID) (allow bool) {
+	if !g.BlockingConnectionGater.InterceptPeerDial(p) {
+		return false
+	}
+	return g.check(p, "InterceptPeerDial", network.DirOutbound)
+}
+
+func (g *AllowlistConnectionGater) InterceptAddrDial(id peer.ID, ma multiaddr.Multiaddr) (allow bool) {
+	if !g.BlockingConnectionGater.InterceptAddrDial(id, ma) {
+		return false
+	}
+	return g.check(id, "InterceptAddrDial", network.DirOutbound)
+}
+
+// InterceptAccept is not overridden: the peer identity is not known before the security handshake.
+
+func (g *AllowlistConnectionGater) InterceptSecured(dir network.Direction, id peer.ID, mas network.ConnMultiaddrs) (allow bool) {
+	if !g.BlockingConnectionGater.InterceptSecured(dir, id, mas) {
+		return false
+	}
+	return g.check(id, "InterceptSecured", dir)
+}
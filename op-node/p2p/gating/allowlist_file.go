@@ -0,0 +1,130 @@
+package gating
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/zircuit-labs/l2-geth-public/log"
+)
+
+// LoadAllowlistFile reads a list of libp2p peer IDs, one per line.
+// Empty lines and lines starting with '#' are ignored.
+func LoadAllowlistFile(path string) ([]peer.ID, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open allowlist file: %w", err)
+	}
+	defer f.Close()
+	var ids []peer.ID
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, err := peer.Decode(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid peer ID on line %d: %w", lineNum, err)
+		}
+		ids = append(ids, id)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read allowlist file: %w", err)
+	}
+	return ids, nil
+}
+
+// AllowlistFileWatcher keeps the allowlist of a gater in sync with the contents of a file.
+// If the file becomes invalid the previous allowlist stays active.
+// Updates should replace the file atomically (e.g. by renaming), to not observe a partially written list.
+type AllowlistFileWatcher struct {
+	log     log.Logger
+	path    string
+	gater   *AllowlistConnectionGater
+	watcher *fsnotify.Watcher
+
+	closeOnce sync.Once
+	closeCh   chan struct{}
+	done      chan struct{}
+}
+
+// WatchAllowlistFile reloads the allowlist file into the gater whenever the file changes.
+// The initial allowlist is loaded by the caller, with LoadAllowlistFile.
+func WatchAllowlistFile(log log.Logger, path string, gater *AllowlistConnectionGater) (*AllowlistFileWatcher, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create allowlist file watcher: %w", err)
+	}
+	// Watch the directory rather than the file, the file may be replaced instead of written to.
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		_ = watcher.Close()
+		return nil, fmt.Errorf("failed to watch allowlist file: %w", err)
+	}
+	w := &AllowlistFileWatcher{
+		log:     log,
+		path:    path,
+		gater:   gater,
+		watcher: watcher,
+		closeCh: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+func (w *AllowlistFileWatcher) run() {
+	defer close(w.done)
+	for {
+		select {
+		case <-w.closeCh:
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Name != w.path && !strings.HasSuffix(event.Name, "/..data") { // kubernetes configmap mount
+				continue
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+				continue
+			}
+			w.reload()
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.log.Error("error watching allowlist file", "path", w.path, "err", err)
+		}
+	}
+}
+
+func (w *AllowlistFileWatcher) reload() {
+	ids, err := LoadAllowlistFile(w.path)
+	if err != nil {
+		w.log.Error("failed to reload peer allowlist, keeping previous allowlist", "path", w.path, "err", err)
+		return
+	}
+	w.gater.SetAllowedPeers(ids)
+	w.log.Info("reloaded peer allowlist", "path", w.path, "peers", len(ids))
+}
+
+func (w *AllowlistFileWatcher) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.closeCh)
+		<-w.done
+		err = w.watcher.Close()
+	})
+	return err
+}
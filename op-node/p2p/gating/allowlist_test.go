@@ -0,0 +1,159 @@
+package gating
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/require"
+	log "github.com/zircuit-labs/l2-geth-public/log"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/metrics"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/p2p/gating/mocks"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/clock"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testlog"
+)
+
+func allowlistTestSetup(t *testing.T) (*clock.DeterministicClock, *mocks.BlockingConnectionGater, *AllowlistConnectionGater) {
+	mockGater := mocks.NewBlockingConnectionGater(t)
+	log := testlog.Logger(t, log.LevelError)
+	cl := clock.NewDeterministicClock(time.Now())
+	gater := AddAllowlist(mockGater, log, cl, metrics.NoopMetrics)
+	return cl, mockGater, gater
+}
+
+func randomPeerID(t *testing.T) peer.ID {
+	_, pub, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	id, err := peer.IDFromPublicKey(pub)
+	require.NoError(t, err)
+	return id
+}
+
+func TestAllowlistConnectionGater_InterceptPeerDial(t *testing.T) {
+	alice := peer.ID("alice")
+	mallory := peer.ID("mallory")
+	t.Run("allowed peer", func(t *testing.T) {
+		_, mockGater, gater := allowlistTestSetup(t)
+		gater.SetAllowedPeers([]peer.ID{alice})
+		mockGater.EXPECT().InterceptPeerDial(alice).Return(true)
+		require.True(t, gater.InterceptPeerDial(alice))
+		require.Empty(t, gater.RecentRejections())
+	})
+	t.Run("unknown peer", func(t *testing.T) {
+		cl, mockGater, gater := allowlistTestSetup(t)
+		gater.SetAllowedPeers([]peer.ID{alice})
+		mockGater.EXPECT().InterceptPeerDial(mallory).Return(true)
+		require.False(t, gater.InterceptPeerDial(mallory))
+		require.Equal(t, []AllowlistDecision{{
+			Time:      cl.Now(),
+			Peer:      mallory,
+			Method:    "InterceptPeerDial",
+			Direction: network.DirOutbound.String(),
+		}}, gater.RecentRejections())
+	})
+	t.Run("inner ban", func(t *testing.T) {
+		_, mockGater, gater := allowlistTestSetup(t)
+		gater.SetAllowedPeers([]peer.ID{alice})
+		mockGater.EXPECT().InterceptPeerDial(alice).Return(false)
+		require.False(t, gater.InterceptPeerDial(alice))
+		require.Empty(t, gater.RecentRejections(), "only allowlist rejections are recorded")
+	})
+}
+
+func TestAllowlistConnectionGater_InterceptAddrDial(t *testing.T) {
+	alice := peer.ID("alice")
+	mallory := peer.ID("mallory")
+	addr, err := multiaddr.NewMultiaddr("/ip4/127.0.0.1/tcp/9000")
+	require.NoError(t, err)
+	_, mockGater, gater := allowlistTestSetup(t)
+	gater.SetAllowedPeers([]peer.ID{alice})
+	mockGater.EXPECT().InterceptAddrDial(alice, addr).Return(true)
+	mockGater.EXPECT().InterceptAddrDial(mallory, addr).Return(true)
+	require.True(t, gater.InterceptAddrDial(alice, addr))
+	require.False(t, gater.InterceptAddrDial(mallory, addr))
+}
+
+func TestAllowlistConnectionGater_InterceptSecured(t *testing.T) {
+	alice := peer.ID("alice")
+	mallory := peer.ID("mallory")
+	_, mockGater, gater := allowlistTestSetup(t)
+	gater.SetAllowedPeers([]peer.ID{alice})
+	mockGater.EXPECT().InterceptSecured(network.DirInbound, alice, nil).Return(true)
+	mockGater.EXPECT().InterceptSecured(network.DirInbound, mallory, nil).Return(true)
+	require.True(t, gater.InterceptSecured(network.DirInbound, alice, nil))
+	require.False(t, gater.InterceptSecured(network.DirInbound, mallory, nil))
+
+	// Replacing the allowlist applies to new connections
+	gater.SetAllowedPeers([]peer.ID{mallory})
+	require.False(t, gater.InterceptSecured(network.DirInbound, alice, nil))
+	require.True(t, gater.InterceptSecured(network.DirInbound, mallory, nil))
+	require.Len(t, gater.RecentRejections(), 2)
+}
+
+func TestAllowlistConnectionGater_RejectionsAreCapped(t *testing.T) {
+	mallory := peer.ID("mallory")
+	_, mockGater, gater := allowlistTestSetup(t)
+	mockGater.EXPECT().InterceptPeerDial(mallory).Return(true)
+	for i := 0; i < maxAllowlistDecisions+10; i++ {
+		require.False(t, gater.InterceptPeerDial(mallory))
+	}
+	require.Len(t, gater.RecentRejections(), maxAllowlistDecisions)
+}
+
+func TestLoadAllowlistFile(t *testing.T) {
+	alice := randomPeerID(t)
+	bob := randomPeerID(t)
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "allowlist.txt")
+	content := "# sequencer\n" + alice.String() + "\n\n  " + bob.String() + "  \n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	ids, err := LoadAllowlistFile(path)
+	require.NoError(t, err)
+	require.Equal(t, []peer.ID{alice, bob}, ids)
+
+	invalid := filepath.Join(dir, "invalid.txt")
+	require.NoError(t, os.WriteFile(invalid, []byte(alice.String()+"\nnot-a-peer-id\n"), 0o644))
+	_, err = LoadAllowlistFile(invalid)
+	require.ErrorContains(t, err, "line 2")
+
+	_, err = LoadAllowlistFile(filepath.Join(dir, "missing.txt"))
+	require.Error(t, err)
+}
+
+func TestWatchAllowlistFile(t *testing.T) {
+	alice := randomPeerID(t)
+	bob := randomPeerID(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "allowlist.txt")
+	require.NoError(t, os.WriteFile(path, []byte(alice.String()+"\n"), 0o644))
+
+	_, _, gater := allowlistTestSetup(t)
+	ids, err := LoadAllowlistFile(path)
+	require.NoError(t, err)
+	gater.SetAllowedPeers(ids)
+	watcher, err := WatchAllowlistFile(testlog.Logger(t, log.LevelError), path, gater)
+	require.NoError(t, err)
+	defer watcher.Close()
+	require.Equal(t, []peer.ID{alice}, gater.AllowedPeers())
+
+	// Replace the file atomically, as operators are expected to
+	tmp := filepath.Join(dir, "allowlist.tmp")
+	require.NoError(t, os.WriteFile(tmp, []byte(bob.String()+"\n"), 0o644))
+	require.NoError(t, os.Rename(tmp, path))
+	require.Eventually(t, func() bool {
+		return gater.IsAllowed(bob) && !gater.IsAllowed(alice)
+	}, 10*time.Second, 10*time.Millisecond)
+
+	// An invalid update keeps the previous allowlist
+	require.NoError(t, os.WriteFile(path, []byte("garbage\n"), 0o644))
+	time.Sleep(100 * time.Millisecond)
+	require.True(t, gater.IsAllowed(bob))
+
+	require.NoError(t, watcher.Close())
+}
@@ -48,6 +48,8 @@ type ExtraHostFeatures interface {
 	host.Host
 	ConnectionGater() gating.BlockingConnectionGater
 	ConnectionManager() connmgr.ConnManager
+	// Allowlist returns the peer allowlist, nil if connections are not restricted to an allowlist
+	Allowlist() *gating.AllowlistConnectionGater
 	IsStatic(peerID peer.ID) bool
 	SyncOnlyReqToStatic() bool
 }
@@ -58,6 +60,9 @@ type extraHost struct {
 	connMgr connmgr.ConnManager
 	log     log.Logger
 
+	allowlist        *gating.AllowlistConnectionGater
+	allowlistWatcher *gating.AllowlistFileWatcher
+
 	staticPeers   []*peer.AddrInfo
 	staticPeerIDs map[peer.ID]struct{}
 
@@ -76,6 +81,10 @@ func (e *extraHost) ConnectionManager() connmgr.ConnManager {
 	return e.connMgr
 }
 
+func (e *extraHost) Allowlist() *gating.AllowlistConnectionGater {
+	return e.allowlist
+}
+
 func (e *extraHost) IsStatic(peerID peer.ID) bool {
 	_, exists := e.staticPeerIDs[peerID]
 	return exists
@@ -90,6 +99,11 @@ func (e *extraHost) Close() error {
 	if e.pinging != nil {
 		e.pinging.Close()
 	}
+	if e.allowlistWatcher != nil {
+		if err := e.allowlistWatcher.Close(); err != nil {
+			e.log.Warn("failed to close allowlist file watcher", "err", err)
+		}
+	}
 	return e.Host.Close()
 }
 
@@ -199,6 +213,18 @@ func (conf *Config) Host(log log.Logger, reporter metrics.Reporter, metrics Host
 		return nil, fmt.Errorf("failed to open connection gater: %w", err)
 	}
 	connGtr = gating.AddBanExpiry(connGtr, ps, log, clock.SystemClock, metrics)
+	var allowlist *gating.AllowlistConnectionGater
+	if conf.AllowlistPath != "" {
+		// The allowlist must be loaded before the host starts accepting connections.
+		allowed, err := gating.LoadAllowlistFile(conf.AllowlistPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load peer allowlist: %w", err)
+		}
+		allowlist = gating.AddAllowlist(connGtr, log, clock.SystemClock, metrics)
+		allowlist.SetAllowedPeers(allowed)
+		log.Info("loaded peer allowlist", "path", conf.AllowlistPath, "peers", len(allowed))
+		connGtr = allowlist
+	}
 	connGtr = gating.AddMetering(connGtr, metrics)
 
 	connMngr, err := DefaultConnManager(conf)
@@ -273,12 +299,21 @@ func (conf *Config) Host(log log.Logger, reporter metrics.Reporter, metrics Host
 		Host:                h,
 		connMgr:             connMngr,
 		log:                 log,
+		allowlist:           allowlist,
 		staticPeers:         staticPeers,
 		staticPeerIDs:       staticPeerIDs,
 		quitC:               make(chan struct{}),
 		syncOnlyReqToStatic: conf.SyncOnlyReqToStatic,
 	}
 
+	if allowlist != nil {
+		out.allowlistWatcher, err = gating.WatchAllowlistFile(log, conf.AllowlistPath, allowlist)
+		if err != nil {
+			_ = h.Close()
+			return nil, fmt.Errorf("failed to watch peer allowlist: %w", err)
+		}
+	}
+
 	if conf.EnablePingService {
 		out.pinging = NewPingService(log,
 			func(ctx context.Context, peerID peer.ID) <-chan ping.Result {
@@ -5,6 +5,8 @@ import (
 	"crypto/rand"
 	"math/big"
 	"net"
+	"os"
+	"path/filepath"
 	"slices"
 	"testing"
 	"time"
@@ -25,6 +27,7 @@ import (
 	"github.com/zircuit-labs/l2-geth-public/rpc"
 
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/metrics"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/p2p/monitor"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/p2p/store"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
@@ -72,6 +75,38 @@ func TestP2PSimple(t *testing.T) {
 	require.Equal(t, hostB.Network().Connectedness(hostA.ID()), network.Connected)
 }
 
+func TestP2PAllowlist(t *testing.T) {
+	confA := TestingConfig(t)
+	confB := TestingConfig(t)
+	confC := TestingConfig(t)
+	idB, err := peer.IDFromPublicKey(confB.Priv.GetPublic())
+	require.NoError(t, err)
+	allowlistPath := filepath.Join(t.TempDir(), "allowlist.txt")
+	require.NoError(t, os.WriteFile(allowlistPath, []byte(idB.String()+"\n"), 0o644))
+	confA.AllowlistPath = allowlistPath
+
+	hostA, err := confA.Host(testlog.Logger(t, log.LevelError).New("host", "A"), nil, metrics.NoopMetrics)
+	require.NoError(t, err, "failed to launch host A")
+	defer hostA.Close()
+	hostB, err := confB.Host(testlog.Logger(t, log.LevelError).New("host", "B"), nil, metrics.NoopMetrics)
+	require.NoError(t, err, "failed to launch host B")
+	defer hostB.Close()
+	hostC, err := confC.Host(testlog.Logger(t, log.LevelError).New("host", "C"), nil, metrics.NoopMetrics)
+	require.NoError(t, err, "failed to launch host C")
+	defer hostC.Close()
+
+	ctx := context.Background()
+	require.NoError(t, hostB.Connect(ctx, peer.AddrInfo{ID: hostA.ID(), Addrs: hostA.Addrs()}), "allowed peer can connect")
+	require.Error(t, hostC.Connect(ctx, peer.AddrInfo{ID: hostA.ID(), Addrs: hostA.Addrs()}), "unknown peer must be rejected")
+	require.Error(t, hostA.Connect(ctx, peer.AddrInfo{ID: hostC.ID(), Addrs: hostC.Addrs()}), "unknown peer must not be dialed")
+
+	rejections := hostA.(ExtraHostFeatures).Allowlist().RecentRejections()
+	require.NotEmpty(t, rejections)
+	for _, r := range rejections {
+		require.Equal(t, hostC.ID(), r.Peer)
+	}
+}
+
 type mockGossipIn struct {
 	OnUnsafeL2PayloadFn func(ctx context.Context, from peer.ID, msg *eth.ExecutionPayloadEnvelope) error
 }
@@ -176,6 +211,19 @@ func TestP2PFull(t *testing.T) {
 	// rpc does not preserve error type
 	require.Equal(t, err.Error(), ErrDisabledDiscovery.Error(), "expecting discv5 to be disabled")
 
+	ban := monitor.BanDecision{Time: time.Unix(1000, 0), Peer: hostB.ID(), Score: -120, MinScore: -100, Expiry: time.Unix(4600, 0), Reason: "low score"}
+	require.NoError(t, nodeA.RecordBan(ban))
+	reputation, err := p2pClientA.PeerReputation(ctx, hostB.ID())
+	require.NoError(t, err)
+	require.Equal(t, hostB.ID(), reputation.PeerID)
+	require.False(t, reputation.Banned, "ban history alone does not ban the peer")
+	require.Len(t, reputation.BanHistory, 1)
+	require.Equal(t, ban.Reason, reputation.BanHistory[0].Reason)
+	require.Equal(t, ban.Score, reputation.BanHistory[0].Score)
+	require.True(t, ban.Expiry.Equal(reputation.BanHistory[0].Expiry))
+	_, err = p2pClientA.PeerReputation(ctx, "")
+	require.Error(t, err)
+
 	require.NoError(t, p2pClientA.BlockPeer(ctx, hostB.ID()))
 	blockedPeers, err := p2pClientA.ListBlockedPeers(ctx)
 	require.NoError(t, err)
@@ -28,6 +28,60 @@ func (_m *API) EXPECT() *API_Expecter {
 	return &API_Expecter{mock: &_m.Mock}
 }
 
+// Allowlist provides a mock function with given fields: ctx
+func (_m *API) Allowlist(ctx context.Context) (*p2p.AllowlistStatus, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *p2p.AllowlistStatus
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*p2p.AllowlistStatus, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *p2p.AllowlistStatus); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*p2p.AllowlistStatus)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// API_Allowlist_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Allowlist'
+type API_Allowlist_Call struct {
+	*mock.Call
+}
+
+// Allowlist is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *API_Expecter) Allowlist(ctx interface{}) *API_Allowlist_Call {
+	return &API_Allowlist_Call{Call: _e.mock.On("Allowlist", ctx)}
+}
+
+func (_c *API_Allowlist_Call) Run(run func(ctx context.Context)) *API_Allowlist_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *API_Allowlist_Call) Return(_a0 *p2p.AllowlistStatus, _a1 error) *API_Allowlist_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *API_Allowlist_Call) RunAndReturn(run func(context.Context) (*p2p.AllowlistStatus, error)) *API_Allowlist_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // BlockAddr provides a mock function with given fields: ctx, ip
 func (_m *API) BlockAddr(ctx context.Context, ip net.IP) error {
 	ret := _m.Called(ctx, ip)
@@ -459,6 +513,61 @@ func (_c *API_ListBlockedSubnets_Call) RunAndReturn(run func(context.Context) ([
 	return _c
 }
 
+// PeerReputation provides a mock function with given fields: ctx, p
+func (_m *API) PeerReputation(ctx context.Context, p peer.ID) (*p2p.PeerReputation, error) {
+	ret := _m.Called(ctx, p)
+
+	var r0 *p2p.PeerReputation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, peer.ID) (*p2p.PeerReputation, error)); ok {
+		return rf(ctx, p)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, peer.ID) *p2p.PeerReputation); ok {
+		r0 = rf(ctx, p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*p2p.PeerReputation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, peer.ID) error); ok {
+		r1 = rf(ctx, p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// API_PeerReputation_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PeerReputation'
+type API_PeerReputation_Call struct {
+	*mock.Call
+}
+
+// PeerReputation is a helper method to define mock.On call
+//   - ctx context.Context
+//   - p peer.ID
+func (_e *API_Expecter) PeerReputation(ctx interface{}, p interface{}) *API_PeerReputation_Call {
+	return &API_PeerReputation_Call{Call: _e.mock.On("PeerReputation", ctx, p)}
+}
+
+func (_c *API_PeerReputation_Call) Run(run func(ctx context.Context, p peer.ID)) *API_PeerReputation_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(peer.ID))
+	})
+	return _c
+}
+
+func (_c *API_PeerReputation_Call) Return(_a0 *p2p.PeerReputation, _a1 error) *API_PeerReputation_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *API_PeerReputation_Call) RunAndReturn(run func(context.Context, peer.ID) (*p2p.PeerReputation, error)) *API_PeerReputation_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // PeerStats provides a mock function with given fields: ctx
 func (_m *API) PeerStats(ctx context.Context) (*p2p.PeerStats, error) {
 	ret := _m.Called(ctx)
@@ -0,0 +1,50 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// BanAuditLog writes every ban decision as a single JSON object per line.
+type BanAuditLog struct {
+	mu  sync.Mutex
+	out io.WriteCloser
+	enc *json.Encoder
+}
+
+var _ BanRecorder = (*BanAuditLog)(nil)
+
+// OpenBanAuditLog opens, or creates, the JSON-lines audit log at the given path. New entries are appended.
+func OpenBanAuditLog(path string) (*BanAuditLog, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ban audit log %q: %w", path, err)
+	}
+	return NewBanAuditLog(f), nil
+}
+
+// NewBanAuditLog creates an audit log that writes to the given output. The output is closed when the log is closed.
+func NewBanAuditLog(out io.WriteCloser) *BanAuditLog {
+	return &BanAuditLog{
+		out: out,
+		enc: json.NewEncoder(out),
+	}
+}
+
+func (a *BanAuditLog) RecordBan(decision BanDecision) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.enc.Encode(decision); err != nil {
+		return fmt.Errorf("failed to write ban audit entry: %w", err)
+	}
+	return nil
+}
+
+func (a *BanAuditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.out.Close()
+}
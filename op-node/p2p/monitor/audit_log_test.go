@@ -0,0 +1,52 @@
+package monitor
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func randomPeerID(t *testing.T) peer.ID {
+	_, pub, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	id, err := peer.IDFromPublicKey(pub)
+	require.NoError(t, err)
+	return id
+}
+
+func TestBanAuditLogAppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bans.jsonl")
+	first := BanDecision{Time: time.Unix(100, 0).UTC(), Peer: randomPeerID(t), Score: -101, MinScore: -100, Expiry: time.Unix(3700, 0).UTC(), Reason: "low score"}
+	second := BanDecision{Time: time.Unix(200, 0).UTC(), Peer: randomPeerID(t), Score: -150, MinScore: -100, Expiry: time.Unix(3800, 0).UTC(), Reason: "low score"}
+
+	auditLog, err := OpenBanAuditLog(path)
+	require.NoError(t, err)
+	require.NoError(t, auditLog.RecordBan(first))
+	require.NoError(t, auditLog.Close())
+
+	// Reopening the log must append rather than truncate
+	auditLog, err = OpenBanAuditLog(path)
+	require.NoError(t, err)
+	require.NoError(t, auditLog.RecordBan(second))
+	require.NoError(t, auditLog.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var decisions []BanDecision
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var decision BanDecision
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &decision))
+		decisions = append(decisions, decision)
+	}
+	require.NoError(t, scanner.Err())
+	require.Equal(t, []BanDecision{first, second}, decisions)
+}
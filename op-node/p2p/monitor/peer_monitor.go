@@ -25,6 +25,21 @@ type PeerManager interface {
 	BanPeer(peer.ID, time.Time) error
 }
 
+// BanDecision describes a ban issued by the PeerMonitor.
+type BanDecision struct {
+	Time     time.Time `json:"time"`
+	Peer     peer.ID   `json:"peer"`
+	Score    float64   `json:"score"`
+	MinScore float64   `json:"minScore"`
+	Expiry   time.Time `json:"expiry"`
+	Reason   string    `json:"reason"`
+}
+
+// BanRecorder is notified of every ban decision made by the PeerMonitor, after the ban was applied.
+type BanRecorder interface {
+	RecordBan(decision BanDecision) error
+}
+
 // PeerMonitor runs a background process to periodically check for peers with scores below a minimum.
 // When it finds bad peers, it disconnects and bans them.
 // A delay is introduced between each peer being checked to avoid spikes in system load.
@@ -36,6 +51,7 @@ type PeerMonitor struct {
 	manager     PeerManager
 	minScore    float64
 	banDuration time.Duration
+	recorder    BanRecorder // may be nil
 
 	bgTasks sync.WaitGroup
 
@@ -44,7 +60,7 @@ type PeerMonitor struct {
 	nextPeerIdx int
 }
 
-func NewPeerMonitor(ctx context.Context, l log.Logger, clock clock.Clock, manager PeerManager, minScore float64, banDuration time.Duration) *PeerMonitor {
+func NewPeerMonitor(ctx context.Context, l log.Logger, clock clock.Clock, manager PeerManager, minScore float64, banDuration time.Duration, recorder BanRecorder) *PeerMonitor {
 	ctx, cancelFn := context.WithCancel(ctx)
 	return &PeerMonitor{
 		ctx:         ctx,
@@ -54,6 +70,7 @@ func NewPeerMonitor(ctx context.Context, l log.Logger, clock clock.Clock, manage
 		manager:     manager,
 		minScore:    minScore,
 		banDuration: banDuration,
+		recorder:    recorder,
 	}
 }
 
@@ -93,9 +110,24 @@ func (p *PeerMonitor) checkNextPeer() error {
 	if p.manager.IsStatic(id) {
 		return nil
 	}
-	if err := p.manager.BanPeer(id, p.clock.Now().Add(p.banDuration)); err != nil {
+	now := p.clock.Now()
+	expiry := now.Add(p.banDuration)
+	if err := p.manager.BanPeer(id, expiry); err != nil {
 		return fmt.Errorf("banning peer %v: %w", id, err)
 	}
+	if p.recorder != nil {
+		decision := BanDecision{
+			Time:     now,
+			Peer:     id,
+			Score:    score,
+			MinScore: p.minScore,
+			Expiry:   expiry,
+			Reason:   fmt.Sprintf("peer score %.2f below minimum %.2f", score, p.minScore),
+		}
+		if err := p.recorder.RecordBan(decision); err != nil {
+			return fmt.Errorf("recording ban of peer %v: %w", id, err)
+		}
+	}
 
 	return nil
 }
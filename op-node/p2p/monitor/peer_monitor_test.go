@@ -22,7 +22,7 @@ func peerMonitorSetup(t *testing.T) (*PeerMonitor, *clock2.DeterministicClock, *
 	l := testlog.Logger(t, log.LevelInfo)
 	clock := clock2.NewDeterministicClock(time.UnixMilli(10000))
 	manager := mocks.NewPeerManager(t)
-	monitor := NewPeerMonitor(context.Background(), l, clock, manager, -100, testBanDuration)
+	monitor := NewPeerMonitor(context.Background(), l, clock, manager, -100, testBanDuration, nil)
 	return monitor, clock, manager
 }
 
@@ -111,6 +111,41 @@ func TestCheckNextPeer(t *testing.T) {
 		require.NoError(t, monitor.checkNextPeer())
 	})
 
+	t.Run("Record ban decision", func(t *testing.T) {
+		monitor, clock, manager := peerMonitorSetup(t)
+		recorder := &recordingBanRecorder{}
+		monitor.recorder = recorder
+		id := peerIDs[0]
+		manager.EXPECT().Peers().Return(peerIDs).Once()
+		manager.EXPECT().GetPeerScore(id).Return(-101, nil).Once()
+		manager.EXPECT().IsStatic(id).Return(false).Once()
+		manager.EXPECT().BanPeer(id, clock.Now().Add(testBanDuration)).Return(nil).Once()
+
+		require.NoError(t, monitor.checkNextPeer())
+		require.Len(t, recorder.decisions, 1)
+		decision := recorder.decisions[0]
+		require.Equal(t, id, decision.Peer)
+		require.Equal(t, clock.Now(), decision.Time)
+		require.Equal(t, clock.Now().Add(testBanDuration), decision.Expiry)
+		require.Equal(t, float64(-101), decision.Score)
+		require.Equal(t, float64(-100), decision.MinScore)
+		require.NotEmpty(t, decision.Reason)
+	})
+
+	t.Run("Do not record failed ban", func(t *testing.T) {
+		monitor, clock, manager := peerMonitorSetup(t)
+		recorder := &recordingBanRecorder{}
+		monitor.recorder = recorder
+		id := peerIDs[0]
+		manager.EXPECT().Peers().Return(peerIDs).Once()
+		manager.EXPECT().GetPeerScore(id).Return(-101, nil).Once()
+		manager.EXPECT().IsStatic(id).Return(false).Once()
+		manager.EXPECT().BanPeer(id, clock.Now().Add(testBanDuration)).Return(errors.New("boom")).Once()
+
+		require.Error(t, monitor.checkNextPeer())
+		require.Empty(t, recorder.decisions)
+	})
+
 	t.Run("Do not close protected peer when below min score", func(t *testing.T) {
 		monitor, _, manager := peerMonitorSetup(t)
 		id := peerIDs[0]
@@ -122,6 +157,15 @@ func TestCheckNextPeer(t *testing.T) {
 	})
 }
 
+type recordingBanRecorder struct {
+	decisions []BanDecision
+}
+
+func (r *recordingBanRecorder) RecordBan(decision BanDecision) error {
+	r.decisions = append(r.decisions, decision)
+	return nil
+}
+
 func waitForChan(t *testing.T, ch chan struct{}, msg string) {
 	ctx, cancelFn := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancelFn()
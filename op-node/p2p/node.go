@@ -33,12 +33,14 @@ import (
 
 // NodeP2P is a p2p node, which can be used to gossip messages.
 type NodeP2P struct {
-	host        host.Host                      // p2p host (optional, may be nil)
-	gater       gating.BlockingConnectionGater // p2p gater, to ban/unban peers with, may be nil even with p2p enabled
-	scorer      Scorer                         // writes score-updates to the peerstore and keeps metrics of score changes
-	connMgr     connmgr.ConnManager            // p2p conn manager, to keep a reliable number of peers, may be nil even with p2p enabled
-	peerMonitor *monitor.PeerMonitor           // peer monitor to disconnect bad peers, may be nil even with p2p enabled
-	store       store.ExtendedPeerstore        // peerstore of host, with extra bindings for scoring and banning
+	host        host.Host                        // p2p host (optional, may be nil)
+	gater       gating.BlockingConnectionGater   // p2p gater, to ban/unban peers with, may be nil even with p2p enabled
+	allowlist   *gating.AllowlistConnectionGater // p2p peer allowlist, nil if connections are not restricted
+	scorer      Scorer                           // writes score-updates to the peerstore and keeps metrics of score changes
+	connMgr     connmgr.ConnManager              // p2p conn manager, to keep a reliable number of peers, may be nil even with p2p enabled
+	peerMonitor *monitor.PeerMonitor             // peer monitor to disconnect bad peers, may be nil even with p2p enabled
+	banAudit    *monitor.BanAuditLog             // JSON-lines log of ban decisions, may be nil
+	store       store.ExtendedPeerstore          // peerstore of host, with extra bindings for scoring and banning
 	appScorer   ApplicationScorer
 	log         log.Logger
 	// the below components are all optional, and may be nil. They require the host to not be nil.
@@ -91,6 +93,7 @@ func (n *NodeP2P) init(resourcesCtx context.Context, rollupCfg *rollup.Config, l
 		if extra, ok := n.host.(ExtraHostFeatures); ok {
 			n.gater = extra.ConnectionGater()
 			n.connMgr = extra.ConnectionManager()
+			n.allowlist = extra.Allowlist()
 		}
 		eps, ok := n.host.Peerstore().(store.ExtendedPeerstore)
 		if !ok {
@@ -160,7 +163,13 @@ func (n *NodeP2P) init(resourcesCtx context.Context, rollupCfg *rollup.Config, l
 		}
 
 		if setup.BanPeers() {
-			n.peerMonitor = monitor.NewPeerMonitor(resourcesCtx, log, clock.SystemClock, n, setup.BanThreshold(), setup.BanDuration())
+			if path := setup.BanAuditLogPath(); path != "" {
+				n.banAudit, err = monitor.OpenBanAuditLog(path)
+				if err != nil {
+					return err
+				}
+			}
+			n.peerMonitor = monitor.NewPeerMonitor(resourcesCtx, log, clock.SystemClock, n, setup.BanThreshold(), setup.BanDuration(), n)
 			n.peerMonitor.Start()
 		}
 		n.appScorer.start()
@@ -204,10 +213,34 @@ func (n *NodeP2P) ConnectionGater() gating.BlockingConnectionGater {
 	return n.gater
 }
 
+func (n *NodeP2P) Allowlist() *gating.AllowlistConnectionGater {
+	return n.allowlist
+}
+
 func (n *NodeP2P) ConnectionManager() connmgr.ConnManager {
 	return n.connMgr
 }
 
+// PeerLimits returns the low and high peer watermarks of the connection manager.
+// It returns false if the connection manager is not resizable.
+func (n *NodeP2P) PeerLimits() (lo, hi uint, ok bool) {
+	resizable, ok := n.connMgr.(*ResizableConnManager)
+	if !ok {
+		return 0, 0, false
+	}
+	lo, hi = resizable.Limits()
+	return lo, hi, true
+}
+
+// SetPeerLimits changes the low and high peer watermarks of the connection manager.
+func (n *NodeP2P) SetPeerLimits(lo, hi uint) error {
+	resizable, ok := n.connMgr.(*ResizableConnManager)
+	if !ok {
+		return errors.New("connection manager does not support changing the peer limits")
+	}
+	return resizable.SetLimits(lo, hi)
+}
+
 func (n *NodeP2P) Peers() []peer.ID {
 	return n.host.Network().Peers()
 }
@@ -230,6 +263,25 @@ func (n *NodeP2P) BanPeer(id peer.ID, expiration time.Time) error {
 	return nil
 }
 
+// RecordBan persists the ban decision in the ban history of the peer, and appends it to the audit log if enabled.
+func (n *NodeP2P) RecordBan(decision monitor.BanDecision) error {
+	record := store.BanRecord{
+		Time:   decision.Time,
+		Expiry: decision.Expiry,
+		Score:  decision.Score,
+		Reason: decision.Reason,
+	}
+	if err := n.store.AddPeerBanRecord(decision.Peer, record); err != nil {
+		return fmt.Errorf("failed to store ban history: %w", err)
+	}
+	if n.banAudit != nil {
+		if err := n.banAudit.RecordBan(decision); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (n *NodeP2P) BanIP(ip net.IP, expiration time.Time) error {
 	if err := n.store.SetIPBanExpiration(ip, expiration); err != nil {
 		return fmt.Errorf("failed to set IP ban expiry: %w", err)
@@ -255,6 +307,11 @@ func (n *NodeP2P) Close() error {
 	if n.peerMonitor != nil {
 		n.peerMonitor.Stop()
 	}
+	if n.banAudit != nil {
+		if err := n.banAudit.Close(); err != nil {
+			result = multierror.Append(result, fmt.Errorf("failed to close ban audit log: %w", err))
+		}
+	}
 	if n.dv5Udp != nil {
 		n.dv5Udp.Close()
 	}
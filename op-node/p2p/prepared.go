@@ -85,6 +85,10 @@ func (p *Prepared) BanDuration() time.Duration {
 	return 1 * time.Hour
 }
 
+func (p *Prepared) BanAuditLogPath() string {
+	return ""
+}
+
 func (p *Prepared) Disabled() bool {
 	return false
 }
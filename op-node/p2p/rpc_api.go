@@ -9,6 +9,7 @@ import (
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/zircuit-labs/l2-geth-public/p2p/enode"
 
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/p2p/gating"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/p2p/store"
 )
 
@@ -41,11 +42,31 @@ type PeerDump struct {
 	BannedSubnets  []*net.IPNet         `json:"bannedSubnets"`
 }
 
+// PeerReputation explains the standing of a peer: the score components that make up its
+// total score, and the bans that were issued against it.
+type PeerReputation struct {
+	PeerID peer.ID `json:"peerID"`
+	// Score is the combined gossip and application score of the peer
+	Score      float64           `json:"score"`
+	Scores     store.PeerScores  `json:"scores"`
+	Banned     bool              `json:"banned"`
+	BanExpiry  time.Time         `json:"banExpiry"` // zero if the peer is not banned
+	BanHistory []store.BanRecord `json:"banHistory"`
+}
+
+// AllowlistStatus describes the peer allowlist and its recent decisions.
+type AllowlistStatus struct {
+	AllowedPeers     []peer.ID                  `json:"allowedPeers"`
+	LastUpdate       time.Time                  `json:"lastUpdate"`       // time the allowlist was last (re)loaded
+	RecentRejections []gating.AllowlistDecision `json:"recentRejections"` // oldest first
+}
+
 //go:generate mockery --name API --output mocks/ --with-expecter=true
 type API interface {
 	Self(ctx context.Context) (*PeerInfo, error)
 	Peers(ctx context.Context, connected bool) (*PeerDump, error)
 	PeerStats(ctx context.Context) (*PeerStats, error)
+	PeerReputation(ctx context.Context, p peer.ID) (*PeerReputation, error)
 	DiscoveryTable(ctx context.Context) ([]*enode.Node, error)
 	BlockPeer(ctx context.Context, p peer.ID) error
 	UnblockPeer(ctx context.Context, p peer.ID) error
@@ -56,6 +77,7 @@ type API interface {
 	BlockSubnet(ctx context.Context, ipnet *net.IPNet) error
 	UnblockSubnet(ctx context.Context, ipnet *net.IPNet) error
 	ListBlockedSubnets(ctx context.Context) ([]*net.IPNet, error)
+	Allowlist(ctx context.Context) (*AllowlistStatus, error)
 	ProtectPeer(ctx context.Context, p peer.ID) error
 	UnprotectPeer(ctx context.Context, p peer.ID) error
 	ConnectPeer(ctx context.Context, addr string) error
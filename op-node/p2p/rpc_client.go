@@ -44,6 +44,12 @@ func (c *Client) PeerStats(ctx context.Context) (*PeerStats, error) {
 	return out, err
 }
 
+func (c *Client) PeerReputation(ctx context.Context, p peer.ID) (*PeerReputation, error) {
+	var out *PeerReputation
+	err := c.c.CallContext(ctx, &out, prefixRPC("peerReputation"), p)
+	return out, err
+}
+
 func (c *Client) DiscoveryTable(ctx context.Context) ([]*enode.Node, error) {
 	var out []*enode.Node
 	err := c.c.CallContext(ctx, &out, prefixRPC("discoveryTable"))
@@ -92,6 +98,12 @@ func (c *Client) ListBlockedSubnets(ctx context.Context) ([]*net.IPNet, error) {
 	return out, err
 }
 
+func (c *Client) Allowlist(ctx context.Context) (*AllowlistStatus, error) {
+	var out *AllowlistStatus
+	err := c.c.CallContext(ctx, &out, prefixRPC("allowlist"))
+	return out, err
+}
+
 func (c *Client) ProtectPeer(ctx context.Context, p peer.ID) error {
 	return c.c.CallContext(ctx, nil, prefixRPC("protectPeer"), p)
 }
@@ -37,6 +37,8 @@ var (
 	ErrNoConnectionManager = errors.New("no connection manager")
 	ErrNoConnectionGater   = errors.New("no connection gater")
 	ErrInvalidRequest      = errors.New("invalid request")
+	ErrNoExtendedPeerstore = errors.New("no extended peerstore")
+	ErrNoAllowlist         = errors.New("no peer allowlist")
 )
 
 type Node interface {
@@ -54,6 +56,8 @@ type Node interface {
 	ConnectionGater() gating.BlockingConnectionGater
 	// ConnectionManager returns the connection manager, to protect peers with, may be nil
 	ConnectionManager() connmgr.ConnManager
+	// Allowlist returns the peer allowlist, may be nil
+	Allowlist() *gating.AllowlistConnectionGater
 }
 
 type APIBackend struct {
@@ -235,6 +239,40 @@ func (s *APIBackend) PeerStats(_ context.Context) (*PeerStats, error) {
 	return stats, nil
 }
 
+// PeerReputation returns the score components and the ban history of the given peer.
+func (s *APIBackend) PeerReputation(_ context.Context, id peer.ID) (*PeerReputation, error) {
+	recordDur := s.m.RecordRPCServerRequest("opp2p_peerReputation")
+	if err := id.Validate(); err != nil {
+		s.log.Warn("invalid peer ID", "method", "PeerReputation", "peer", id, "err", err)
+		return nil, ErrInvalidRequest
+	}
+	defer recordDur()
+	eps, ok := s.node.Host().Peerstore().(store.ExtendedPeerstore)
+	if !ok {
+		return nil, ErrNoExtendedPeerstore
+	}
+	rep := &PeerReputation{PeerID: id}
+	var err error
+	if rep.Scores, err = eps.GetPeerScores(id); err != nil {
+		return nil, fmt.Errorf("failed to load peer scores: %w", err)
+	}
+	if rep.Score, err = eps.GetPeerScore(id); err != nil {
+		return nil, fmt.Errorf("failed to load peer score: %w", err)
+	}
+	expiry, err := eps.GetPeerBanExpiration(id)
+	if err != nil && !errors.Is(err, store.ErrUnknownBan) {
+		return nil, fmt.Errorf("failed to load peer ban expiry: %w", err)
+	}
+	if err == nil && expiry.After(time.Now()) {
+		rep.Banned = true
+		rep.BanExpiry = expiry
+	}
+	if rep.BanHistory, err = eps.GetPeerBanHistory(id); err != nil {
+		return nil, fmt.Errorf("failed to load peer ban history: %w", err)
+	}
+	return rep, nil
+}
+
 func (s *APIBackend) DiscoveryTable(_ context.Context) ([]*enode.Node, error) {
 	recordDur := s.m.RecordRPCServerRequest("opp2p_discoveryTable")
 	defer recordDur()
@@ -363,6 +401,21 @@ func (s *APIBackend) ListBlockedSubnets(_ context.Context) ([]*net.IPNet, error)
 	}
 }
 
+// Allowlist returns the allowed peers and the recently rejected connections, if the peer allowlist is enabled.
+func (s *APIBackend) Allowlist(_ context.Context) (*AllowlistStatus, error) {
+	recordDur := s.m.RecordRPCServerRequest("opp2p_allowlist")
+	defer recordDur()
+	allowlist := s.node.Allowlist()
+	if allowlist == nil {
+		return nil, ErrNoAllowlist
+	}
+	return &AllowlistStatus{
+		AllowedPeers:     allowlist.AllowedPeers(),
+		LastUpdate:       allowlist.LastUpdate(),
+		RecentRejections: allowlist.RecentRejections(),
+	}, nil
+}
+
 func (s *APIBackend) ProtectPeer(_ context.Context, id peer.ID) error {
 	recordDur := s.m.RecordRPCServerRequest("opp2p_protectPeer")
 	if err := id.Validate(); err != nil {
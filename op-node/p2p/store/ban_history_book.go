@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/zircuit-labs/l2-geth-public/log"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/clock"
+)
+
+const (
+	banHistoryCacheSize        = 100
+	banHistoryRecordExpiration = time.Hour * 24 * 7
+	// maxBanHistoryEntries limits the number of ban entries retained per peer, the oldest entries are dropped first.
+	maxBanHistoryEntries = 16
+)
+
+var banHistoryBase = ds.NewKey("/peers/ban_history")
+
+type banHistoryRecord struct {
+	Entries    []BanRecord `json:"entries"`
+	LastUpdate int64       `json:"lastUpdate"` // unix timestamp in seconds
+}
+
+func (s *banHistoryRecord) SetLastUpdated(t time.Time) {
+	s.LastUpdate = t.Unix()
+}
+
+func (s *banHistoryRecord) LastUpdated() time.Time {
+	return time.Unix(s.LastUpdate, 0)
+}
+
+func (s *banHistoryRecord) MarshalBinary() (data []byte, err error) {
+	return json.Marshal(s)
+}
+
+func (s *banHistoryRecord) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, s)
+}
+
+type appendBanRecord BanRecord
+
+func (a appendBanRecord) Apply(rec *banHistoryRecord) {
+	rec.Entries = append(rec.Entries, BanRecord(a))
+	if len(rec.Entries) > maxBanHistoryEntries {
+		rec.Entries = rec.Entries[len(rec.Entries)-maxBanHistoryEntries:]
+	}
+}
+
+type banHistoryBook struct {
+	mu   sync.RWMutex
+	book *recordsBook[peer.ID, *banHistoryRecord]
+}
+
+func newBanHistoryBook(ctx context.Context, logger log.Logger, clock clock.Clock, store ds.Batching) (*banHistoryBook, error) {
+	book, err := newRecordsBook[peer.ID, *banHistoryRecord](ctx, logger, clock, store, banHistoryCacheSize, banHistoryRecordExpiration, banHistoryBase, genNew, peerIDKey)
+	if err != nil {
+		return nil, err
+	}
+	return &banHistoryBook{book: book}, nil
+}
+
+func (d *banHistoryBook) startGC() {
+	d.book.startGC()
+}
+
+func (d *banHistoryBook) GetPeerBanHistory(id peer.ID) ([]BanRecord, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rec, err := d.book.getRecord(id)
+	if err == errUnknownRecord {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// copy the entries, the record itself is shared with the cache
+	return append([]BanRecord(nil), rec.Entries...), nil
+}
+
+func (d *banHistoryBook) AddPeerBanRecord(id peer.ID, record BanRecord) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.book.setRecord(id, appendBanRecord(record))
+	return err
+}
+
+func (d *banHistoryBook) Close() {
+	d.book.Close()
+}
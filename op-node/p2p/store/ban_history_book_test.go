@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+	"github.com/zircuit-labs/l2-geth-public/log"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/clock"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testlog"
+)
+
+func TestGetUnknownBanHistory(t *testing.T) {
+	book := createMemoryBanHistoryBook(t)
+	defer book.Close()
+	history, err := book.GetPeerBanHistory("a")
+	require.NoError(t, err)
+	require.Empty(t, history)
+}
+
+func TestRoundTripBanHistory(t *testing.T) {
+	book := createMemoryBanHistoryBook(t)
+	defer book.Close()
+	first := BanRecord{Time: time.Unix(100, 0), Expiry: time.Unix(3700, 0), Score: -120, Reason: "low score"}
+	second := BanRecord{Time: time.Unix(4000, 0), Expiry: time.Unix(7600, 0), Score: -150, Reason: "low score"}
+	require.NoError(t, book.AddPeerBanRecord("a", first))
+	require.NoError(t, book.AddPeerBanRecord("a", second))
+
+	history, err := book.GetPeerBanHistory("a")
+	require.NoError(t, err)
+	require.Equal(t, []BanRecord{first, second}, history)
+
+	other, err := book.GetPeerBanHistory("b")
+	require.NoError(t, err)
+	require.Empty(t, other)
+}
+
+func TestBanHistoryIsCapped(t *testing.T) {
+	book := createMemoryBanHistoryBook(t)
+	defer book.Close()
+	for i := 0; i < maxBanHistoryEntries+5; i++ {
+		require.NoError(t, book.AddPeerBanRecord("a", BanRecord{Time: time.Unix(int64(i), 0), Reason: "low score"}))
+	}
+	history, err := book.GetPeerBanHistory("a")
+	require.NoError(t, err)
+	require.Len(t, history, maxBanHistoryEntries)
+	require.Equal(t, time.Unix(5, 0), history[0].Time, "oldest entries should be dropped first")
+	require.Equal(t, time.Unix(maxBanHistoryEntries+4, 0), history[len(history)-1].Time)
+}
+
+func createMemoryBanHistoryBook(t *testing.T) *banHistoryBook {
+	store := sync.MutexWrap(ds.NewMapDatastore())
+	logger := testlog.Logger(t, log.LevelInfo)
+	c := clock.NewDeterministicClock(time.UnixMilli(100))
+	book, err := newBanHistoryBook(context.Background(), logger, c, store)
+	require.NoError(t, err)
+	return book
+}
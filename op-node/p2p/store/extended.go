@@ -17,6 +17,7 @@ type extendedStore struct {
 	peerstore.CertifiedAddrBook
 	*scoreBook
 	*peerBanBook
+	*banHistoryBook
 	*ipBanBook
 	*metadataBook
 }
@@ -36,6 +37,11 @@ func NewExtendedPeerstore(ctx context.Context, logger log.Logger, clock clock.Cl
 		return nil, fmt.Errorf("create peer ban book: %w", err)
 	}
 	pb.startGC()
+	bh, err := newBanHistoryBook(ctx, logger, clock, store)
+	if err != nil {
+		return nil, fmt.Errorf("create ban history book: %w", err)
+	}
+	bh.startGC()
 	ib, err := newIPBanBook(ctx, logger, clock, store)
 	if err != nil {
 		return nil, fmt.Errorf("create IP ban book: %w", err)
@@ -51,6 +57,7 @@ func NewExtendedPeerstore(ctx context.Context, logger log.Logger, clock clock.Cl
 		CertifiedAddrBook: cab,
 		scoreBook:         sb,
 		peerBanBook:       pb,
+		banHistoryBook:    bh,
 		ipBanBook:         ib,
 		metadataBook:      md,
 	}, nil
@@ -59,6 +66,7 @@ func NewExtendedPeerstore(ctx context.Context, logger log.Logger, clock clock.Cl
 func (s *extendedStore) Close() error {
 	s.scoreBook.Close()
 	s.peerBanBook.Close()
+	s.banHistoryBook.Close()
 	s.ipBanBook.Close()
 	s.metadataBook.Close()
 	return s.Peerstore.Close()
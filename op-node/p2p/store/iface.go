@@ -112,6 +112,22 @@ type PeerBanStore interface {
 	GetPeerBanExpiration(id peer.ID) (time.Time, error)
 }
 
+// BanRecord describes a single ban decision that was made for a peer.
+type BanRecord struct {
+	Time   time.Time `json:"time"`
+	Expiry time.Time `json:"expiry"`
+	// Score is the combined peer score at the time of the ban.
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+type PeerBanHistoryStore interface {
+	// AddPeerBanRecord appends the ban record to the ban history of the peer.
+	AddPeerBanRecord(id peer.ID, record BanRecord) error
+	// GetPeerBanHistory returns the recorded bans of the peer, oldest first. Returns an empty list if none exist.
+	GetPeerBanHistory(id peer.ID) ([]BanRecord, error)
+}
+
 type IPBanStore interface {
 	// SetIPBanExpiration create the IP ban with expiration time.
 	// If expiry == time.Time{} then the ban is deleted.
@@ -133,6 +149,7 @@ type ExtendedPeerstore interface {
 	ScoreDatastore
 	peerstore.CertifiedAddrBook
 	PeerBanStore
+	PeerBanHistoryStore
 	IPBanStore
 	MetadataStore
 }
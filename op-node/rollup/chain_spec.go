@@ -68,6 +68,11 @@ func (s *ChainSpec) IsCanyon(t uint64) bool {
 	return s.config.IsCanyon(t)
 }
 
+// IsFeatSpanBatchExclusions specifies from which timestamp span batches carry deposit exclusions.
+func (s *ChainSpec) IsFeatSpanBatchExclusions(t uint64) bool {
+	return s.config.IsSpanBatchExclusions(t)
+}
+
 // MaxChannelBankSize returns the maximum number of bytes the can allocated inside the channel bank
 // before pruning occurs at the given timestamp.
 func (s *ChainSpec) MaxChannelBankSize(t uint64) uint64 {
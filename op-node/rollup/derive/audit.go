@@ -0,0 +1,357 @@
+package derive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	l1types "github.com/ethereum/go-ethereum/core/types"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/jsonutil"
+)
+
+var ErrAuditNotFound = errors.New("derivation audit not found")
+
+// ChannelEvent is a change of the state of a channel in the channel bank.
+type ChannelEvent string
+
+const (
+	ChannelOpened   ChannelEvent = "opened"
+	ChannelClosed   ChannelEvent = "closed"
+	ChannelRead     ChannelEvent = "read"
+	ChannelTimedOut ChannelEvent = "timed_out"
+	ChannelPruned   ChannelEvent = "pruned"
+)
+
+// AuditBatcherTx is a batcher transaction found in the L1 block.
+type AuditBatcherTx struct {
+	Hash  common.Hash `json:"hash"`
+	Blobs int         `json:"blobs"`
+}
+
+// AuditFrame is a frame that was ingested into the channel bank.
+// Error is set if the frame was ignored.
+type AuditFrame struct {
+	Channel ChannelID `json:"channel"`
+	Number  uint16    `json:"number"`
+	Length  int       `json:"length"`
+	IsLast  bool      `json:"isLast"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// AuditChannel is a channel state change in the channel bank.
+type AuditChannel struct {
+	ID     ChannelID    `json:"id"`
+	Event  ChannelEvent `json:"event"`
+	Frames int          `json:"frames"`
+	Size   uint64       `json:"size"`
+}
+
+// AuditBatch is the latest validity decision of the batch queue for a batch.
+// Reason is set if the batch was not accepted.
+type AuditBatch struct {
+	Type           int         `json:"type"`
+	Timestamp      uint64      `json:"timestamp"`
+	InclusionBlock eth.BlockID `json:"inclusionBlock"`
+	Validity       string      `json:"validity"`
+	Reason         string      `json:"reason,omitempty"`
+}
+
+func (b *AuditBatch) sameBatch(other *AuditBatch) bool {
+	return b.Type == other.Type && b.Timestamp == other.Timestamp && b.InclusionBlock == other.InclusionBlock
+}
+
+// DerivationAudit is the audit trail of the derivation of a single L1 block:
+// the batcher data it contained, and what happened to the channels and batches while it was the pipeline origin.
+type DerivationAudit struct {
+	L1Block    eth.BlockID      `json:"l1Block"`
+	BatcherTxs []AuditBatcherTx `json:"batcherTxs"`
+	Frames     []AuditFrame     `json:"frames"`
+	Channels   []AuditChannel   `json:"channels"`
+	Batches    []AuditBatch     `json:"batches"`
+}
+
+func (a *DerivationAudit) copy() *DerivationAudit {
+	return &DerivationAudit{
+		L1Block:    a.L1Block,
+		BatcherTxs: slices.Clone(a.BatcherTxs),
+		Frames:     slices.Clone(a.Frames),
+		Channels:   slices.Clone(a.Channels),
+		Batches:    slices.Clone(a.Batches),
+	}
+}
+
+// DerivationAuditor records the audit trail of the derivation pipeline, by L1 block.
+type DerivationAuditor interface {
+	AuditBatcherTx(l1 eth.L1BlockRef, tx AuditBatcherTx)
+	AuditFrame(l1 eth.L1BlockRef, frame AuditFrame)
+	AuditChannel(l1 eth.L1BlockRef, ch AuditChannel)
+	AuditBatch(l1 eth.L1BlockRef, batch AuditBatch)
+}
+
+type NoopDerivationAuditor struct{}
+
+func (NoopDerivationAuditor) AuditBatcherTx(eth.L1BlockRef, AuditBatcherTx) {}
+func (NoopDerivationAuditor) AuditFrame(eth.L1BlockRef, AuditFrame)         {}
+func (NoopDerivationAuditor) AuditChannel(eth.L1BlockRef, AuditChannel)     {}
+func (NoopDerivationAuditor) AuditBatch(eth.L1BlockRef, AuditBatch)         {}
+
+var _ DerivationAuditor = NoopDerivationAuditor{}
+
+// auditBatch converts a batch and its validity to an audit entry.
+func auditBatch(batch *BatchWithL1InclusionBlock, validity BatchValidity, reason string) AuditBatch {
+	return AuditBatch{
+		Type:           batch.GetBatchType(),
+		Timestamp:      batch.GetTimestamp(),
+		InclusionBlock: batch.L1InclusionBlock.ID(),
+		Validity:       validity.String(),
+		Reason:         reason,
+	}
+}
+
+// auditingTxFetcher records the batcher transactions of the L1 block it fetches the transactions of.
+type auditingTxFetcher struct {
+	L1TransactionFetcher
+	audit       DerivationAuditor
+	dsCfg       DataSourceConfig
+	ref         eth.L1BlockRef
+	batcherAddr common.Address
+}
+
+func (f *auditingTxFetcher) InfoAndTxsByHash(ctx context.Context, hash common.Hash) (eth.BlockInfo, l1types.Transactions, error) {
+	info, txs, err := f.L1TransactionFetcher.InfoAndTxsByHash(ctx, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, tx := range txs {
+		if isValidBatchTx(tx, f.dsCfg.l1Signer, f.dsCfg.batchInboxAddress, f.batcherAddr) {
+			f.audit.AuditBatcherTx(f.ref, AuditBatcherTx{Hash: common.Hash(tx.Hash()), Blobs: len(tx.BlobHashes())})
+		}
+	}
+	return info, txs, nil
+}
+
+// DerivationAuditLog is a DerivationAuditor that keeps the audit trail of the most recent L1 blocks on disk,
+// as one JSON file per L1 block in the given directory.
+// Entries are kept in memory while the pipeline may still be working on the L1 block,
+// and written in the background when the pipeline moves on, so re-deriving an L1 block replaces its entry.
+type DerivationAuditLog struct {
+	log    log.Logger
+	dir    string
+	retain uint64
+
+	mu      sync.Mutex
+	pending map[uint64]*DerivationAudit
+	// unwritten are the entries the pipeline moved on from, until they are written
+	unwritten map[uint64]*DerivationAudit
+
+	wake    chan struct{}
+	closing chan struct{}
+	done    chan struct{}
+}
+
+var _ DerivationAuditor = (*DerivationAuditLog)(nil)
+
+// NewDerivationAuditLog opens the audit log in the given directory, keeping the entries of the last retain L1 blocks.
+// A retain of 0 keeps all entries.
+func NewDerivationAuditLog(log log.Logger, dir string, retain uint64) (*DerivationAuditLog, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create derivation audit directory: %w", err)
+	}
+	a := &DerivationAuditLog{
+		log:       log,
+		dir:       dir,
+		retain:    retain,
+		pending:   make(map[uint64]*DerivationAudit),
+		unwritten: make(map[uint64]*DerivationAudit),
+		wake:      make(chan struct{}, 1),
+		closing:   make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	if err := a.pruneAll(); err != nil {
+		return nil, err
+	}
+	go a.writeLoop()
+	return a, nil
+}
+
+func (a *DerivationAuditLog) path(num uint64) string {
+	return filepath.Join(a.dir, strconv.FormatUint(num, 10)+".json")
+}
+
+// pruneAll removes the entries that are older than the retention, relative to the latest entry.
+func (a *DerivationAuditLog) pruneAll() error {
+	if a.retain == 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(a.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read derivation audit directory: %w", err)
+	}
+	var nums []uint64
+	for _, e := range entries {
+		num, err := strconv.ParseUint(strings.TrimSuffix(e.Name(), ".json"), 10, 64)
+		if err != nil || e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		nums = append(nums, num)
+	}
+	if len(nums) == 0 {
+		return nil
+	}
+	latest := slices.Max(nums)
+	for _, num := range nums {
+		if num+a.retain <= latest {
+			if err := os.Remove(a.path(num)); err != nil {
+				return fmt.Errorf("failed to prune derivation audit of L1 block %d: %w", num, err)
+			}
+		}
+	}
+	return nil
+}
+
+// flush hands the pending entry of the given L1 block to the writer.
+func (a *DerivationAuditLog) flush(num uint64) {
+	a.unwritten[num] = a.pending[num]
+	delete(a.pending, num)
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+// writeLoop writes the entries the pipeline moved on from, until the audit log is closed.
+func (a *DerivationAuditLog) writeLoop() {
+	defer close(a.done)
+	for {
+		select {
+		case <-a.wake:
+			a.writeUnwritten()
+		case <-a.closing:
+			a.writeUnwritten()
+			return
+		}
+	}
+}
+
+// writeUnwritten writes the unwritten entries in order of their L1 block,
+// and removes the entries that fell out of the retention.
+func (a *DerivationAuditLog) writeUnwritten() {
+	a.mu.Lock()
+	entries := maps.Clone(a.unwritten)
+	a.mu.Unlock()
+	for _, num := range slices.Sorted(maps.Keys(entries)) {
+		entry := entries[num]
+		if err := jsonutil.WriteJSON(a.path(num), entry, 0o644); err != nil {
+			a.log.Warn("Failed to write derivation audit", "l1", entry.L1Block, "err", err)
+		} else if a.retain > 0 && num >= a.retain {
+			if err := os.Remove(a.path(num - a.retain)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				a.log.Warn("Failed to prune derivation audit", "number", num-a.retain, "err", err)
+			}
+		}
+		a.mu.Lock()
+		// the L1 block may have been derived again while writing
+		if a.unwritten[num] == entry {
+			delete(a.unwritten, num)
+		}
+		a.mu.Unlock()
+	}
+}
+
+// entry returns the pending entry of the L1 block, and writes the entries the pipeline moved on from.
+// The pipeline stages may trail each other by one L1 block, so the entry of the previous L1 block is kept pending.
+func (a *DerivationAuditLog) entry(l1 eth.L1BlockRef) *DerivationAudit {
+	if e, ok := a.pending[l1.Number]; ok && e.L1Block == l1.ID() {
+		return e
+	}
+	for num := range a.pending {
+		// after a reset the pipeline derives older L1 blocks again
+		if num+1 < l1.Number || num > l1.Number {
+			a.flush(num)
+		}
+	}
+	// a different block at the same height was reorged out, and replaced
+	e := &DerivationAudit{L1Block: l1.ID()}
+	a.pending[l1.Number] = e
+	return e
+}
+
+func (a *DerivationAuditLog) AuditBatcherTx(l1 eth.L1BlockRef, tx AuditBatcherTx) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	e := a.entry(l1)
+	// the transactions are fetched again if opening the data source is retried
+	if slices.Contains(e.BatcherTxs, tx) {
+		return
+	}
+	e.BatcherTxs = append(e.BatcherTxs, tx)
+}
+
+func (a *DerivationAuditLog) AuditFrame(l1 eth.L1BlockRef, frame AuditFrame) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	e := a.entry(l1)
+	e.Frames = append(e.Frames, frame)
+}
+
+func (a *DerivationAuditLog) AuditChannel(l1 eth.L1BlockRef, ch AuditChannel) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	e := a.entry(l1)
+	e.Channels = append(e.Channels, ch)
+}
+
+// AuditBatch records the validity of the batch, replacing any earlier decision on the same batch in the L1 block.
+func (a *DerivationAuditLog) AuditBatch(l1 eth.L1BlockRef, batch AuditBatch) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	e := a.entry(l1)
+	for i := range e.Batches {
+		if e.Batches[i].sameBatch(&batch) {
+			e.Batches[i] = batch
+			return
+		}
+	}
+	e.Batches = append(e.Batches, batch)
+}
+
+// AuditAtL1 returns the audit trail of the L1 block with the given number, or ErrAuditNotFound.
+func (a *DerivationAuditLog) AuditAtL1(num uint64) (*DerivationAudit, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if e, ok := a.pending[num]; ok {
+		return e.copy(), nil
+	}
+	if e, ok := a.unwritten[num]; ok {
+		return e.copy(), nil
+	}
+	e, err := jsonutil.LoadJSON[DerivationAudit](a.path(num))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrAuditNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load derivation audit of L1 block %d: %w", num, err)
+	}
+	return e, nil
+}
+
+// Close writes all pending entries, and waits for the writer to finish.
+func (a *DerivationAuditLog) Close() error {
+	a.mu.Lock()
+	for num := range a.pending {
+		a.flush(num)
+	}
+	a.mu.Unlock()
+	close(a.closing)
+	<-a.done
+	return nil
+}
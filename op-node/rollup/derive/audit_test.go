@@ -0,0 +1,137 @@
+package derive
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/metrics"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testlog"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testutils"
+)
+
+func TestDerivationAuditLog(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	dir := t.TempDir()
+	logger := testlog.Logger(t, log.LevelDebug)
+	audit, err := NewDerivationAuditLog(logger, dir, 3)
+	require.NoError(t, err)
+
+	chain := []eth.L1BlockRef{testutils.RandomBlockRef(rng)}
+	for i := 0; i < 5; i++ {
+		chain = append(chain, testutils.NextRandomRef(rng, chain[len(chain)-1]))
+	}
+	a := chain[0]
+
+	tx := AuditBatcherTx{Hash: common.Hash{0x01}, Blobs: 2}
+	audit.AuditBatcherTx(a, tx)
+	audit.AuditBatcherTx(a, tx)
+	batch := AuditBatch{Type: SingularBatchType, Timestamp: 10, InclusionBlock: a.ID(), Validity: "future"}
+	audit.AuditBatch(a, batch)
+	batch.Validity = "drop"
+	batch.Reason = "batch was included too late, sequence window expired"
+	audit.AuditBatch(a, batch)
+
+	entry, err := audit.AuditAtL1(a.Number)
+	require.NoError(t, err)
+	require.Equal(t, a.ID(), entry.L1Block)
+	require.Equal(t, []AuditBatcherTx{tx}, entry.BatcherTxs, "transactions are only recorded once")
+	require.Equal(t, []AuditBatch{batch}, entry.Batches, "batch decisions are updated")
+
+	// the previous block stays pending, older blocks are written
+	audit.AuditChannel(chain[1], AuditChannel{ID: ChannelID{1}, Event: ChannelOpened})
+	_, err = os.Stat(audit.path(a.Number))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	audit.AuditChannel(chain[2], AuditChannel{ID: ChannelID{1}, Event: ChannelRead})
+	entry, err = audit.AuditAtL1(a.Number)
+	require.NoError(t, err)
+	require.Equal(t, []AuditBatch{batch}, entry.Batches)
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(audit.path(a.Number))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond, "entry is written in the background")
+	entry, err = audit.AuditAtL1(a.Number)
+	require.NoError(t, err)
+	require.Equal(t, []AuditBatch{batch}, entry.Batches)
+
+	// a reorged block replaces the pending entry
+	reorged := testutils.NextRandomRef(rng, chain[1])
+	audit.AuditFrame(reorged, AuditFrame{Channel: ChannelID{2}})
+	entry, err = audit.AuditAtL1(reorged.Number)
+	require.NoError(t, err)
+	require.Equal(t, reorged.ID(), entry.L1Block)
+	require.Empty(t, entry.Channels)
+	require.Len(t, entry.Frames, 1)
+
+	// entries out of the retention are removed
+	for _, ref := range chain[3:] {
+		audit.AuditFrame(ref, AuditFrame{Channel: ChannelID{3}})
+	}
+	require.NoError(t, audit.Close())
+	_, err = audit.AuditAtL1(a.Number)
+	require.ErrorIs(t, err, ErrAuditNotFound)
+	for _, ref := range chain[3:] {
+		entry, err = audit.AuditAtL1(ref.Number)
+		require.NoError(t, err)
+		require.Equal(t, ref.ID(), entry.L1Block)
+	}
+
+	// entries out of the retention are removed when opening with a smaller retention
+	audit, err = NewDerivationAuditLog(logger, dir, 1)
+	require.NoError(t, err)
+	for _, ref := range chain[:5] {
+		_, err = audit.AuditAtL1(ref.Number)
+		require.ErrorIs(t, err, ErrAuditNotFound)
+	}
+	_, err = audit.AuditAtL1(chain[5].Number)
+	require.NoError(t, err)
+}
+
+func TestChannelBankAudit(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	a := testutils.RandomBlockRef(rng)
+
+	input := &fakeChannelBankInput{origin: a}
+	input.AddFrames("a:0:first", "a:0:again", "a:1:second!")
+	input.AddFrame(Frame{}, io.EOF)
+
+	cfg := &rollup.Config{ChannelTimeoutBedrock: 10}
+	cb := NewChannelBank(testlog.Logger(t, log.LevelCrit), cfg, input, nil, metrics.NoopMetrics)
+	audit, err := NewDerivationAuditLog(testlog.Logger(t, log.LevelCrit), t.TempDir(), 0)
+	require.NoError(t, err)
+	cb.audit = audit
+
+	var out []byte
+	for {
+		data, err := cb.NextData(context.Background())
+		if err == io.EOF {
+			break
+		} else if err == nil {
+			out = data
+		}
+	}
+	require.Equal(t, "firstsecond", string(out))
+
+	entry, err := audit.AuditAtL1(a.Number)
+	require.NoError(t, err)
+	id := testFrame("a:0:first").ChannelID()
+	require.Len(t, entry.Frames, 3)
+	require.Equal(t, AuditFrame{Channel: id, Number: 0, Length: len("again"), Error: entry.Frames[1].Error}, entry.Frames[1])
+	require.NotEmpty(t, entry.Frames[1].Error, "duplicate frames are ignored")
+	require.Equal(t, AuditFrame{Channel: id, Number: 1, Length: len("second"), IsLast: true}, entry.Frames[2])
+	events := make([]ChannelEvent, 0, len(entry.Channels))
+	for _, ch := range entry.Channels {
+		require.Equal(t, id, ch.ID)
+		events = append(events, ch.Event)
+	}
+	require.Equal(t, []ChannelEvent{ChannelOpened, ChannelClosed, ChannelRead}, events)
+}
@@ -28,6 +28,9 @@ const (
 	SingularBatchType = 0
 	// SpanBatchType is the Batch version used after Delta hard fork, representing a span of L2 blocks.
 	SpanBatchType = 1
+	// SpanBatchWithExclusionsType is the Batch version used after span batch exclusions activation,
+	// representing a span of L2 blocks together with their deposit exclusions.
+	SpanBatchWithExclusionsType = 2
 )
 
 // Batch contains information to build one or multiple L2 blocks.
@@ -132,6 +135,8 @@ func (b *BatchData) decodeTyped(data []byte) error {
 		inner = new(SingularBatch)
 	case SpanBatchType:
 		inner = new(RawSpanBatch)
+	case SpanBatchWithExclusionsType:
+		inner = &RawSpanBatch{spanBatchPayload: spanBatchPayload{withExclusions: true}}
 	default:
 		return fmt.Errorf("unrecognized batch type: %d", data[0])
 	}
@@ -60,6 +60,8 @@ type BatchQueue struct {
 	nextSpan []*SingularBatch
 
 	l2 SafeBlockFetcher
+
+	audit DerivationAuditor
 }
 
 // NewBatchQueue creates a BatchQueue, which should be Reset(origin) before use.
@@ -69,6 +71,7 @@ func NewBatchQueue(log log.Logger, cfg *rollup.Config, prev NextBatchProvider, l
 		config: cfg,
 		prev:   prev,
 		l2:     l2,
+		audit:  NoopDerivationAuditor{},
 	}
 }
 
@@ -228,7 +231,8 @@ func (bq *BatchQueue) AddBatch(ctx context.Context, batch Batch, parent eth.L2Bl
 		L1InclusionBlock: bq.origin,
 		Batch:            batch,
 	}
-	validity := CheckBatch(ctx, bq.config, bq.log, bq.l1Blocks, parent, &data, bq.l2)
+	validity, reason := CheckBatch(ctx, bq.config, bq.log, bq.l1Blocks, parent, &data, bq.l2)
+	bq.audit.AuditBatch(bq.origin, auditBatch(&data, validity, reason))
 	if validity == BatchDrop {
 		return // if we do drop the batch, CheckBatch will log the drop reason with WARN level.
 	}
@@ -265,7 +269,8 @@ func (bq *BatchQueue) deriveNextBatch(ctx context.Context, outOfData bool, paren
 	var remaining []*BatchWithL1InclusionBlock
 batchLoop:
 	for i, batch := range bq.batches {
-		validity := CheckBatch(ctx, bq.config, bq.log.New("batch_index", i), bq.l1Blocks, parent, batch, bq.l2)
+		validity, reason := CheckBatch(ctx, bq.config, bq.log.New("batch_index", i), bq.l1Blocks, parent, batch, bq.l2)
+		bq.audit.AuditBatch(bq.origin, auditBatch(batch, validity, reason))
 		switch validity {
 		case BatchFuture:
 			remaining = append(remaining, batch)
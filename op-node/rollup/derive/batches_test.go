@@ -47,6 +47,18 @@ func fjordAt(t *uint64) func(*rollup.Config) {
 	}
 }
 
+func spanBatchExclusionsAt(t *uint64) func(*rollup.Config) {
+	return func(c *rollup.Config) {
+		c.SpanBatchExclusionsTime = t
+	}
+}
+
+// withExclusions marks the span batch as carrying deposit exclusions
+func withExclusions(b *SpanBatch) *SpanBatch {
+	b.WithExclusions = true
+	return b
+}
+
 func multiMod[T any](mods ...func(T)) func(T) {
 	return func(x T) {
 		for _, mod := range mods {
@@ -247,6 +259,26 @@ func TestValidBatch(t *testing.T) {
 			},
 			Expected: BatchUndecided,
 		},
+		{
+			Name:       "span batch with exclusions before activation",
+			L1Blocks:   []eth.L1BlockRef{l1A, l1B, l1C},
+			L2SafeHead: l2A0,
+			Batch: BatchWithL1InclusionBlock{
+				L1InclusionBlock: l1B,
+				Batch: withExclusions(initializedSpanBatch([]*SingularBatch{
+					{
+						ParentHash:   l2A1.ParentHash,
+						EpochNum:     rollup.Epoch(l2A1.L1Origin.Number),
+						EpochHash:    l2A1.L1Origin.Hash,
+						Timestamp:    l2A1.Time,
+						Transactions: nil,
+					},
+				}, uint64(0), big.NewInt(0))),
+			},
+			Expected:    BatchDrop,
+			ExpectedLog: "received SpanBatch with exclusions before their activation",
+			ConfigMod:   multiMod(deltaAtGenesis, spanBatchExclusionsAt(&l2A2.Time)),
+		},
 		{
 			Name:       "future timestamp",
 			L1Blocks:   []eth.L1BlockRef{l1A, l1B, l1C},
@@ -1546,8 +1578,13 @@ func TestValidBatch(t *testing.T) {
 		if mod := testCase.ConfigMod; mod != nil {
 			mod(rcfg)
 		}
-		validity := CheckBatch(ctx, rcfg, logger, testCase.L1Blocks, testCase.L2SafeHead, &testCase.Batch, &l2Client)
+		validity, reason := CheckBatch(ctx, rcfg, logger, testCase.L1Blocks, testCase.L2SafeHead, &testCase.Batch, &l2Client)
 		require.Equal(t, testCase.Expected, validity, "batch check must return expected validity level")
+		if testCase.Expected == BatchAccept {
+			require.Empty(t, reason)
+		} else if expLog := testCase.ExpectedLog; expLog != "" {
+			require.Contains(t, reason, expLog, "reason of the decision is returned")
+		}
 		if expLog := testCase.ExpectedLog; expLog != "" {
 			// Check if ExpectedLog is contained in the log buffer
 			containsFilter := testlog.NewMessageContainsFilter(expLog)
@@ -2,6 +2,8 @@ package derive
 
 import (
 	"bytes"
+	"fmt"
+	"math/big"
 
 	"github.com/bits-and-blooms/bitset"
 	"github.com/zircuit-labs/l2-geth-public/common/hexutil"
@@ -15,16 +17,56 @@ func EmptyBitmap(lengthHint int) *types.Bitmap {
 
 // MustBitmap returns a Bitmap from the given portable bytes.
 func MustBitmap(b hexutil.Bytes) *types.Bitmap {
+	bm, err := readBitmap(b)
+	if err != nil {
+		panic("failed to read from buffer")
+	}
+	return bm
+}
+
+// readBitmap returns a Bitmap from the given portable bytes, or nil if there are no bytes.
+func readBitmap(b hexutil.Bytes) (*types.Bitmap, error) {
 	if len(b) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	bs := bitset.New(uint(len(b)))
 	buf := bytes.NewBuffer(b)
-	_, err := bs.ReadFrom(buf)
+	if _, err := bs.ReadFrom(buf); err != nil {
+		return nil, err
+	}
+
+	return types.NewBitmap(bs), nil
+}
+
+// bitmapToSpanBatchBits converts the bitmap in the given portable bytes to its length,
+// and its bits as a standard span-batch bitlist.
+func bitmapToSpanBatchBits(b hexutil.Bytes) (uint64, *big.Int, error) {
+	bm, err := readBitmap(b)
 	if err != nil {
-		panic("failed to read from buffer")
+		return 0, nil, fmt.Errorf("invalid bitmap: %w", err)
+	}
+	bits := new(big.Int)
+	if bm == nil {
+		return 0, bits, nil
+	}
+	for i, ok := bm.Bs.NextSet(0); ok; i, ok = bm.Bs.NextSet(i + 1) {
+		bits.SetBit(bits, int(i), 1)
 	}
+	return uint64(bm.Len()), bits, nil
+}
 
-	return types.NewBitmap(bs)
+// spanBatchBitsToBitmap converts a standard span-batch bitlist of the given length
+// to the portable bytes of a bitmap of that length, or nil if the length is 0.
+func spanBatchBitsToBitmap(bitLength uint64, bits *big.Int) hexutil.Bytes {
+	if bitLength == 0 {
+		return nil
+	}
+	bs := bitset.New(uint(bitLength))
+	for i := 0; i < bits.BitLen(); i++ {
+		if bits.Bit(i) == 1 {
+			bs.Set(uint(i))
+		}
+	}
+	return types.NewBitmap(bs).MustBytes()
 }
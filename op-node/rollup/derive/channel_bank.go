@@ -39,6 +39,8 @@ type ChannelBank struct {
 
 	prev    NextFrameProvider
 	fetcher L1Fetcher
+
+	audit DerivationAuditor
 }
 
 var _ ResettableStage = (*ChannelBank)(nil)
@@ -53,6 +55,7 @@ func NewChannelBank(log log.Logger, cfg *rollup.Config, prev NextFrameProvider,
 		channelQueue: make([]ChannelID, 0, 10),
 		prev:         prev,
 		fetcher:      fetcher,
+		audit:        NoopDerivationAuditor{},
 	}
 }
 
@@ -73,6 +76,7 @@ func (cb *ChannelBank) prune() {
 		cb.channelQueue = cb.channelQueue[1:]
 		delete(cb.channels, id)
 		cb.log.Info("pruning channel", "channel", id, "totalSize", totalSize, "channel_size", ch.size, "remaining_channel_count", len(cb.channels))
+		cb.audit.AuditChannel(cb.Origin(), AuditChannel{ID: id, Event: ChannelPruned, Frames: len(ch.inputs), Size: ch.size})
 		totalSize -= ch.size
 	}
 }
@@ -95,20 +99,30 @@ func (cb *ChannelBank) IngestFrame(f Frame) {
 		cb.channels[f.ID] = currentCh
 		cb.channelQueue = append(cb.channelQueue, f.ID)
 		log.Info("created new channel")
+		cb.audit.AuditChannel(origin, AuditChannel{ID: f.ID, Event: ChannelOpened})
 	}
 
+	frame := AuditFrame{Channel: f.ID, Number: f.FrameNumber, Length: len(f.Data), IsLast: f.IsLast}
 	// check if the channel is not timed out
 	if currentCh.OpenBlockNumber()+cb.spec.ChannelTimeout(origin.Time) < origin.Number {
 		log.Warn("channel is timed out, ignore frame")
+		frame.Error = "channel timed out"
+		cb.audit.AuditFrame(origin, frame)
 		return
 	}
 
 	log.Trace("ingesting frame")
 	if err := currentCh.AddFrame(f, origin); err != nil {
 		log.Warn("failed to ingest frame into channel", "err", err)
+		frame.Error = err.Error()
+		cb.audit.AuditFrame(origin, frame)
 		return
 	}
 	cb.metrics.RecordFrame()
+	cb.audit.AuditFrame(origin, frame)
+	if f.IsLast {
+		cb.audit.AuditChannel(origin, AuditChannel{ID: f.ID, Event: ChannelClosed, Frames: len(currentCh.inputs), Size: currentCh.size})
+	}
 
 	// Prune after the frame is loaded.
 	cb.prune()
@@ -128,6 +142,7 @@ func (cb *ChannelBank) Read() (data []byte, err error) {
 	timedOut := ch.OpenBlockNumber()+cb.spec.ChannelTimeout(cb.Origin().Time) < cb.Origin().Number
 	if timedOut {
 		cb.log.Info("channel timed out", "channel", first, "frames", len(ch.inputs))
+		cb.audit.AuditChannel(cb.Origin(), AuditChannel{ID: first, Event: ChannelTimedOut, Frames: len(ch.inputs), Size: ch.size})
 		cb.metrics.RecordChannelTimedOut()
 		delete(cb.channels, first)
 		cb.channelQueue = cb.channelQueue[1:]
@@ -162,6 +177,7 @@ func (cb *ChannelBank) tryReadChannelAtIndex(i int) (data []byte, err error) {
 		return nil, io.EOF
 	}
 	cb.log.Info("Reading channel", "channel", chanID, "frames", len(ch.inputs))
+	cb.audit.AuditChannel(cb.Origin(), AuditChannel{ID: chanID, Event: ChannelRead, Frames: len(ch.inputs), Size: ch.size})
 
 	delete(cb.channels, chanID)
 	cb.channelQueue = slices.Delete(cb.channelQueue, i, i+1)
@@ -23,6 +23,11 @@ type ChannelInReader struct {
 	nextBatchFn func() (*BatchData, error)
 	prev        *ChannelBank
 	metrics     Metrics
+
+	// the channel being read, kept to checkpoint the reading progress
+	data        []byte
+	maxRLPBytes uint64
+	batchesRead uint64
 }
 
 var _ ResettableStage = (*ChannelInReader)(nil)
@@ -44,8 +49,12 @@ func (cr *ChannelInReader) Origin() eth.L1BlockRef {
 
 // TODO: Take full channel for better logging
 func (cr *ChannelInReader) WriteChannel(data []byte) error {
-	if f, err := BatchReader(bytes.NewBuffer(data), cr.spec.MaxRLPBytesPerChannel(cr.prev.Origin().Time), false); err == nil {
+	maxRLPBytes := cr.spec.MaxRLPBytesPerChannel(cr.prev.Origin().Time)
+	if f, err := BatchReader(bytes.NewBuffer(data), maxRLPBytes, false); err == nil {
 		cr.nextBatchFn = f
+		cr.data = data
+		cr.maxRLPBytes = maxRLPBytes
+		cr.batchesRead = 0
 		cr.metrics.RecordChannelInputBytes(len(data))
 		return nil
 	} else {
@@ -58,6 +67,8 @@ func (cr *ChannelInReader) WriteChannel(data []byte) error {
 // resetting any decoding/decompression state to a fresh start.
 func (cr *ChannelInReader) NextChannel() {
 	cr.nextBatchFn = nil
+	cr.data = nil
+	cr.batchesRead = 0
 }
 
 // NextBatch pulls out the next batch from the channel if it has it.
@@ -87,6 +98,7 @@ func (cr *ChannelInReader) NextBatch(ctx context.Context) (Batch, error) {
 		cr.NextChannel()
 		return nil, ErrNotEnoughData
 	}
+	cr.batchesRead++
 
 	batch := batchWithMetadata{comprAlgo: batchData.ComprAlgo}
 	switch batchData.GetBatchType() {
@@ -98,13 +110,17 @@ func (cr *ChannelInReader) NextBatch(ctx context.Context) (Batch, error) {
 		batch.LogContext(cr.log).Debug("decoded singular batch from channel", "stage_origin", cr.Origin())
 		cr.metrics.RecordDerivedBatches("singular")
 		return batch, nil
-	case SpanBatchType:
+	case SpanBatchType, SpanBatchWithExclusionsType:
 		if origin := cr.Origin(); !cr.cfg.IsDelta(origin.Time) {
 			// Check hard fork activation with the L1 inclusion block time instead of the L1 origin block time.
 			// Therefore, even if the batch passed this rule, it can be dropped in the batch queue.
 			// This is just for early dropping invalid batches as soon as possible.
 			return nil, NewTemporaryError(fmt.Errorf("cannot accept span batch in L1 block %s at time %d", origin, origin.Time))
 		}
+		if origin := cr.Origin(); batchData.GetBatchType() == SpanBatchWithExclusionsType && !cr.cfg.IsSpanBatchExclusions(origin.Time) {
+			// The L2 blocks of the batch are before the L1 inclusion block, so they cannot be after the activation either.
+			return nil, NewTemporaryError(fmt.Errorf("cannot accept span batch with exclusions in L1 block %s at time %d", origin, origin.Time))
+		}
 		batch.Batch, err = DeriveSpanBatch(batchData, cr.cfg.BlockTime, cr.cfg.Genesis.L2Time, cr.cfg.L2ChainID)
 		if err != nil {
 			return nil, err
@@ -119,6 +135,6 @@ func (cr *ChannelInReader) NextBatch(ctx context.Context) (Batch, error) {
 }
 
 func (cr *ChannelInReader) Reset(ctx context.Context, _ eth.L1BlockRef, _ eth.SystemConfig) error {
-	cr.nextBatchFn = nil
+	cr.NextChannel()
 	return io.EOF
 }
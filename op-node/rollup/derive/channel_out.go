@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 
 	"github.com/zircuit-labs/l2-geth-public/common/hexutil"
 	"github.com/zircuit-labs/l2-geth-public/core/types"
@@ -19,6 +20,9 @@ var (
 	ErrTooManyRLPBytes         = errors.New("batch would cause RLP bytes to go over limit")
 	ErrChannelOutAlreadyClosed = errors.New("channel-out already closed")
 	ErrCompressorFull          = errors.New("compressor is full")
+	// ErrSpanBatchExclusionsActivation is returned when a batch after the span batch exclusions activation is
+	// added to a span channel that started before it. The channel is full, the batch goes into the next channel.
+	ErrSpanBatchExclusionsActivation = errors.New("span batch exclusions activation reached")
 )
 
 // FrameV0OverHeadSize is the absolute minimum size of a frame.
@@ -253,6 +257,9 @@ func BlockToSingularBatch(rollupCfg *rollup.Config, block *types.Block, l1Info *
 // ForceCloseTxData generates the transaction data for a transaction which will force close
 // a channel. It should be given every frame of that channel which has been submitted on
 // chain. The frames should be given in order that they appear on L1.
+// If the channel is not closed yet, it is closed with an empty frame after the highest
+// submitted frame, or with an empty first frame if the first frame was not submitted.
+// The tx data is just the version byte if the channel is already complete.
 func ForceCloseTxData(frames []Frame) ([]byte, error) {
 	if len(frames) == 0 {
 		return nil, errors.New("must provide at least one frame")
@@ -260,6 +267,7 @@ func ForceCloseTxData(frames []Frame) ([]byte, error) {
 	frameNumbers := make(map[uint16]struct{})
 	id := frames[0].ID
 	closeNumber := uint16(0)
+	highestNumber := uint16(0)
 	closed := false
 	for i, frame := range frames {
 		if !closed && frame.IsLast {
@@ -267,6 +275,7 @@ func ForceCloseTxData(frames []Frame) ([]byte, error) {
 		}
 		closed = closed || frame.IsLast
 		frameNumbers[frame.FrameNumber] = struct{}{}
+		highestNumber = max(highestNumber, frame.FrameNumber)
 		if frame.ID != id {
 			return nil, fmt.Errorf("invalid ID in list: first ID: %v, %vth ID: %v", id, i, frame.ID)
 		}
@@ -275,6 +284,17 @@ func ForceCloseTxData(frames []Frame) ([]byte, error) {
 	var out bytes.Buffer
 	out.WriteByte(DerivationVersion0)
 
+	// A first frame that closes the channel would be a duplicate if the first frame is on chain already,
+	// so the channel is closed after the highest frame instead.
+	if _, ok := frameNumbers[0]; ok && !closed {
+		if highestNumber == math.MaxUint16 {
+			return nil, errors.New("cannot close channel after the maximum frame number")
+		}
+		closed = true
+		closeNumber = highestNumber + 1
+		frames = append(frames, Frame{ID: id, FrameNumber: closeNumber, IsLast: true})
+	}
+
 	if !closed {
 		f := Frame{
 			ID:          id,
@@ -294,7 +314,7 @@ func ForceCloseTxData(frames []Frame) ([]byte, error) {
 				ID:          id,
 				FrameNumber: i,
 				Data:        nil,
-				IsLast:      false,
+				IsLast:      i == closeNumber,
 			}
 			if err := f.MarshalBinary(&out); err != nil {
 				return nil, err
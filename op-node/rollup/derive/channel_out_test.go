@@ -12,6 +12,7 @@ import (
 	"github.com/zircuit-labs/l2-geth-public/rlp"
 
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
 )
 
 var rollupCfg rollup.Config
@@ -147,7 +148,12 @@ func TestForceCloseTxData(t *testing.T) {
 		{
 			frames: []Frame{{ID: id, FrameNumber: 0, IsLast: false}},
 			errors: false,
-			output: "00deadbeefdeadbeefdeadbeefdeadbeef00000000000001",
+			output: "00deadbeefdeadbeefdeadbeefdeadbeef00010000000001",
+		},
+		{
+			frames: []Frame{{ID: id, FrameNumber: 0, IsLast: false}, {ID: id, FrameNumber: 2, IsLast: false}},
+			errors: false,
+			output: "00deadbeefdeadbeefdeadbeefdeadbeef00010000000000deadbeefdeadbeefdeadbeefdeadbeef00030000000001",
 		},
 		{
 			frames: []Frame{{ID: id, FrameNumber: 0, IsLast: true}},
@@ -299,3 +305,75 @@ func SpanChannelOutClose(t *testing.T, algo CompressionAlgo) {
 	require.Greater(t, cout.compressor.Len(), 0)
 	require.Equal(t, rlpLen, cout.activeRLP().Len())
 }
+
+// TestSpanChannelOutWithExclusions tests that the span batch of the channel carries the deposit exclusions
+// if its first block is after the activation.
+func TestSpanChannelOutWithExclusions(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x543332))
+	chainID := big.NewInt(rng.Int63n(1000))
+	batch := RandomSingularBatch(rng, 1, chainID)
+
+	for _, activation := range []uint64{batch.Timestamp, batch.Timestamp + 1} {
+		cfg := rollupCfg
+		cfg.SpanBatchExclusionsTime = &activation
+		cout, err := NewSpanChannelOut(0, chainID, 128_000, Zlib, rollup.NewChainSpec(&cfg))
+		require.NoError(t, err)
+		require.NoError(t, cout.AddSingularBatch(batch, 0))
+		require.Equal(t, activation <= batch.Timestamp, cout.spanBatch.WithExclusions)
+
+		rawSpanBatch, err := cout.spanBatch.ToRawSpanBatch()
+		require.NoError(t, err)
+		expected := SpanBatchType
+		if activation <= batch.Timestamp {
+			expected = SpanBatchWithExclusionsType
+		}
+		require.Equal(t, expected, rawSpanBatch.GetBatchType())
+	}
+}
+
+// TestSpanChannelOutExclusionsActivation tests that a span channel that started before the activation of span batch
+// exclusions is cut at the activation, so that the blocks after the activation go into a channel with exclusions.
+func TestSpanChannelOutExclusionsActivation(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x543333))
+	chainID := big.NewInt(rng.Int63n(1000))
+	before := RandomSingularBatch(rng, 1, chainID)
+	after := RandomSingularBatch(rng, 1, chainID)
+	after.Timestamp = before.Timestamp + 2
+
+	cfg := rollupCfg
+	cfg.SpanBatchExclusionsTime = &after.Timestamp
+	cout, err := NewSpanChannelOut(0, chainID, 128_000, Zlib, rollup.NewChainSpec(&cfg))
+	require.NoError(t, err)
+	require.NoError(t, cout.AddSingularBatch(before, 0))
+	require.ErrorIs(t, cout.AddSingularBatch(after, 0), ErrSpanBatchExclusionsActivation)
+	require.ErrorIs(t, cout.FullErr(), ErrSpanBatchExclusionsActivation)
+	require.False(t, cout.spanBatch.WithExclusions)
+	require.Len(t, cout.spanBatch.Batches, 1, "batch after the activation is not added")
+	require.Greater(t, cout.ReadyBytes(), 0, "data before the activation is ready")
+	require.NoError(t, cout.Close())
+
+	// the data of the channel is the span batch before the activation
+	var buf bytes.Buffer
+	for {
+		_, err := cout.OutputFrame(&buf, 128_000)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+	frame := new(Frame)
+	require.NoError(t, frame.UnmarshalBinary(&buf))
+	require.True(t, frame.IsLast)
+	ch := NewChannel(frame.ID, eth.L1BlockRef{})
+	require.NoError(t, ch.AddFrame(*frame, eth.L1BlockRef{}))
+	readBatch, err := BatchReader(ch.Reader(), rollup.NewChainSpec(&cfg).MaxRLPBytesPerChannel(before.Timestamp), true)
+	require.NoError(t, err)
+	batchData, err := readBatch()
+	require.NoError(t, err)
+	require.Equal(t, SpanBatchType, int(batchData.GetBatchType()))
+
+	// the next channel starts with the block after the activation, with exclusions
+	require.NoError(t, cout.Reset())
+	require.NoError(t, cout.AddSingularBatch(after, 0))
+	require.True(t, cout.spanBatch.WithExclusions)
+}
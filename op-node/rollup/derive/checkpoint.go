@@ -0,0 +1,425 @@
+package derive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/zircuit-labs/l2-geth-public/common/hexutil"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/jsonutil"
+)
+
+// CheckpointVersion is the version of the pipeline checkpoint format.
+// Checkpoints of any other version are ignored.
+const CheckpointVersion = 1
+
+// ErrNotCheckpointable is returned when the pipeline is in a state that cannot be checkpointed,
+// e.g. while resetting, or after deriving attributes past the requested safe head.
+var ErrNotCheckpointable = errors.New("derivation pipeline cannot be checkpointed")
+
+// PipelineCheckpoint is a snapshot of the derivation pipeline stages, taken after the attributes
+// of the safe head were derived, and before the attributes of the next block are.
+//
+// Restoring a checkpoint that matches the safe head saves rewinding the L1 traversal by a channel
+// timeout, and re-reading all the channel data in that range: only the data of the current L1
+// traversal block is fetched again.
+type PipelineCheckpoint struct {
+	Version uint64 `json:"version"`
+	// L2Genesis identifies the chain the checkpoint was taken on.
+	L2Genesis eth.BlockID `json:"l2_genesis"`
+	// SafeHead is the L2 block that the pipeline continues to derive from.
+	SafeHead eth.L2BlockRef `json:"safe_head"`
+	// Origin is the L1 block the safe head was derived from.
+	Origin eth.L1BlockRef `json:"origin"`
+
+	Traversal  TraversalCheckpoint  `json:"traversal"`
+	Frames     []Frame              `json:"frames"`
+	Channels   []ChannelCheckpoint  `json:"channels"`
+	Reader     *ReaderCheckpoint    `json:"reader,omitempty"`
+	BatchQueue BatchQueueCheckpoint `json:"batch_queue"`
+}
+
+// TraversalCheckpoint is the state of the L1 traversal, and of the retrieval of its current block.
+type TraversalCheckpoint struct {
+	Block        eth.L1BlockRef   `json:"block"`
+	Done         bool             `json:"done"`
+	SystemConfig eth.SystemConfig `json:"system_config"`
+	// DataOpen is true if the data of the block is being read,
+	// after DataConsumed pieces of data were passed on to the frame queue.
+	DataOpen     bool   `json:"data_open"`
+	DataConsumed uint64 `json:"data_consumed"`
+}
+
+// ChannelCheckpoint is a channel buffered in the channel bank.
+type ChannelCheckpoint struct {
+	ID                      ChannelID      `json:"id"`
+	OpenBlock               eth.L1BlockRef `json:"open_block"`
+	HighestL1InclusionBlock eth.L1BlockRef `json:"highest_l1_inclusion_block"`
+	Size                    uint64         `json:"size"`
+	Closed                  bool           `json:"closed"`
+	HighestFrameNumber      uint16         `json:"highest_frame_number"`
+	EndFrameNumber          uint16         `json:"end_frame_number"`
+	Frames                  []Frame        `json:"frames"`
+}
+
+// ReaderCheckpoint is the channel that is being read into batches,
+// after BatchesRead batches were passed on to the batch queue.
+type ReaderCheckpoint struct {
+	Data        hexutil.Bytes `json:"data"`
+	MaxRLPBytes uint64        `json:"max_rlp_bytes"`
+	BatchesRead uint64        `json:"batches_read"`
+}
+
+// BatchQueueCheckpoint is the state of the batch queue. Batches are in their binary encoding.
+type BatchQueueCheckpoint struct {
+	Origin   eth.L1BlockRef   `json:"origin"`
+	L1Blocks []eth.L1BlockRef `json:"l1_blocks"`
+	Batches  []QueuedBatch    `json:"batches"`
+	NextSpan []hexutil.Bytes  `json:"next_span"`
+}
+
+type QueuedBatch struct {
+	L1InclusionBlock eth.L1BlockRef `json:"l1_inclusion_block"`
+	Data             hexutil.Bytes  `json:"data"`
+}
+
+// CheckpointStore persists the latest pipeline checkpoint.
+type CheckpointStore interface {
+	// LoadCheckpoint returns the latest checkpoint, or nil if there is none.
+	LoadCheckpoint() (*PipelineCheckpoint, error)
+	StoreCheckpoint(cp *PipelineCheckpoint) error
+}
+
+// FileCheckpointStore keeps the latest checkpoint in a JSON file, gzipped if the path ends in .gz.
+type FileCheckpointStore struct {
+	path string
+}
+
+var _ CheckpointStore = (*FileCheckpointStore)(nil)
+
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+func (s *FileCheckpointStore) LoadCheckpoint() (*PipelineCheckpoint, error) {
+	if _, err := os.Stat(s.path); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return jsonutil.LoadJSON[PipelineCheckpoint](s.path)
+}
+
+func (s *FileCheckpointStore) StoreCheckpoint(cp *PipelineCheckpoint) error {
+	return jsonutil.WriteJSON(s.path, cp, 0o644)
+}
+
+// Checkpoint snapshots the pipeline stages, to continue derivation after the given safe head.
+// It returns ErrNotCheckpointable if the pipeline is resetting, or if it already derived attributes past the safe head.
+func (dp *DerivationPipeline) Checkpoint(safe eth.L2BlockRef) (*PipelineCheckpoint, error) {
+	if !dp.engineIsReset || dp.resetting < len(dp.stages) {
+		return nil, fmt.Errorf("%w: pipeline is resetting", ErrNotCheckpointable)
+	}
+	if dp.derived != safe.Number {
+		return nil, fmt.Errorf("%w: derived up to block %d, not safe head %s", ErrNotCheckpointable, dp.derived, safe)
+	}
+	if dp.attrib.batch != nil {
+		return nil, fmt.Errorf("%w: attributes of the next block are being derived", ErrNotCheckpointable)
+	}
+	bq, err := checkpointBatchQueue(dp.batches)
+	if err != nil {
+		return nil, err
+	}
+	return &PipelineCheckpoint{
+		Version:    CheckpointVersion,
+		L2Genesis:  dp.rollupCfg.Genesis.L2,
+		SafeHead:   safe,
+		Origin:     dp.origin,
+		Traversal:  checkpointTraversal(dp.traversal, dp.retrieval),
+		Frames:     append([]Frame(nil), dp.frames.frames...),
+		Channels:   checkpointChannels(dp.bank),
+		Reader:     dp.reader.checkpoint(),
+		BatchQueue: bq,
+	}, nil
+}
+
+// SetCheckpoint provides a checkpoint to restore the pipeline from on the next reset.
+// The checkpoint is only used if it matches the safe head the pipeline is reset to,
+// and is discarded after the first reset either way.
+func (dp *DerivationPipeline) SetCheckpoint(cp *PipelineCheckpoint) {
+	dp.checkpoint = cp
+}
+
+// restoreCheckpoint restores the pipeline stages from the checkpoint, if it matches the safe head
+// and is still canonical on L1. It returns false if the pipeline has to be reset instead.
+func (dp *DerivationPipeline) restoreCheckpoint(ctx context.Context, safe eth.L2BlockRef) bool {
+	cp := dp.checkpoint
+	if cp == nil {
+		return false
+	}
+	dp.checkpoint = nil
+	if cp.Version != CheckpointVersion || cp.L2Genesis != dp.rollupCfg.Genesis.L2 {
+		dp.log.Warn("Ignoring incompatible derivation pipeline checkpoint", "version", cp.Version, "genesis", cp.L2Genesis)
+		return false
+	}
+	if cp.SafeHead != safe {
+		dp.log.Info("Derivation pipeline checkpoint does not match safe head", "checkpoint", cp.SafeHead, "safe", safe)
+		return false
+	}
+	if err := dp.verifyCheckpoint(ctx, cp); err != nil {
+		dp.log.Warn("Failed to verify derivation pipeline checkpoint against L1", "err", err)
+		return false
+	}
+	if err := dp.restoreStages(ctx, cp); err != nil {
+		dp.log.Warn("Failed to restore derivation pipeline checkpoint", "err", err)
+		return false
+	}
+	dp.origin = cp.Origin
+	dp.resetSysConfig = cp.Traversal.SystemConfig
+	dp.resetL2Safe = safe
+	dp.derived = safe.Number
+	dp.resetting = len(dp.stages)
+	dp.log.Info("Restored derivation pipeline from checkpoint", "safe", safe, "origin", cp.Origin, "traversal", cp.Traversal.Block,
+		"channels", len(cp.Channels), "batches", len(cp.BatchQueue.Batches))
+	return true
+}
+
+// verifyCheckpoint checks that all the L1 blocks the checkpoint refers to are canonical.
+func (dp *DerivationPipeline) verifyCheckpoint(ctx context.Context, cp *PipelineCheckpoint) error {
+	if cp.SafeHead.L1Origin.Number > cp.Traversal.Block.Number || cp.Origin.Number > cp.Traversal.Block.Number {
+		return fmt.Errorf("L1 traversal %s is behind origin %s of safe head %s", cp.Traversal.Block, cp.Origin, cp.SafeHead)
+	}
+	refs := []eth.BlockID{cp.SafeHead.L1Origin, cp.Origin.ID(), cp.Traversal.Block.ID(), cp.BatchQueue.Origin.ID()}
+	for _, ref := range cp.BatchQueue.L1Blocks {
+		refs = append(refs, ref.ID())
+	}
+	for _, b := range cp.BatchQueue.Batches {
+		refs = append(refs, b.L1InclusionBlock.ID())
+	}
+	for _, ch := range cp.Channels {
+		refs = append(refs, ch.OpenBlock.ID(), ch.HighestL1InclusionBlock.ID())
+	}
+	verified := make(map[eth.BlockID]struct{})
+	for _, id := range refs {
+		if _, ok := verified[id]; ok {
+			continue
+		}
+		canonical, err := dp.l1Fetcher.L1BlockRefByNumber(ctx, id.Number)
+		if err != nil {
+			return fmt.Errorf("failed to fetch L1 block %d: %w", id.Number, err)
+		}
+		if canonical.Hash != id.Hash {
+			return fmt.Errorf("L1 block %s is not canonical, expected %s", id, canonical)
+		}
+		verified[id] = struct{}{}
+	}
+	return nil
+}
+
+func (dp *DerivationPipeline) restoreStages(ctx context.Context, cp *PipelineCheckpoint) error {
+	// Decode and open everything first, so the stages are left untouched if the checkpoint is invalid.
+	batches := make([]*BatchWithL1InclusionBlock, 0, len(cp.BatchQueue.Batches))
+	for i, b := range cp.BatchQueue.Batches {
+		batch, err := decodeCheckpointBatch(dp.rollupCfg, b.Data)
+		if err != nil {
+			return fmt.Errorf("invalid queued batch %d: %w", i, err)
+		}
+		batches = append(batches, &BatchWithL1InclusionBlock{Batch: batch, L1InclusionBlock: b.L1InclusionBlock})
+	}
+	nextSpan := make([]*SingularBatch, 0, len(cp.BatchQueue.NextSpan))
+	for i, data := range cp.BatchQueue.NextSpan {
+		batch, err := decodeCheckpointBatch(dp.rollupCfg, data)
+		if err != nil {
+			return fmt.Errorf("invalid span batch element %d: %w", i, err)
+		}
+		singular, ok := batch.AsSingularBatch()
+		if !ok {
+			return fmt.Errorf("span batch element %d is not a singular batch", i)
+		}
+		nextSpan = append(nextSpan, singular)
+	}
+
+	tr := cp.Traversal
+	var datas DataIter
+	if tr.DataOpen {
+		var err error
+		if datas, err = dp.retrieval.dataSrc.OpenData(ctx, tr.Block, tr.SystemConfig.BatcherAddr); err != nil {
+			return fmt.Errorf("failed to open data of L1 block %s: %w", tr.Block, err)
+		}
+		// skip the data that was already passed on to the frame queue
+		for i := uint64(0); i < tr.DataConsumed; i++ {
+			if _, err := datas.Next(ctx); err != nil {
+				return fmt.Errorf("failed to skip data %d of L1 block %s: %w", i, tr.Block, err)
+			}
+		}
+	}
+	var reader func() (*BatchData, error)
+	if r := cp.Reader; r != nil {
+		var err error
+		if reader, err = r.open(); err != nil {
+			return err
+		}
+	}
+
+	dp.traversal.setBlock(tr.Block)
+	dp.traversal.done = tr.Done
+	dp.traversal.sysCfg = tr.SystemConfig
+
+	dp.retrieval.datas = datas
+	dp.retrieval.consumed = tr.DataConsumed
+
+	dp.frames.frames = append(dp.frames.frames[:0], cp.Frames...)
+
+	dp.bank.channels = make(map[ChannelID]*Channel, len(cp.Channels))
+	dp.bank.channelQueue = make([]ChannelID, 0, len(cp.Channels))
+	for _, c := range cp.Channels {
+		ch := NewChannel(c.ID, c.OpenBlock)
+		ch.highestL1InclusionBlock = c.HighestL1InclusionBlock
+		ch.size = c.Size
+		ch.closed = c.Closed
+		ch.highestFrameNumber = c.HighestFrameNumber
+		ch.endFrameNumber = c.EndFrameNumber
+		for _, f := range c.Frames {
+			ch.inputs[uint64(f.FrameNumber)] = f
+		}
+		dp.bank.channels[c.ID] = ch
+		dp.bank.channelQueue = append(dp.bank.channelQueue, c.ID)
+	}
+
+	dp.reader.NextChannel()
+	if r := cp.Reader; r != nil {
+		dp.reader.nextBatchFn = reader
+		dp.reader.data = r.Data
+		dp.reader.maxRLPBytes = r.MaxRLPBytes
+		dp.reader.batchesRead = r.BatchesRead
+	}
+
+	dp.batches.origin = cp.BatchQueue.Origin
+	dp.batches.l1Blocks = append([]eth.L1BlockRef(nil), cp.BatchQueue.L1Blocks...)
+	dp.batches.batches = batches
+	dp.batches.nextSpan = nextSpan
+
+	dp.attrib.batch = nil
+	dp.attrib.isLastInSpan = false
+	return nil
+}
+
+func checkpointTraversal(tr *L1Traversal, retrieval *L1Retrieval) TraversalCheckpoint {
+	return TraversalCheckpoint{
+		Block:        tr.block,
+		Done:         tr.done,
+		SystemConfig: tr.sysCfg,
+		DataOpen:     retrieval.datas != nil,
+		DataConsumed: retrieval.consumed,
+	}
+}
+
+func checkpointChannels(bank *ChannelBank) []ChannelCheckpoint {
+	out := make([]ChannelCheckpoint, 0, len(bank.channelQueue))
+	for _, id := range bank.channelQueue {
+		ch := bank.channels[id]
+		c := ChannelCheckpoint{
+			ID:                      id,
+			OpenBlock:               ch.openBlock,
+			HighestL1InclusionBlock: ch.highestL1InclusionBlock,
+			Size:                    ch.size,
+			Closed:                  ch.closed,
+			HighestFrameNumber:      ch.highestFrameNumber,
+			EndFrameNumber:          ch.endFrameNumber,
+			Frames:                  make([]Frame, 0, len(ch.inputs)),
+		}
+		for i := uint64(0); i <= uint64(ch.highestFrameNumber); i++ {
+			if f, ok := ch.inputs[i]; ok {
+				c.Frames = append(c.Frames, f)
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func checkpointBatchQueue(bq *BatchQueue) (BatchQueueCheckpoint, error) {
+	out := BatchQueueCheckpoint{
+		Origin:   bq.origin,
+		L1Blocks: append([]eth.L1BlockRef(nil), bq.l1Blocks...),
+		Batches:  make([]QueuedBatch, 0, len(bq.batches)),
+		NextSpan: make([]hexutil.Bytes, 0, len(bq.nextSpan)),
+	}
+	for _, b := range bq.batches {
+		data, err := encodeCheckpointBatch(b.Batch)
+		if err != nil {
+			return BatchQueueCheckpoint{}, fmt.Errorf("failed to encode queued batch: %w", err)
+		}
+		out.Batches = append(out.Batches, QueuedBatch{L1InclusionBlock: b.L1InclusionBlock, Data: data})
+	}
+	for _, b := range bq.nextSpan {
+		data, err := encodeCheckpointBatch(b)
+		if err != nil {
+			return BatchQueueCheckpoint{}, fmt.Errorf("failed to encode span batch element: %w", err)
+		}
+		out.NextSpan = append(out.NextSpan, data)
+	}
+	return out, nil
+}
+
+func encodeCheckpointBatch(batch Batch) ([]byte, error) {
+	if singular, ok := batch.AsSingularBatch(); ok {
+		return NewBatchData(singular).MarshalBinary()
+	}
+	if span, ok := batch.AsSpanBatch(); ok {
+		// span batches read from L1 are encoded as they were read, the caches ToRawSpanBatch uses
+		// are only filled when singular batches are appended.
+		raw := span.raw
+		if raw == nil {
+			var err error
+			if raw, err = span.ToRawSpanBatch(); err != nil {
+				return nil, err
+			}
+		}
+		return NewBatchData(raw).MarshalBinary()
+	}
+	return nil, fmt.Errorf("unrecognized batch type: %d", batch.GetBatchType())
+}
+
+func decodeCheckpointBatch(cfg *rollup.Config, data []byte) (Batch, error) {
+	var batchData BatchData
+	if err := batchData.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	switch batchData.GetBatchType() {
+	case SingularBatchType:
+		return GetSingularBatch(&batchData)
+	case SpanBatchType, SpanBatchWithExclusionsType:
+		return DeriveSpanBatch(&batchData, cfg.BlockTime, cfg.Genesis.L2Time, cfg.L2ChainID)
+	default:
+		return nil, fmt.Errorf("unrecognized batch type: %d", batchData.GetBatchType())
+	}
+}
+
+func (cr *ChannelInReader) checkpoint() *ReaderCheckpoint {
+	if cr.nextBatchFn == nil {
+		return nil
+	}
+	return &ReaderCheckpoint{
+		Data:        cr.data,
+		MaxRLPBytes: cr.maxRLPBytes,
+		BatchesRead: cr.batchesRead,
+	}
+}
+
+// open continues reading the checkpointed channel, after the batches that were already read.
+func (r *ReaderCheckpoint) open() (func() (*BatchData, error), error) {
+	f, err := BatchReader(bytes.NewBuffer(r.Data), r.MaxRLPBytes, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpointed channel: %w", err)
+	}
+	for i := uint64(0); i < r.BatchesRead; i++ {
+		if _, err := f(); err != nil {
+			return nil, fmt.Errorf("failed to skip batch %d of checkpointed channel: %w", i, err)
+		}
+	}
+	return f, nil
+}
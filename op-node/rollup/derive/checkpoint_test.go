@@ -0,0 +1,254 @@
+package derive
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"math/big"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zircuit-labs/l2-geth-public/common/hexutil"
+	"github.com/zircuit-labs/l2-geth-public/log"
+	"github.com/zircuit-labs/l2-geth-public/rlp"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testlog"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testutils"
+)
+
+// canonicalL1 serves L1 blocks by number, to verify checkpoints against.
+type canonicalL1 struct {
+	L1Fetcher
+	blocks map[uint64]eth.L1BlockRef
+}
+
+func (c *canonicalL1) L1BlockRefByNumber(_ context.Context, num uint64) (eth.L1BlockRef, error) {
+	ref, ok := c.blocks[num]
+	if !ok {
+		return eth.L1BlockRef{}, errors.New("not found")
+	}
+	return ref, nil
+}
+
+func checkpointTestConfig() *rollup.Config {
+	return &rollup.Config{
+		Genesis:       rollup.Genesis{L2: eth.BlockID{Number: 0, Hash: [32]byte{0xaa}}},
+		BlockTime:     2,
+		SeqWindowSize: 10,
+		L2ChainID:     big.NewInt(1234),
+	}
+}
+
+func newCheckpointTestPipeline(t *testing.T, cfg *rollup.Config, l1 *canonicalL1) *DerivationPipeline {
+	return NewDerivationPipeline(testlog.Logger(t, log.LevelDebug), cfg, l1, nil, nil, &testutils.TestDerivationMetrics{})
+}
+
+func channelData(t *testing.T, batches ...*SingularBatch) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	for _, b := range batches {
+		require.NoError(t, rlp.Encode(w, NewBatchData(b)))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestPipelineCheckpointRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	cfg := checkpointTestConfig()
+	a := testutils.RandomBlockRef(rng)
+	b := testutils.NextRandomRef(rng, a)
+	c := testutils.NextRandomRef(rng, b)
+	l1 := &canonicalL1{blocks: map[uint64]eth.L1BlockRef{a.Number: a, b.Number: b, c.Number: c}}
+	safe := testutils.RandomL2BlockRef(rng)
+	safe.L1Origin = a.ID()
+
+	dp := newCheckpointTestPipeline(t, cfg, l1)
+	dp.engineIsReset = true
+	dp.resetting = len(dp.stages)
+	dp.derived = safe.Number
+	dp.origin = a
+
+	dp.traversal.block = c
+	dp.traversal.done = true
+	dp.traversal.sysCfg = eth.SystemConfig{BatcherAddr: testutils.RandomAddress(rng)}
+
+	frames := []Frame{
+		{ID: ChannelID{1}, FrameNumber: 0, Data: testutils.RandomData(rng, 20)},
+		{ID: ChannelID{1}, FrameNumber: 2, Data: testutils.RandomData(rng, 20), IsLast: true},
+		{ID: ChannelID{2}, FrameNumber: 0, Data: testutils.RandomData(rng, 20)},
+	}
+	dp.frames.frames = []Frame{{ID: ChannelID{3}, Data: testutils.RandomData(rng, 10)}}
+	dp.bank.channels[ChannelID{1}] = NewChannel(ChannelID{1}, b)
+	require.NoError(t, dp.bank.channels[ChannelID{1}].AddFrame(frames[0], b))
+	require.NoError(t, dp.bank.channels[ChannelID{1}].AddFrame(frames[1], c))
+	dp.bank.channels[ChannelID{2}] = NewChannel(ChannelID{2}, c)
+	require.NoError(t, dp.bank.channels[ChannelID{2}].AddFrame(frames[2], c))
+	dp.bank.channelQueue = []ChannelID{{1}, {2}}
+
+	readBatches := []*SingularBatch{RandomSingularBatch(rng, 2, cfg.L2ChainID), RandomSingularBatch(rng, 3, cfg.L2ChainID)}
+	require.NoError(t, dp.reader.WriteChannel(channelData(t, readBatches...)))
+	_, err := dp.reader.NextBatch(context.Background())
+	require.NoError(t, err)
+
+	queued := RandomSingularBatch(rng, 1, cfg.L2ChainID)
+	nextSpan := RandomSingularBatch(rng, 1, cfg.L2ChainID)
+	dp.batches.origin = a
+	dp.batches.l1Blocks = []eth.L1BlockRef{a, b}
+	dp.batches.batches = []*BatchWithL1InclusionBlock{{Batch: queued, L1InclusionBlock: b}}
+	dp.batches.nextSpan = []*SingularBatch{nextSpan}
+
+	cp, err := dp.Checkpoint(safe)
+	require.NoError(t, err)
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "checkpoint.json.gz"))
+	loaded, err := store.LoadCheckpoint()
+	require.NoError(t, err)
+	require.Nil(t, loaded, "no checkpoint yet")
+	require.NoError(t, store.StoreCheckpoint(cp))
+	loaded, err = store.LoadCheckpoint()
+	require.NoError(t, err)
+
+	restored := newCheckpointTestPipeline(t, cfg, l1)
+	restored.ConfirmEngineReset()
+	restored.SetCheckpoint(loaded)
+	require.True(t, restored.restoreCheckpoint(context.Background(), safe))
+	require.Nil(t, restored.checkpoint, "checkpoint is consumed")
+	require.Equal(t, len(restored.stages), restored.resetting)
+	require.Equal(t, a, restored.Origin())
+
+	require.Equal(t, dp.traversal.block, restored.traversal.block)
+	require.Equal(t, dp.traversal.done, restored.traversal.done)
+	require.Equal(t, dp.traversal.sysCfg, restored.traversal.sysCfg)
+	require.Nil(t, restored.retrieval.datas)
+	require.Equal(t, dp.frames.frames, restored.frames.frames)
+	require.Equal(t, dp.bank.channelQueue, restored.bank.channelQueue)
+	require.Equal(t, dp.bank.channels, restored.bank.channels)
+	require.Equal(t, dp.batches.origin, restored.batches.origin)
+	require.Equal(t, dp.batches.l1Blocks, restored.batches.l1Blocks)
+	require.Equal(t, dp.batches.batches, restored.batches.batches)
+	require.Equal(t, dp.batches.nextSpan, restored.batches.nextSpan)
+
+	// the restored reader continues after the batches that were already read
+	next, err := restored.reader.NextBatch(context.Background())
+	require.NoError(t, err)
+	singular, ok := next.AsSingularBatch()
+	require.True(t, ok)
+	require.Equal(t, readBatches[1], singular)
+}
+
+// TestPipelineCheckpointSpanBatch checkpoints a queued span batch as it is decoded from L1,
+// without the caches of a span batch built from singular batches.
+func TestPipelineCheckpointSpanBatch(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	cfg := checkpointTestConfig()
+	a := testutils.RandomBlockRef(rng)
+	b := testutils.NextRandomRef(rng, a)
+	l1 := &canonicalL1{blocks: map[uint64]eth.L1BlockRef{a.Number: a, b.Number: b}}
+	safe := testutils.RandomL2BlockRef(rng)
+	safe.L1Origin = a.ID()
+
+	data, err := NewBatchData(RandomRawSpanBatch(rng, cfg.L2ChainID)).MarshalBinary()
+	require.NoError(t, err)
+	var batchData BatchData
+	require.NoError(t, batchData.UnmarshalBinary(data))
+	span, err := DeriveSpanBatch(&batchData, cfg.BlockTime, cfg.Genesis.L2Time, cfg.L2ChainID)
+	require.NoError(t, err)
+
+	dp := newCheckpointTestPipeline(t, cfg, l1)
+	dp.engineIsReset = true
+	dp.resetting = len(dp.stages)
+	dp.derived = safe.Number
+	dp.origin = a
+	dp.traversal.block = b
+	dp.batches.origin = a
+	dp.batches.l1Blocks = []eth.L1BlockRef{a}
+	dp.batches.batches = []*BatchWithL1InclusionBlock{{Batch: span, L1InclusionBlock: b}}
+
+	cp, err := dp.Checkpoint(safe)
+	require.NoError(t, err)
+	require.Len(t, cp.BatchQueue.Batches, 1)
+	require.Equal(t, hexutil.Bytes(data), cp.BatchQueue.Batches[0].Data, "encoded as read from L1")
+
+	restored := newCheckpointTestPipeline(t, cfg, l1)
+	restored.ConfirmEngineReset()
+	restored.SetCheckpoint(cp)
+	require.True(t, restored.restoreCheckpoint(context.Background(), safe))
+	require.Len(t, restored.batches.batches, 1)
+	restoredSpan, ok := restored.batches.batches[0].AsSpanBatch()
+	require.True(t, ok)
+	require.Equal(t, span.Batches, restoredSpan.Batches)
+	require.Equal(t, span.ParentCheck, restoredSpan.ParentCheck)
+	require.Equal(t, span.L1OriginCheck, restoredSpan.L1OriginCheck)
+	require.Equal(t, b, restored.batches.batches[0].L1InclusionBlock)
+}
+
+func TestPipelineCheckpointRestoreRejected(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	cfg := checkpointTestConfig()
+	a := testutils.RandomBlockRef(rng)
+	b := testutils.NextRandomRef(rng, a)
+	l1 := &canonicalL1{blocks: map[uint64]eth.L1BlockRef{a.Number: a, b.Number: b}}
+	safe := testutils.RandomL2BlockRef(rng)
+	safe.L1Origin = a.ID()
+
+	dp := newCheckpointTestPipeline(t, cfg, l1)
+	dp.engineIsReset = true
+	dp.resetting = len(dp.stages)
+	dp.derived = safe.Number
+	dp.origin = a
+	dp.traversal.block = b
+	dp.batches.origin = a
+	dp.batches.l1Blocks = []eth.L1BlockRef{a}
+	cp, err := dp.Checkpoint(safe)
+	require.NoError(t, err)
+
+	t.Run("OtherSafeHead", func(t *testing.T) {
+		restored := newCheckpointTestPipeline(t, cfg, l1)
+		restored.SetCheckpoint(cp)
+		other := safe
+		other.Number++
+		require.False(t, restored.restoreCheckpoint(context.Background(), other))
+		require.Nil(t, restored.checkpoint, "checkpoint is only tried once")
+		require.Zero(t, restored.resetting)
+	})
+	t.Run("OtherChain", func(t *testing.T) {
+		other := *cfg
+		other.Genesis.L2 = eth.BlockID{Number: 0, Hash: [32]byte{0xbb}}
+		restored := newCheckpointTestPipeline(t, &other, l1)
+		restored.SetCheckpoint(cp)
+		require.False(t, restored.restoreCheckpoint(context.Background(), safe))
+	})
+	t.Run("L1Reorg", func(t *testing.T) {
+		reorged := testutils.NextRandomRef(rng, a)
+		restored := newCheckpointTestPipeline(t, cfg, &canonicalL1{blocks: map[uint64]eth.L1BlockRef{a.Number: a, b.Number: reorged}})
+		restored.SetCheckpoint(cp)
+		require.False(t, restored.restoreCheckpoint(context.Background(), safe))
+		require.Zero(t, restored.resetting)
+	})
+}
+
+func TestPipelineNotCheckpointable(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	cfg := checkpointTestConfig()
+	dp := newCheckpointTestPipeline(t, cfg, &canonicalL1{})
+	safe := testutils.RandomL2BlockRef(rng)
+
+	_, err := dp.Checkpoint(safe)
+	require.ErrorIs(t, err, ErrNotCheckpointable, "resetting")
+
+	dp.engineIsReset = true
+	dp.resetting = len(dp.stages)
+	dp.derived = safe.Number + 1
+	_, err = dp.Checkpoint(safe)
+	require.ErrorIs(t, err, ErrNotCheckpointable, "derived past the safe head")
+
+	dp.derived = safe.Number
+	dp.attrib.batch = RandomSingularBatch(rng, 1, cfg.L2ChainID)
+	_, err = dp.Checkpoint(safe)
+	require.ErrorIs(t, err, ErrNotCheckpointable, "deriving attributes")
+}
@@ -36,6 +36,8 @@ type DataSourceFactory struct {
 	fetcher      L1Fetcher
 	blobsFetcher L1BlobsFetcher
 	ecotoneTime  *uint64
+
+	audit DerivationAuditor // records the batcher transactions, nil if disabled
 }
 
 func NewDataSourceFactory(log log.Logger, cfg *rollup.Config, fetcher L1Fetcher, blobsFetcher L1BlobsFetcher) *DataSourceFactory {
@@ -57,13 +59,17 @@ func (ds *DataSourceFactory) OpenData(ctx context.Context, ref eth.L1BlockRef, b
 	// Creates a data iterator from blob or calldata source so we can forward it to the plasma source
 	// if enabled as it still requires an L1 data source for fetching input commmitments.
 	var src DataIter
+	var fetcher L1TransactionFetcher = ds.fetcher
+	if ds.audit != nil {
+		fetcher = &auditingTxFetcher{L1TransactionFetcher: ds.fetcher, audit: ds.audit, dsCfg: ds.dsCfg, ref: ref, batcherAddr: batcherAddr}
+	}
 	if ds.ecotoneTime != nil && ref.Time >= *ds.ecotoneTime {
 		if ds.blobsFetcher == nil {
 			return nil, fmt.Errorf("ecotone upgrade active but beacon endpoint not configured")
 		}
-		src = NewBlobDataSource(ctx, ds.log, ds.dsCfg, ds.fetcher, ds.blobsFetcher, ref, batcherAddr)
+		src = NewBlobDataSource(ctx, ds.log, ds.dsCfg, fetcher, ds.blobsFetcher, ref, batcherAddr)
 	} else {
-		src = NewCalldataSource(ctx, ds.log, ds.dsCfg, ds.fetcher, ref, batcherAddr)
+		src = NewCalldataSource(ctx, ds.log, ds.dsCfg, fetcher, ref, batcherAddr)
 	}
 	return src, nil
 }
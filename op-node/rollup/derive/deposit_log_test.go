@@ -133,3 +133,54 @@ func TestDeriveUserDeposits(t *testing.T) {
 		})
 	}
 }
+
+func TestTraceDeposits(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	blockHash := testutils.RandomL1Hash(rng)
+	receipts, deposits, err := makeReceipts(rng, blockHash, MockDepositContractAddr, []receiptData{
+		{true, []bool{true}},
+		{false, []bool{true}},
+		{true, []bool{true, false, true}},
+		{true, []bool{false}},
+	})
+	require.NoError(t, err)
+	for i, rec := range receipts {
+		rec.TxHash = l1common.Hash{byte(i + 1)}
+	}
+	// the first deposit of the third transaction is the second user deposit of the block
+	exclusions := EmptyBitmap(8)
+	exclusions.Set(2)
+	l1Info := &L1BlockInfo{L1Info: &types.L1Info{BlockHash: common.Hash(blockHash), DepositExclusions: exclusions}}
+
+	traced, err := TraceDeposits(receipts, receipts[2].TxHash, MockDepositContractAddr, l1Info)
+	require.NoError(t, err)
+	require.Equal(t, []TracedDeposit{
+		{Index: 1, SourceHash: deposits[1].SourceHash, L2TxHash: types.NewTx(deposits[1]).Hash(), Status: DepositExcluded},
+		{Index: 2, SourceHash: deposits[2].SourceHash, L2TxHash: types.NewTx(deposits[2]).Hash(), Status: DepositIncluded},
+	}, traced)
+
+	traced, err = TraceDeposits(receipts, receipts[0].TxHash, MockDepositContractAddr, l1Info)
+	require.NoError(t, err)
+	require.Equal(t, []TracedDeposit{
+		{Index: 0, SourceHash: deposits[0].SourceHash, L2TxHash: types.NewTx(deposits[0]).Hash(), Status: DepositIncluded},
+	}, traced)
+
+	// without an L2 block for the epoch, the deposits are pending
+	traced, err = TraceDeposits(receipts, receipts[0].TxHash, MockDepositContractAddr, nil)
+	require.NoError(t, err)
+	require.Len(t, traced, 1)
+	require.Equal(t, DepositPending, traced[0].Status)
+
+	// failed transactions and transactions without deposit logs have no deposits
+	for _, i := range []int{1, 3} {
+		traced, err = TraceDeposits(receipts, receipts[i].TxHash, MockDepositContractAddr, l1Info)
+		require.NoError(t, err)
+		require.Empty(t, traced)
+	}
+
+	_, err = TraceDeposits(receipts, l1common.Hash{0xff}, MockDepositContractAddr, l1Info)
+	require.Error(t, err)
+	otherEpoch := &L1BlockInfo{L1Info: &types.L1Info{BlockHash: common.Hash{0x01}}}
+	_, err = TraceDeposits(receipts, receipts[0].TxHash, MockDepositContractAddr, otherEpoch)
+	require.Error(t, err)
+}
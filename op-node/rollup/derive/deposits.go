@@ -56,3 +56,85 @@ func DeriveDeposits(receipts []*l1types.Receipt, depositContractAddr common.Addr
 	}
 	return encodedTxs, result
 }
+
+// DepositStatus is the outcome on L2 of a user deposit.
+type DepositStatus string
+
+const (
+	// DepositIncluded deposits are part of the first L2 block of the epoch of their L1 block.
+	DepositIncluded DepositStatus = "included"
+	// DepositExcluded deposits are skipped by the deposit exclusions of the epoch of their L1 block.
+	DepositExcluded DepositStatus = "excluded"
+	// DepositPending deposits are in an L1 block that no L2 block has as L1 origin yet.
+	DepositPending DepositStatus = "pending"
+)
+
+// TracedDeposit is a user deposit of an L1 transaction, and its outcome on L2.
+type TracedDeposit struct {
+	// Index is the position of the deposit among the user deposits of its L1 block.
+	// The deposit is excluded if bit Index+1 of the deposit exclusions is set, bit 0 is the L1 info deposit.
+	Index      int           `json:"index"`
+	SourceHash common.Hash   `json:"sourceHash"`
+	L2TxHash   common.Hash   `json:"l2TxHash"`
+	Status     DepositStatus `json:"status"`
+}
+
+// TraceDeposits finds the user deposits of an L1 transaction, given the receipts of its L1 block,
+// and determines whether the epoch of the L1 block includes or excludes them.
+// The L1 info is the one of the first L2 block of the epoch, which carries the deposit exclusions of the epoch,
+// or nil if there is no such L2 block yet, in which case the deposits are pending.
+func TraceDeposits(receipts []*l1types.Receipt, txHash l1common.Hash, depositContractAddr common.Address, l1Info *L1BlockInfo) ([]TracedDeposit, error) {
+	var txReceipt *l1types.Receipt
+	for _, rec := range receipts {
+		if rec.TxHash == txHash {
+			txReceipt = rec
+			break
+		}
+	}
+	if txReceipt == nil {
+		return nil, fmt.Errorf("transaction %s is not in the L1 block receipts", txHash)
+	}
+	if l1Info != nil && (l1Info.BlockHash != common.Hash(txReceipt.BlockHash) || l1Info.SequenceNumber != 0) {
+		return nil, fmt.Errorf("L1 info of L1 block %s, sequence number %d, does not start the epoch of L1 block %s",
+			l1Info.BlockHash, l1Info.SequenceNumber, txReceipt.BlockHash)
+	}
+	// deposits of failed transactions are never derived
+	if txReceipt.Status != types.ReceiptStatusSuccessful {
+		return nil, nil
+	}
+	// the source hash identifies the deposits of the transaction among all deposits of the L1 block
+	sources := make(map[common.Hash]struct{})
+	for _, log := range txReceipt.Logs {
+		if log.Address == l1common.Address(depositContractAddr) && len(log.Topics) > 0 && log.Topics[0] == l1common.Hash(DepositEventABIHash) {
+			source := UserDepositSource{L1BlockHash: log.BlockHash, LogIndex: uint64(log.Index)}
+			sources[source.SourceHash()] = struct{}{}
+		}
+	}
+	if len(sources) == 0 {
+		return nil, nil
+	}
+	userDeposits, err := UserDeposits(receipts, depositContractAddr)
+	if err != nil {
+		return nil, err
+	}
+	var traced []TracedDeposit
+	for i, dep := range userDeposits {
+		if _, ok := sources[dep.SourceHash]; !ok {
+			continue
+		}
+		status := DepositPending
+		if l1Info != nil {
+			status = DepositIncluded
+			if l1Info.DepositExclusions != nil && l1Info.DepositExclusions.Test(i+1) {
+				status = DepositExcluded
+			}
+		}
+		traced = append(traced, TracedDeposit{
+			Index:      i,
+			SourceHash: dep.SourceHash,
+			L2TxHash:   types.NewTx(dep).Hash(),
+			Status:     status,
+		})
+	}
+	return traced, nil
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/zircuit-labs/l2-geth-public/accounts/abi"
 	"github.com/zircuit-labs/l2-geth-public/accounts/abi/bind"
 	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/common/hexutil"
 	"github.com/zircuit-labs/l2-geth-public/core/types"
 	l1eth "github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/l1/eth"
 
@@ -326,3 +327,63 @@ func FuzzUnmarshallLogEvent(f *testing.F) {
 		}
 	})
 }
+
+// FuzzSpanBatchDepositExclusionsRoundTrip checks that deposit exclusions bitmaps round trip
+// through the span batch encoding of deposit exclusions
+func FuzzSpanBatchDepositExclusionsRoundTrip(f *testing.F) {
+	f.Add(uint16(0), []byte{})
+	f.Add(uint16(8), []byte{0x01, 0x80})
+	f.Fuzz(func(t *testing.T, length uint16, indices []byte) {
+		bitmap := EmptyBitmap(int(length))
+		for _, i := range indices {
+			bitmap.Set(int(i))
+		}
+		in := bitmap.MustBytes()
+
+		var sb RawSpanBatch
+		sb.blockCount = 1
+		sb.originBits = big.NewInt(1)
+		sb.withExclusions = true
+		sb.depositExclusions = []hexutil.Bytes{in}
+		var buf bytes.Buffer
+		if err := sb.encodeDepositExclusions(&buf); err != nil {
+			t.Fatalf("Failed to encode deposit exclusions: %v", err)
+		}
+		out := RawSpanBatch{spanBatchPayload: spanBatchPayload{blockCount: 1, originBits: big.NewInt(1), withExclusions: true}}
+		r := bytes.NewReader(buf.Bytes())
+		if err := out.decodeDepositExclusions(r); err != nil {
+			t.Fatalf("Failed to decode deposit exclusions: %v", err)
+		}
+		if r.Len() != 0 {
+			t.Fatalf("Deposit exclusions were not fully decoded, %d bytes left", r.Len())
+		}
+		if !bytes.Equal(in, out.depositExclusions[0]) {
+			t.Fatalf("The deposit exclusions did not round trip correctly. in: %x. out: %x", in, out.depositExclusions[0])
+		}
+	})
+}
+
+// FuzzSpanBatchWithExclusionsDecode checks that decoding arbitrary span batches with exclusions never panics,
+// and that decoded span batches round trip
+func FuzzSpanBatchWithExclusionsDecode(f *testing.F) {
+	f.Add([]byte{0x00, 0x00})
+	f.Add([]byte{0x01, 0x01, 0x03, 0x01, 0x00})
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		var batchData BatchData
+		data := append([]byte{SpanBatchWithExclusionsType}, payload...)
+		if err := batchData.UnmarshalBinary(data); err != nil {
+			return
+		}
+		enc, err := batchData.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to encode decoded span batch: %v", err)
+		}
+		var batchData2 BatchData
+		if err := batchData2.UnmarshalBinary(enc); err != nil {
+			t.Fatalf("Failed to decode encoded span batch: %v", err)
+		}
+		if !cmp.Equal(batchData.inner, batchData2.inner, cmp.AllowUnexported(RawSpanBatch{}, spanBatchPrefix{}, spanBatchPayload{}, spanBatchTxs{}), cmp.Comparer(testutils.BigEqual)) {
+			t.Fatalf("The span batch did not round trip correctly. in: %x. out: %x", data, enc)
+		}
+	})
+}
@@ -0,0 +1,267 @@
+package derive
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	l1types "github.com/ethereum/go-ethereum/core/types"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+)
+
+// prefetchTimeout bounds the time spent prefetching a single L1 block.
+const prefetchTimeout = 30 * time.Second
+
+type L1PrefetchMetrics interface {
+	RecordL1Prefetch(kind string, hit bool)
+}
+
+// prefetchedBlock is an L1 block of which the receipts and transactions were fetched ahead of use.
+type prefetchedBlock struct {
+	ref eth.L1BlockRef
+	// blobs sent to the batch inbox, by versioned hash. Dropped once the block is traversed.
+	blobs map[common.Hash]*eth.Blob
+}
+
+// L1Prefetcher is a bounded look-ahead in front of an L1Fetcher and L1BlobsFetcher.
+//
+// It is only to be used by the derivation pipeline, as its L1OriginListener: every time the
+// L1 traversal moves to a new block, the receipts, transactions and batch inbox blobs of the
+// next blocks are fetched concurrently, so catching up with L1 is not dominated
+// by sequential L1 round-trips. Receipts and transactions are kept in the caches of the
+// underlying L1 client; blobs are held by the prefetcher until their block is traversed.
+// Prefetched blocks that do not link up with the canonical chain are discarded.
+type L1Prefetcher struct {
+	L1Fetcher
+	blobs L1BlobsFetcher
+
+	log     log.Logger
+	inbox   common.Address
+	depth   uint64
+	retain  uint64
+	metrics L1PrefetchMetrics
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	blocks   map[common.Hash]*prefetchedBlock
+	numbers  map[uint64]common.Hash
+	inflight map[uint64]struct{}
+}
+
+var (
+	_ L1Fetcher        = (*L1Prefetcher)(nil)
+	_ L1BlobsFetcher   = (*L1Prefetcher)(nil)
+	_ L1OriginListener = (*L1Prefetcher)(nil)
+)
+
+// NewL1Prefetcher creates a prefetcher that looks ahead depth L1 blocks.
+func NewL1Prefetcher(log log.Logger, cfg *rollup.Config, l1 L1Fetcher, blobs L1BlobsFetcher, depth uint64, metrics L1PrefetchMetrics) *L1Prefetcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &L1Prefetcher{
+		L1Fetcher: l1,
+		blobs:     blobs,
+		log:       log,
+		inbox:     cfg.BatchInboxAddress,
+		depth:     depth,
+		// The L1 origin of the L2 chain lags behind the L1 traversal by up to a sequencing window,
+		// the receipts of those blocks are requested again when building the L2 epochs.
+		retain:   cfg.SeqWindowSize,
+		metrics:  metrics,
+		ctx:      ctx,
+		cancel:   cancel,
+		blocks:   make(map[common.Hash]*prefetchedBlock),
+		numbers:  make(map[uint64]common.Hash),
+		inflight: make(map[uint64]struct{}),
+	}
+}
+
+// OnL1Origin starts prefetching the blocks after the new L1 origin of the pipeline.
+func (p *L1Prefetcher) OnL1Origin(ref eth.L1BlockRef) {
+	p.advance(ref)
+}
+
+func (p *L1Prefetcher) FetchReceipts(ctx context.Context, blockHash common.Hash) (eth.BlockInfo, l1types.Receipts, error) {
+	p.record("receipts", blockHash)
+	return p.L1Fetcher.FetchReceipts(ctx, blockHash)
+}
+
+func (p *L1Prefetcher) InfoAndTxsByHash(ctx context.Context, hash common.Hash) (eth.BlockInfo, l1types.Transactions, error) {
+	p.record("transactions", hash)
+	return p.L1Fetcher.InfoAndTxsByHash(ctx, hash)
+}
+
+// GetBlobs returns the prefetched blobs of the block, or fetches them if they were not all prefetched.
+func (p *L1Prefetcher) GetBlobs(ctx context.Context, ref eth.L1BlockRef, hashes []eth.IndexedBlobHash) ([]*eth.Blob, error) {
+	if blobs, ok := p.prefetchedBlobs(ref, hashes); ok {
+		p.metrics.RecordL1Prefetch("blobs", true)
+		return blobs, nil
+	}
+	p.metrics.RecordL1Prefetch("blobs", false)
+	return p.blobs.GetBlobs(ctx, ref, hashes)
+}
+
+// Close stops prefetching, and waits for the ongoing prefetches to return.
+func (p *L1Prefetcher) Close() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *L1Prefetcher) record(kind string, hash common.Hash) {
+	p.mu.Lock()
+	_, ok := p.blocks[hash]
+	p.mu.Unlock()
+	p.metrics.RecordL1Prefetch(kind, ok)
+}
+
+func (p *L1Prefetcher) prefetchedBlobs(ref eth.L1BlockRef, hashes []eth.IndexedBlobHash) ([]*eth.Blob, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	b, ok := p.blocks[ref.Hash]
+	if !ok || b.blobs == nil {
+		return nil, false
+	}
+	out := make([]*eth.Blob, len(hashes))
+	for i, ih := range hashes {
+		blob, ok := b.blobs[ih.Hash]
+		if !ok {
+			return nil, false
+		}
+		out[i] = blob
+	}
+	return out, true
+}
+
+// advance registers ref as the latest canonical block seen by the pipeline:
+// it discards what conflicts with it, and schedules the prefetching of the blocks after it.
+func (p *L1Prefetcher) advance(ref eth.L1BlockRef) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ctx.Err() != nil {
+		return
+	}
+	if h, ok := p.numbers[ref.Number]; ok && h != ref.Hash {
+		p.log.Debug("Discarding prefetched L1 blocks after reorg", "block", ref)
+		p.discardFrom(ref.Number)
+	}
+	for num, h := range p.numbers {
+		if num < ref.Number {
+			// traversed blocks do not need their blobs anymore
+			p.blocks[h].blobs = nil
+		}
+		if num+p.retain < ref.Number {
+			delete(p.blocks, h)
+			delete(p.numbers, num)
+		}
+	}
+	for num := ref.Number + 1; num <= ref.Number+p.depth; num++ {
+		if _, ok := p.numbers[num]; ok {
+			continue
+		}
+		if _, ok := p.inflight[num]; ok {
+			continue
+		}
+		p.inflight[num] = struct{}{}
+		p.wg.Add(1)
+		go p.prefetch(num)
+	}
+}
+
+// discardFrom drops all prefetched blocks starting at num. The caller must hold the lock.
+func (p *L1Prefetcher) discardFrom(num uint64) {
+	for n, h := range p.numbers {
+		if n >= num {
+			delete(p.blocks, h)
+			delete(p.numbers, n)
+		}
+	}
+}
+
+func (p *L1Prefetcher) prefetch(num uint64) {
+	defer p.wg.Done()
+	defer func() {
+		p.mu.Lock()
+		delete(p.inflight, num)
+		p.mu.Unlock()
+	}()
+	ctx, cancel := context.WithTimeout(p.ctx, prefetchTimeout)
+	defer cancel()
+
+	ref, err := p.L1Fetcher.L1BlockRefByNumber(ctx, num)
+	if err != nil {
+		// blocks past the L1 head are not found until they are produced
+		p.log.Debug("Failed to prefetch L1 block", "number", num, "err", err)
+		return
+	}
+	if _, _, err := p.L1Fetcher.FetchReceipts(ctx, ref.Hash); err != nil {
+		p.log.Debug("Failed to prefetch L1 receipts", "block", ref, "err", err)
+		return
+	}
+	_, txs, err := p.L1Fetcher.InfoAndTxsByHash(ctx, ref.Hash)
+	if err != nil {
+		p.log.Debug("Failed to prefetch L1 transactions", "block", ref, "err", err)
+		return
+	}
+	var blobs map[common.Hash]*eth.Blob
+	if hashes := inboxBlobHashes(txs, p.inbox); len(hashes) > 0 {
+		res, err := p.blobs.GetBlobs(ctx, ref, hashes)
+		if err != nil {
+			p.log.Debug("Failed to prefetch L1 blobs", "block", ref, "err", err)
+			return
+		}
+		blobs = make(map[common.Hash]*eth.Blob, len(hashes))
+		for i, ih := range hashes {
+			blobs[ih.Hash] = res[i]
+		}
+	}
+	p.insert(&prefetchedBlock{ref: ref, blobs: blobs})
+}
+
+// insert adds a prefetched block, unless it does not link up with its prefetched neighbours,
+// in which case the L1 chain changed while prefetching and the neighbours are discarded too.
+func (p *L1Prefetcher) insert(b *prefetchedBlock) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ctx.Err() != nil {
+		return
+	}
+	num := b.ref.Number
+	if parent, ok := p.numbers[num-1]; num > 0 && ok && parent != b.ref.ParentHash {
+		p.log.Debug("Prefetched L1 block does not match its parent", "block", b.ref, "parent", parent)
+		p.discardFrom(num - 1)
+		return
+	}
+	if child, ok := p.numbers[num+1]; ok && p.blocks[child].ref.ParentHash != b.ref.Hash {
+		p.log.Debug("Prefetched L1 block does not match its child", "block", b.ref, "child", child)
+		p.discardFrom(num)
+		return
+	}
+	p.numbers[num] = b.ref.Hash
+	p.blocks[b.ref.Hash] = b
+}
+
+// inboxBlobHashes returns the indexed hashes of the blobs sent to the batch inbox.
+// Unlike dataAndHashesFromTxs it does not filter by batcher, which is not known ahead of
+// traversing the system config updates: prefetching a superset of the batcher blobs is harmless.
+func inboxBlobHashes(txs l1types.Transactions, inbox common.Address) []eth.IndexedBlobHash {
+	var hashes []eth.IndexedBlobHash
+	blobIndex := 0
+	for _, tx := range txs {
+		to := tx.To()
+		if tx.Type() != l1types.BlobTxType || to == nil || common.Address(*to) != inbox {
+			blobIndex += len(tx.BlobHashes())
+			continue
+		}
+		for _, h := range tx.BlobHashes() {
+			hashes = append(hashes, eth.IndexedBlobHash{Index: uint64(blobIndex), Hash: common.Hash(h)})
+			blobIndex += 1
+		}
+	}
+	return hashes
+}
@@ -0,0 +1,240 @@
+package derive
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	l1ethereum "github.com/ethereum/go-ethereum"
+	l1common "github.com/ethereum/go-ethereum/common"
+	l1types "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testlog"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testutils"
+)
+
+// fakePrefetchL1 serves a chain of L1 blocks, and counts the blobs requests.
+type fakePrefetchL1 struct {
+	mu         sync.Mutex
+	chain      []eth.L1BlockRef
+	txs        map[common.Hash]l1types.Transactions
+	blobsCalls int
+}
+
+func (f *fakePrefetchL1) L1BlockRefByLabel(ctx context.Context, label eth.BlockLabel) (eth.L1BlockRef, error) {
+	return eth.L1BlockRef{}, errors.New("not supported")
+}
+
+func (f *fakePrefetchL1) L1BlockRefByHash(ctx context.Context, hash common.Hash) (eth.L1BlockRef, error) {
+	return eth.L1BlockRef{}, errors.New("not supported")
+}
+
+func (f *fakePrefetchL1) InfoByHash(ctx context.Context, hash common.Hash) (eth.BlockInfo, error) {
+	return nil, errors.New("not supported")
+}
+
+func (f *fakePrefetchL1) L1BlockRefByNumber(ctx context.Context, num uint64) (eth.L1BlockRef, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if num >= uint64(len(f.chain)) {
+		return eth.L1BlockRef{}, l1ethereum.NotFound
+	}
+	return f.chain[num], nil
+}
+
+func (f *fakePrefetchL1) FetchReceipts(ctx context.Context, blockHash common.Hash) (eth.BlockInfo, l1types.Receipts, error) {
+	return nil, nil, nil
+}
+
+func (f *fakePrefetchL1) InfoAndTxsByHash(ctx context.Context, hash common.Hash) (eth.BlockInfo, l1types.Transactions, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return nil, f.txs[hash], nil
+}
+
+func (f *fakePrefetchL1) GetBlobs(ctx context.Context, ref eth.L1BlockRef, hashes []eth.IndexedBlobHash) ([]*eth.Blob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.blobsCalls++
+	out := make([]*eth.Blob, len(hashes))
+	for i := range hashes {
+		out[i] = new(eth.Blob)
+	}
+	return out, nil
+}
+
+// fork replaces the chain from the given number with new random blocks.
+func (f *fakePrefetchL1) fork(rng *rand.Rand, from uint64, length int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.chain = f.chain[:from]
+	for len(f.chain) < length {
+		f.chain = append(f.chain, testutils.NextRandomRef(rng, f.chain[len(f.chain)-1]))
+	}
+}
+
+type testPrefetchMetrics struct {
+	mu     sync.Mutex
+	hits   map[string]int
+	misses map[string]int
+}
+
+func (m *testPrefetchMetrics) RecordL1Prefetch(kind string, hit bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if hit {
+		m.hits[kind]++
+	} else {
+		m.misses[kind]++
+	}
+}
+
+func prefetchedNumbers(p *L1Prefetcher) map[uint64]common.Hash {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make(map[uint64]common.Hash, len(p.numbers))
+	for n, h := range p.numbers {
+		out[n] = h
+	}
+	return out
+}
+
+func setupPrefetcher(t *testing.T, depth uint64) (*L1Prefetcher, *fakePrefetchL1, *testPrefetchMetrics, *rand.Rand) {
+	rng := rand.New(rand.NewSource(1234))
+	genesis := testutils.RandomBlockRef(rng)
+	genesis.Number = 0
+	l1 := &fakePrefetchL1{chain: []eth.L1BlockRef{genesis}, txs: make(map[common.Hash]l1types.Transactions)}
+	l1.fork(rng, 1, 10)
+	m := &testPrefetchMetrics{hits: make(map[string]int), misses: make(map[string]int)}
+	cfg := &rollup.Config{BatchInboxAddress: common.Address{0x42}, SeqWindowSize: 4}
+	p := NewL1Prefetcher(testlog.Logger(t, log.LevelDebug), cfg, l1, l1, depth, m)
+	t.Cleanup(p.Close)
+	return p, l1, m, rng
+}
+
+func TestL1PrefetcherLookAhead(t *testing.T) {
+	p, l1, m, _ := setupPrefetcher(t, 3)
+	ctx := context.Background()
+
+	inbox := l1common.Address{0x42}
+	blobTx := l1types.NewTx(&l1types.BlobTx{To: inbox, BlobHashes: []l1common.Hash{{0x01}, {0x02}}})
+	l1.txs[l1.chain[2].Hash] = l1types.Transactions{blobTx}
+
+	p.OnL1Origin(l1.chain[0])
+	require.Eventually(t, func() bool {
+		return len(prefetchedNumbers(p)) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	for n := uint64(1); n <= 3; n++ {
+		require.Equal(t, l1.chain[n].Hash, prefetchedNumbers(p)[n])
+	}
+
+	_, _, err := p.FetchReceipts(ctx, l1.chain[1].Hash)
+	require.NoError(t, err)
+	_, _, err = p.FetchReceipts(ctx, l1.chain[5].Hash)
+	require.NoError(t, err)
+	_, _, err = p.InfoAndTxsByHash(ctx, l1.chain[2].Hash)
+	require.NoError(t, err)
+	require.Equal(t, 1, m.hits["receipts"])
+	require.Equal(t, 1, m.misses["receipts"])
+	require.Equal(t, 1, m.hits["transactions"])
+
+	// blobs of the prefetched block are served without another request
+	hashes := []eth.IndexedBlobHash{{Index: 1, Hash: common.Hash{0x02}}}
+	blobs, err := p.GetBlobs(ctx, l1.chain[2], hashes)
+	require.NoError(t, err)
+	require.Len(t, blobs, 1)
+	require.Equal(t, 1, l1.blobsCalls)
+	require.Equal(t, 1, m.hits["blobs"])
+
+	// blocks are not prefetched past the L1 head, and blobs are dropped once traversed
+	p.OnL1Origin(l1.chain[8])
+	require.Eventually(t, func() bool {
+		_, ok := prefetchedNumbers(p)[9]
+		return ok
+	}, 5*time.Second, 10*time.Millisecond)
+	p.Close()
+	require.NotContains(t, prefetchedNumbers(p), uint64(2), "pruned beyond the sequencing window")
+	require.NotContains(t, prefetchedNumbers(p), uint64(10))
+	_, err = p.GetBlobs(ctx, l1.chain[2], hashes)
+	require.NoError(t, err)
+	require.Equal(t, 2, l1.blobsCalls)
+	require.Equal(t, 1, m.misses["blobs"])
+}
+
+func TestL1PrefetcherReorg(t *testing.T) {
+	p, l1, m, rng := setupPrefetcher(t, 3)
+	ctx := context.Background()
+
+	p.OnL1Origin(l1.chain[0])
+	require.Eventually(t, func() bool {
+		return len(prefetchedNumbers(p)) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	stale := l1.chain[2]
+
+	l1.fork(rng, 2, 10)
+	require.NotEqual(t, stale.Hash, l1.chain[2].Hash)
+	p.OnL1Origin(l1.chain[2])
+	require.Eventually(t, func() bool {
+		numbers := prefetchedNumbers(p)
+		return numbers[3] == l1.chain[3].Hash && numbers[4] == l1.chain[4].Hash && numbers[5] == l1.chain[5].Hash
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, l1.chain[1].Hash, prefetchedNumbers(p)[1])
+	require.NotContains(t, prefetchedNumbers(p), uint64(2))
+
+	_, _, err := p.FetchReceipts(ctx, stale.Hash)
+	require.NoError(t, err)
+	require.Equal(t, 1, m.misses["receipts"])
+}
+
+func TestL1PrefetcherFollowsPipelineOrigin(t *testing.T) {
+	p, l1, m, _ := setupPrefetcher(t, 3)
+	ctx := context.Background()
+
+	inbox := l1common.Address{0x42}
+	blobTx := l1types.NewTx(&l1types.BlobTx{To: inbox, BlobHashes: []l1common.Hash{{0x01}}})
+	l1.txs[l1.chain[2].Hash] = l1types.Transactions{blobTx}
+
+	traversal := NewL1Traversal(testlog.Logger(t, log.LevelDebug), &rollup.Config{}, p)
+	traversal.listener = p
+	require.Equal(t, io.EOF, traversal.Reset(ctx, l1.chain[0], eth.SystemConfig{}))
+	require.Eventually(t, func() bool {
+		return len(prefetchedNumbers(p)) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// other consumers of the L1 source, like the sequencer or the finalizer, do not move the window
+	ref, err := p.L1BlockRefByNumber(ctx, 8)
+	require.NoError(t, err)
+	require.Equal(t, l1.chain[8], ref)
+	require.NotContains(t, prefetchedNumbers(p), uint64(9))
+
+	require.NoError(t, traversal.AdvanceL1Block(ctx))
+	require.NoError(t, traversal.AdvanceL1Block(ctx))
+	require.Equal(t, l1.chain[2], traversal.Origin())
+	blobs, err := p.GetBlobs(ctx, l1.chain[2], []eth.IndexedBlobHash{{Index: 0, Hash: common.Hash{0x01}}})
+	require.NoError(t, err)
+	require.Len(t, blobs, 1)
+	require.Equal(t, 1, l1.blobsCalls, "blobs of the pipeline origin are prefetched")
+	require.Equal(t, 1, m.hits["blobs"])
+}
+
+func TestInboxBlobHashes(t *testing.T) {
+	inbox := common.Address{0x42}
+	txs := l1types.Transactions{
+		l1types.NewTx(&l1types.BlobTx{To: l1common.Address{0x01}, BlobHashes: []l1common.Hash{{0x01}}}),
+		l1types.NewTx(&l1types.DynamicFeeTx{To: (*l1common.Address)(&inbox)}),
+		l1types.NewTx(&l1types.BlobTx{To: l1common.Address(inbox), BlobHashes: []l1common.Hash{{0x02}, {0x03}}}),
+	}
+	require.Equal(t, []eth.IndexedBlobHash{
+		{Index: 1, Hash: common.Hash{0x02}},
+		{Index: 2, Hash: common.Hash{0x03}},
+	}, inboxBlobHashes(txs, inbox))
+}
@@ -27,6 +27,8 @@ type L1Retrieval struct {
 	prev    NextBlockProvider
 
 	datas DataIter
+	// number of pieces of data of the current block passed on to the next stage
+	consumed uint64
 }
 
 var _ ResettableStage = (*L1Retrieval)(nil)
@@ -57,6 +59,7 @@ func (l1r *L1Retrieval) NextData(ctx context.Context) ([]byte, error) {
 		if l1r.datas, err = l1r.dataSrc.OpenData(ctx, next, l1r.prev.SystemConfig().BatcherAddr); err != nil {
 			return nil, fmt.Errorf("failed to open data source: %w", err)
 		}
+		l1r.consumed = 0
 	}
 
 	l1r.log.Debug("fetching next piece of data")
@@ -68,6 +71,7 @@ func (l1r *L1Retrieval) NextData(ctx context.Context) ([]byte, error) {
 		// CalldataSource appropriately wraps the error so avoid double wrapping errors here.
 		return nil, err
 	} else {
+		l1r.consumed++
 		return data, nil
 	}
 }
@@ -80,6 +84,7 @@ func (l1r *L1Retrieval) Reset(ctx context.Context, base eth.L1BlockRef, sysCfg e
 	if l1r.datas, err = l1r.dataSrc.OpenData(ctx, base, sysCfg.BatcherAddr); err != nil {
 		return fmt.Errorf("failed to open data source: %w", err)
 	}
+	l1r.consumed = 0
 	l1r.log.Info("Reset of L1Retrieval done", "origin", base)
 	return io.EOF
 }
@@ -21,6 +21,11 @@ type L1BlockRefByNumberFetcher interface {
 	FetchReceipts(ctx context.Context, blockHash common.Hash) (eth.BlockInfo, l1types.Receipts, error)
 }
 
+// L1OriginListener is notified of every L1 block the L1 traversal moves to.
+type L1OriginListener interface {
+	OnL1Origin(ref eth.L1BlockRef)
+}
+
 type L1Traversal struct {
 	block    eth.L1BlockRef
 	done     bool
@@ -28,6 +33,7 @@ type L1Traversal struct {
 	log      log.Logger
 	sysCfg   eth.SystemConfig
 	cfg      *rollup.Config
+	listener L1OriginListener // nil if nothing listens
 }
 
 var _ ResettableStage = (*L1Traversal)(nil)
@@ -79,20 +85,27 @@ func (l1t *L1Traversal) AdvanceL1Block(ctx context.Context) error {
 		return NewCriticalError(fmt.Errorf("failed to update L1 sysCfg with receipts from block %s: %w", nextL1Origin, err))
 	}
 
-	l1t.block = nextL1Origin
+	l1t.setBlock(nextL1Origin)
 	l1t.done = false
 	return nil
 }
 
 // Reset sets the internal L1 block to the supplied base.
 func (l1t *L1Traversal) Reset(ctx context.Context, base eth.L1BlockRef, cfg eth.SystemConfig) error {
-	l1t.block = base
+	l1t.setBlock(base)
 	l1t.done = false
 	l1t.sysCfg = cfg
 	l1t.log.Info("completed reset of derivation pipeline", "origin", base)
 	return io.EOF
 }
 
+func (l1t *L1Traversal) setBlock(ref eth.L1BlockRef) {
+	l1t.block = ref
+	if l1t.listener != nil {
+		l1t.listener.OnL1Origin(ref)
+	}
+}
+
 func (l1c *L1Traversal) SystemConfig() eth.SystemConfig {
 	return l1c.sysCfg
 }
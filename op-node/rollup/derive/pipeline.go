@@ -8,11 +8,16 @@ import (
 
 	"github.com/zircuit-labs/l2-geth-public/common"
 	"github.com/zircuit-labs/l2-geth-public/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/optracing"
 )
 
+var tracer = optracing.Tracer("github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive")
+
 type Metrics interface {
 	RecordL1Ref(name string, ref eth.L1BlockRef)
 	RecordL2Ref(name string, ref eth.L2BlockRef)
@@ -65,6 +70,20 @@ type DerivationPipeline struct {
 
 	attrib *AttributesQueue
 
+	dataSrc *DataSourceFactory
+
+	// Stages with state that is checkpointed
+	retrieval *L1Retrieval
+	frames    *FrameQueue
+	bank      *ChannelBank
+	reader    *ChannelInReader
+	batches   *BatchQueue
+
+	// Number of the L2 block of the last derived attributes, or of the safe head the pipeline was reset to.
+	derived uint64
+	// Checkpoint to restore from on the next reset instead of rewinding the L1 traversal, if any.
+	checkpoint *PipelineCheckpoint
+
 	// L1 block that the next returned attributes are derived from, i.e. at the L2-end of the pipeline.
 	origin         eth.L1BlockRef
 	resetL2Safe    eth.L2BlockRef
@@ -101,10 +120,28 @@ func NewDerivationPipeline(log log.Logger, rollupCfg *rollup.Config, l1Fetcher L
 		metrics:   metrics,
 		traversal: l1Traversal,
 		attrib:    attributesQueue,
+		dataSrc:   dataSrc,
+		retrieval: l1Src,
+		frames:    frameQueue,
+		bank:      bank,
+		reader:    chInReader,
+		batches:   batchQueue,
 		l2:        l2Source,
 	}
 }
 
+// SetAuditor sets the auditor to record the batcher data, channels and batches of each L1 block with.
+func (dp *DerivationPipeline) SetAuditor(audit DerivationAuditor) {
+	dp.dataSrc.audit = audit
+	dp.bank.audit = audit
+	dp.batches.audit = audit
+}
+
+// SetL1OriginListener sets the listener to notify of every L1 block the pipeline traverses.
+func (dp *DerivationPipeline) SetL1OriginListener(listener L1OriginListener) {
+	dp.traversal.listener = listener
+}
+
 // DerivationReady returns true if the derivation pipeline is ready to be used.
 // When it's being reset its state is inconsistent, and should not be used externally.
 func (dp *DerivationPipeline) DerivationReady() bool {
@@ -133,11 +170,19 @@ func (dp *DerivationPipeline) Origin() eth.L1BlockRef {
 func (dp *DerivationPipeline) Step(ctx context.Context, pendingSafeHead eth.L2BlockRef) (outAttrib *AttributesWithParent, outErr error) {
 	defer dp.metrics.RecordL1Ref("l1_derived", dp.Origin())
 
+	ctx, span := tracer.Start(ctx, "derive.step", trace.WithAttributes(
+		attribute.Int64("pending_safe", int64(pendingSafeHead.Number)),
+		attribute.Int64("origin", int64(dp.origin.Number)),
+	))
 	dp.metrics.SetDerivationIdle(false)
 	defer func() {
 		if outErr == io.EOF || errors.Is(outErr, ErrEngineELSyncing) {
 			dp.metrics.SetDerivationIdle(true)
+			// running out of data is the normal end of a derivation run, not a failed step
+			span.End()
+			return
 		}
+		optracing.EndSpan(span, outErr)
 	}()
 
 	// if any stages need to be reset, do that first.
@@ -150,11 +195,15 @@ func (dp *DerivationPipeline) Step(ctx context.Context, pendingSafeHead eth.L2Bl
 		// we still need to internally rewind the L1 traversal further,
 		// so we can read all the L2 data necessary for constructing the next batches that come after the safe head.
 		if pendingSafeHead != dp.resetL2Safe {
+			if dp.restoreCheckpoint(ctx, pendingSafeHead) {
+				return nil, nil
+			}
 			if err := dp.initialReset(ctx, pendingSafeHead); err != nil {
 				return nil, fmt.Errorf("failed initial reset work: %w", err)
 			}
 		}
 
+		span.SetAttributes(attribute.Int("reset_stage", dp.resetting))
 		if err := dp.stages[dp.resetting].Reset(ctx, dp.origin, dp.resetSysConfig); err == io.EOF {
 			dp.log.Debug("reset of stage completed", "stage", dp.resetting, "origin", dp.origin)
 			dp.resetting += 1
@@ -177,9 +226,12 @@ func (dp *DerivationPipeline) Step(ctx context.Context, pendingSafeHead eth.L2Bl
 	}
 
 	if attrib, err := dp.attrib.NextAttributes(ctx, pendingSafeHead); err == nil {
+		span.SetAttributes(attribute.Int64("attributes_timestamp", int64(attrib.Attributes.Timestamp)))
+		dp.derived = attrib.Parent.Number + 1
 		return attrib, nil
 	} else if err == io.EOF {
 		// If every stage has returned io.EOF, try to advance the L1 Origin
+		span.AddEvent("advance-l1")
 		return nil, dp.traversal.AdvanceL1Block(ctx)
 	} else if errors.Is(err, ErrEngineELSyncing) {
 		return nil, err
@@ -231,6 +283,7 @@ func (dp *DerivationPipeline) initialReset(ctx context.Context, resetL2Safe eth.
 	dp.origin = pipelineOrigin
 	dp.resetSysConfig = sysCfg
 	dp.resetL2Safe = resetL2Safe
+	dp.derived = resetL2Safe.Number
 	return nil
 }
 
@@ -26,6 +26,12 @@ import (
 // prefix := rel_timestamp ++ l1_origin_num ++ parent_check ++ l1_origin_check
 // payload := block_count ++ origin_bits ++ block_tx_counts ++ txs
 // txs := contract_creation_bits ++ y_parity_bits ++ tx_sigs ++ tx_tos ++ tx_datas ++ tx_nonces ++ tx_gases ++ protected_bits
+//
+// SpanBatchWithExclusionsType := 2
+// spanBatchWithExclusions := SpanBatchWithExclusionsType ++ prefix ++ payloadWithExclusions
+// payloadWithExclusions := block_count ++ origin_bits ++ deposit_exclusions ++ block_tx_counts ++ txs
+// deposit_exclusions := deposit_exclusion ++ ... (one for every block with its origin bit set, in block order)
+// deposit_exclusion := bit_length ++ exclusion_bits
 
 var ErrTooBigSpanBatchSize = errors.New("span batch size limit reached")
 
@@ -39,10 +45,14 @@ type spanBatchPrefix struct {
 }
 
 type spanBatchPayload struct {
-	blockCount    uint64        // Number of L2 block in the span
-	originBits    *big.Int      // Standard span-batch bitlist of blockCount bits. Each bit indicates if the L1 origin is changed at the L2 block.
-	blockTxCounts []uint64      // List of transaction counts for each L2 block
-	txs           *spanBatchTxs // Transactions encoded in SpanBatch specs
+	blockCount uint64   // Number of L2 block in the span
+	originBits *big.Int // Standard span-batch bitlist of blockCount bits. Each bit indicates if the L1 origin is changed at the L2 block.
+	// Whether the deposit exclusions are encoded, in a span batch of type SpanBatchWithExclusionsType
+	withExclusions bool
+	// Deposit exclusions bitmaps of the L2 blocks with their origin bit set, in portable bytes
+	depositExclusions []hexutil.Bytes
+	blockTxCounts     []uint64      // List of transaction counts for each L2 block
+	txs               *spanBatchTxs // Transactions encoded in SpanBatch specs
 }
 
 // RawSpanBatch is another representation of SpanBatch, that encodes data according to SpanBatch specs.
@@ -53,6 +63,9 @@ type RawSpanBatch struct {
 
 // GetBatchType returns its batch type (batch_version)
 func (b *RawSpanBatch) GetBatchType() int {
+	if b.withExclusions {
+		return SpanBatchWithExclusionsType
+	}
 	return SpanBatchType
 }
 
@@ -69,6 +82,39 @@ func (bp *spanBatchPayload) decodeOriginBits(r *bytes.Reader) error {
 	return nil
 }
 
+// originBitCount returns the number of L2 blocks with their origin bit set
+func (bp *spanBatchPayload) originBitCount() int {
+	count := 0
+	for i := 0; i < int(bp.blockCount); i++ {
+		count += int(bp.originBits.Bit(i))
+	}
+	return count
+}
+
+// decodeDepositExclusions parses data into bp.depositExclusions,
+// one bitmap for every L2 block with its origin bit set
+func (bp *spanBatchPayload) decodeDepositExclusions(r *bytes.Reader) error {
+	count := bp.originBitCount()
+	depositExclusions := make([]hexutil.Bytes, 0, count)
+	for i := 0; i < count; i++ {
+		bitLength, err := binary.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("failed to read deposit exclusions length: %w", err)
+		}
+		// a deposit exclusions bitmap has a bit for every transaction of the L2 block
+		if bitLength > MaxSpanBatchElementCount {
+			return ErrTooBigSpanBatchSize
+		}
+		bits, err := decodeSpanBatchBits(r, bitLength)
+		if err != nil {
+			return fmt.Errorf("failed to decode deposit exclusions: %w", err)
+		}
+		depositExclusions = append(depositExclusions, spanBatchBitsToBitmap(bitLength, bits))
+	}
+	bp.depositExclusions = depositExclusions
+	return nil
+}
+
 // decodeRelTimestamp parses data into bp.relTimestamp
 func (bp *spanBatchPrefix) decodeRelTimestamp(r *bytes.Reader) error {
 	relTimestamp, err := binary.ReadUvarint(r)
@@ -196,6 +242,11 @@ func (bp *spanBatchPayload) decodePayload(r *bytes.Reader) error {
 	if err := bp.decodeOriginBits(r); err != nil {
 		return err
 	}
+	if bp.withExclusions {
+		if err := bp.decodeDepositExclusions(r); err != nil {
+			return err
+		}
+	}
 	if err := bp.decodeBlockTxCounts(r); err != nil {
 		return err
 	}
@@ -277,6 +328,28 @@ func (bp *spanBatchPayload) encodeOriginBits(w io.Writer) error {
 	return nil
 }
 
+// encodeDepositExclusions encodes bp.depositExclusions
+func (bp *spanBatchPayload) encodeDepositExclusions(w io.Writer) error {
+	if len(bp.depositExclusions) != bp.originBitCount() {
+		return fmt.Errorf("cannot write deposit exclusions: %d bitmaps for %d epochs", len(bp.depositExclusions), bp.originBitCount())
+	}
+	var buf [binary.MaxVarintLen64]byte
+	for _, exclusions := range bp.depositExclusions {
+		bitLength, bits, err := bitmapToSpanBatchBits(exclusions)
+		if err != nil {
+			return fmt.Errorf("cannot write deposit exclusions: %w", err)
+		}
+		n := binary.PutUvarint(buf[:], bitLength)
+		if _, err := w.Write(buf[:n]); err != nil {
+			return fmt.Errorf("cannot write deposit exclusions length: %w", err)
+		}
+		if err := encodeSpanBatchBits(w, bitLength, bits); err != nil {
+			return fmt.Errorf("failed to encode deposit exclusions: %w", err)
+		}
+	}
+	return nil
+}
+
 // encodeBlockCount encodes bp.blockCount
 func (bp *spanBatchPayload) encodeBlockCount(w io.Writer) error {
 	var buf [binary.MaxVarintLen64]byte
@@ -318,6 +391,11 @@ func (bp *spanBatchPayload) encodePayload(w io.Writer) error {
 	if err := bp.encodeOriginBits(w); err != nil {
 		return err
 	}
+	if bp.withExclusions {
+		if err := bp.encodeDepositExclusions(w); err != nil {
+			return err
+		}
+	}
 	if err := bp.encodeBlockTxCounts(w); err != nil {
 		return err
 	}
@@ -362,14 +440,20 @@ func (b *RawSpanBatch) derive(blockTime, genesisTimestamp uint64, chainID *big.I
 	}
 
 	spanBatch := SpanBatch{
-		ParentCheck:   b.parentCheck,
-		L1OriginCheck: b.l1OriginCheck,
+		ParentCheck:    b.parentCheck,
+		L1OriginCheck:  b.l1OriginCheck,
+		WithExclusions: b.withExclusions,
 	}
 	txIdx := 0
+	exclusionsIdx := 0
 	for i := 0; i < int(b.blockCount); i++ {
 		batch := SpanBatchElement{}
 		batch.Timestamp = genesisTimestamp + b.relTimestamp + blockTime*uint64(i)
 		batch.EpochNum = rollup.Epoch(blockOriginNums[i])
+		if b.withExclusions && b.originBits.Bit(i) == 1 {
+			batch.DepositExclusions = b.depositExclusions[exclusionsIdx]
+			exclusionsIdx++
+		}
 		for j := 0; j < int(b.blockTxCounts[i]); j++ {
 			batch.Transactions = append(batch.Transactions, fullTxs[txIdx])
 			txIdx++
@@ -386,6 +470,7 @@ func (b *RawSpanBatch) ToSpanBatch(blockTime, genesisTimestamp uint64, chainID *
 	if err != nil {
 		return nil, err
 	}
+	spanBatch.raw = b
 	return spanBatch, nil
 }
 
@@ -396,14 +481,17 @@ type SpanBatchElement struct {
 	EpochNum     rollup.Epoch // aka l1 num
 	Timestamp    uint64
 	Transactions []hexutil.Bytes
+	// DepositExclusions is only set in span batches with exclusions, on the first block of an epoch
+	DepositExclusions hexutil.Bytes `json:",omitempty"`
 }
 
 // singularBatchToElement converts a SingularBatch to a SpanBatchElement
 func singularBatchToElement(singularBatch *SingularBatch) *SpanBatchElement {
 	return &SpanBatchElement{
-		EpochNum:     singularBatch.EpochNum,
-		Timestamp:    singularBatch.Timestamp,
-		Transactions: singularBatch.Transactions,
+		EpochNum:          singularBatch.EpochNum,
+		Timestamp:         singularBatch.Timestamp,
+		Transactions:      singularBatch.Transactions,
+		DepositExclusions: singularBatch.DepositExclusions,
 	}
 }
 
@@ -415,11 +503,20 @@ type SpanBatch struct {
 	GenesisTimestamp uint64
 	ChainID          *big.Int
 	Batches          []*SpanBatchElement // List of block input in derived form
+	// WithExclusions indicates the span batch carries the deposit exclusions of its blocks,
+	// and is encoded as SpanBatchWithExclusionsType.
+	// It must be set before appending the first batch.
+	WithExclusions bool
+
+	// raw is the raw span batch this span batch was derived from, nil if it was built by appending
+	// singular batches. Only the latter fill the caches below.
+	raw *RawSpanBatch
 
 	// caching
-	originBits    *big.Int
-	blockTxCounts []uint64
-	sbtxs         *spanBatchTxs
+	originBits        *big.Int
+	depositExclusions []hexutil.Bytes
+	blockTxCounts     []uint64
+	sbtxs             *spanBatchTxs
 }
 
 func (b *SpanBatch) AsSingularBatch() (*SingularBatch, bool) { return nil, false }
@@ -441,7 +538,8 @@ func (b *SpanBatch) MarshalJSON() ([]byte, error) {
 	return json.Marshal(spanBatch)
 }
 
-// GetBatchType returns its batch type (batch_version)
+// GetBatchType returns its batch type (batch_version).
+// Span batches with and without exclusions derive into the same SpanBatch, which is always of SpanBatchType.
 func (b *SpanBatch) GetBatchType() int {
 	return SpanBatchType
 }
@@ -466,6 +564,7 @@ func (b *SpanBatch) LogContext(log log.Logger) log.Logger {
 	}
 	return log.New(
 		"batch_type", "SpanBatch",
+		"with_exclusions", b.WithExclusions,
 		"batch_timestamp", b.Batches[0].Timestamp,
 		"parent_check", hexutil.Encode(b.ParentCheck[:]),
 		"origin_check", hexutil.Encode(b.L1OriginCheck[:]),
@@ -543,6 +642,19 @@ func (b *SpanBatch) AppendSingularBatch(singularBatch *SingularBatch, seqNum uin
 	// set the respective bit in the originBits
 	b.originBits.SetBit(b.originBits, len(b.Batches)-1, epochBit)
 
+	// deposits are only included in the first block of an epoch, and so are their exclusions
+	if epochBit == 1 {
+		b.depositExclusions = append(b.depositExclusions, b.peek(0).DepositExclusions)
+	} else if b.WithExclusions {
+		exclusions, err := readBitmap(b.peek(0).DepositExclusions)
+		if err != nil {
+			return fmt.Errorf("invalid deposit exclusions: %w", err)
+		}
+		if exclusions != nil && exclusions.Count() > 0 {
+			return fmt.Errorf("deposit exclusions in block %d, which does not start an epoch", b.peek(0).Timestamp)
+		}
+	}
+
 	// update the blockTxCounts cache with the latest batch's tx count
 	b.blockTxCounts = append(b.blockTxCounts, uint64(len(b.peek(0).Transactions)))
 
@@ -572,10 +684,12 @@ func (b *SpanBatch) ToRawSpanBatch() (*RawSpanBatch, error) {
 			l1OriginCheck: b.L1OriginCheck,
 		},
 		spanBatchPayload: spanBatchPayload{
-			blockCount:    uint64(len(b.Batches)),
-			originBits:    b.originBits,
-			blockTxCounts: b.blockTxCounts,
-			txs:           b.sbtxs,
+			blockCount:        uint64(len(b.Batches)),
+			originBits:        b.originBits,
+			withExclusions:    b.WithExclusions,
+			depositExclusions: b.depositExclusions,
+			blockTxCounts:     b.blockTxCounts,
+			txs:               b.sbtxs,
 		},
 	}, nil
 }
@@ -591,9 +705,10 @@ func (b *SpanBatch) GetSingularBatches(l1Origins []eth.L1BlockRef, l2SafeHead et
 			continue
 		}
 		singularBatch := SingularBatch{
-			EpochNum:     batch.EpochNum,
-			Timestamp:    batch.Timestamp,
-			Transactions: batch.Transactions,
+			EpochNum:          batch.EpochNum,
+			Timestamp:         batch.Timestamp,
+			Transactions:      batch.Transactions,
+			DepositExclusions: batch.DepositExclusions,
 		}
 		originFound := false
 		for i := originIdx; i < len(l1Origins); i++ {
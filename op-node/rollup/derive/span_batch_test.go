@@ -2,6 +2,7 @@ package derive
 
 import (
 	"bytes"
+	"encoding/binary"
 	"math"
 	"math/big"
 	"math/rand"
@@ -11,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/common/hexutil"
 	"github.com/zircuit-labs/l2-geth-public/core/types"
 	"github.com/zircuit-labs/l2-geth-public/rlp"
 
@@ -602,3 +604,112 @@ func TestSpanBatchTotalBlockTxCountNotOverflow(t *testing.T) {
 
 	require.ErrorIs(t, err, ErrTooBigSpanBatchSize)
 }
+
+// randomDepositExclusions returns the portable bytes of a random deposit exclusions bitmap, possibly empty.
+func randomDepositExclusions(rng *rand.Rand) hexutil.Bytes {
+	bitmap := EmptyBitmap(0)
+	for i := rng.Intn(4); i > 0; i-- {
+		bitmap.Set(1 + rng.Intn(200))
+	}
+	return bitmap.MustBytes()
+}
+
+func TestSpanBatchWithExclusionsRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x5ba7c4e5))
+	chainID := new(big.Int).SetUint64(rng.Uint64())
+	l2BlockTime := uint64(2)
+
+	singularBatches := RandomValidConsecutiveSingularBatches(rng, chainID)
+	for i, batch := range singularBatches {
+		if i == 0 || batch.EpochNum != singularBatches[i-1].EpochNum {
+			batch.DepositExclusions = randomDepositExclusions(rng)
+		}
+	}
+	safeL2Head := testutils.RandomL2BlockRef(rng)
+	safeL2Head.Hash = common.BytesToHash(singularBatches[0].ParentHash[:])
+	safeL2Head.Time = singularBatches[0].Timestamp - 2
+	genesisTimeStamp := 1 + singularBatches[0].Timestamp - 128
+
+	spanBatch := NewSpanBatch(genesisTimeStamp, chainID)
+	spanBatch.WithExclusions = true
+	for i, batch := range singularBatches {
+		require.NoError(t, spanBatch.AppendSingularBatch(batch, uint64(i)))
+	}
+	rawSpanBatch, err := spanBatch.ToRawSpanBatch()
+	require.NoError(t, err)
+	require.Equal(t, SpanBatchWithExclusionsType, rawSpanBatch.GetBatchType())
+
+	data, err := NewBatchData(rawSpanBatch).MarshalBinary()
+	require.NoError(t, err)
+	require.Equal(t, byte(SpanBatchWithExclusionsType), data[0])
+	var batchData BatchData
+	require.NoError(t, batchData.UnmarshalBinary(data))
+	require.Equal(t, uint8(SpanBatchWithExclusionsType), batchData.GetBatchType())
+
+	derived, err := DeriveSpanBatch(&batchData, l2BlockTime, genesisTimeStamp, chainID)
+	require.NoError(t, err)
+	require.True(t, derived.WithExclusions)
+	require.Equal(t, SpanBatchType, derived.GetBatchType())
+
+	l1Origins := mockL1Origin(rng, rawSpanBatch, singularBatches)
+	singularBatches2, err := derived.GetSingularBatches(l1Origins, safeL2Head)
+	require.NoError(t, err)
+	require.Len(t, singularBatches2, len(singularBatches))
+	for i := range singularBatches {
+		require.Equal(t, singularBatches[i].DepositExclusions, singularBatches2[i].DepositExclusions, "block %d", i)
+		require.Len(t, singularBatches2[i].Transactions, len(singularBatches[i].Transactions), "block %d", i)
+	}
+}
+
+func TestSpanBatchWithoutExclusionsDropsExclusions(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x5ba7c4e6))
+	chainID := new(big.Int).SetUint64(rng.Uint64())
+
+	singularBatches := RandomValidConsecutiveSingularBatches(rng, chainID)
+	singularBatches[0].DepositExclusions = randomDepositExclusions(rng)
+	spanBatch := initializedSpanBatch(singularBatches, 0, chainID)
+	rawSpanBatch, err := spanBatch.ToRawSpanBatch()
+	require.NoError(t, err)
+	require.Equal(t, SpanBatchType, rawSpanBatch.GetBatchType())
+
+	var result bytes.Buffer
+	require.NoError(t, rawSpanBatch.encode(&result))
+	var sb RawSpanBatch
+	require.NoError(t, sb.decode(bytes.NewReader(result.Bytes())))
+	require.False(t, sb.withExclusions)
+	require.Empty(t, sb.depositExclusions)
+}
+
+func TestSpanBatchExclusionsOutsideEpochStart(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x5ba7c4e7))
+	chainID := new(big.Int).SetUint64(rng.Uint64())
+
+	singularBatches := RandomValidConsecutiveSingularBatches(rng, chainID)
+	spanBatch := NewSpanBatch(0, chainID)
+	spanBatch.WithExclusions = true
+	require.NoError(t, spanBatch.AppendSingularBatch(singularBatches[0], 0))
+
+	next := *singularBatches[0]
+	next.Timestamp += 2
+	next.DepositExclusions = EmptyBitmap(0).MustBytes()
+	// an empty bitmap is allowed in any block
+	require.NoError(t, spanBatch.AppendSingularBatch(&next, 1))
+
+	next.Timestamp += 2
+	bitmap := EmptyBitmap(0)
+	bitmap.Set(1)
+	next.DepositExclusions = bitmap.MustBytes()
+	require.ErrorContains(t, spanBatch.AppendSingularBatch(&next, 2), "does not start an epoch")
+}
+
+func TestSpanBatchMaxDepositExclusionsLength(t *testing.T) {
+	var sb RawSpanBatch
+	sb.blockCount = 1
+	sb.originBits = big.NewInt(1)
+	sb.withExclusions = true
+
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], MaxSpanBatchElementCount+1)
+	err := sb.decodeDepositExclusions(bytes.NewReader(buf[:n]))
+	require.ErrorIs(t, err, ErrTooBigSpanBatchSize)
+}
@@ -126,6 +126,21 @@ func (co *SpanChannelOut) AddSingularBatch(batch *SingularBatch, seqNum uint64)
 		return err
 	}
 
+	// The encoding of the span batch is decided by its first block. A span batch with exclusions is only valid
+	// if it starts after the activation, so it is used as soon as the activation is reached, and a channel that
+	// started before the activation is cut at it: the blocks after the activation need their exclusions.
+	withExclusions := co.chainSpec.IsFeatSpanBatchExclusions(batch.Timestamp)
+	if len(co.spanBatch.Batches) == 0 {
+		co.spanBatch.WithExclusions = withExclusions
+	} else if withExclusions != co.spanBatch.WithExclusions {
+		// a full channel has its data compressed, without the new batch
+		if err := co.compress(); err != nil {
+			return err
+		}
+		co.full = ErrSpanBatchExclusionsActivation
+		return co.full
+	}
+
 	// update the SpanBatch with the SingularBatch
 	if err := co.spanBatch.AppendSingularBatch(batch, seqNum); err != nil {
 		return fmt.Errorf("failed to append SingularBatch to SpanBatch: %w", err)
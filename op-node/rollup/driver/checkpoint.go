@@ -0,0 +1,68 @@
+package driver
+
+import (
+	"errors"
+	"time"
+
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/engine"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/event"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+)
+
+type CheckpointablePipeline interface {
+	Checkpoint(safe eth.L2BlockRef) (*derive.PipelineCheckpoint, error)
+}
+
+// PipelineCheckpointer stores a checkpoint of the derivation pipeline when the safe head is updated,
+// at most once per interval, so a restarted node can continue derivation from the checkpoint.
+type PipelineCheckpointer struct {
+	log      log.Logger
+	pipeline CheckpointablePipeline
+	store    derive.CheckpointStore
+	interval time.Duration
+
+	now  func() time.Time
+	last time.Time
+}
+
+var _ event.Deriver = (*PipelineCheckpointer)(nil)
+
+func NewPipelineCheckpointer(log log.Logger, pipeline CheckpointablePipeline, store derive.CheckpointStore, interval time.Duration) *PipelineCheckpointer {
+	return &PipelineCheckpointer{
+		log:      log,
+		pipeline: pipeline,
+		store:    store,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+func (c *PipelineCheckpointer) OnEvent(ev event.Event) bool {
+	x, ok := ev.(engine.SafeDerivedEvent)
+	if !ok {
+		return false
+	}
+	now := c.now()
+	if now.Sub(c.last) < c.interval {
+		return true
+	}
+	cp, err := c.pipeline.Checkpoint(x.Safe)
+	if errors.Is(err, derive.ErrNotCheckpointable) {
+		// try again on the next safe head update
+		c.log.Debug("Skipping derivation pipeline checkpoint", "safe", x.Safe, "err", err)
+		return true
+	} else if err != nil {
+		c.log.Warn("Failed to checkpoint derivation pipeline", "safe", x.Safe, "err", err)
+		return true
+	}
+	if err := c.store.StoreCheckpoint(cp); err != nil {
+		c.log.Warn("Failed to store derivation pipeline checkpoint", "safe", x.Safe, "err", err)
+		return true
+	}
+	c.last = now
+	c.log.Debug("Stored derivation pipeline checkpoint", "safe", x.Safe, "origin", cp.Origin)
+	return true
+}
@@ -0,0 +1,72 @@
+package driver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/engine"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testlog"
+)
+
+type fakeCheckpointPipeline struct {
+	err error
+}
+
+func (f *fakeCheckpointPipeline) Checkpoint(safe eth.L2BlockRef) (*derive.PipelineCheckpoint, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &derive.PipelineCheckpoint{SafeHead: safe}, nil
+}
+
+type fakeCheckpointStore struct {
+	stored []*derive.PipelineCheckpoint
+}
+
+func (f *fakeCheckpointStore) LoadCheckpoint() (*derive.PipelineCheckpoint, error) {
+	if len(f.stored) == 0 {
+		return nil, nil
+	}
+	return f.stored[len(f.stored)-1], nil
+}
+
+func (f *fakeCheckpointStore) StoreCheckpoint(cp *derive.PipelineCheckpoint) error {
+	f.stored = append(f.stored, cp)
+	return nil
+}
+
+func TestPipelineCheckpointer(t *testing.T) {
+	pipeline := &fakeCheckpointPipeline{}
+	store := &fakeCheckpointStore{}
+	c := NewPipelineCheckpointer(testlog.Logger(t, log.LevelDebug), pipeline, store, time.Minute)
+	now := time.Unix(1000, 0)
+	c.now = func() time.Time { return now }
+	safe := func(n uint64) engine.SafeDerivedEvent {
+		return engine.SafeDerivedEvent{Safe: eth.L2BlockRef{Number: n}}
+	}
+
+	require.False(t, c.OnEvent(engine.PendingSafeUpdateEvent{}))
+
+	require.True(t, c.OnEvent(safe(1)))
+	require.Len(t, store.stored, 1)
+
+	// within the interval no checkpoint is taken
+	now = now.Add(30 * time.Second)
+	require.True(t, c.OnEvent(safe(2)))
+	require.Len(t, store.stored, 1)
+
+	// checkpoints that cannot be taken are retried on the next update
+	now = now.Add(time.Minute)
+	pipeline.err = derive.ErrNotCheckpointable
+	require.True(t, c.OnEvent(safe(3)))
+	require.Len(t, store.stored, 1)
+	pipeline.err = nil
+	require.True(t, c.OnEvent(safe(4)))
+	require.Len(t, store.stored, 2)
+	require.Equal(t, uint64(4), store.stored[1].SafeHead.Number)
+}
@@ -1,5 +1,11 @@
 package driver
 
+import (
+	"time"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/sequencing"
+)
+
 type Config struct {
 	// VerifierConfDepth is the distance to keep from the L1 head when reading L1 data for L2 derivation.
 	VerifierConfDepth uint64 `json:"verifier_conf_depth"`
@@ -11,13 +17,34 @@ type Config struct {
 	// and thus fail to produce a block with anything more than deposits.
 	SequencerConfDepth uint64 `json:"sequencer_conf_depth"`
 
+	// SequencerOriginPolicy decides how long the sequencer holds on to the current L1 origin,
+	// before adopting the next L1 origin. It applies on top of SequencerConfDepth.
+	SequencerOriginPolicy sequencing.OriginPolicyConfig `json:"sequencer_origin_policy"`
+
 	// SequencerEnabled is true when the driver should sequence new blocks.
 	SequencerEnabled bool `json:"sequencer_enabled"`
 
 	// SequencerStopped is false when the driver should sequence new blocks.
 	SequencerStopped bool `json:"sequencer_stopped"`
 
+	// SequencerDepositPreSimulation enables dry-running the deposits of the first block of an epoch
+	// against the circuit capacity checker of the engine, to exclude rejected deposits before building the block.
+	// The engine must serve engine_simulateDepositsV1, which the node checks at startup.
+	SequencerDepositPreSimulation bool `json:"sequencer_deposit_pre_simulation"`
+
 	// SequencerMaxSafeLag is the maximum number of L2 blocks for restricting the distance between L2 safe and unsafe.
 	// Disabled if 0.
 	SequencerMaxSafeLag uint64 `json:"sequencer_max_safe_lag"`
+
+	// L1PrefetchDepth is the number of L1 blocks to prefetch ahead of the derivation pipeline.
+	// Disabled if 0.
+	L1PrefetchDepth uint64 `json:"l1_prefetch_depth"`
+
+	// PipelineCheckpointPath is the file to keep a checkpoint of the derivation pipeline in,
+	// to continue derivation from the safe head after a restart instead of rewinding the L1 traversal.
+	// Disabled if empty.
+	PipelineCheckpointPath string `json:"pipeline_checkpoint_path"`
+
+	// PipelineCheckpointInterval is the minimum time between two pipeline checkpoints.
+	PipelineCheckpointInterval time.Duration `json:"pipeline_checkpoint_interval"`
 }
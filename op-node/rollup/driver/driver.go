@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/core/types"
 	"github.com/zircuit-labs/l2-geth-public/log"
 
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
@@ -16,6 +17,7 @@ import (
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/engine"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/event"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/finality"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/interop"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/sequencing"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/status"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/sync"
@@ -52,8 +54,10 @@ type Metrics interface {
 
 	engine.Metrics
 	L1FetcherMetrics
+	derive.L1PrefetchMetrics
 	event.Metrics
 	sequencing.Metrics
+	sequencing.OriginSelectorMetrics
 }
 
 type L1Chain interface {
@@ -66,6 +70,7 @@ type L2Chain interface {
 	L2BlockRefByLabel(ctx context.Context, label eth.BlockLabel) (eth.L2BlockRef, error)
 	L2BlockRefByHash(ctx context.Context, l2Hash common.Hash) (eth.L2BlockRef, error)
 	L2BlockRefByNumber(ctx context.Context, num uint64) (eth.L2BlockRef, error)
+	FetchReceipts(ctx context.Context, blockHash common.Hash) (eth.BlockInfo, types.Receipts, error)
 }
 
 type DerivationPipeline interface {
@@ -152,6 +157,8 @@ func NewDriver(
 	metrics Metrics,
 	sequencerStateListener sequencing.SequencerStateListener,
 	safeHeadListener rollup.SafeHeadListener,
+	derivationAuditor derive.DerivationAuditor,
+	interopChecker interop.Checker,
 	syncCfg *sync.Config,
 	sequencerConductor conductor.SequencerConductor,
 	producer status.L2BlockProducer,
@@ -168,6 +175,7 @@ func NewDriver(
 	}
 	sys := event.NewSystem(log, executor)
 	sys.AddTracer(event.NewMetricsTracer(metrics))
+	sys.AddTracer(event.NewOTelTracer())
 
 	opts := event.DefaultRegisterOpts()
 
@@ -177,6 +185,8 @@ func NewDriver(
 	l1Tracker := status.NewL1Tracker(l1)
 	sys.Register("l1-blocks", l1Tracker, opts)
 
+	sys.Register("l1-reorgs", status.NewL1ReorgTracker(driverCtx, log, l1), opts)
+
 	l1 = NewMeteredL1Fetcher(l1Tracker, metrics)
 	verifConfDepth := confdepth.NewConfDepth(driverCfg.VerifierConfDepth, statusTracker.L1Head, l1)
 
@@ -195,11 +205,44 @@ func NewDriver(
 	sys.Register("attributes-handler",
 		attributes.NewAttributesHandler(log, cfg, driverCtx, l2), opts)
 
-	derivationPipeline := derive.NewDerivationPipeline(log, cfg, verifConfDepth, l1Blobs, l2, metrics)
+	var pipelineL1 derive.L1Fetcher = verifConfDepth
+	var l1Prefetch *derive.L1Prefetcher
+	if driverCfg.L1PrefetchDepth > 0 {
+		// Only the pipeline reads from the prefetcher: it traverses L1 block by block,
+		// unlike the other users of the L1 source, which look up blocks out of order.
+		l1Prefetch = derive.NewL1Prefetcher(log, cfg, verifConfDepth, l1Blobs, driverCfg.L1PrefetchDepth, metrics)
+		pipelineL1, l1Blobs = l1Prefetch, l1Prefetch
+	}
+	derivationPipeline := derive.NewDerivationPipeline(log, cfg, pipelineL1, l1Blobs, l2, metrics)
+	if l1Prefetch != nil {
+		derivationPipeline.SetL1OriginListener(l1Prefetch)
+	}
+	if derivationAuditor != nil {
+		derivationPipeline.SetAuditor(derivationAuditor)
+	}
 
 	sys.Register("pipeline",
 		derive.NewPipelineDeriver(driverCtx, derivationPipeline), opts)
 
+	if driverCfg.PipelineCheckpointPath != "" {
+		store := derive.NewFileCheckpointStore(driverCfg.PipelineCheckpointPath)
+		if cp, err := store.LoadCheckpoint(); err != nil {
+			log.Warn("Failed to load derivation pipeline checkpoint", "path", driverCfg.PipelineCheckpointPath, "err", err)
+		} else if cp != nil {
+			log.Info("Loaded derivation pipeline checkpoint", "safe", cp.SafeHead, "origin", cp.Origin)
+			derivationPipeline.SetCheckpoint(cp)
+		}
+		sys.Register("pipeline-checkpointer",
+			NewPipelineCheckpointer(log, derivationPipeline, store, driverCfg.PipelineCheckpointInterval), opts)
+	}
+
+	// The cross-unsafe head is only tracked if interop is scheduled
+	if cfg.InteropTime != nil && interopChecker != nil {
+		checks := sys.Register("cross-unsafe-checks", nil, opts)
+		sys.Register("cross-unsafe",
+			interop.NewCrossUnsafeTracker(driverCtx, log, cfg, l2, interopChecker, checks), opts)
+	}
+
 	syncDeriver := &SyncDeriver{
 		Derivation:     derivationPipeline,
 		SafeHeadNotifs: safeHeadListener,
@@ -225,9 +268,21 @@ func NewDriver(
 		asyncGossiper := async.NewAsyncGossiper(driverCtx, network, log, metrics)
 		attrBuilder := derive.NewFetchingAttributesBuilder(cfg, l1, l2)
 		sequencerConfDepth := confdepth.NewConfDepth(driverCfg.SequencerConfDepth, statusTracker.L1Head, l1)
-		findL1Origin := sequencing.NewL1OriginSelector(log, cfg, sequencerConfDepth)
+		originPolicy := sequencing.NewOriginPolicy(driverCfg.SequencerOriginPolicy)
+		if deriver, ok := originPolicy.(event.Deriver); ok {
+			sys.Register("origin-policy", deriver, opts)
+		}
+		findL1Origin := sequencing.NewL1OriginSelector(log, cfg, sequencerConfDepth, statusTracker.L1Head, originPolicy, metrics)
+		var depositSimulator sequencing.DepositSimulator
+		if driverCfg.SequencerDepositPreSimulation {
+			if sim, ok := l2.(sequencing.DepositSimulator); ok {
+				depositSimulator = sim
+			} else {
+				log.Warn("L2 engine does not support deposit simulation, relying on the engine to reject deposits")
+			}
+		}
 		sequencer = sequencing.NewSequencer(driverCtx, log, cfg, attrBuilder, findL1Origin,
-			sequencerStateListener, sequencerConductor, asyncGossiper, metrics)
+			sequencerStateListener, sequencerConductor, asyncGossiper, depositSimulator, metrics)
 		sys.Register("sequencer", sequencer, opts)
 	} else {
 		sequencer = sequencing.DisabledSequencer{}
@@ -255,6 +310,7 @@ func NewDriver(
 		l1FinalizedSig:   make(chan eth.L1BlockRef, 10),
 		unsafeL2Payloads: make(chan *eth.ExecutionPayloadEnvelope, 10),
 		altSync:          altSync,
+		l1Prefetch:       l1Prefetch,
 	}
 
 	return driver
@@ -68,6 +68,8 @@ type Driver struct {
 	sequencer sequencing.SequencerIface
 	network   Network // may be nil, network for is optional
 
+	l1Prefetch *derive.L1Prefetcher // nil if L1 prefetching is disabled
+
 	metrics Metrics
 	log     log.Logger
 
@@ -102,6 +104,9 @@ func (s *Driver) Close() error {
 	s.wg.Wait()
 	s.eventSys.Stop()
 	s.sequencer.Close()
+	if s.l1Prefetch != nil {
+		s.l1Prefetch.Close()
+	}
 	return nil
 }
 
@@ -136,6 +141,16 @@ func (s *Driver) OnL1Finalized(ctx context.Context, finalized eth.L1BlockRef) er
 	}
 }
 
+// OnL1ProviderDisagreement signals the driver that L1 providers returned different blocks for the same block number.
+func (s *Driver) OnL1ProviderDisagreement(err error) {
+	s.emitter.Emit(rollup.L1ProviderDisagreementEvent{Err: err})
+}
+
+// OnEngineReplicaDivergence signals the driver that a replica of the execution engine diverged from the primary.
+func (s *Driver) OnEngineReplicaDivergence(err error) {
+	s.emitter.Emit(engine.ReplicaDivergenceEvent{Err: err})
+}
+
 func (s *Driver) OnUnsafeL2Payload(ctx context.Context, envelope *eth.ExecutionPayloadEnvelope) error {
 	select {
 	case <-ctx.Done():
@@ -346,6 +361,9 @@ func (s *SyncDeriver) OnEvent(ev event.Event) bool {
 	case rollup.L1TemporaryErrorEvent:
 		s.Log.Warn("L1 temporary error", "err", x.Err)
 		s.Emitter.Emit(StepReqEvent{})
+	case rollup.L1ProviderDisagreementEvent:
+		// Derivation only continues on blocks that the quorum of L1 providers agrees on.
+		s.Log.Warn("L1 providers disagree", "err", x.Err)
 	case rollup.EngineTemporaryErrorEvent:
 		s.Log.Warn("Engine temporary error", "err", x.Err)
 		// Make sure that for any temporarily failed attributes we retry processing.
@@ -498,6 +516,10 @@ func (s *Driver) SequencerActive(ctx context.Context) (bool, error) {
 	return s.sequencer.Active(), nil
 }
 
+func (s *Driver) SetSequencerMaxSafeLag(ctx context.Context, v uint64) error {
+	return s.sequencer.SetMaxSafeLag(ctx, v)
+}
+
 func (s *Driver) OverrideLeader(ctx context.Context) error {
 	return s.sequencer.OverrideLeader(ctx)
 }
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
 )
@@ -54,14 +56,21 @@ type BuildSealEvent struct {
 	IsLastInSpan bool
 	// payload is promoted to pending-safe if non-zero
 	DerivedFrom eth.L1BlockRef
+
+	// Trace of the block building, if any
+	Trace trace.SpanContext
 }
 
 func (ev BuildSealEvent) String() string {
 	return "build-seal"
 }
 
+func (ev BuildSealEvent) TraceContext() trace.SpanContext {
+	return ev.Trace
+}
+
 func (eq *EngDeriver) onBuildSeal(ev BuildSealEvent) {
-	ctx, cancel := context.WithTimeout(eq.ctx, buildSealTimeout)
+	ctx, cancel := context.WithTimeout(trace.ContextWithSpanContext(eq.ctx, ev.Trace), buildSealTimeout)
 	defer cancel()
 
 	sealingStart := time.Now()
@@ -146,5 +155,6 @@ func (eq *EngDeriver) onBuildSeal(ev BuildSealEvent) {
 		Info:         ev.Info,
 		Envelope:     envelope,
 		Ref:          ref,
+		Trace:        ev.Trace,
 	})
 }
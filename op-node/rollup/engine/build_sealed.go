@@ -1,6 +1,8 @@
 package engine
 
 import (
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
 )
 
@@ -15,12 +17,19 @@ type BuildSealedEvent struct {
 	Info     eth.PayloadInfo
 	Envelope *eth.ExecutionPayloadEnvelope
 	Ref      eth.L2BlockRef
+
+	// Trace of the block building, if any
+	Trace trace.SpanContext
 }
 
 func (ev BuildSealedEvent) String() string {
 	return "build-sealed"
 }
 
+func (ev BuildSealedEvent) TraceContext() trace.SpanContext {
+	return ev.Trace
+}
+
 func (eq *EngDeriver) onBuildSealed(ev BuildSealedEvent) {
 	// If a (pending) safe block, immediately process the block
 	if ev.DerivedFrom != (eth.L1BlockRef{}) {
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/zircuit-labs/l2-geth-public/common"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
@@ -23,14 +25,20 @@ func (ev BuildTransactionsRejectedEvent) String() string {
 type BuildStartEvent struct {
 	Attributes   *derive.AttributesWithParent
 	IsSequencing bool
+	// Trace of the block building, if any
+	Trace trace.SpanContext
 }
 
 func (ev BuildStartEvent) String() string {
 	return "build-start"
 }
 
+func (ev BuildStartEvent) TraceContext() trace.SpanContext {
+	return ev.Trace
+}
+
 func (eq *EngDeriver) onBuildStart(ev BuildStartEvent) {
-	ctx, cancel := context.WithTimeout(eq.ctx, buildStartTimeout)
+	ctx, cancel := context.WithTimeout(trace.ContextWithSpanContext(eq.ctx, ev.Trace), buildStartTimeout)
 	defer cancel()
 
 	if ev.Attributes.DerivedFrom != (eth.L1BlockRef{}) &&
@@ -82,5 +90,6 @@ func (eq *EngDeriver) onBuildStart(ev BuildStartEvent) {
 		IsLastInSpan: ev.Attributes.IsLastInSpan,
 		DerivedFrom:  ev.Attributes.DerivedFrom,
 		Parent:       ev.Attributes.Parent,
+		Trace:        ev.Trace,
 	})
 }
@@ -3,6 +3,8 @@ package engine
 import (
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
 )
@@ -20,12 +22,19 @@ type BuildStartedEvent struct {
 	IsLastInSpan bool
 	// payload is promoted to pending-safe if non-zero
 	DerivedFrom eth.L1BlockRef
+
+	// Trace of the block building, if any
+	Trace trace.SpanContext
 }
 
 func (ev BuildStartedEvent) String() string {
 	return "build-started"
 }
 
+func (ev BuildStartedEvent) TraceContext() trace.SpanContext {
+	return ev.Trace
+}
+
 func (eq *EngDeriver) onBuildStarted(ev BuildStartedEvent) {
 	// If a (pending) safe block, immediately seal the block
 	if ev.DerivedFrom != (eth.L1BlockRef{}) {
@@ -34,6 +43,7 @@ func (eq *EngDeriver) onBuildStarted(ev BuildStartedEvent) {
 			BuildStarted: ev.BuildStarted,
 			IsLastInSpan: ev.IsLastInSpan,
 			DerivedFrom:  ev.DerivedFrom,
+			Trace:        ev.Trace,
 		})
 	}
 }
@@ -129,6 +129,16 @@ func (ev PromoteFinalizedEvent) String() string {
 	return "promote-finalized"
 }
 
+// ReplicaDivergenceEvent signals that a replica of the execution engine
+// did not agree with the primary engine on an engine API call.
+type ReplicaDivergenceEvent struct {
+	Err error
+}
+
+func (ev ReplicaDivergenceEvent) String() string {
+	return "replica-divergence"
+}
+
 type EngDeriver struct {
 	metrics Metrics
 
@@ -282,6 +292,9 @@ func (d *EngDeriver) OnEvent(ev event.Event) bool {
 		d.onPayloadSuccess(x)
 	case PayloadInvalidEvent:
 		d.onPayloadInvalid(x)
+	case ReplicaDivergenceEvent:
+		// The primary engine remains the source of truth, the replica needs to be repaired by the operator.
+		d.log.Error("Engine replica diverged from primary", "err", x.Err)
 	default:
 		return false
 	}
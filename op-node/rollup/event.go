@@ -27,6 +27,17 @@ func (ev EngineTemporaryErrorEvent) String() string {
 	return "engine-temporary-error"
 }
 
+// L1ProviderDisagreementEvent identifies L1 providers that returned different blocks for the same block number.
+type L1ProviderDisagreementEvent struct {
+	Err error
+}
+
+var _ event.Event = L1ProviderDisagreementEvent{}
+
+func (ev L1ProviderDisagreementEvent) String() string {
+	return "l1-provider-disagreement"
+}
+
 type ResetEvent struct {
 	Err error
 }
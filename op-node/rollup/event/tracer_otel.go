@@ -0,0 +1,127 @@
+package event
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/simplelru"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/optracing"
+)
+
+// otelEmittersCacheSize is the number of emitted events to remember the emitting span of,
+// until the events are derived.
+const otelEmittersCacheSize = 1000
+
+// TracedEvent is an event that carries the trace context of the work it is part of,
+// e.g. the building of a block, so that the processing of the event continues that trace.
+type TracedEvent interface {
+	Event
+	TraceContext() trace.SpanContext
+}
+
+// OTelTracer records the processing of events by derivers as OpenTelemetry spans.
+// The span of a deriver processing an event is a child of the span of the deriver processing
+// that emitted the event, so that a chain of events forms a single trace.
+// A TracedEvent continues the trace it carries instead.
+// Spans are only exported when tracing is enabled, see the optracing package.
+type OTelTracer struct {
+	tracer trace.Tracer
+
+	mu sync.Mutex
+	// derivations in progress, by derive context
+	derivations map[uint64]*otelDerivation
+	// span contexts of the derivations that emitted recent events, by emit context
+	emitters *simplelru.LRU[uint64, trace.SpanContext]
+}
+
+type otelDerivation struct {
+	name         string
+	ev           AnnotatedEvent
+	derivContext uint64
+	start        time.Time
+	parent       context.Context
+	// span is only started once the derivation emits an event, or ends with an effect,
+	// as pass-through events would only add noise to the trace.
+	span trace.Span
+}
+
+var _ Tracer = (*OTelTracer)(nil)
+
+func NewOTelTracer() *OTelTracer {
+	return newOTelTracer(optracing.Tracer("github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/event"))
+}
+
+func newOTelTracer(tracer trace.Tracer) *OTelTracer {
+	emitters, _ := simplelru.NewLRU[uint64, trace.SpanContext](otelEmittersCacheSize, nil)
+	return &OTelTracer{
+		tracer:      tracer,
+		derivations: make(map[uint64]*otelDerivation),
+		emitters:    emitters,
+	}
+}
+
+func (ot *OTelTracer) OnDeriveStart(name string, ev AnnotatedEvent, derivContext uint64, startTime time.Time) {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	parent := context.Background()
+	if traced, ok := ev.Event.(TracedEvent); ok && traced.TraceContext().IsValid() {
+		parent = trace.ContextWithSpanContext(parent, traced.TraceContext())
+	} else if emitter, ok := ot.emitters.Get(ev.EmitContext); ok {
+		parent = trace.ContextWithSpanContext(parent, emitter)
+	}
+	ot.derivations[derivContext] = &otelDerivation{
+		name:         name,
+		ev:           ev,
+		derivContext: derivContext,
+		start:        startTime,
+		parent:       parent,
+	}
+}
+
+func (ot *OTelTracer) OnDeriveEnd(name string, ev AnnotatedEvent, derivContext uint64, startTime time.Time, duration time.Duration, effect bool) {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	d, ok := ot.derivations[derivContext]
+	if !ok {
+		return
+	}
+	delete(ot.derivations, derivContext)
+	if d.span == nil && !effect {
+		return
+	}
+	ot.startSpan(d).End(trace.WithTimestamp(startTime.Add(duration)))
+}
+
+func (ot *OTelTracer) OnRateLimited(name string, derivContext uint64) {
+}
+
+func (ot *OTelTracer) OnEmit(name string, ev AnnotatedEvent, derivContext uint64, emitTime time.Time) {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	d, ok := ot.derivations[derivContext]
+	if !ok { // not emitted during the processing of an event
+		return
+	}
+	span := ot.startSpan(d)
+	span.AddEvent("emit/"+ev.Event.String(), trace.WithTimestamp(emitTime),
+		trace.WithAttributes(attribute.Int64("emit_context", int64(ev.EmitContext))))
+	ot.emitters.Add(ev.EmitContext, span.SpanContext())
+}
+
+// startSpan starts the span of the derivation, if it is not started yet, and returns it.
+func (ot *OTelTracer) startSpan(d *otelDerivation) trace.Span {
+	if d.span == nil {
+		_, d.span = ot.tracer.Start(d.parent, "event/"+d.ev.Event.String(),
+			trace.WithTimestamp(d.start),
+			trace.WithAttributes(
+				attribute.String("deriver", d.name),
+				attribute.Int64("emit_context", int64(d.ev.EmitContext)),
+				attribute.Int64("derive_context", int64(d.derivContext)),
+			))
+	}
+	return d.span
+}
@@ -0,0 +1,76 @@
+package event
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type tracedTestEvent struct {
+	trace trace.SpanContext
+}
+
+func (ev tracedTestEvent) String() string {
+	return "traced"
+}
+
+func (ev tracedTestEvent) TraceContext() trace.SpanContext {
+	return ev.trace
+}
+
+func TestOTelTracer(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tracer := newOTelTracer(provider.Tracer("test"))
+	spans := func() map[string]tracetest.SpanStub {
+		out := make(map[string]tracetest.SpanStub)
+		for _, s := range exporter.GetSpans() {
+			for _, attr := range s.Attributes {
+				if attr.Key == "deriver" {
+					out[s.Name+"/"+attr.Value.AsString()] = s
+				}
+			}
+		}
+		return out
+	}
+
+	now := time.Now()
+	// deriver "a" processes an event, and emits another one
+	tracer.OnDeriveStart("a", AnnotatedEvent{Event: TestEvent{}, EmitContext: 1}, 1, now)
+	tracer.OnEmit("a", AnnotatedEvent{Event: TestEvent{}, EmitContext: 2}, 1, now)
+	tracer.OnDeriveEnd("a", AnnotatedEvent{Event: TestEvent{}, EmitContext: 1}, 1, now, time.Millisecond, true)
+	// deriver "b" passes on the emitted event, deriver "c" processes it
+	tracer.OnDeriveStart("b", AnnotatedEvent{Event: TestEvent{}, EmitContext: 2}, 2, now)
+	tracer.OnDeriveEnd("b", AnnotatedEvent{Event: TestEvent{}, EmitContext: 2}, 2, now, time.Millisecond, false)
+	tracer.OnDeriveStart("c", AnnotatedEvent{Event: TestEvent{}, EmitContext: 2}, 3, now)
+	tracer.OnDeriveEnd("c", AnnotatedEvent{Event: TestEvent{}, EmitContext: 2}, 3, now, time.Millisecond, true)
+
+	got := spans()
+	require.Len(t, got, 2, "pass-through event is not traced")
+	a, c := got["event/X/a"], got["event/X/c"]
+	require.False(t, a.Parent.IsValid(), "event emitted outside of a deriver starts a trace")
+	require.Equal(t, a.SpanContext.TraceID(), c.Parent.TraceID())
+	require.Equal(t, a.SpanContext.SpanID(), c.Parent.SpanID(), "processing of the event is a child of its emitter")
+	require.Len(t, a.Events, 1)
+	require.Equal(t, "emit/X", a.Events[0].Name)
+	require.Equal(t, time.Millisecond, c.EndTime.Sub(c.StartTime))
+
+	// an event that carries a trace context continues that trace, not the one of its emitter
+	_, span := provider.Tracer("test").Start(context.Background(), "build")
+	span.End()
+	ev := tracedTestEvent{trace: span.SpanContext()}
+	tracer.OnDeriveStart("d", AnnotatedEvent{Event: TestEvent{}, EmitContext: 3}, 4, now)
+	tracer.OnEmit("d", AnnotatedEvent{Event: ev, EmitContext: 4}, 4, now)
+	tracer.OnDeriveEnd("d", AnnotatedEvent{Event: TestEvent{}, EmitContext: 3}, 4, now, time.Millisecond, true)
+	tracer.OnDeriveStart("e", AnnotatedEvent{Event: ev, EmitContext: 4}, 5, now)
+	tracer.OnDeriveEnd("e", AnnotatedEvent{Event: ev, EmitContext: 4}, 5, now, time.Millisecond, true)
+
+	e := spans()["event/traced/e"]
+	require.Equal(t, span.SpanContext().SpanID(), e.Parent.SpanID())
+	require.Empty(t, tracer.derivations)
+}
@@ -0,0 +1,87 @@
+package interop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ethereum "github.com/zircuit-labs/l2-geth-public"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/core/types"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+)
+
+// MessageStatus is the result of checking an executing message against the chain of the initiating message.
+type MessageStatus string
+
+const (
+	// MessageValid indicates the initiating message exists, and matches the executing message.
+	MessageValid MessageStatus = "valid"
+	// MessageUnknown indicates the initiating message is not known yet, e.g. because the chain is not synced up to it.
+	MessageUnknown MessageStatus = "unknown"
+	// MessageInvalid indicates the executing message does not match the initiating message.
+	MessageInvalid MessageStatus = "invalid"
+)
+
+// ChainSource provides the blocks and logs of a chain that messages may be initiated on.
+type ChainSource interface {
+	InfoByNumber(ctx context.Context, number uint64) (eth.BlockInfo, error)
+	FetchReceipts(ctx context.Context, blockHash common.Hash) (eth.BlockInfo, types.Receipts, error)
+}
+
+// MessageChecker checks executing messages against the chains the node knows about.
+type MessageChecker struct {
+	chains map[uint64]ChainSource
+}
+
+func NewMessageChecker() *MessageChecker {
+	return &MessageChecker{chains: make(map[uint64]ChainSource)}
+}
+
+// AddChain registers the source of the chain with the given chain ID, to check messages of the chain against.
+func (c *MessageChecker) AddChain(chainID uint64, src ChainSource) {
+	c.chains[chainID] = src
+}
+
+// CheckMessage checks if the identified initiating message exists with the given payload hash.
+// Messages of chains that are not registered are unknown.
+func (c *MessageChecker) CheckMessage(ctx context.Context, id Identifier, payloadHash common.Hash) (MessageStatus, error) {
+	src, ok := c.chains[uint64(id.ChainID)]
+	if !ok {
+		return MessageUnknown, nil
+	}
+	info, err := src.InfoByNumber(ctx, uint64(id.BlockNumber))
+	if errors.Is(err, ethereum.NotFound) {
+		return MessageUnknown, nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to fetch block of %s: %w", id, err)
+	}
+	if info.Time() != uint64(id.Timestamp) {
+		return MessageInvalid, nil
+	}
+	_, receipts, err := src.FetchReceipts(ctx, info.Hash())
+	if errors.Is(err, ethereum.NotFound) {
+		// the block was reorged out since we fetched it
+		return MessageUnknown, nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to fetch receipts of %s: %w", id, err)
+	}
+	l := findLog(receipts, uint(id.LogIndex))
+	if l == nil || l.Address != id.Origin || LogPayloadHash(l) != payloadHash {
+		return MessageInvalid, nil
+	}
+	return MessageValid, nil
+}
+
+// findLog returns the log with the given block-wide log index, or nil if there is none.
+func findLog(receipts types.Receipts, index uint) *types.Log {
+	for _, rec := range receipts {
+		for _, l := range rec.Logs {
+			if l.Index == index {
+				return l
+			}
+		}
+	}
+	return nil
+}
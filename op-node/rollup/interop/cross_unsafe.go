@@ -0,0 +1,260 @@
+package interop
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/core/types"
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/engine"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/event"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+)
+
+type L2Source interface {
+	L2BlockRefByNumber(ctx context.Context, num uint64) (eth.L2BlockRef, error)
+	FetchReceipts(ctx context.Context, blockHash common.Hash) (eth.BlockInfo, types.Receipts, error)
+}
+
+type Checker interface {
+	CheckMessage(ctx context.Context, id Identifier, payloadHash common.Hash) (MessageStatus, error)
+}
+
+// crossUnsafeCheckTimeout bounds the time spent checking the dependencies of a single block,
+// so that an unavailable peer chain cannot keep the check in flight.
+const crossUnsafeCheckTimeout = 10 * time.Second
+
+// CrossUnsafeUpdateEvent signals that all dependencies of the L2 blocks up to and including CrossUnsafe are valid.
+type CrossUnsafeUpdateEvent struct {
+	CrossUnsafe eth.L2BlockRef
+}
+
+func (ev CrossUnsafeUpdateEvent) String() string {
+	return "cross-unsafe-update"
+}
+
+// CrossUnsafeCheckEvent is the result of checking the dependencies of the block after the cross-unsafe head.
+type CrossUnsafeCheckEvent struct {
+	// CrossUnsafe is the cross-unsafe head the check started from.
+	CrossUnsafe eth.L2BlockRef
+	// Next is the block after the cross-unsafe head, zero if it could not be fetched.
+	Next eth.L2BlockRef
+	// Valid is true if all executing messages of Next are known to be valid.
+	Valid bool
+}
+
+func (ev CrossUnsafeCheckEvent) String() string {
+	return "cross-unsafe-check"
+}
+
+// CrossUnsafeTracker records the executing messages of unsafe L2 blocks past the interop activation,
+// and tracks the cross-unsafe head: the last unsafe L2 block of which all executing messages,
+// and those of its ancestors, are known to be valid on the chain of the initiating message.
+// The cross-unsafe head starts at the local safe head, and stops advancing at the first block with
+// a dependency that is not known yet. Checks of unknown dependencies are retried on every forkchoice update.
+//
+// The dependencies are checked one block at a time, outside of the event processing, since they are
+// fetched from the L2 engine and the peer chains. The result of each check is emitted as CrossUnsafeCheckEvent,
+// with an emitter that is not used by any deriver, as it is called concurrently with the event processing.
+//
+// The cross-unsafe head is only reported, in the sync status: the unsafe and safe heads do not wait on it.
+type CrossUnsafeTracker struct {
+	ctx     context.Context
+	log     log.Logger
+	cfg     *rollup.Config
+	l2      L2Source
+	checker Checker
+
+	emitter event.Emitter
+	// checks emits the results of the dependency checks
+	checks event.Emitter
+
+	mu sync.Mutex
+
+	crossUnsafe eth.L2BlockRef
+	unsafe      eth.L2BlockRef
+	safe        eth.L2BlockRef
+
+	// checking is true while the dependencies of the block after the cross-unsafe head are being checked
+	checking bool
+	// held is true if the last check did not find all dependencies of the next block to be valid
+	held bool
+
+	// executing messages of the blocks past the cross-unsafe head, by block hash
+	deps map[common.Hash][]ExecutingMessage
+}
+
+var _ event.Deriver = (*CrossUnsafeTracker)(nil)
+
+func NewCrossUnsafeTracker(ctx context.Context, log log.Logger, cfg *rollup.Config, l2 L2Source, checker Checker, checks event.Emitter) *CrossUnsafeTracker {
+	return &CrossUnsafeTracker{
+		ctx:     ctx,
+		log:     log,
+		cfg:     cfg,
+		l2:      l2,
+		checker: checker,
+		checks:  checks,
+		deps:    make(map[common.Hash][]ExecutingMessage),
+	}
+}
+
+func (t *CrossUnsafeTracker) AttachEmitter(em event.Emitter) {
+	t.emitter = em
+}
+
+// CrossUnsafe returns the current cross-unsafe head.
+func (t *CrossUnsafeTracker) CrossUnsafe() eth.L2BlockRef {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.crossUnsafe
+}
+
+// ExecutingMessages returns the recorded executing messages of the given block past the cross-unsafe head.
+func (t *CrossUnsafeTracker) ExecutingMessages(blockHash common.Hash) ([]ExecutingMessage, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	deps, ok := t.deps[blockHash]
+	return deps, ok
+}
+
+func (t *CrossUnsafeTracker) OnEvent(ev event.Event) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch x := ev.(type) {
+	case engine.EngineResetConfirmedEvent:
+		t.unsafe = x.Unsafe
+		t.safe = x.Safe
+		t.reset()
+	case engine.PayloadSuccessEvent:
+		if x.Ref.Number <= t.crossUnsafe.Number {
+			t.log.Warn("Unsafe block replaced cross-unsafe block", "block", x.Ref, "cross_unsafe", t.crossUnsafe)
+			t.reset()
+		}
+		t.unsafe = x.Ref
+		t.held = false
+	case engine.ForkchoiceUpdateEvent:
+		t.unsafe = x.UnsafeL2Head
+		t.safe = x.SafeL2Head
+		t.held = false
+	case CrossUnsafeCheckEvent:
+		t.checking = false
+		t.onCheck(x)
+	default:
+		return false
+	}
+	t.startCheck()
+	return true
+}
+
+// reset rewinds the cross-unsafe head to the local safe head, to check all unsafe blocks again.
+func (t *CrossUnsafeTracker) reset() {
+	t.crossUnsafe = t.safe
+	t.held = false
+	clear(t.deps)
+	t.emitter.Emit(CrossUnsafeUpdateEvent{CrossUnsafe: t.crossUnsafe})
+}
+
+// onCheck moves the cross-unsafe head forward if the checked block is valid,
+// or holds it until the next forkchoice update otherwise.
+func (t *CrossUnsafeTracker) onCheck(x CrossUnsafeCheckEvent) {
+	if x.CrossUnsafe != t.crossUnsafe {
+		// the cross-unsafe head was reset while checking
+		return
+	}
+	if x.Next != (eth.L2BlockRef{}) && x.Next.ParentHash != t.crossUnsafe.Hash {
+		t.log.Warn("Cross-unsafe head was reorged out", "cross_unsafe", t.crossUnsafe, "next", x.Next)
+		t.reset()
+		return
+	}
+	if !x.Valid {
+		t.held = true
+		return
+	}
+	delete(t.deps, x.Next.Hash)
+	t.crossUnsafe = x.Next
+	t.emitter.Emit(CrossUnsafeUpdateEvent{CrossUnsafe: x.Next})
+}
+
+// startCheck starts checking the dependencies of the block after the cross-unsafe head,
+// unless a check is in flight already, or there is nothing to check until the next forkchoice update.
+func (t *CrossUnsafeTracker) startCheck() {
+	if t.checking || t.held || t.crossUnsafe.Number >= t.unsafe.Number {
+		return
+	}
+	t.checking = true
+	go func(crossUnsafe eth.L2BlockRef) {
+		ctx, cancel := context.WithTimeout(t.ctx, crossUnsafeCheckTimeout)
+		defer cancel()
+		next, valid := t.check(ctx, crossUnsafe)
+		t.checks.Emit(CrossUnsafeCheckEvent{CrossUnsafe: crossUnsafe, Next: next, Valid: valid})
+	}(t.crossUnsafe)
+}
+
+// check fetches the block after the cross-unsafe head, and checks if all its executing messages are valid.
+func (t *CrossUnsafeTracker) check(ctx context.Context, crossUnsafe eth.L2BlockRef) (eth.L2BlockRef, bool) {
+	next, err := t.l2.L2BlockRefByNumber(ctx, crossUnsafe.Number+1)
+	if err != nil {
+		t.log.Warn("Failed to fetch next block to check dependencies of", "number", crossUnsafe.Number+1, "err", err)
+		return eth.L2BlockRef{}, false
+	}
+	if next.ParentHash != crossUnsafe.Hash {
+		return next, false
+	}
+	msgs, err := t.record(ctx, next)
+	if err != nil {
+		t.log.Warn("Failed to record executing messages", "block", next, "err", err)
+		return next, false
+	}
+	for _, msg := range msgs {
+		status, err := t.checker.CheckMessage(ctx, msg.Identifier, msg.PayloadHash)
+		if err != nil {
+			t.log.Warn("Failed to check executing message", "block", next, "message", msg.Identifier, "err", err)
+			return next, false
+		}
+		switch status {
+		case MessageValid:
+			continue
+		case MessageUnknown:
+			t.log.Debug("Holding cross-unsafe head at unknown dependency", "block", next, "message", msg.Identifier)
+		default:
+			t.log.Error("Block has invalid executing message", "block", next, "message", msg.Identifier, "status", status)
+		}
+		return next, false
+	}
+	return next, true
+}
+
+// record returns the executing messages of the block, if it is past the interop activation.
+// They are fetched once, and kept until the block is cross-unsafe.
+func (t *CrossUnsafeTracker) record(ctx context.Context, ref eth.L2BlockRef) ([]ExecutingMessage, error) {
+	if !t.cfg.IsInterop(ref.Time) {
+		return nil, nil
+	}
+	t.mu.Lock()
+	msgs, ok := t.deps[ref.Hash]
+	t.mu.Unlock()
+	if ok {
+		return msgs, nil
+	}
+	_, receipts, err := t.l2.FetchReceipts(ctx, ref.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch receipts: %w", err)
+	}
+	msgs, err = ExecutingMessagesFromReceipts(receipts)
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) > 0 {
+		t.log.Info("Recorded executing messages", "block", ref, "messages", len(msgs))
+	}
+	t.mu.Lock()
+	t.deps[ref.Hash] = msgs
+	t.mu.Unlock()
+	return msgs, nil
+}
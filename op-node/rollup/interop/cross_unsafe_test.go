@@ -0,0 +1,286 @@
+package interop
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	ethereum "github.com/zircuit-labs/l2-geth-public"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/common/hexutil"
+	"github.com/zircuit-labs/l2-geth-public/core/types"
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/engine"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/event"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testlog"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testutils"
+)
+
+// fakeChain is an in-process chain, serving as both the local L2 source and a peer chain source.
+type fakeChain struct {
+	blocks   []eth.L2BlockRef
+	receipts map[common.Hash]types.Receipts
+}
+
+func newFakeChain(genesis eth.L2BlockRef) *fakeChain {
+	return &fakeChain{blocks: []eth.L2BlockRef{genesis}, receipts: make(map[common.Hash]types.Receipts)}
+}
+
+func (c *fakeChain) addBlock(rng *rand.Rand, logs ...*types.Log) eth.L2BlockRef {
+	ref := testutils.NextRandomL2Ref(rng, 2, c.blocks[len(c.blocks)-1], eth.BlockID{})
+	for i, l := range logs {
+		l.Index = uint(i)
+	}
+	c.blocks = append(c.blocks, ref)
+	c.receipts[ref.Hash] = types.Receipts{{Logs: logs}}
+	return ref
+}
+
+func (c *fakeChain) get(num uint64) (eth.L2BlockRef, bool) {
+	start := c.blocks[0].Number
+	if num < start || num-start >= uint64(len(c.blocks)) {
+		return eth.L2BlockRef{}, false
+	}
+	return c.blocks[num-start], true
+}
+
+func (c *fakeChain) info(ref eth.L2BlockRef) eth.BlockInfo {
+	return &testutils.MockBlockInfo{InfoHash: ref.Hash, InfoParentHash: ref.ParentHash, InfoNum: ref.Number, InfoTime: ref.Time}
+}
+
+func (c *fakeChain) L2BlockRefByNumber(ctx context.Context, num uint64) (eth.L2BlockRef, error) {
+	ref, ok := c.get(num)
+	if !ok {
+		return eth.L2BlockRef{}, ethereum.NotFound
+	}
+	return ref, nil
+}
+
+func (c *fakeChain) InfoByNumber(ctx context.Context, num uint64) (eth.BlockInfo, error) {
+	ref, ok := c.get(num)
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return c.info(ref), nil
+}
+
+func (c *fakeChain) FetchReceipts(ctx context.Context, blockHash common.Hash) (eth.BlockInfo, types.Receipts, error) {
+	for _, ref := range c.blocks {
+		if ref.Hash == blockHash {
+			return c.info(ref), c.receipts[blockHash], nil
+		}
+	}
+	return nil, nil, ethereum.NotFound
+}
+
+// recordingEmitter records the emitted events, and passes on the results of dependency checks,
+// which are emitted outside of the event processing.
+type recordingEmitter struct {
+	mu     sync.Mutex
+	events []event.Event
+	checks chan CrossUnsafeCheckEvent
+}
+
+func (e *recordingEmitter) Emit(ev event.Event) {
+	if x, ok := ev.(CrossUnsafeCheckEvent); ok {
+		e.checks <- x
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, ev)
+}
+
+// settle processes the results of the dependency checks, until no check is in flight.
+func settle(t *testing.T, tracker *CrossUnsafeTracker, em *recordingEmitter) {
+	for {
+		tracker.mu.Lock()
+		checking := tracker.checking
+		tracker.mu.Unlock()
+		if !checking {
+			return
+		}
+		select {
+		case ev := <-em.checks:
+			tracker.OnEvent(ev)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for dependency check")
+		}
+	}
+}
+
+func TestMessageChecker(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	peer := newFakeChain(testutils.RandomL2BlockRef(rng))
+	initiating := &types.Log{Address: common.Address{0xaa}, Topics: []common.Hash{{0x01}}, Data: []byte("hello")}
+	ref := peer.addBlock(rng, &types.Log{Address: common.Address{0xbb}}, initiating)
+
+	checker := NewMessageChecker()
+	checker.AddChain(902, peer)
+	id := Identifier{Origin: common.Address{0xaa}, BlockNumber: hexutil.Uint64(ref.Number), LogIndex: 1, Timestamp: hexutil.Uint64(ref.Time), ChainID: 902}
+	payloadHash := LogPayloadHash(initiating)
+
+	check := func(id Identifier, payloadHash common.Hash) MessageStatus {
+		status, err := checker.CheckMessage(context.Background(), id, payloadHash)
+		require.NoError(t, err)
+		return status
+	}
+	require.Equal(t, MessageValid, check(id, payloadHash))
+	require.Equal(t, MessageInvalid, check(id, common.Hash{0x02}), "payload mismatch")
+
+	other := id
+	other.Origin = common.Address{0xbb}
+	require.Equal(t, MessageInvalid, check(other, payloadHash), "origin mismatch")
+	other = id
+	other.LogIndex = 5
+	require.Equal(t, MessageInvalid, check(other, payloadHash), "no such log")
+	other = id
+	other.Timestamp++
+	require.Equal(t, MessageInvalid, check(other, payloadHash), "timestamp mismatch")
+	other = id
+	other.BlockNumber++
+	require.Equal(t, MessageUnknown, check(other, payloadHash), "block not known yet")
+	other = id
+	other.ChainID = 903
+	require.Equal(t, MessageUnknown, check(other, payloadHash), "chain not registered")
+}
+
+func TestCrossUnsafeTracker(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	logger := testlog.Logger(t, log.LevelDebug)
+
+	genesisA := testutils.RandomL2BlockRef(rng)
+	genesisA.Number = 100
+	genesisA.Time = 1000
+	chainA := newFakeChain(genesisA)
+	genesisB := testutils.RandomL2BlockRef(rng)
+	genesisB.Number = 200
+	genesisB.Time = 1000
+	chainB := newFakeChain(genesisB)
+
+	interopTime := uint64(1004)
+	cfg := &rollup.Config{InteropTime: &interopTime}
+
+	checker := NewMessageChecker()
+	checker.AddChain(902, chainB)
+	em := &recordingEmitter{checks: make(chan CrossUnsafeCheckEvent, 1)}
+	tracker := NewCrossUnsafeTracker(context.Background(), logger, cfg, chainA, checker, em)
+	tracker.AttachEmitter(em)
+
+	tracker.OnEvent(engine.EngineResetConfirmedEvent{Unsafe: genesisA, Safe: genesisA, Finalized: genesisA})
+	require.Equal(t, genesisA, tracker.CrossUnsafe())
+
+	// pre-interop blocks have no dependencies to check
+	a1 := chainA.addBlock(rng)
+	a2 := chainA.addBlock(rng)
+	require.True(t, tracker.OnEvent(engine.PayloadSuccessEvent{Ref: a1}))
+	require.True(t, tracker.OnEvent(engine.PayloadSuccessEvent{Ref: a2}))
+	settle(t, tracker, em)
+	require.Equal(t, a2, tracker.CrossUnsafe())
+
+	// a block executing a message of chain B, which chain B does not have yet
+	initiating := &types.Log{Address: common.Address{0xaa}, Topics: []common.Hash{{0x01}}, Data: []byte("hello")}
+	msg := ExecutingMessage{
+		Identifier: Identifier{
+			Origin:      common.Address{0xaa},
+			BlockNumber: hexutil.Uint64(genesisB.Number + 1),
+			LogIndex:    0,
+			Timestamp:   hexutil.Uint64(genesisB.Time + 2),
+			ChainID:     902,
+		},
+		PayloadHash: LogPayloadHash(initiating),
+	}
+	a3 := chainA.addBlock(rng, ExecutingMessageLog(msg))
+	a4 := chainA.addBlock(rng)
+	em.events = nil
+	tracker.OnEvent(engine.PayloadSuccessEvent{Ref: a3})
+	tracker.OnEvent(engine.PayloadSuccessEvent{Ref: a4})
+	settle(t, tracker, em)
+	require.Equal(t, a2, tracker.CrossUnsafe(), "held at unknown dependency")
+	require.Empty(t, em.events)
+	deps, ok := tracker.ExecutingMessages(a3.Hash)
+	require.True(t, ok)
+	require.Equal(t, []ExecutingMessage{msg}, deps)
+
+	// once chain B includes the initiating message, the cross-unsafe head advances
+	chainB.addBlock(rng, initiating)
+	tracker.OnEvent(engine.ForkchoiceUpdateEvent{UnsafeL2Head: a4, SafeL2Head: genesisA})
+	settle(t, tracker, em)
+	require.Equal(t, a4, tracker.CrossUnsafe())
+	require.Equal(t, []event.Event{
+		CrossUnsafeUpdateEvent{CrossUnsafe: a3},
+		CrossUnsafeUpdateEvent{CrossUnsafe: a4},
+	}, em.events)
+	_, ok = tracker.ExecutingMessages(a3.Hash)
+	require.False(t, ok, "dependencies are dropped once checked")
+
+	// a block with an invalid executing message holds the cross-unsafe head
+	bad := msg
+	bad.PayloadHash = common.Hash{0x02}
+	a5 := chainA.addBlock(rng, ExecutingMessageLog(bad))
+	tracker.OnEvent(engine.PayloadSuccessEvent{Ref: a5})
+	tracker.OnEvent(engine.ForkchoiceUpdateEvent{UnsafeL2Head: a5, SafeL2Head: genesisA})
+	settle(t, tracker, em)
+	require.Equal(t, a4, tracker.CrossUnsafe())
+
+	// a reset rewinds the cross-unsafe head to the safe head, and checks the unsafe blocks again
+	em.events = nil
+	tracker.OnEvent(engine.EngineResetConfirmedEvent{Unsafe: a5, Safe: a1, Finalized: genesisA})
+	settle(t, tracker, em)
+	require.Equal(t, a4, tracker.CrossUnsafe())
+	require.Equal(t, CrossUnsafeUpdateEvent{CrossUnsafe: a1}, em.events[0])
+}
+
+// blockingChecker holds every check until it is released.
+type blockingChecker struct {
+	release chan struct{}
+}
+
+func (c *blockingChecker) CheckMessage(ctx context.Context, id Identifier, payloadHash common.Hash) (MessageStatus, error) {
+	select {
+	case <-c.release:
+		return MessageValid, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func TestCrossUnsafeTrackerSlowPeer(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	genesis := testutils.RandomL2BlockRef(rng)
+	chain := newFakeChain(genesis)
+	interopTime := uint64(0)
+	cfg := &rollup.Config{InteropTime: &interopTime}
+
+	checker := &blockingChecker{release: make(chan struct{})}
+	em := &recordingEmitter{checks: make(chan CrossUnsafeCheckEvent, 1)}
+	tracker := NewCrossUnsafeTracker(context.Background(), testlog.Logger(t, log.LevelDebug), cfg, chain, checker, em)
+	tracker.AttachEmitter(em)
+	tracker.OnEvent(engine.EngineResetConfirmedEvent{Unsafe: genesis, Safe: genesis, Finalized: genesis})
+
+	msg := ExecutingMessage{Identifier: Identifier{ChainID: 902}, PayloadHash: common.Hash{0x01}}
+	a1 := chain.addBlock(rng, ExecutingMessageLog(msg))
+	// the event processing does not wait on the peer chain
+	done := make(chan struct{})
+	go func() {
+		tracker.OnEvent(engine.PayloadSuccessEvent{Ref: a1})
+		tracker.OnEvent(engine.ForkchoiceUpdateEvent{UnsafeL2Head: a1, SafeL2Head: genesis})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("event processing blocked on dependency check")
+	}
+	require.Equal(t, genesis, tracker.CrossUnsafe())
+
+	close(checker.release)
+	settle(t, tracker, em)
+	require.Equal(t, a1, tracker.CrossUnsafe())
+}
@@ -0,0 +1,128 @@
+package interop
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/common/hexutil"
+	"github.com/zircuit-labs/l2-geth-public/core/types"
+	"github.com/zircuit-labs/l2-geth-public/crypto"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-bindings/predeploys"
+)
+
+// ExecutingMessageEventABI is the event the CrossL2Inbox emits when a message of another chain is executed.
+const ExecutingMessageEventABI = "ExecutingMessage(bytes32,(address,uint256,uint256,uint256,uint256))"
+
+var ExecutingMessageEventABIHash = crypto.Keccak256Hash([]byte(ExecutingMessageEventABI))
+
+var (
+	ErrNotExecutingMessage = errors.New("not an executing message log")
+	ErrInvalidIdentifier   = errors.New("invalid message identifier encoding")
+)
+
+// Identifier identifies the log of the initiating message on the source chain.
+type Identifier struct {
+	Origin      common.Address `json:"origin"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	LogIndex    hexutil.Uint64 `json:"logIndex"`
+	Timestamp   hexutil.Uint64 `json:"timestamp"`
+	ChainID     hexutil.Uint64 `json:"chainID"`
+}
+
+func (id Identifier) String() string {
+	return fmt.Sprintf("chain %d block %d log %d", uint64(id.ChainID), uint64(id.BlockNumber), uint64(id.LogIndex))
+}
+
+// ExecutingMessage is a dependency of an L2 block on a message of a (peer) chain.
+type ExecutingMessage struct {
+	Identifier  Identifier  `json:"identifier"`
+	PayloadHash common.Hash `json:"payloadHash"`
+}
+
+// DecodeExecutingMessageLog decodes the executing message from a CrossL2Inbox ExecutingMessage log.
+// ErrNotExecutingMessage is returned if the log is not an executing message.
+func DecodeExecutingMessageLog(l *types.Log) (ExecutingMessage, error) {
+	if l.Address != predeploys.CrossL2InboxAddr || len(l.Topics) != 2 || l.Topics[0] != ExecutingMessageEventABIHash {
+		return ExecutingMessage{}, ErrNotExecutingMessage
+	}
+	// the identifier is ABI-encoded as static tuple of 5 words
+	if len(l.Data) != 5*32 {
+		return ExecutingMessage{}, fmt.Errorf("%w: unexpected data length %d", ErrInvalidIdentifier, len(l.Data))
+	}
+	word := func(i int) []byte { return l.Data[i*32 : (i+1)*32] }
+	if !isZero(word(0)[:12]) {
+		return ExecutingMessage{}, fmt.Errorf("%w: origin is not an address", ErrInvalidIdentifier)
+	}
+	var nums [4]uint64
+	for i := range nums {
+		w := word(i + 1)
+		if !isZero(w[:24]) {
+			return ExecutingMessage{}, fmt.Errorf("%w: word %d exceeds uint64", ErrInvalidIdentifier, i+1)
+		}
+		nums[i] = binary.BigEndian.Uint64(w[24:])
+	}
+	return ExecutingMessage{
+		Identifier: Identifier{
+			Origin:      common.BytesToAddress(word(0)),
+			BlockNumber: hexutil.Uint64(nums[0]),
+			LogIndex:    hexutil.Uint64(nums[1]),
+			Timestamp:   hexutil.Uint64(nums[2]),
+			ChainID:     hexutil.Uint64(nums[3]),
+		},
+		PayloadHash: l.Topics[1],
+	}, nil
+}
+
+// ExecutingMessageLog encodes the executing message as the ExecutingMessage log of the CrossL2Inbox.
+func ExecutingMessageLog(msg ExecutingMessage) *types.Log {
+	data := make([]byte, 5*32)
+	copy(data[12:32], msg.Identifier.Origin[:])
+	for i, v := range []uint64{uint64(msg.Identifier.BlockNumber), uint64(msg.Identifier.LogIndex),
+		uint64(msg.Identifier.Timestamp), uint64(msg.Identifier.ChainID)} {
+		binary.BigEndian.PutUint64(data[(i+2)*32-8:(i+2)*32], v)
+	}
+	return &types.Log{
+		Address: predeploys.CrossL2InboxAddr,
+		Topics:  []common.Hash{ExecutingMessageEventABIHash, msg.PayloadHash},
+		Data:    data,
+	}
+}
+
+func isZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ExecutingMessagesFromReceipts returns the executing messages of a block, in log order.
+func ExecutingMessagesFromReceipts(receipts types.Receipts) ([]ExecutingMessage, error) {
+	var out []ExecutingMessage
+	for _, rec := range receipts {
+		for _, l := range rec.Logs {
+			msg, err := DecodeExecutingMessageLog(l)
+			if errors.Is(err, ErrNotExecutingMessage) {
+				continue
+			} else if err != nil {
+				return nil, fmt.Errorf("invalid executing message in tx %s: %w", rec.TxHash, err)
+			}
+			out = append(out, msg)
+		}
+	}
+	return out, nil
+}
+
+// LogPayloadHash computes the hash of the log payload (the topics and data), which an executing message commits to.
+func LogPayloadHash(l *types.Log) common.Hash {
+	payload := make([]byte, 0, 32*len(l.Topics)+len(l.Data))
+	for _, topic := range l.Topics {
+		payload = append(payload, topic[:]...)
+	}
+	payload = append(payload, l.Data...)
+	return crypto.Keccak256Hash(payload)
+}
@@ -0,0 +1,59 @@
+package interop
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/core/types"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-bindings/predeploys"
+)
+
+func TestDecodeExecutingMessageLog(t *testing.T) {
+	msg := ExecutingMessage{
+		Identifier: Identifier{
+			Origin:      common.Address{0xaa},
+			BlockNumber: 123,
+			LogIndex:    4,
+			Timestamp:   5678,
+			ChainID:     901,
+		},
+		PayloadHash: common.Hash{0xbb},
+	}
+	l := ExecutingMessageLog(msg)
+	decoded, err := DecodeExecutingMessageLog(l)
+	require.NoError(t, err)
+	require.Equal(t, msg, decoded)
+
+	other := *l
+	other.Address = common.Address{0x01}
+	_, err = DecodeExecutingMessageLog(&other)
+	require.ErrorIs(t, err, ErrNotExecutingMessage)
+
+	other = *l
+	other.Data = append([]byte{}, l.Data...)
+	other.Data[32] = 1 // block number exceeds uint64
+	_, err = DecodeExecutingMessageLog(&other)
+	require.ErrorIs(t, err, ErrInvalidIdentifier)
+
+	other = *l
+	other.Data = l.Data[:4*32]
+	_, err = DecodeExecutingMessageLog(&other)
+	require.ErrorIs(t, err, ErrInvalidIdentifier)
+
+	msgs, err := ExecutingMessagesFromReceipts(types.Receipts{
+		{Logs: []*types.Log{{Address: common.Address{0x01}}}},
+		{Logs: []*types.Log{l, {Address: predeploys.CrossL2InboxAddr}}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []ExecutingMessage{msg}, msgs)
+}
+
+func TestLogPayloadHash(t *testing.T) {
+	a := &types.Log{Topics: []common.Hash{{0x01}, {0x02}}, Data: []byte{0x03}}
+	b := &types.Log{Topics: []common.Hash{{0x01}}, Data: append(common.Hash{0x02}.Bytes(), 0x03)}
+	require.Equal(t, LogPayloadHash(a), LogPayloadHash(b), "payload is the concatenation of topics and data")
+	c := &types.Log{Topics: []common.Hash{{0x01}, {0x02}}, Data: []byte{0x04}}
+	require.NotEqual(t, LogPayloadHash(a), LogPayloadHash(c))
+}
@@ -0,0 +1,152 @@
+package sequencing
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/event"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/status"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+)
+
+// OriginPolicyKind names a strategy for adopting new L1 origins as sequencer.
+type OriginPolicyKind string
+
+const (
+	// OriginPolicyImmediate adopts the next L1 origin as soon as the L2 block time allows it.
+	OriginPolicyImmediate OriginPolicyKind = "immediate"
+	// OriginPolicyHold keeps the current L1 origin until the next L1 origin has a fixed number of confirmations,
+	// or until the sequencer drift forces the sequencer to adopt it.
+	OriginPolicyHold OriginPolicyKind = "hold"
+	// OriginPolicyAdaptive is like OriginPolicyHold, but the number of confirmations follows the recent L1 reorg depth.
+	OriginPolicyAdaptive OriginPolicyKind = "adaptive"
+)
+
+var OriginPolicyKinds = []OriginPolicyKind{OriginPolicyImmediate, OriginPolicyHold, OriginPolicyAdaptive}
+
+func (k OriginPolicyKind) String() string {
+	return string(k)
+}
+
+func (k OriginPolicyKind) Check() error {
+	for _, v := range OriginPolicyKinds {
+		if k == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown L1 origin policy %q, expected one of %v", string(k), OriginPolicyKinds)
+}
+
+// OriginPolicyConfig configures the L1 origin policy of the sequencer.
+type OriginPolicyConfig struct {
+	Kind OriginPolicyKind `json:"kind"`
+
+	// Confs is the number of confirmations of the next L1 origin to hold on the current origin for.
+	// With the adaptive policy this is the minimum number of confirmations.
+	Confs uint64 `json:"confs"`
+
+	// MaxConfs caps the number of confirmations the adaptive policy requires.
+	MaxConfs uint64 `json:"max_confs"`
+
+	// ReorgWindow is the number of L1 blocks an observed L1 reorg is taken into account for by the adaptive policy.
+	ReorgWindow uint64 `json:"reorg_window"`
+}
+
+func (c *OriginPolicyConfig) Check() error {
+	if err := c.Kind.Check(); err != nil {
+		return err
+	}
+	if c.Kind == OriginPolicyAdaptive {
+		if c.MaxConfs < c.Confs {
+			return fmt.Errorf("max L1 origin confirmations %d must not be less than the minimum %d", c.MaxConfs, c.Confs)
+		}
+		if c.ReorgWindow == 0 {
+			return fmt.Errorf("L1 reorg window of the adaptive L1 origin policy must be positive")
+		}
+	}
+	return nil
+}
+
+// OriginPolicy decides how many confirmations the next L1 origin needs before the sequencer adopts it.
+// The sequencer holds on to the current L1 origin while the next origin has fewer confirmations,
+// but never beyond the max sequencer drift.
+type OriginPolicy interface {
+	RequiredConfs() uint64
+}
+
+// NewOriginPolicy creates the L1 origin policy of the given config.
+// The config is expected to be checked already; unknown kinds fall back to the immediate policy.
+func NewOriginPolicy(cfg OriginPolicyConfig) OriginPolicy {
+	switch cfg.Kind {
+	case OriginPolicyHold:
+		return FixedConfsPolicy(cfg.Confs)
+	case OriginPolicyAdaptive:
+		return NewAdaptiveConfsPolicy(cfg.Confs, cfg.MaxConfs, cfg.ReorgWindow)
+	default:
+		return FixedConfsPolicy(0)
+	}
+}
+
+// FixedConfsPolicy requires a fixed number of confirmations. At 0 the next L1 origin is adopted immediately.
+type FixedConfsPolicy uint64
+
+func (p FixedConfsPolicy) RequiredConfs() uint64 {
+	return uint64(p)
+}
+
+type observedReorg struct {
+	// l1 head number after the reorg
+	at    uint64
+	depth uint64
+}
+
+// AdaptiveConfsPolicy requires as many confirmations as the depth of the deepest L1 reorg observed within the reorg window,
+// bounded by the minimum and maximum number of confirmations.
+type AdaptiveConfsPolicy struct {
+	minConfs uint64
+	maxConfs uint64
+	window   uint64
+
+	mu     sync.Mutex
+	head   eth.L1BlockRef
+	reorgs []observedReorg
+}
+
+var _ event.Deriver = (*AdaptiveConfsPolicy)(nil)
+
+func NewAdaptiveConfsPolicy(minConfs, maxConfs, window uint64) *AdaptiveConfsPolicy {
+	return &AdaptiveConfsPolicy{minConfs: minConfs, maxConfs: maxConfs, window: window}
+}
+
+func (p *AdaptiveConfsPolicy) OnEvent(ev event.Event) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch x := ev.(type) {
+	case status.L1ReorgEvent:
+		p.reorgs = append(p.reorgs, observedReorg{at: x.NewHead.Number, depth: x.Depth})
+	case status.L1UnsafeEvent:
+		p.head = x.L1Unsafe
+		// drop the reorgs that fell out of the window
+		kept := p.reorgs[:0]
+		for _, r := range p.reorgs {
+			if r.at+p.window > p.head.Number {
+				kept = append(kept, r)
+			}
+		}
+		p.reorgs = kept
+	default:
+		return false
+	}
+	return true
+}
+
+func (p *AdaptiveConfsPolicy) RequiredConfs() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	confs := p.minConfs
+	for _, r := range p.reorgs {
+		// a reorg of depth d replaced the d most recent blocks, an origin with d confirmations survives it
+		confs = max(confs, r.depth)
+	}
+	return min(confs, p.maxConfs)
+}
@@ -0,0 +1,281 @@
+package sequencing
+
+import (
+	"context"
+	"math/rand" // nosemgrep
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	l1ethereum "github.com/ethereum/go-ethereum"
+	l1types "github.com/ethereum/go-ethereum/core/types"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/metrics"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/event"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/status"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testlog"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testutils"
+)
+
+func TestOriginPolicyConfig(t *testing.T) {
+	require.NoError(t, (&OriginPolicyConfig{Kind: OriginPolicyImmediate}).Check())
+	require.NoError(t, (&OriginPolicyConfig{Kind: OriginPolicyHold, Confs: 3}).Check())
+	require.NoError(t, (&OriginPolicyConfig{Kind: OriginPolicyAdaptive, Confs: 1, MaxConfs: 4, ReorgWindow: 10}).Check())
+	require.ErrorContains(t, (&OriginPolicyConfig{Kind: "foo"}).Check(), "unknown L1 origin policy")
+	require.Error(t, (&OriginPolicyConfig{Kind: OriginPolicyAdaptive, Confs: 5, MaxConfs: 4, ReorgWindow: 10}).Check())
+	require.Error(t, (&OriginPolicyConfig{Kind: OriginPolicyAdaptive, Confs: 1, MaxConfs: 4}).Check())
+
+	require.Equal(t, FixedConfsPolicy(0), NewOriginPolicy(OriginPolicyConfig{Kind: OriginPolicyImmediate, Confs: 3}))
+	require.Equal(t, FixedConfsPolicy(3), NewOriginPolicy(OriginPolicyConfig{Kind: OriginPolicyHold, Confs: 3}))
+	require.IsType(t, &AdaptiveConfsPolicy{}, NewOriginPolicy(OriginPolicyConfig{Kind: OriginPolicyAdaptive, Confs: 1, MaxConfs: 4, ReorgWindow: 10}))
+}
+
+func TestAdaptiveConfsPolicy(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	p := NewAdaptiveConfsPolicy(1, 4, 10)
+	require.Equal(t, uint64(1), p.RequiredConfs())
+
+	head := testutils.RandomBlockRef(rng)
+	head.Number = 100
+	require.True(t, p.OnEvent(status.L1UnsafeEvent{L1Unsafe: head}))
+	head = testutils.NextRandomRef(rng, head)
+	p.OnEvent(status.L1UnsafeEvent{L1Unsafe: head})
+	require.Equal(t, uint64(1), p.RequiredConfs(), "linear extension is not a reorg")
+
+	// reorg of depth 2: the two most recent blocks are replaced
+	reorged := testutils.RandomBlockRef(rng)
+	reorged.Number = head.Number - 1
+	require.True(t, p.OnEvent(status.L1ReorgEvent{OldHead: head, NewHead: reorged, Depth: 2}))
+	p.OnEvent(status.L1UnsafeEvent{L1Unsafe: reorged})
+	require.Equal(t, uint64(2), p.RequiredConfs())
+
+	// reorg deeper than the max
+	head = reorged
+	reorged = testutils.RandomBlockRef(rng)
+	reorged.Number = head.Number - 7
+	p.OnEvent(status.L1ReorgEvent{OldHead: head, NewHead: reorged, Depth: 8})
+	p.OnEvent(status.L1UnsafeEvent{L1Unsafe: reorged})
+	require.Equal(t, uint64(4), p.RequiredConfs(), "capped at max confs")
+
+	// the deep reorg leaves the window, the shallow reorg is still in it
+	head = reorged
+	for head.Number < reorged.Number+9 {
+		head = testutils.NextRandomRef(rng, head)
+		p.OnEvent(status.L1UnsafeEvent{L1Unsafe: head})
+	}
+	require.Equal(t, uint64(4), p.RequiredConfs())
+	head = testutils.NextRandomRef(rng, head)
+	p.OnEvent(status.L1UnsafeEvent{L1Unsafe: head})
+	require.Equal(t, uint64(2), p.RequiredConfs(), "reorgs out of the window are forgotten")
+	for head.Number < 110 {
+		head = testutils.NextRandomRef(rng, head)
+		p.OnEvent(status.L1UnsafeEvent{L1Unsafe: head})
+	}
+	require.Equal(t, uint64(1), p.RequiredConfs())
+
+	require.False(t, p.OnEvent(status.L1SafeEvent{L1Safe: head}))
+}
+
+// chaoticL1 is an L1 chain that randomly reorgs its most recent blocks.
+// The chain only reorgs at its highest block number, so the blocks that are reorged out
+// are always within the max reorg depth of the highest L1 block.
+type chaoticL1 struct {
+	t   *testing.T
+	rng *rand.Rand
+
+	canonical []eth.L1BlockRef
+	byHash    map[common.Hash]eth.L1BlockRef
+
+	reorgChance   float64
+	maxReorgDepth int
+
+	highest uint64
+	// depth of the most recent reorg, the number of blocks it replaced
+	lastReorgDepth uint64
+}
+
+func newChaoticL1(t *testing.T, rng *rand.Rand, reorgChance float64, maxReorgDepth int) *chaoticL1 {
+	genesis := testutils.RandomBlockRef(rng)
+	genesis.Number = 1000
+	genesis.Time = 10_000
+	return &chaoticL1{
+		t:             t,
+		rng:           rng,
+		canonical:     []eth.L1BlockRef{genesis},
+		byHash:        map[common.Hash]eth.L1BlockRef{genesis.Hash: genesis},
+		reorgChance:   reorgChance,
+		maxReorgDepth: maxReorgDepth,
+		highest:       genesis.Number,
+	}
+}
+
+func (c *chaoticL1) head() eth.L1BlockRef {
+	return c.canonical[len(c.canonical)-1]
+}
+
+func (c *chaoticL1) add(ref eth.L1BlockRef) {
+	c.canonical = append(c.canonical, ref)
+	c.byHash[ref.Hash] = ref
+	c.highest = max(c.highest, ref.Number)
+}
+
+// step produces a new L1 block at the given time, or reorgs the most recent blocks.
+func (c *chaoticL1) step(now uint64) {
+	if c.rng.Float64() < c.reorgChance && len(c.canonical) > c.maxReorgDepth+1 && c.head().Number == c.highest {
+		depth := c.rng.Intn(c.maxReorgDepth + 1)
+		// replace the blocks from the new head number onwards, with a new head that is depth blocks behind
+		replaced := c.canonical[len(c.canonical)-1-depth]
+		c.canonical = c.canonical[:len(c.canonical)-1-depth]
+		ref := testutils.NextRandomRef(c.rng, c.head())
+		ref.Time = replaced.Time
+		c.add(ref)
+		c.lastReorgDepth = uint64(depth) + 1
+		return
+	}
+	ref := testutils.NextRandomRef(c.rng, c.head())
+	ref.Time = now
+	c.add(ref)
+}
+
+func (c *chaoticL1) isCanonical(id eth.BlockID) bool {
+	first := c.canonical[0].Number
+	if id.Number < first || id.Number-first >= uint64(len(c.canonical)) {
+		return false
+	}
+	return c.canonical[id.Number-first].Hash == id.Hash
+}
+
+func (c *chaoticL1) L1BlockRefByHash(ctx context.Context, hash common.Hash) (eth.L1BlockRef, error) {
+	ref, ok := c.byHash[hash]
+	if !ok {
+		return eth.L1BlockRef{}, l1ethereum.NotFound
+	}
+	return ref, nil
+}
+
+func (c *chaoticL1) L1BlockRefByNumber(ctx context.Context, num uint64) (eth.L1BlockRef, error) {
+	first := c.canonical[0].Number
+	if num < first || num-first >= uint64(len(c.canonical)) {
+		return eth.L1BlockRef{}, l1ethereum.NotFound
+	}
+	return c.canonical[num-first], nil
+}
+
+func (c *chaoticL1) FetchReceipts(ctx context.Context, blockHash common.Hash) (eth.BlockInfo, l1types.Receipts, error) {
+	c.t.Fatal("origin selection does not fetch receipts")
+	return nil, nil, nil
+}
+
+type originChaosResult struct {
+	// number of sequenced L2 blocks that were reorged out because their L1 origin was reorged out
+	reorgedL2Blocks int
+	// number of L1 origins that were adopted with fewer confirmations than required, due to the sequencer drift
+	forcedOrigins int
+}
+
+// runOriginChaos sequences L2 blocks on top of a chaotic L1 chain, with the given L1 origin policy,
+// and checks that the L1 origins respect the sequencer drift, and the confirmations of the policy.
+func runOriginChaos(t *testing.T, seed int64, reorgChance float64, maxSeqDrift uint64, policy OriginPolicy) originChaosResult {
+	rng := rand.New(rand.NewSource(seed))
+	logger := testlog.Logger(t, log.LevelError)
+	cfg := &rollup.Config{
+		MaxSequencerDrift: maxSeqDrift,
+		BlockTime:         2,
+	}
+	l1 := newChaoticL1(t, rng, reorgChance, 2)
+	reorgs := status.NewL1ReorgTracker(context.Background(), logger, l1)
+	reorgs.AttachEmitter(event.EmitterFunc(func(ev event.Event) {
+		reorg := ev.(status.L1ReorgEvent)
+		require.Equal(t, l1.lastReorgDepth, reorg.Depth, "reorg depth is measured from the common ancestor")
+		if d, ok := policy.(*AdaptiveConfsPolicy); ok {
+			d.OnEvent(reorg)
+		}
+	}))
+	emitHead := func() {
+		reorgs.OnEvent(status.L1UnsafeEvent{L1Unsafe: l1.head()})
+		if d, ok := policy.(*AdaptiveConfsPolicy); ok {
+			d.OnEvent(status.L1UnsafeEvent{L1Unsafe: l1.head()})
+		}
+	}
+	emitHead()
+	los := NewL1OriginSelector(logger, cfg, l1, l1.head, policy, metrics.NoopMetrics)
+
+	l2 := []eth.L2BlockRef{{
+		Hash:     testutils.RandomHash(rng),
+		Number:   500,
+		Time:     l1.head().Time,
+		L1Origin: l1.head().ID(),
+	}}
+	var res originChaosResult
+	now := l2[0].Time
+	nextL1Time := now + 12
+	for i := 0; i < 5000; i++ {
+		now += cfg.BlockTime
+		if now >= nextL1Time {
+			l1.step(now)
+			emitHead()
+			nextL1Time += 12
+			// re-sequence the L2 blocks of which the L1 origin was reorged out
+			for j, ref := range l2 {
+				if !l1.isCanonical(ref.L1Origin) {
+					res.reorgedL2Blocks += len(l2) - j
+					l2 = l2[:j]
+					break
+				}
+			}
+			require.NotEmpty(t, l2, "the L2 genesis origin cannot be reorged out")
+		}
+		for l2[len(l2)-1].Time+cfg.BlockTime <= now {
+			head := l2[len(l2)-1]
+			origin, err := los.FindL1Origin(context.Background(), head)
+			require.NoError(t, err)
+			ref := eth.L2BlockRef{
+				Hash:       testutils.RandomHash(rng),
+				Number:     head.Number + 1,
+				ParentHash: head.Hash,
+				Time:       head.Time + cfg.BlockTime,
+				L1Origin:   origin.ID(),
+			}
+			require.LessOrEqual(t, origin.Time, ref.Time, "L1 origin may not be ahead of L2 block")
+			require.LessOrEqual(t, ref.Time-origin.Time, maxSeqDrift, "L2 block must stay within sequencer drift")
+			if origin.Number != head.L1Origin.Number {
+				require.Equal(t, head.L1Origin.Number+1, origin.Number, "L1 origin may only advance one block")
+				if confs := l1.head().Number - origin.Number; confs < policy.RequiredConfs() {
+					current := l1.byHash[head.L1Origin.Hash]
+					require.Greater(t, ref.Time-current.Time, maxSeqDrift, "may only adopt unconfirmed origin when forced by sequencer drift")
+					res.forcedOrigins++
+				}
+			}
+			l2 = append(l2, ref)
+		}
+	}
+	return res
+}
+
+// TestOriginPolicyChaos sequences on top of an L1 chain with frequent shallow reorgs,
+// and checks that holding the L1 origin until it is confirmed avoids re-sequencing L2 blocks.
+func TestOriginPolicyChaos(t *testing.T) {
+	const maxSeqDrift = 600
+	const seed = 1234
+	immediate := runOriginChaos(t, seed, 0.1, maxSeqDrift, FixedConfsPolicy(0))
+	require.Greater(t, immediate.reorgedL2Blocks, 0, "adopting unconfirmed L1 origins results in L2 reorgs")
+
+	hold := runOriginChaos(t, seed, 0.1, maxSeqDrift, FixedConfsPolicy(3))
+	require.Zero(t, hold.reorgedL2Blocks, "L1 origins with more confirmations than the max reorg depth are not reorged out")
+	require.Zero(t, hold.forcedOrigins)
+
+	adaptive := runOriginChaos(t, seed, 0.1, maxSeqDrift, NewAdaptiveConfsPolicy(0, 12, 256))
+	require.Less(t, adaptive.reorgedL2Blocks, immediate.reorgedL2Blocks, "adaptive confirmations reduce L2 reorgs")
+	require.Zero(t, adaptive.forcedOrigins)
+}
+
+// TestOriginPolicyChaosSeqDrift checks that the sequencer drift takes precedence over the confirmations of the policy.
+// L1 reorgs are disabled: the reorged L1 blocks are older, and may exceed the small sequencer drift by themselves.
+func TestOriginPolicyChaosSeqDrift(t *testing.T) {
+	res := runOriginChaos(t, 1234, 0, 60, FixedConfsPolicy(8))
+	require.Greater(t, res.forcedOrigins, 0, "next L1 origins are adopted before they are confirmed, to stay within the sequencer drift")
+}
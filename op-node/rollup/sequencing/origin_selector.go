@@ -19,20 +19,33 @@ type L1Blocks interface {
 	derive.L1BlockRefByNumberFetcher
 }
 
+type OriginSelectorMetrics interface {
+	RecordSequencerL1OriginConfs(required uint64)
+	RecordSequencerL1OriginHeld()
+}
+
 type L1OriginSelector struct {
 	log  log.Logger
 	cfg  *rollup.Config
 	spec *rollup.ChainSpec
 
-	l1 L1Blocks
+	l1     L1Blocks
+	l1Head func() eth.L1BlockRef
+
+	policy  OriginPolicy
+	metrics OriginSelectorMetrics
 }
 
-func NewL1OriginSelector(log log.Logger, cfg *rollup.Config, l1 L1Blocks) *L1OriginSelector {
+func NewL1OriginSelector(log log.Logger, cfg *rollup.Config, l1 L1Blocks, l1Head func() eth.L1BlockRef,
+	policy OriginPolicy, metrics OriginSelectorMetrics) *L1OriginSelector {
 	return &L1OriginSelector{
-		log:  log,
-		cfg:  cfg,
-		spec: rollup.NewChainSpec(cfg),
-		l1:   l1,
+		log:     log,
+		cfg:     cfg,
+		spec:    rollup.NewChainSpec(cfg),
+		l1:      l1,
+		l1Head:  l1Head,
+		policy:  policy,
+		metrics: metrics,
 	}
 }
 
@@ -87,11 +100,41 @@ func (los *L1OriginSelector) FindL1Origin(ctx context.Context, l2Head eth.L2Bloc
 	// If the next L2 block time is greater than the next origin block's time, we can choose to
 	// start building on top of the next origin. Sequencer implementation has some leeway here and
 	// could decide to continue to build on top of the previous origin until the Sequencer runs out
-	// of slack. For simplicity, we implement our Sequencer to always start building on the latest
-	// L1 block when we can.
+	// of slack. The origin policy decides how long to hold on to the previous origin: until the next
+	// origin is sufficiently confirmed, as long as the sequencer drift allows it.
 	if l2Head.Time+los.cfg.BlockTime >= nextOrigin.Time {
+		if confs, required, ok := los.confirmed(nextOrigin); !ok {
+			if pastSeqDrift {
+				log.Warn("Adopting insufficiently confirmed L1 origin to stay within sequencer drift",
+					"next", nextOrigin, "confs", confs, "required_confs", required)
+				return nextOrigin, nil
+			}
+			log.Debug("Holding current origin until next origin is confirmed",
+				"next", nextOrigin, "confs", confs, "required_confs", required)
+			los.metrics.RecordSequencerL1OriginHeld()
+			return currentOrigin, nil
+		}
 		return nextOrigin, nil
 	}
 
 	return currentOrigin, nil
 }
+
+// confirmed returns the number of confirmations of the given L1 block, the number of confirmations
+// the origin policy requires for the block to be adopted as L1 origin, and whether the block has these.
+// Without L1 head (as during startup before the L1 head is known) any block is considered confirmed.
+func (los *L1OriginSelector) confirmed(origin eth.L1BlockRef) (confs uint64, required uint64, ok bool) {
+	required = los.policy.RequiredConfs()
+	los.metrics.RecordSequencerL1OriginConfs(required)
+	if required == 0 {
+		return 0, 0, true
+	}
+	l1Head := los.l1Head()
+	if l1Head == (eth.L1BlockRef{}) {
+		return 0, required, true
+	}
+	if l1Head.Number > origin.Number {
+		confs = l1Head.Number - origin.Number
+	}
+	return confs, required, confs >= required
+}
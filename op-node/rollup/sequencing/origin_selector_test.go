@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/zircuit-labs/l2-geth-public/common"
 	"github.com/zircuit-labs/l2-geth-public/log"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/metrics"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/confdepth"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
@@ -49,7 +50,7 @@ func TestOriginSelectorAdvances(t *testing.T) {
 
 	l1.EXPECT().L1BlockRefByHash(gomock.Any(), a.Hash).Return(a, nil).AnyTimes()
 	l1.EXPECT().L1BlockRefByNumber(gomock.Any(), b.Number).Return(b, nil).AnyTimes()
-	s := NewL1OriginSelector(log, cfg, l1)
+	s := NewL1OriginSelector(log, cfg, l1, nil, FixedConfsPolicy(0), metrics.NoopMetrics)
 	next, err := s.FindL1Origin(context.Background(), l2Head)
 	require.Nil(t, err)
 	require.Equal(t, b, next)
@@ -90,7 +91,7 @@ func TestOriginSelectorRespectsOriginTiming(t *testing.T) {
 	l1.EXPECT().L1BlockRefByHash(gomock.Any(), a.Hash).Return(a, nil).Times(1)
 	l1.EXPECT().L1BlockRefByNumber(gomock.Any(), b.Number).Return(b, nil).Times(1)
 
-	s := NewL1OriginSelector(log, cfg, l1)
+	s := NewL1OriginSelector(log, cfg, l1, nil, FixedConfsPolicy(0), metrics.NoopMetrics)
 	next, err := s.FindL1Origin(context.Background(), l2Head)
 	require.Nil(t, err)
 	require.Equal(t, a, next)
@@ -129,7 +130,7 @@ func TestOriginSelectorRespectsConfDepth(t *testing.T) {
 
 	l1.EXPECT().L1BlockRefByHash(gomock.Any(), a.Hash).Return(a, nil).Times(1)
 	confDepthL1 := confdepth.NewConfDepth(10, func() eth.L1BlockRef { return b }, l1)
-	s := NewL1OriginSelector(log, cfg, confDepthL1)
+	s := NewL1OriginSelector(log, cfg, confDepthL1, nil, FixedConfsPolicy(0), metrics.NoopMetrics)
 
 	next, err := s.FindL1Origin(context.Background(), l2Head)
 	require.Nil(t, err)
@@ -172,7 +173,7 @@ func TestOriginSelectorStrictConfDepth(t *testing.T) {
 
 	l1.EXPECT().L1BlockRefByHash(gomock.Any(), a.Hash).Return(a, nil).Times(1)
 	confDepthL1 := confdepth.NewConfDepth(10, func() eth.L1BlockRef { return b }, l1)
-	s := NewL1OriginSelector(log, cfg, confDepthL1)
+	s := NewL1OriginSelector(log, cfg, confDepthL1, nil, FixedConfsPolicy(0), metrics.NoopMetrics)
 
 	_, err := s.FindL1Origin(context.Background(), l2Head)
 	require.ErrorContains(t, err, "sequencer time drift")
@@ -207,7 +208,7 @@ func u64ptr(n uint64) *uint64 {
 //
 // 	l1.ExpectL1BlockRefByHash(a.Hash, a, nil)
 // 	l1.ExpectL1BlockRefByNumber(a.Number+1, eth.L1BlockRef{}, ethereum.NotFound)
-// 	s := NewL1OriginSelector(log, cfg, l1)
+// 	s := NewL1OriginSelector(log, cfg, l1, nil, FixedConfsPolicy(0), metrics.NoopMetrics)
 //
 // 	l1O, err := s.FindL1Origin(context.Background(), l2Head)
 // 	require.NoError(t, err, "with Fjord activated, have increased max seq drift")
@@ -247,7 +248,7 @@ func TestOriginSelectorSeqDriftRespectsNextOriginTime(t *testing.T) {
 	l1.EXPECT().L1BlockRefByHash(gomock.Any(), a.Hash).Return(a, nil).Times(1)
 	l1.EXPECT().L1BlockRefByNumber(gomock.Any(), b.Number).Return(b, nil).Times(1)
 
-	s := NewL1OriginSelector(log, cfg, l1)
+	s := NewL1OriginSelector(log, cfg, l1, nil, FixedConfsPolicy(0), metrics.NoopMetrics)
 	next, err := s.FindL1Origin(context.Background(), l2Head)
 	require.Nil(t, err)
 	require.Equal(t, a, next)
@@ -304,7 +305,7 @@ func TestOriginSelectorHandlesLateL1Blocks(t *testing.T) {
 
 	l1Head := b
 	confDepthL1 := confdepth.NewConfDepth(2, func() eth.L1BlockRef { return l1Head }, l1)
-	s := NewL1OriginSelector(log, cfg, confDepthL1)
+	s := NewL1OriginSelector(log, cfg, confDepthL1, nil, FixedConfsPolicy(0), metrics.NoopMetrics)
 
 	_, err := s.FindL1Origin(context.Background(), l2Head)
 	require.ErrorContains(t, err, "sequencer time drift")
@@ -14,6 +14,9 @@ import (
 	"github.com/zircuit-labs/l2-geth-public/common/hexutil"
 	"github.com/zircuit-labs/l2-geth-public/core/types"
 	"github.com/zircuit-labs/l2-geth-public/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/conductor"
@@ -21,11 +24,14 @@ import (
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/engine"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/event"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/optracing"
 )
 
 // sealingDuration defines the expected time it takes to seal the block
 const sealingDuration = time.Millisecond * 50
 
+var tracer = optracing.Tracer("github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/sequencing")
+
 var (
 	ErrSequencerAlreadyStarted = errors.New("sequencer already running")
 	ErrSequencerAlreadyStopped = errors.New("sequencer not running")
@@ -54,6 +60,11 @@ type AsyncGossiper interface {
 	Start()
 }
 
+// DepositSimulator dry-runs the deposits of payload attributes against the circuit capacity checker of the engine.
+type DepositSimulator interface {
+	SimulateDeposits(ctx context.Context, parent common.Hash, attrs *eth.PayloadAttributes) ([]common.Hash, error)
+}
+
 // SequencerActionEvent triggers the sequencer to start/seal a block, if active and ready to act.
 // This event is used to prioritize sequencer work over derivation work,
 // by emitting it before e.g. a derivation-pipeline step.
@@ -101,6 +112,10 @@ type Sequencer struct {
 
 	asyncGossip AsyncGossiper
 
+	// depositSimulator is used to exclude the deposits the engine would reject before starting to build a block,
+	// instead of rebuilding the block after the engine rejected them. Disabled if nil.
+	depositSimulator DepositSimulator
+
 	emitter event.Emitter
 
 	attrBuilder      derive.AttributesBuilder
@@ -117,6 +132,8 @@ type Sequencer struct {
 
 	latest     BuildingState
 	latestHead eth.L2BlockRef
+	// trace of the building of the latest block, kept out of BuildingState to keep it comparable
+	latestTrace trace.SpanContext
 
 	// toBlockRef converts a payload to a block-ref, and is only configurable for test-purposes
 	toBlockRef func(rollupCfg *rollup.Config, payload *eth.ExecutionPayload, l1Info *types.L1Info) (eth.L2BlockRef, error)
@@ -133,6 +150,7 @@ func NewSequencer(driverCtx context.Context, log log.Logger, rollupCfg *rollup.C
 	listener SequencerStateListener,
 	conductor conductor.SequencerConductor,
 	asyncGossip AsyncGossiper,
+	depositSimulator DepositSimulator,
 	metrics Metrics,
 ) *Sequencer {
 	return &Sequencer{
@@ -143,6 +161,7 @@ func NewSequencer(driverCtx context.Context, log log.Logger, rollupCfg *rollup.C
 		listener:         listener,
 		conductor:        conductor,
 		asyncGossip:      asyncGossip,
+		depositSimulator: depositSimulator,
 		attrBuilder:      attributesBuilder,
 		l1OriginSelector: l1OriginSelector,
 		metrics:          metrics,
@@ -272,6 +291,7 @@ func (d *Sequencer) onBuildStarted(x engine.BuildStartedEvent) {
 	d.latest.Info = x.Info
 	d.latest.Started = x.BuildStarted
 	d.latest.Attributes = x.Attributes
+	d.latestTrace = x.Trace
 
 	d.nextActionOK = d.active.Load()
 
@@ -429,6 +449,7 @@ func (d *Sequencer) onSequencerAction(x SequencerActionEvent) {
 				BuildStarted: d.latest.Started,
 				IsLastInSpan: false,
 				DerivedFrom:  eth.L1BlockRef{},
+				Trace:        d.latestTrace,
 			})
 		} else if d.latest == (BuildingState{}) {
 			// If we have not started building anything, start building.
@@ -532,9 +553,16 @@ func (d *Sequencer) startBuildingBlock() {
 		return
 	}
 
+	ctx, span := tracer.Start(ctx, "sequencer.start-building", trace.WithAttributes(
+		attribute.String("parent", l2Head.Hash.String()),
+		attribute.Int64("parent_number", int64(l2Head.Number)),
+	))
+	defer span.End()
+
 	// Figure out which L1 origin block we're going to be building on top of.
 	l1Origin, err := d.l1OriginSelector.FindL1Origin(ctx, l2Head)
 	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
 		d.log.Error("Error finding next L1 Origin", "err", err)
 		d.emitter.Emit(rollup.L1TemporaryErrorEvent{Err: err})
 		return
@@ -548,12 +576,22 @@ func (d *Sequencer) startBuildingBlock() {
 	}
 
 	d.log.Info("Started sequencing new block", "parent", l2Head, "l1Origin", l1Origin)
+	span.SetAttributes(attribute.Int64("l1_origin", int64(l1Origin.Number)))
 
 	fetchCtx, cancel := context.WithTimeout(ctx, time.Second*20)
 	defer cancel()
 
 	attrs, err := d.attrBuilder.PreparePayloadAttributes(fetchCtx, l2Head, l1Origin.ID(), d.latest.DepositExclusions)
+	if err == nil && d.latest.DepositExclusions == nil && l2Head.L1Origin != l1Origin.ID() {
+		// First attempt at the first block of the epoch: exclude the deposits the engine would reject up front.
+		if exclusions := d.preSimulateDeposits(fetchCtx, l2Head, attrs); exclusions != nil {
+			d.latest = BuildingState{Onto: l2Head, DepositExclusions: exclusions}
+			// the L1 info deposit commits to the exclusions, so the attributes have to be prepared again
+			attrs, err = d.attrBuilder.PreparePayloadAttributes(fetchCtx, l2Head, l1Origin.ID(), d.latest.DepositExclusions)
+		}
+	}
 	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
 		if errors.Is(err, derive.ErrTemporary) {
 			d.emitter.Emit(rollup.EngineTemporaryErrorEvent{Err: err})
 			return
@@ -606,9 +644,38 @@ func (d *Sequencer) startBuildingBlock() {
 	d.emitter.Emit(engine.BuildStartEvent{
 		Attributes:   withParent,
 		IsSequencing: true,
+		Trace:        span.SpanContext(),
 	})
 }
 
+// preSimulateDeposits dry-runs the deposits of the attributes, and returns the exclusion bitmap of the deposits
+// the engine would reject, or nil if no deposits would be rejected.
+// Simulation is best-effort: upon failure the engine rejecting deposits while building is the fallback.
+func (d *Sequencer) preSimulateDeposits(ctx context.Context, l2Head eth.L2BlockRef, attrs *eth.PayloadAttributes) *types.Bitmap {
+	if d.depositSimulator == nil {
+		return nil
+	}
+	rejected, err := d.depositSimulator.SimulateDeposits(ctx, l2Head.Hash, attrs)
+	if err != nil {
+		d.log.Warn("Failed to pre-simulate deposits, relying on the engine to reject deposits", "parent", l2Head, "err", err)
+		return nil
+	}
+	if len(rejected) == 0 {
+		return nil
+	}
+	exclusions := derive.EmptyBitmap(0)
+	if err := SetDepositExclusionBitmap(*exclusions, attrs.Transactions, rejected); err != nil {
+		d.log.Warn("Failed to construct deposit exclusion bitmap from pre-simulation", "parent", l2Head, "err", err)
+		return nil
+	}
+	if exclusions.Count() == 0 {
+		d.log.Warn("Pre-simulation rejected transactions that are not deposits of the attributes", "parent", l2Head, "rejected", rejected)
+		return nil
+	}
+	d.log.Info("Excluding deposits rejected by pre-simulation", "parent", l2Head, "rejected", rejected)
+	return exclusions
+}
+
 // Set the bitmap according to the rejected transaction hashes.
 // If the bitmap is non-empty, it will use this information to carry over indices
 // of the bitmap, assuming that bits set in the bitmap correspond to already
@@ -3,6 +3,7 @@ package sequencing
 import (
 	"context"
 	"encoding/binary"
+	"errors"
 	"math/rand" // nosemgrep
 	"testing"
 	"time"
@@ -416,7 +417,7 @@ func createSequencer(log log.Logger) (*Sequencer, *sequencerTestDeps) {
 	}
 	seq := NewSequencer(context.Background(), log, cfg, deps.attribBuilder,
 		deps.l1OriginSelector, deps.seqState, deps.conductor,
-		deps.asyncGossip, metrics.NoopMetrics)
+		deps.asyncGossip, nil, metrics.NoopMetrics)
 	// We create mock payloads, with the epoch-id as tx[0], rather than proper L1Block-info deposit tx.
 	seq.toBlockRef = func(rollupCfg *rollup.Config, payload *eth.ExecutionPayload, l1Info *types.L1Info) (eth.L2BlockRef, error) {
 		return eth.L2BlockRef{
@@ -487,3 +488,116 @@ func TestSetDepositExclusionBitmap(t *testing.T) {
 		require.Equal(t, depositExclusions, expectedBitmap)
 	}
 }
+
+// depositAttributesBuilder prepares attributes with deposits, and leaves out the excluded deposits.
+type depositAttributesBuilder struct {
+	deposits   []hexutil.Bytes
+	exclusions []*types.Bitmap
+}
+
+func (b *depositAttributesBuilder) PreparePayloadAttributes(ctx context.Context,
+	l2Parent eth.L2BlockRef, epoch eth.BlockID, exclusions *types.Bitmap,
+) (attrs *eth.PayloadAttributes, err error) {
+	b.exclusions = append(b.exclusions, exclusions)
+	attrs = &eth.PayloadAttributes{Timestamp: eth.Uint64Quantity(l2Parent.Time + 2)}
+	for i, tx := range b.deposits {
+		// the first deposit is the L1 info deposit, and cannot be excluded
+		if i > 0 && l2Parent.L1Origin != epoch && exclusions != nil && exclusions.Test(i) {
+			continue
+		}
+		attrs.Transactions = append(attrs.Transactions, eth.Data(tx))
+	}
+	return attrs, nil
+}
+
+type fakeDepositSimulator struct {
+	rejected []common.Hash
+	err      error
+	calls    int
+}
+
+func (f *fakeDepositSimulator) SimulateDeposits(ctx context.Context, parent common.Hash, attrs *eth.PayloadAttributes) ([]common.Hash, error) {
+	f.calls++
+	return f.rejected, f.err
+}
+
+func TestSequencerDepositPreSimulation(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	var deposits []hexutil.Bytes
+	var hashes []common.Hash
+	for i := 0; i < 4; i++ {
+		tx := testutils.RandomDepositTx(rng)
+		data, err := tx.MarshalBinary()
+		require.NoError(t, err)
+		deposits = append(deposits, data)
+		hashes = append(hashes, tx.Hash())
+	}
+
+	head := eth.L2BlockRef{
+		Hash:     common.Hash{0x22},
+		Number:   100,
+		L1Origin: eth.BlockID{Hash: common.Hash{0x11, 0xa}, Number: 1000},
+		Time:     30000,
+	}
+	nextOrigin := eth.L1BlockRef{Hash: common.Hash{0x11, 0xb}, ParentHash: head.L1Origin.Hash, Number: 1001, Time: 29998}
+	currentOrigin := eth.L1BlockRef{Hash: head.L1Origin.Hash, Number: head.L1Origin.Number, Time: 29990}
+
+	startBuilding := func(t *testing.T, origin eth.L1BlockRef, sim *fakeDepositSimulator) (*Sequencer, *depositAttributesBuilder, *derive.AttributesWithParent) {
+		seq, deps := createSequencer(testlog.Logger(t, log.LevelError))
+		builder := &depositAttributesBuilder{deposits: deposits}
+		seq.attrBuilder = builder
+		seq.depositSimulator = sim
+		emitter := &testutils.MockEmitter{}
+		seq.AttachEmitter(emitter)
+		emitter.ExpectOnce(engine.ForkchoiceRequestEvent{})
+		require.NoError(t, seq.Init(context.Background(), true))
+		seq.OnEvent(engine.ForkchoiceUpdateEvent{UnsafeL2Head: head})
+		deps.l1OriginSelector.l1OriginFn = func(l2Head eth.L2BlockRef) (eth.L1BlockRef, error) {
+			return origin, nil
+		}
+		var attrs *derive.AttributesWithParent
+		emitter.ExpectOnceRun(func(ev event.Event) {
+			x, ok := ev.(engine.BuildStartEvent)
+			require.True(t, ok)
+			attrs = x.Attributes
+		})
+		seq.OnEvent(SequencerActionEvent{})
+		emitter.AssertExpectations(t)
+		return seq, builder, attrs
+	}
+
+	t.Run("excludes rejected deposits up front", func(t *testing.T) {
+		sim := &fakeDepositSimulator{rejected: []common.Hash{hashes[2]}}
+		seq, builder, attrs := startBuilding(t, nextOrigin, sim)
+		require.Equal(t, 1, sim.calls)
+		require.Len(t, builder.exclusions, 2, "attributes are prepared again with the exclusions")
+		require.Nil(t, builder.exclusions[0])
+		require.Equal(t, []int{2}, builder.exclusions[1].Indices())
+		require.Len(t, attrs.Attributes.Transactions, 3)
+		require.Equal(t, []int{2}, seq.latest.DepositExclusions.Indices(), "exclusions are carried over to reactive rebuilds")
+	})
+
+	t.Run("falls back to the engine upon simulation failure", func(t *testing.T) {
+		sim := &fakeDepositSimulator{err: errors.New("boom")}
+		seq, builder, attrs := startBuilding(t, nextOrigin, sim)
+		require.Equal(t, 1, sim.calls)
+		require.Len(t, builder.exclusions, 1)
+		require.Len(t, attrs.Attributes.Transactions, 4)
+		require.Zero(t, seq.latest.DepositExclusions.Count())
+	})
+
+	t.Run("no rejected deposits", func(t *testing.T) {
+		sim := &fakeDepositSimulator{}
+		_, builder, attrs := startBuilding(t, nextOrigin, sim)
+		require.Equal(t, 1, sim.calls)
+		require.Len(t, builder.exclusions, 1)
+		require.Len(t, attrs.Attributes.Transactions, 4)
+	})
+
+	t.Run("only simulates the first block of the epoch", func(t *testing.T) {
+		sim := &fakeDepositSimulator{rejected: []common.Hash{hashes[2]}}
+		_, builder, _ := startBuilding(t, currentOrigin, sim)
+		require.Zero(t, sim.calls)
+		require.Len(t, builder.exclusions, 1)
+	})
+}
@@ -0,0 +1,131 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/event"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+)
+
+const (
+	// l1ReorgMaxDepth bounds the depth of the L1 reorgs that are measured.
+	l1ReorgMaxDepth = 64
+	// l1ReorgFetchTimeout bounds the fetching of the new L1 chain, back to the common ancestor.
+	l1ReorgFetchTimeout = 10 * time.Second
+)
+
+// L1ReorgEvent is emitted when the new L1 head replaces blocks of the chain of the previous L1 head.
+type L1ReorgEvent struct {
+	OldHead eth.L1BlockRef
+	NewHead eth.L1BlockRef
+	// Depth is the number of blocks of the previous chain that were replaced,
+	// i.e. the distance from the previous head to the common ancestor.
+	Depth uint64
+}
+
+func (ev L1ReorgEvent) String() string {
+	return "l1-reorg"
+}
+
+type L1BlockRefByHashFetcher interface {
+	L1BlockRefByHash(ctx context.Context, hash common.Hash) (eth.L1BlockRef, error)
+}
+
+// L1ReorgTracker keeps the chain of the recent L1 heads, and measures the depth of L1 reorgs
+// from the common ancestor of the previous and the new L1 head.
+// The new chain is fetched back to the common ancestor, since the head signals may skip its blocks.
+type L1ReorgTracker struct {
+	ctx     context.Context
+	log     log.Logger
+	l1      L1BlockRefByHashFetcher
+	emitter event.Emitter
+
+	head  eth.L1BlockRef
+	chain *l1HeadBuffer
+}
+
+var _ event.Deriver = (*L1ReorgTracker)(nil)
+
+func NewL1ReorgTracker(ctx context.Context, log log.Logger, l1 L1BlockRefByHashFetcher) *L1ReorgTracker {
+	return &L1ReorgTracker{
+		ctx:   ctx,
+		log:   log,
+		l1:    l1,
+		chain: newL1HeadBuffer(l1ReorgMaxDepth + 1),
+	}
+}
+
+func (t *L1ReorgTracker) AttachEmitter(em event.Emitter) {
+	t.emitter = em
+}
+
+func (t *L1ReorgTracker) OnEvent(ev event.Event) bool {
+	x, ok := ev.(L1UnsafeEvent)
+	if !ok {
+		return false
+	}
+	prev, next := t.head, x.L1Unsafe
+	if prev.Hash == next.Hash {
+		return true
+	}
+	t.head = next
+	if prev == (eth.L1BlockRef{}) || prev.Hash == next.ParentHash {
+		t.chain.Insert(next)
+		return true
+	}
+	newChain, ancestor, err := t.findCommonAncestor(prev, next)
+	if err != nil {
+		t.log.Warn("Failed to measure L1 reorg depth", "old_l1_head", prev, "new_l1_head", next, "err", err)
+		// starts tracking the chain again from the new head
+		t.chain.Insert(next)
+		return true
+	}
+	for _, ref := range newChain {
+		t.chain.Insert(ref)
+	}
+	// the new head may extend the previous head, with a gap
+	if ancestor.Number < prev.Number {
+		t.emitter.Emit(L1ReorgEvent{OldHead: prev, NewHead: next, Depth: prev.Number - ancestor.Number})
+	}
+	return true
+}
+
+// findCommonAncestor walks back from the new head, until its parent is in the chain of the previous head.
+// It returns the blocks of the new chain after the common ancestor in ascending order, and the common ancestor.
+func (t *L1ReorgTracker) findCommonAncestor(prev, next eth.L1BlockRef) ([]eth.L1BlockRef, eth.L1BlockRef, error) {
+	ctx, cancel := context.WithTimeout(t.ctx, l1ReorgFetchTimeout)
+	defer cancel()
+	newChain := []eth.L1BlockRef{next}
+	ref := next
+	for {
+		if ref.Number == 0 {
+			return nil, eth.L1BlockRef{}, errors.New("no common ancestor")
+		}
+		if ref.Number <= prev.Number+1 {
+			parent, ok := t.chain.Get(ref.Number - 1)
+			if !ok {
+				return nil, eth.L1BlockRef{}, fmt.Errorf("common ancestor is older than the tracked L1 chain, at block %d", ref.Number-1)
+			}
+			if parent.Hash == ref.ParentHash {
+				slices.Reverse(newChain)
+				return newChain, parent, nil
+			}
+		}
+		if len(newChain) > l1ReorgMaxDepth {
+			return nil, eth.L1BlockRef{}, fmt.Errorf("common ancestor is more than %d blocks behind the new head", l1ReorgMaxDepth)
+		}
+		parent, err := t.l1.L1BlockRefByHash(ctx, ref.ParentHash)
+		if err != nil {
+			return nil, eth.L1BlockRef{}, fmt.Errorf("failed to fetch L1 block %s: %w", ref.ParentHash, err)
+		}
+		newChain = append(newChain, parent)
+		ref = parent
+	}
+}
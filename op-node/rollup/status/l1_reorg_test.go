@@ -0,0 +1,93 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/event"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testlog"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testutils"
+)
+
+type fakeL1Blocks map[common.Hash]eth.L1BlockRef
+
+func (f fakeL1Blocks) L1BlockRefByHash(ctx context.Context, hash common.Hash) (eth.L1BlockRef, error) {
+	ref, ok := f[hash]
+	if !ok {
+		return eth.L1BlockRef{}, errors.New("not found")
+	}
+	return ref, nil
+}
+
+// extend adds n blocks on top of the given block, and returns the new chain.
+func (f fakeL1Blocks) extend(rng *rand.Rand, parent eth.L1BlockRef, n int) []eth.L1BlockRef {
+	var chain []eth.L1BlockRef
+	for i := 0; i < n; i++ {
+		parent = testutils.NextRandomRef(rng, parent)
+		f[parent.Hash] = parent
+		chain = append(chain, parent)
+	}
+	return chain
+}
+
+func TestL1ReorgTracker(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	l1 := fakeL1Blocks{}
+	genesis := testutils.RandomBlockRef(rng)
+	l1[genesis.Hash] = genesis
+	var reorgs []L1ReorgEvent
+	tracker := NewL1ReorgTracker(context.Background(), testlog.Logger(t, log.LevelError), l1)
+	tracker.AttachEmitter(event.EmitterFunc(func(ev event.Event) {
+		reorgs = append(reorgs, ev.(L1ReorgEvent))
+	}))
+	head := func(ref eth.L1BlockRef) {
+		require.True(t, tracker.OnEvent(L1UnsafeEvent{L1Unsafe: ref}))
+	}
+
+	chain := l1.extend(rng, genesis, 4)
+	for _, ref := range chain {
+		head(ref)
+	}
+	head(chain[3])
+	require.Empty(t, reorgs, "linear extension is not a reorg")
+
+	// a reorg to a lower head replaces the blocks after the common ancestor
+	reorged := l1.extend(rng, chain[1], 1)
+	head(reorged[0])
+	require.Equal(t, []L1ReorgEvent{{OldHead: chain[3], NewHead: reorged[0], Depth: 2}}, reorgs)
+
+	// an extension with skipped blocks is not a reorg
+	chain = append(chain[:2], reorged...)
+	extended := l1.extend(rng, chain[2], 3)
+	head(extended[2])
+	require.Len(t, reorgs, 1)
+	chain = append(chain, extended...)
+
+	// a reorg to a higher head, of which the blocks back to the common ancestor were not signaled
+	reorged = l1.extend(rng, chain[4], 3)
+	head(reorged[2])
+	require.Len(t, reorgs, 2)
+	require.Equal(t, L1ReorgEvent{OldHead: chain[5], NewHead: reorged[2], Depth: 1}, reorgs[1])
+
+	// the fetched blocks of the new chain are tracked, to measure the next reorg
+	chain = append(chain[:5], reorged...)
+	reorged = l1.extend(rng, chain[5], 1)
+	head(reorged[0])
+	require.Len(t, reorgs, 3)
+	require.Equal(t, L1ReorgEvent{OldHead: chain[7], NewHead: reorged[0], Depth: 2}, reorgs[2])
+
+	// the depth is not reported if the new chain cannot be fetched
+	chain = append(chain[:6], reorged...)
+	orphan := testutils.NextRandomRef(rng, testutils.NextRandomRef(rng, chain[4]))
+	head(orphan)
+	require.Len(t, reorgs, 3)
+
+	require.False(t, tracker.OnEvent(L1SafeEvent{L1Safe: orphan}))
+}
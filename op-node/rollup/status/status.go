@@ -13,6 +13,7 @@ import (
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/engine"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/event"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/finality"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/interop"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
 	"github.com/zircuit-labs/zkr-monorepo-public/zr-proof-orchestrator/common/types"
 )
@@ -91,6 +92,8 @@ func (st *StatusTracker) OnEvent(ev event.Event) bool {
 		st.data.PendingSafeL2 = x.PendingSafe
 	case derive.DeriverL1StatusEvent:
 		st.data.CurrentL1 = x.Origin
+	case interop.CrossUnsafeUpdateEvent:
+		st.data.CrossUnsafeL2 = x.CrossUnsafe
 	case L1UnsafeEvent:
 		st.metrics.RecordL1Ref("l1_head", x.L1Unsafe)
 		// We don't need to do anything if the head hasn't changed.
@@ -103,15 +106,15 @@ func (st *StatusTracker) OnEvent(ev event.Event) bool {
 			// dealing with a linear extension (new block is the immediate child of the old one).
 			st.log.Debug("L1 head moved forward", "l1_head", x.L1Unsafe)
 		} else {
-			if st.data.HeadL1.Number >= x.L1Unsafe.Number {
-				st.metrics.RecordL1ReorgDepth(st.data.HeadL1.Number - x.L1Unsafe.Number)
-			}
 			// New L1 block is not the same as the current head or a single step linear extension.
 			// This could either be a long L1 extension, or a reorg, or we simply missed a head update.
 			st.log.Warn("L1 head signal indicates a possible L1 re-org",
 				"old_l1_head", st.data.HeadL1, "new_l1_head_parent", x.L1Unsafe.ParentHash, "new_l1_head", x.L1Unsafe)
 		}
 		st.data.HeadL1 = x.L1Unsafe
+	case L1ReorgEvent:
+		st.log.Warn("L1 reorg", "old_l1_head", x.OldHead, "new_l1_head", x.NewHead, "depth", x.Depth)
+		st.metrics.RecordL1ReorgDepth(x.Depth)
 	case L1SafeEvent:
 		st.log.Info("New L1 safe block", "l1_safe", x.L1Safe)
 		st.metrics.RecordL1Ref("l1_safe", x.L1Safe)
@@ -106,6 +106,11 @@ type Config struct {
 	// Active if HyraxTime != nil && L2 block timestamp >= *HyraxTime, inactive otherwise.
 	HyraxTime *uint64 `json:"hyrax_time,omitempty"`
 
+	// SpanBatchExclusionsTime sets the activation time of span batches that carry deposit exclusions,
+	// activated like a hardfork.
+	// Active if SpanBatchExclusionsTime != nil && L2 block timestamp >= *SpanBatchExclusionsTime, inactive otherwise.
+	SpanBatchExclusionsTime *uint64 `json:"span_batch_exclusions_time,omitempty"`
+
 	// Note: below addresses are part of the block-derivation process,
 	// and required to be the same network-wide to stay in consensus.
 
@@ -333,6 +338,11 @@ func (c *Config) IsHyrax(timestamp uint64) bool {
 	return c.HyraxTime != nil && timestamp >= *c.HyraxTime
 }
 
+// IsSpanBatchExclusions returns true if span batches with deposit exclusions are active at or past the given timestamp.
+func (c *Config) IsSpanBatchExclusions(timestamp uint64) bool {
+	return c.SpanBatchExclusionsTime != nil && timestamp >= *c.SpanBatchExclusionsTime
+}
+
 // IsCanyon returns true if the Canyon hardfork is active at or past the given timestamp.
 func (c *Config) IsCanyon(timestamp uint64) bool {
 	return c.CanyonTime != nil && timestamp >= *c.CanyonTime
@@ -469,6 +479,7 @@ func (c *Config) Description(l2Chains map[string]string) string {
 	banner += fmt.Sprintf("  - Fjord: %s\n", fmtForkTimeOrUnset(c.FjordTime))
 	banner += fmt.Sprintf("  - Interop: %s\n", fmtForkTimeOrUnset(c.InteropTime))
 	banner += fmt.Sprintf("  - Hyrax: %s\n", fmtForkTimeOrUnset(c.HyraxTime))
+	banner += fmt.Sprintf("  - Span batch exclusions: %s\n", fmtForkTimeOrUnset(c.SpanBatchExclusionsTime))
 	return banner
 }
 
@@ -500,6 +511,7 @@ func (c *Config) LogDescription(log log.Logger, l2Chains map[string]string) {
 		"fjord_time", fmtForkTimeOrUnset(c.FjordTime),
 		"interop_time", fmtForkTimeOrUnset(c.InteropTime),
 		"hyrax_time", fmtForkTimeOrUnset(c.HyraxTime),
+		"span_batch_exclusions_time", fmtForkTimeOrUnset(c.SpanBatchExclusionsTime),
 	)
 }
 
@@ -19,13 +19,16 @@ import (
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/chaincfg"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/flags"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/node"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/node/safedb"
 	p2pcli "github.com/zircuit-labs/zkr-monorepo-public/op-node/p2p/cli"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/driver"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/engine"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/sequencing"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/sync"
 	opflags "github.com/zircuit-labs/zkr-monorepo-public/op-service/flags"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/oppprof"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/optracing"
 	l1 "github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/l1"
 )
 
@@ -45,7 +48,10 @@ func NewConfig(ctx *cli.Context, log log.Logger) (*node.Config, error) {
 		rollupConfig.ProtocolVersionsAddress = common.Address{}
 	}
 
-	configPersistence := NewConfigPersistence(ctx)
+	configPersistence, err := NewConfigPersistence(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config persistence: %w", err)
+	}
 
 	driverConfig := NewDriverConfig(ctx)
 
@@ -93,9 +99,11 @@ func NewConfig(ctx *cli.Context, log log.Logger) (*node.Config, error) {
 			ListenPort: ctx.Int(flags.MetricsPortFlag.Name),
 		},
 		Pprof:                       oppprof.ReadCLIConfig(ctx),
+		Tracing:                     optracing.ReadCLIConfig(ctx),
 		P2P:                         p2pConfig,
 		P2PSigner:                   p2pSignerSetup,
 		L1EpochPollInterval:         ctx.Duration(flags.L1EpochPollIntervalFlag.Name),
+		L1HeadsPollInterval:         ctx.Duration(flags.L1HeadsPollIntervalFlag.Name),
 		RuntimeConfigReloadInterval: ctx.Duration(flags.RuntimeConfigReloadIntervalFlag.Name),
 		Heartbeat: node.HeartbeatConfig{
 			Enabled: ctx.Bool(flags.HeartbeatEnabledFlag.Name),
@@ -107,6 +115,16 @@ func NewConfig(ctx *cli.Context, log log.Logger) (*node.Config, error) {
 		Sync:              *syncConfig,
 		RollupHalt:        haltOption,
 		RethDBPath:        ctx.String(flags.L1RethDBPath.Name),
+		L1CacheDir:        ctx.String(flags.L1CacheDir.Name),
+		L1CacheSize:       ctx.Uint64(flags.L1CacheSize.Name) << 20,
+		SafeDBRetention: safedb.RetentionConfig{
+			Blocks:   ctx.Uint64(flags.SafeDBRetentionBlocks.Name),
+			Age:      ctx.Duration(flags.SafeDBRetentionAge.Name),
+			Interval: ctx.Duration(flags.SafeDBPruneInterval.Name),
+		},
+		DerivationAuditDir:       ctx.String(flags.DerivationAuditDir.Name),
+		DerivationAuditRetention: ctx.Uint64(flags.DerivationAuditRetention.Name),
+		InteropPeerRPC:           ctx.String(flags.InteropPeerRPC.Name),
 
 		ConductorEnabled:    ctx.Bool(flags.ConductorEnabledFlag.Name),
 		ConductorRpc:        ctx.String(flags.ConductorRpcFlag.Name),
@@ -177,6 +195,8 @@ func NewL1EndpointConfig(ctx *cli.Context) *node.L1EndpointConfig {
 		BatchSize:        ctx.Int(flags.L1RPCMaxBatchSize.Name),
 		HttpPollInterval: ctx.Duration(flags.L1HTTPPollInterval.Name),
 		MaxConcurrency:   ctx.Int(flags.L1RPCMaxConcurrency.Name),
+		QuorumAddrs:      ctx.StringSlice(flags.L1QuorumAddrs.Name),
+		QuorumThreshold:  ctx.Int(flags.L1QuorumThreshold.Name),
 	}
 }
 
@@ -205,17 +225,40 @@ func NewL2EndpointConfig(ctx *cli.Context, log log.Logger) (*node.L2EndpointConf
 	}
 
 	return &node.L2EndpointConfig{
-		L2EngineAddr:      l2Addr,
-		L2EngineJWTSecret: secret,
+		L2EngineAddr:               l2Addr,
+		L2EngineJWTSecret:          secret,
+		L2EngineReplicaAddrs:       ctx.StringSlice(flags.L2EngineReplicas.Name),
+		ReplicaHealthCheckInterval: ctx.Duration(flags.L2EngineReplicasHealthCheckInterval.Name),
 	}, nil
 }
 
-func NewConfigPersistence(ctx *cli.Context) node.ConfigPersistence {
-	stateFile := ctx.String(flags.RPCAdminPersistence.Name)
-	if stateFile == "" {
-		return node.DisabledConfigPersistence{}
+func NewConfigPersistence(ctx *cli.Context) (node.ConfigPersistence, error) {
+	switch backend := ctx.String(flags.RPCAdminPersistenceBackend.Name); backend {
+	case "file":
+		stateFile := ctx.String(flags.RPCAdminPersistence.Name)
+		if stateFile == "" {
+			return node.DisabledConfigPersistence{}, nil
+		}
+		return node.NewConfigPersistence(stateFile), nil
+	case "nats":
+		owner := ctx.String(flags.RPCAdminPersistenceNATSOwner.Name)
+		if owner == "" {
+			hostname, err := os.Hostname()
+			if err != nil {
+				return nil, fmt.Errorf("failed to determine the owner of the sequencer state: %w", err)
+			}
+			owner = hostname
+		}
+		return node.NewKVConfigPersistence(
+			ctx.String(flags.RPCAdminPersistenceNATSDomain.Name),
+			ctx.String(flags.RPCAdminPersistenceNATSBucket.Name),
+			ctx.String(flags.RPCAdminPersistenceNATSKey.Name),
+			owner,
+			ctx.Duration(flags.RPCAdminPersistenceNATSLease.Name),
+		), nil
+	default:
+		return nil, fmt.Errorf("unknown admin state backend: %q", backend)
 	}
-	return node.NewConfigPersistence(stateFile)
 }
 
 func NewDriverConfig(ctx *cli.Context) *driver.Config {
@@ -225,6 +268,19 @@ func NewDriverConfig(ctx *cli.Context) *driver.Config {
 		SequencerEnabled:    ctx.Bool(flags.SequencerEnabledFlag.Name),
 		SequencerStopped:    ctx.Bool(flags.SequencerStoppedFlag.Name),
 		SequencerMaxSafeLag: ctx.Uint64(flags.SequencerMaxSafeLagFlag.Name),
+
+		SequencerDepositPreSimulation: ctx.Bool(flags.SequencerDepositPreSimulationFlag.Name),
+		SequencerOriginPolicy: sequencing.OriginPolicyConfig{
+			Kind:        sequencing.OriginPolicyKind(ctx.String(flags.SequencerL1OriginPolicy.Name)),
+			Confs:       ctx.Uint64(flags.SequencerL1OriginConfs.Name),
+			MaxConfs:    ctx.Uint64(flags.SequencerL1OriginMaxConfs.Name),
+			ReorgWindow: ctx.Uint64(flags.SequencerL1OriginReorgWindow.Name),
+		},
+
+		L1PrefetchDepth: ctx.Uint64(flags.L1PrefetchDepth.Name),
+
+		PipelineCheckpointPath:     ctx.String(flags.PipelineCheckpointPath.Name),
+		PipelineCheckpointInterval: ctx.Duration(flags.PipelineCheckpointInterval.Name),
 	}
 }
 
@@ -0,0 +1,256 @@
+package tracker
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/common/hexutil"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-bindings/bindings"
+)
+
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrInvalidEntry = errors.New("invalid db entry")
+)
+
+// Status is the stage of a withdrawal in its lifecycle on L1.
+type Status uint8
+
+const (
+	// StatusInitiated withdrawals are initiated on L2, and are not proven on L1 yet.
+	StatusInitiated Status = iota
+	// StatusProven withdrawals are proven on L1, and wait for the finalization period to pass.
+	StatusProven
+	// StatusFinalized withdrawals are finalized on L1, and are no longer tracked.
+	StatusFinalized
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusInitiated:
+		return "initiated"
+	case StatusProven:
+		return "proven"
+	case StatusFinalized:
+		return "finalized"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(s))
+	}
+}
+
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Status) UnmarshalText(text []byte) error {
+	for _, v := range []Status{StatusInitiated, StatusProven, StatusFinalized} {
+		if v.String() == string(text) {
+			*s = v
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown withdrawal status: %q", text)
+}
+
+// Withdrawal is a withdrawal initiated on L2, and its progress on L1.
+type Withdrawal struct {
+	Hash     common.Hash    `json:"hash"`
+	TxHash   common.Hash    `json:"txHash"`
+	L2Block  uint64         `json:"l2Block"`
+	Nonce    *hexutil.Big   `json:"nonce"`
+	Sender   common.Address `json:"sender"`
+	Target   common.Address `json:"target"`
+	Value    *hexutil.Big   `json:"value"`
+	GasLimit *hexutil.Big   `json:"gasLimit"`
+	Data     hexutil.Bytes  `json:"data"`
+
+	Status Status `json:"status"`
+	// ProveTx and FinalizeTx are the L1 transactions sent by the tracker, if any.
+	// They are left empty when the withdrawal was proven or finalized by someone else.
+	ProveTx    *common.Hash `json:"proveTx,omitempty"`
+	FinalizeTx *common.Hash `json:"finalizeTx,omitempty"`
+}
+
+// NewWithdrawal creates an initiated withdrawal from its MessagePassed event.
+func NewWithdrawal(ev *bindings.L2ToL1MessagePasserMessagePassed) Withdrawal {
+	return Withdrawal{
+		Hash:     ev.WithdrawalHash,
+		TxHash:   ev.Raw.TxHash,
+		L2Block:  ev.Raw.BlockNumber,
+		Nonce:    (*hexutil.Big)(ev.Nonce),
+		Sender:   ev.Sender,
+		Target:   ev.Target,
+		Value:    (*hexutil.Big)(ev.Value),
+		GasLimit: (*hexutil.Big)(ev.GasLimit),
+		Data:     ev.Data,
+		Status:   StatusInitiated,
+	}
+}
+
+// Event returns the MessagePassed event that initiated the withdrawal.
+func (w *Withdrawal) Event() *bindings.L2ToL1MessagePasserMessagePassed {
+	return &bindings.L2ToL1MessagePasserMessagePassed{
+		Nonce:          (*big.Int)(w.Nonce),
+		Sender:         w.Sender,
+		Target:         w.Target,
+		Value:          (*big.Int)(w.Value),
+		GasLimit:       (*big.Int)(w.GasLimit),
+		Data:           w.Data,
+		WithdrawalHash: w.Hash,
+	}
+}
+
+// Transaction returns the withdrawal as it is passed to the OptimismPortal.
+func (w *Withdrawal) Transaction() bindings.TypesWithdrawalTransaction {
+	return bindings.TypesWithdrawalTransaction{
+		Nonce:    (*big.Int)(w.Nonce),
+		Sender:   w.Sender,
+		Target:   w.Target,
+		Value:    (*big.Int)(w.Value),
+		GasLimit: (*big.Int)(w.GasLimit),
+		Data:     w.Data,
+	}
+}
+
+const (
+	// Keys are prefixed with a constant byte to allow us to differentiate different "columns" within the data
+	keyPrefixWithdrawal byte = 0
+	keyPrefixMeta       byte = 1
+)
+
+// lastScannedKey stores the number of the last L2 block that was scanned for withdrawals.
+var lastScannedKey = []byte{keyPrefixMeta, 0}
+
+func withdrawalKey(hash common.Hash) []byte {
+	return append([]byte{keyPrefixWithdrawal}, hash[:]...)
+}
+
+var withdrawalRange = &pebble.IterOptions{
+	LowerBound: []byte{keyPrefixWithdrawal},
+	UpperBound: []byte{keyPrefixWithdrawal + 1},
+}
+
+// DB persists the tracked withdrawals and the scan progress, so the tracker can resume after a restart.
+type DB struct {
+	db        *pebble.DB
+	writeOpts *pebble.WriteOptions
+}
+
+func OpenDB(path string) (*DB, error) {
+	db, err := pebble.Open(path, &pebble.Options{})
+	if err != nil {
+		return nil, err
+	}
+	return &DB{
+		db:        db,
+		writeOpts: &pebble.WriteOptions{Sync: true},
+	}, nil
+}
+
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// LastScanned returns the number of the last L2 block that was scanned for withdrawals,
+// or ErrNotFound if no block was scanned yet.
+func (d *DB) LastScanned() (uint64, error) {
+	val, closer, err := d.db.Get(lastScannedKey)
+	if errors.Is(err, pebble.ErrNotFound) {
+		return 0, ErrNotFound
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to read last scanned block: %w", err)
+	}
+	defer closer.Close()
+	if len(val) != 8 {
+		return 0, fmt.Errorf("%w: last scanned block of length %d", ErrInvalidEntry, len(val))
+	}
+	return binary.BigEndian.Uint64(val), nil
+}
+
+// RecordScan atomically stores the withdrawals found up to and including the given L2 block, and the block as scanned.
+// Withdrawals that are already tracked are kept as they are.
+func (d *DB) RecordScan(found []Withdrawal, lastScanned uint64) error {
+	batch := d.db.NewBatch()
+	defer batch.Close()
+	for _, w := range found {
+		if _, err := d.Get(w.Hash); err == nil {
+			continue
+		} else if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		val, err := json.Marshal(w)
+		if err != nil {
+			return fmt.Errorf("failed to encode withdrawal %s: %w", w.Hash, err)
+		}
+		if err := batch.Set(withdrawalKey(w.Hash), val, d.writeOpts); err != nil {
+			return fmt.Errorf("failed to record withdrawal %s: %w", w.Hash, err)
+		}
+	}
+	if err := batch.Set(lastScannedKey, binary.BigEndian.AppendUint64(nil, lastScanned), d.writeOpts); err != nil {
+		return fmt.Errorf("failed to record last scanned block: %w", err)
+	}
+	if err := batch.Commit(d.writeOpts); err != nil {
+		return fmt.Errorf("failed to commit scan: %w", err)
+	}
+	return nil
+}
+
+// Put stores the withdrawal, replacing any previous state of it.
+func (d *DB) Put(w Withdrawal) error {
+	val, err := json.Marshal(w)
+	if err != nil {
+		return fmt.Errorf("failed to encode withdrawal %s: %w", w.Hash, err)
+	}
+	if err := d.db.Set(withdrawalKey(w.Hash), val, d.writeOpts); err != nil {
+		return fmt.Errorf("failed to store withdrawal %s: %w", w.Hash, err)
+	}
+	return nil
+}
+
+// Get returns the withdrawal with the given hash, or ErrNotFound if it is not tracked.
+func (d *DB) Get(hash common.Hash) (Withdrawal, error) {
+	val, closer, err := d.db.Get(withdrawalKey(hash))
+	if errors.Is(err, pebble.ErrNotFound) {
+		return Withdrawal{}, ErrNotFound
+	} else if err != nil {
+		return Withdrawal{}, fmt.Errorf("failed to read withdrawal %s: %w", hash, err)
+	}
+	defer closer.Close()
+	var w Withdrawal
+	if err := json.Unmarshal(val, &w); err != nil {
+		return Withdrawal{}, fmt.Errorf("%w: withdrawal %s: %w", ErrInvalidEntry, hash, err)
+	}
+	return w, nil
+}
+
+// All returns the tracked withdrawals with any of the given statuses, or all of them if no status is given.
+// Withdrawals are ordered by their hash.
+func (d *DB) All(statuses ...Status) ([]Withdrawal, error) {
+	iter, err := d.db.NewIter(withdrawalRange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create iterator: %w", err)
+	}
+	defer iter.Close()
+	var result []Withdrawal
+	for valid := iter.First(); valid; valid = iter.Next() {
+		val, err := iter.ValueAndErr()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read withdrawal: %w", err)
+		}
+		var w Withdrawal
+		if err := json.Unmarshal(val, &w); err != nil {
+			return nil, fmt.Errorf("%w: withdrawal %x: %w", ErrInvalidEntry, iter.Key()[1:], err)
+		}
+		if len(statuses) == 0 || slices.Contains(statuses, w.Status) {
+			result = append(result, w)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,87 @@
+package tracker
+
+import (
+	"encoding/json"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/common/hexutil"
+)
+
+func testWithdrawal(i byte, l2Block uint64) Withdrawal {
+	return Withdrawal{
+		Hash:     common.Hash{i},
+		TxHash:   common.Hash{0xff, i},
+		L2Block:  l2Block,
+		Nonce:    (*hexutil.Big)(big.NewInt(int64(i))),
+		Sender:   common.Address{0xaa},
+		Target:   common.Address{0xbb},
+		Value:    (*hexutil.Big)(big.NewInt(1000)),
+		GasLimit: (*hexutil.Big)(big.NewInt(100_000)),
+		Data:     []byte{i},
+		Status:   StatusInitiated,
+	}
+}
+
+func TestDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "withdrawals")
+	db, err := OpenDB(path)
+	require.NoError(t, err)
+
+	_, err = db.LastScanned()
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = db.Get(common.Hash{1})
+	require.ErrorIs(t, err, ErrNotFound)
+
+	w1, w2 := testWithdrawal(1, 5), testWithdrawal(2, 7)
+	require.NoError(t, db.RecordScan([]Withdrawal{w1, w2}, 10))
+	last, err := db.LastScanned()
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), last)
+
+	proveTx := common.Hash{0xcc}
+	w1.Status = StatusProven
+	w1.ProveTx = &proveTx
+	require.NoError(t, db.Put(w1))
+
+	// scanning a block again does not reset the progress of known withdrawals
+	require.NoError(t, db.RecordScan([]Withdrawal{testWithdrawal(1, 5)}, 12))
+	got, err := db.Get(w1.Hash)
+	require.NoError(t, err)
+	require.Equal(t, w1, got)
+
+	initiated, err := db.All(StatusInitiated)
+	require.NoError(t, err)
+	require.Equal(t, []Withdrawal{w2}, initiated)
+	all, err := db.All()
+	require.NoError(t, err)
+	require.Equal(t, []Withdrawal{w1, w2}, all)
+
+	// the state is kept after re-opening
+	require.NoError(t, db.Close())
+	db, err = OpenDB(path)
+	require.NoError(t, err)
+	defer db.Close()
+	last, err = db.LastScanned()
+	require.NoError(t, err)
+	require.Equal(t, uint64(12), last)
+	all, err = db.All(StatusInitiated, StatusProven)
+	require.NoError(t, err)
+	require.Equal(t, []Withdrawal{w1, w2}, all)
+}
+
+func TestStatusJSON(t *testing.T) {
+	for _, s := range []Status{StatusInitiated, StatusProven, StatusFinalized} {
+		data, err := json.Marshal(s)
+		require.NoError(t, err)
+		require.Equal(t, `"`+s.String()+`"`, string(data))
+		var decoded Status
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		require.Equal(t, s, decoded)
+	}
+	var s Status
+	require.Error(t, json.Unmarshal([]byte(`"unknown"`), &s))
+}
@@ -0,0 +1,362 @@
+// Package tracker follows withdrawals from their initiation on L2 until they are finalized on L1.
+// It proves withdrawals once an L2 output covers them, and finalizes them once the finalization period passed.
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	ethereum "github.com/zircuit-labs/l2-geth-public"
+	"github.com/zircuit-labs/l2-geth-public/accounts/abi"
+	"github.com/zircuit-labs/l2-geth-public/accounts/abi/bind"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/common/hexutil"
+	"github.com/zircuit-labs/l2-geth-public/core/types"
+	"github.com/zircuit-labs/l2-geth-public/log"
+	"github.com/zircuit-labs/l2-geth-public/rpc"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-bindings/bindings"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-bindings/predeploys"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/withdrawals"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/txmgr"
+)
+
+// L1Client is used to read the OptimismPortal and L2OutputOracle contracts.
+type L1Client interface {
+	bind.ContractCaller
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// L2Client is used to find withdrawals, and to build the proofs of them.
+type L2Client interface {
+	withdrawals.ProofClient
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+}
+
+// DefaultPollInterval is the time between steps when running, matching the L1 block time.
+const DefaultPollInterval = 12 * time.Second
+
+type Config struct {
+	OptimismPortal common.Address
+	L2OutputOracle common.Address
+	// StartBlock is the first L2 block to scan for withdrawals, if nothing was scanned before.
+	StartBlock uint64
+	// ScanRange is the maximum number of L2 blocks to request logs for at once.
+	ScanRange uint64
+	// Senders restricts the tracked withdrawals to the ones initiated by these L2 addresses. All are tracked if empty.
+	Senders []common.Address
+	// PollInterval is the time between steps when running.
+	PollInterval time.Duration
+}
+
+func (c *Config) Check() error {
+	if c.OptimismPortal == (common.Address{}) {
+		return errors.New("missing OptimismPortal address")
+	}
+	if c.L2OutputOracle == (common.Address{}) {
+		return errors.New("missing L2OutputOracle address")
+	}
+	if c.ScanRange == 0 {
+		return errors.New("scan range must be positive")
+	}
+	if c.PollInterval <= 0 {
+		return errors.New("poll interval must be positive")
+	}
+	return nil
+}
+
+// Tracker scans L2 for initiated withdrawals, and moves them through their lifecycle on L1.
+//
+// Only safe L2 blocks are scanned, so withdrawals are not lost to L2 reorgs.
+// Without a transaction manager the tracker only logs the L1 transactions that are ready to be sent,
+// and keeps tracking the withdrawals until someone else sends them.
+type Tracker struct {
+	log log.Logger
+	cfg Config
+	db  *DB
+	l1  L1Client
+	l2  L2Client
+	// txMgr sends the prove and finalize transactions. It is nil when the tracker does not submit transactions.
+	txMgr txmgr.TxManager
+
+	portal    *bindings.OptimismPortalCaller
+	portalABI *abi.ABI
+	oracle    *bindings.L2OutputOracleCaller
+
+	finalizationPeriod *big.Int
+	// reported avoids logging the same ready transaction on every step, when not submitting
+	reported map[common.Hash]Status
+}
+
+// NewTracker creates a tracker. The transaction manager is optional.
+func NewTracker(logger log.Logger, cfg Config, db *DB, l1 L1Client, l2 L2Client, txMgr txmgr.TxManager) (*Tracker, error) {
+	if err := cfg.Check(); err != nil {
+		return nil, fmt.Errorf("invalid tracker config: %w", err)
+	}
+	portal, err := bindings.NewOptimismPortalCaller(cfg.OptimismPortal, l1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bind OptimismPortal: %w", err)
+	}
+	portalABI, err := bindings.OptimismPortalMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OptimismPortal ABI: %w", err)
+	}
+	oracle, err := bindings.NewL2OutputOracleCaller(cfg.L2OutputOracle, l1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bind L2OutputOracle: %w", err)
+	}
+	return &Tracker{
+		log:       logger,
+		cfg:       cfg,
+		db:        db,
+		l1:        l1,
+		l2:        l2,
+		txMgr:     txMgr,
+		portal:    portal,
+		portalABI: portalABI,
+		oracle:    oracle,
+		reported:  make(map[common.Hash]Status),
+	}, nil
+}
+
+// Run steps the tracker until the context is done. Errors of a step are logged, and retried in the next step.
+func (t *Tracker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(t.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		if err := t.Step(ctx); err != nil && ctx.Err() == nil {
+			t.log.Error("Failed to track withdrawals", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Step scans for new withdrawals, and advances all withdrawals that are not finalized yet.
+// A withdrawal that fails to advance is logged, and does not hold up the others.
+func (t *Tracker) Step(ctx context.Context) error {
+	if err := t.scan(ctx); err != nil {
+		return err
+	}
+	pending, err := t.db.All(StatusInitiated, StatusProven)
+	if err != nil {
+		return err
+	}
+	for _, w := range pending {
+		if err := t.advance(ctx, w); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			t.log.Warn("Failed to advance withdrawal", "hash", w.Hash, "status", w.Status, "err", err)
+		}
+	}
+	return nil
+}
+
+// scan records the withdrawals initiated in the safe L2 blocks since the last scan.
+func (t *Tracker) scan(ctx context.Context) error {
+	from := t.cfg.StartBlock
+	if last, err := t.db.LastScanned(); err == nil {
+		from = last + 1
+	} else if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	safe, err := t.l2.HeaderByNumber(ctx, big.NewInt(int64(rpc.SafeBlockNumber)))
+	if err != nil {
+		return fmt.Errorf("failed to fetch L2 safe head: %w", err)
+	}
+	head := safe.Number.Uint64()
+
+	passer, err := bindings.NewL2ToL1MessagePasserFilterer(predeploys.L2ToL1MessagePasserAddr, nil)
+	if err != nil {
+		return fmt.Errorf("failed to bind L2ToL1MessagePasser: %w", err)
+	}
+	topics := [][]common.Hash{{withdrawals.MessagePassedTopic}}
+	if len(t.cfg.Senders) > 0 {
+		senders := make([]common.Hash, len(t.cfg.Senders))
+		for i, s := range t.cfg.Senders {
+			senders[i] = common.BytesToHash(s.Bytes())
+		}
+		// the nonce is the first indexed topic, the sender the second
+		topics = append(topics, nil, senders)
+	}
+	for from <= head {
+		to := min(from+t.cfg.ScanRange-1, head)
+		logs, err := t.l2.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(from),
+			ToBlock:   new(big.Int).SetUint64(to),
+			Addresses: []common.Address{predeploys.L2ToL1MessagePasserAddr},
+			Topics:    topics,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to fetch withdrawal logs of L2 blocks %d to %d: %w", from, to, err)
+		}
+		found := make([]Withdrawal, 0, len(logs))
+		for _, l := range logs {
+			ev, err := passer.ParseMessagePassed(l)
+			if err != nil {
+				return fmt.Errorf("failed to parse withdrawal log of tx %s: %w", l.TxHash, err)
+			}
+			w := NewWithdrawal(ev)
+			t.log.Info("Found withdrawal", "hash", w.Hash, "tx", w.TxHash, "l2Block", w.L2Block, "sender", w.Sender, "target", w.Target)
+			found = append(found, w)
+		}
+		if err := t.db.RecordScan(found, to); err != nil {
+			return err
+		}
+		from = to + 1
+	}
+	return nil
+}
+
+// advance moves the withdrawal to its next status, if it is ready for it.
+func (t *Tracker) advance(ctx context.Context, w Withdrawal) error {
+	opts := &bind.CallOpts{Context: ctx}
+	finalized, err := t.portal.FinalizedWithdrawals(opts, w.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to check if withdrawal is finalized: %w", err)
+	}
+	if finalized {
+		t.log.Info("Withdrawal finalized", "hash", w.Hash)
+		w.Status = StatusFinalized
+		return t.db.Put(w)
+	}
+	proven, err := t.portal.ProvenWithdrawals(opts, w.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to check if withdrawal is proven: %w", err)
+	}
+	isProven := proven.Timestamp.Sign() != 0
+	if isProven {
+		// the proven output may have been deleted, and replaced with a different one, after which the proof is invalid
+		output, err := t.oracle.GetL2Output(opts, proven.L2OutputIndex)
+		if err != nil {
+			return fmt.Errorf("failed to fetch proven L2 output %v: %w", proven.L2OutputIndex, err)
+		}
+		if output.OutputRoot != proven.OutputRoot {
+			t.log.Warn("Proven L2 output was replaced, withdrawal must be proven again", "hash", w.Hash, "index", proven.L2OutputIndex)
+			isProven = false
+		}
+	}
+	switch {
+	case isProven && w.Status != StatusProven:
+		t.log.Info("Withdrawal proven", "hash", w.Hash, "index", proven.L2OutputIndex)
+		w.Status = StatusProven
+		return t.db.Put(w)
+	case !isProven && w.Status == StatusProven:
+		w.Status = StatusInitiated
+		w.ProveTx = nil
+		return t.db.Put(w)
+	case isProven:
+		return t.finalize(ctx, w, proven.Timestamp, proven.L2OutputIndex)
+	default:
+		return t.prove(ctx, w)
+	}
+}
+
+// prove sends the proof of the withdrawal, once an L2 output covers the block that initiated it.
+func (t *Tracker) prove(ctx context.Context, w Withdrawal) error {
+	opts := &bind.CallOpts{Context: ctx}
+	latest, err := t.oracle.LatestBlockNumber(opts)
+	if err != nil {
+		return fmt.Errorf("failed to fetch latest L2 output block: %w", err)
+	}
+	if latest.Uint64() < w.L2Block {
+		t.log.Debug("Waiting for L2 output", "hash", w.Hash, "l2Block", w.L2Block, "latestOutput", latest)
+		return nil
+	}
+	output, err := t.oracle.GetL2OutputAfter(opts, new(big.Int).SetUint64(w.L2Block))
+	if err != nil {
+		return fmt.Errorf("failed to fetch L2 output after block %d: %w", w.L2Block, err)
+	}
+	header, err := t.l2.HeaderByNumber(ctx, output.L2BlockNumber)
+	if err != nil {
+		return fmt.Errorf("failed to fetch L2 output block %v: %w", output.L2BlockNumber, err)
+	}
+	params, err := withdrawals.ProveWithdrawalParametersForEvent(ctx, t.l2, w.Event(), header, t.oracle)
+	if err != nil {
+		return fmt.Errorf("failed to build withdrawal proof: %w", err)
+	}
+	data, err := t.portalABI.Pack("proveWithdrawalTransaction", w.Transaction(), params.L2OutputIndex, params.OutputRootProof, params.WithdrawalProof)
+	if err != nil {
+		return fmt.Errorf("failed to encode prove transaction: %w", err)
+	}
+	receipt, err := t.send(ctx, w, StatusInitiated, "prove", data)
+	if err != nil || receipt == nil {
+		return err
+	}
+	t.log.Info("Withdrawal proven", "hash", w.Hash, "index", params.L2OutputIndex, "tx", receipt.TxHash)
+	w.Status = StatusProven
+	w.ProveTx = &receipt.TxHash
+	return t.db.Put(w)
+}
+
+// finalize sends the finalization of the proven withdrawal, once the finalization period passed.
+func (t *Tracker) finalize(ctx context.Context, w Withdrawal, provenAt *big.Int, outputIndex *big.Int) error {
+	opts := &bind.CallOpts{Context: ctx}
+	if t.finalizationPeriod == nil {
+		period, err := t.oracle.FinalizationPeriodSeconds(opts)
+		if err != nil {
+			return fmt.Errorf("failed to fetch finalization period: %w", err)
+		}
+		t.finalizationPeriod = period
+	}
+	head, err := t.l1.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to fetch L1 head: %w", err)
+	}
+	readyAt := new(big.Int).Add(provenAt, t.finalizationPeriod)
+	if new(big.Int).SetUint64(head.Time).Cmp(readyAt) <= 0 {
+		t.log.Debug("Waiting for finalization period", "hash", w.Hash, "readyAt", readyAt)
+		return nil
+	}
+	outputFinalized, err := t.portal.IsOutputFinalized(opts, outputIndex)
+	if err != nil {
+		return fmt.Errorf("failed to check if L2 output %v is finalized: %w", outputIndex, err)
+	}
+	if !outputFinalized {
+		t.log.Debug("Waiting for L2 output to finalize", "hash", w.Hash, "index", outputIndex)
+		return nil
+	}
+	data, err := t.portalABI.Pack("finalizeWithdrawalTransaction", w.Transaction())
+	if err != nil {
+		return fmt.Errorf("failed to encode finalize transaction: %w", err)
+	}
+	receipt, err := t.send(ctx, w, StatusProven, "finalize", data)
+	if err != nil || receipt == nil {
+		return err
+	}
+	t.log.Info("Withdrawal finalized", "hash", w.Hash, "tx", receipt.TxHash)
+	w.Status = StatusFinalized
+	w.FinalizeTx = &receipt.TxHash
+	return t.db.Put(w)
+}
+
+// send sends the OptimismPortal call with the transaction manager.
+// Without a transaction manager, the call is logged once instead, and a nil receipt is returned.
+func (t *Tracker) send(ctx context.Context, w Withdrawal, status Status, action string, data []byte) (*types.Receipt, error) {
+	if t.txMgr == nil {
+		if reported, ok := t.reported[w.Hash]; !ok || reported != status {
+			t.log.Info("Withdrawal ready to "+action, "hash", w.Hash, "to", t.cfg.OptimismPortal, "data", hexutil.Bytes(data))
+			t.reported[w.Hash] = status
+		}
+		return nil, nil
+	}
+	receipt, err := t.txMgr.Send(ctx, txmgr.TxCandidate{
+		TxData: data,
+		To:     &t.cfg.OptimismPortal,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send %s transaction: %w", action, err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("%s transaction %s failed", action, receipt.TxHash)
+	}
+	return receipt, nil
+}
@@ -0,0 +1,314 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	ethereum "github.com/zircuit-labs/l2-geth-public"
+	"github.com/zircuit-labs/l2-geth-public/accounts/abi"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/core/types"
+	"github.com/zircuit-labs/l2-geth-public/ethclient/gethclient"
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-bindings/bindings"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-bindings/predeploys"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/withdrawals"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testlog"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/txmgr"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/txmgr/mocks"
+)
+
+var (
+	portalAddr = common.Address{0x01}
+	oracleAddr = common.Address{0x02}
+)
+
+// fakeL1 answers the contract calls of the tracker from its fields.
+type fakeL1 struct {
+	time uint64
+
+	finalized   map[common.Hash]bool
+	proven      map[common.Hash]provenWithdrawal
+	outputs     []bindings.TypesOutputProposal
+	outputFinal bool
+	period      int64
+}
+
+type provenWithdrawal struct {
+	OutputRoot    [32]byte
+	Timestamp     *big.Int
+	L2OutputIndex *big.Int
+}
+
+func (f *fakeL1) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return []byte{0x01}, nil
+}
+
+func (f *fakeL1) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	var contract *abi.ABI
+	var err error
+	switch *call.To {
+	case portalAddr:
+		contract, err = bindings.OptimismPortalMetaData.GetAbi()
+	case oracleAddr:
+		contract, err = bindings.L2OutputOracleMetaData.GetAbi()
+	default:
+		return nil, fmt.Errorf("unexpected call to %s", call.To)
+	}
+	if err != nil {
+		return nil, err
+	}
+	method, err := contract.MethodById(call.Data[:4])
+	if err != nil {
+		return nil, err
+	}
+	args, err := method.Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	var out []interface{}
+	switch method.Name {
+	case "finalizedWithdrawals":
+		out = []interface{}{f.finalized[args[0].([32]byte)]}
+	case "provenWithdrawals":
+		p, ok := f.proven[args[0].([32]byte)]
+		if !ok {
+			p = provenWithdrawal{Timestamp: new(big.Int), L2OutputIndex: new(big.Int)}
+		}
+		out = []interface{}{p.OutputRoot, p.Timestamp, p.L2OutputIndex}
+	case "isOutputFinalized":
+		out = []interface{}{f.outputFinal}
+	case "getL2Output":
+		out = []interface{}{f.outputs[args[0].(*big.Int).Uint64()]}
+	case "latestBlockNumber":
+		latest := new(big.Int)
+		if len(f.outputs) > 0 {
+			latest = f.outputs[len(f.outputs)-1].L2BlockNumber
+		}
+		out = []interface{}{latest}
+	case "finalizationPeriodSeconds":
+		out = []interface{}{big.NewInt(f.period)}
+	default:
+		return nil, fmt.Errorf("unexpected call of %s", method.Name)
+	}
+	return method.Outputs.Pack(out...)
+}
+
+func (f *fakeL1) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(100), Time: f.time}, nil
+}
+
+// fakeL2 serves withdrawal logs up to its safe head.
+type fakeL2 struct {
+	safe uint64
+	logs []types.Log
+}
+
+func (f *fakeL2) GetProof(ctx context.Context, account common.Address, keys []string, blockNumber *big.Int) (*gethclient.AccountResult, error) {
+	return nil, errors.New("not supported")
+}
+
+func (f *fakeL2) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: new(big.Int).SetUint64(f.safe)}, nil
+}
+
+func (f *fakeL2) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	if q.ToBlock.Uint64() > f.safe {
+		return nil, errors.New("range beyond safe head")
+	}
+	var result []types.Log
+	for _, l := range f.logs {
+		if l.BlockNumber < q.FromBlock.Uint64() || l.BlockNumber > q.ToBlock.Uint64() || !slices.Contains(q.Addresses, l.Address) {
+			continue
+		}
+		match := true
+		for i, set := range q.Topics {
+			if len(set) > 0 && !slices.Contains(set, l.Topics[i]) {
+				match = false
+			}
+		}
+		if match {
+			result = append(result, l)
+		}
+	}
+	return result, nil
+}
+
+func messagePassedLog(t *testing.T, nonce int64, sender common.Address, l2Block uint64) (types.Log, common.Hash) {
+	ev := &bindings.L2ToL1MessagePasserMessagePassed{
+		Nonce:    big.NewInt(nonce),
+		Sender:   sender,
+		Target:   common.Address{0xbb},
+		Value:    big.NewInt(1000),
+		GasLimit: big.NewInt(100_000),
+		Data:     []byte{0x01, 0x02},
+	}
+	hash, err := withdrawals.WithdrawalHash(ev)
+	require.NoError(t, err)
+	passer, err := bindings.L2ToL1MessagePasserMetaData.GetAbi()
+	require.NoError(t, err)
+	data, err := passer.Events["MessagePassed"].Inputs.NonIndexed().Pack(ev.Value, ev.GasLimit, ev.Data, hash)
+	require.NoError(t, err)
+	return types.Log{
+		Address: predeploys.L2ToL1MessagePasserAddr,
+		Topics: []common.Hash{
+			withdrawals.MessagePassedTopic,
+			common.BigToHash(ev.Nonce),
+			common.BytesToHash(ev.Sender.Bytes()),
+			common.BytesToHash(ev.Target.Bytes()),
+		},
+		Data:        data,
+		BlockNumber: l2Block,
+		TxHash:      common.Hash{0xee, byte(nonce)},
+	}, hash
+}
+
+func testConfig() Config {
+	return Config{
+		OptimismPortal: portalAddr,
+		L2OutputOracle: oracleAddr,
+		StartBlock:     1,
+		ScanRange:      3,
+		PollInterval:   time.Second,
+	}
+}
+
+func openTestDB(t *testing.T) *DB {
+	db, err := OpenDB(filepath.Join(t.TempDir(), "withdrawals"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestTrackerScan(t *testing.T) {
+	logger := testlog.Logger(t, log.LevelDebug)
+	db := openTestDB(t)
+	sender, other := common.Address{0xaa}, common.Address{0xab}
+	log1, hash1 := messagePassedLog(t, 1, sender, 5)
+	log2, _ := messagePassedLog(t, 2, other, 6)
+	log3, hash3 := messagePassedLog(t, 3, sender, 15)
+	l1 := &fakeL1{}
+	l2 := &fakeL2{safe: 10, logs: []types.Log{log1, log2, log3}}
+
+	cfg := testConfig()
+	cfg.Senders = []common.Address{sender}
+	tracker, err := NewTracker(logger, cfg, db, l1, l2, nil)
+	require.NoError(t, err)
+	require.NoError(t, tracker.Step(context.Background()))
+
+	all, err := db.All()
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	require.Equal(t, hash1, all[0].Hash)
+	require.Equal(t, log1.TxHash, all[0].TxHash)
+	require.Equal(t, uint64(5), all[0].L2Block)
+	last, err := db.LastScanned()
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), last)
+
+	// a new tracker resumes from the last scanned block
+	l2.safe = 20
+	tracker, err = NewTracker(logger, cfg, db, l1, l2, nil)
+	require.NoError(t, err)
+	require.NoError(t, tracker.Step(context.Background()))
+	all, err = db.All()
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	w, err := db.Get(hash3)
+	require.NoError(t, err)
+	require.Equal(t, uint64(15), w.L2Block)
+	last, err = db.LastScanned()
+	require.NoError(t, err)
+	require.Equal(t, uint64(20), last)
+}
+
+func TestTrackerLifecycle(t *testing.T) {
+	logger := testlog.Logger(t, log.LevelDebug)
+	db := openTestDB(t)
+	l, hash := messagePassedLog(t, 1, common.Address{0xaa}, 5)
+	outputRoot := common.Hash{0x0a}
+	l1 := &fakeL1{
+		time:      100,
+		finalized: map[common.Hash]bool{},
+		proven:    map[common.Hash]provenWithdrawal{},
+		period:    50,
+	}
+	l2 := &fakeL2{safe: 10, logs: []types.Log{l}}
+	txMgr := mocks.NewTxManager(t)
+	tracker, err := NewTracker(logger, testConfig(), db, l1, l2, txMgr)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	requireStatus := func(status Status) Withdrawal {
+		w, err := db.Get(hash)
+		require.NoError(t, err)
+		require.Equal(t, status, w.Status)
+		return w
+	}
+
+	// no output covers the withdrawal yet
+	require.NoError(t, tracker.Step(ctx))
+	requireStatus(StatusInitiated)
+
+	// proven by someone else
+	l1.outputs = []bindings.TypesOutputProposal{{OutputRoot: outputRoot, Timestamp: big.NewInt(90), L2BlockNumber: big.NewInt(8)}}
+	l1.proven[hash] = provenWithdrawal{OutputRoot: outputRoot, Timestamp: big.NewInt(100), L2OutputIndex: big.NewInt(0)}
+	require.NoError(t, tracker.Step(ctx))
+	w := requireStatus(StatusProven)
+	require.Nil(t, w.ProveTx)
+
+	// the output was replaced, so the withdrawal has to be proven again
+	l1.outputs[0].OutputRoot = common.Hash{0x0b}
+	require.NoError(t, tracker.Step(ctx))
+	requireStatus(StatusInitiated)
+	l1.outputs[0].OutputRoot = outputRoot
+	require.NoError(t, tracker.Step(ctx))
+	requireStatus(StatusProven)
+
+	// the finalization period did not pass yet
+	l1.time = 150
+	require.NoError(t, tracker.Step(ctx))
+	requireStatus(StatusProven)
+
+	// nor is the output finalized
+	l1.time = 151
+	require.NoError(t, tracker.Step(ctx))
+	requireStatus(StatusProven)
+
+	l1.outputFinal = true
+	finalizeTx := common.Hash{0xf1}
+	txMgr.On("Send", mock.Anything, mock.MatchedBy(func(c txmgr.TxCandidate) bool { return *c.To == portalAddr })).
+		Return(&types.Receipt{TxHash: finalizeTx, Status: types.ReceiptStatusSuccessful}, nil).Once()
+	require.NoError(t, tracker.Step(ctx))
+	w = requireStatus(StatusFinalized)
+	require.Equal(t, &finalizeTx, w.FinalizeTx)
+
+	// finalized withdrawals are no longer checked
+	require.NoError(t, tracker.Step(ctx))
+	txMgr.AssertExpectations(t)
+}
+
+func TestTrackerFinalizedElsewhere(t *testing.T) {
+	logger := testlog.Logger(t, log.LevelDebug)
+	db := openTestDB(t)
+	l, hash := messagePassedLog(t, 1, common.Address{0xaa}, 5)
+	l1 := &fakeL1{finalized: map[common.Hash]bool{hash: true}}
+	l2 := &fakeL2{safe: 10, logs: []types.Log{l}}
+	// without a transaction manager, nothing is sent
+	tracker, err := NewTracker(logger, testConfig(), db, l1, l2, nil)
+	require.NoError(t, err)
+	require.NoError(t, tracker.Step(context.Background()))
+	w, err := db.Get(hash)
+	require.NoError(t, err)
+	require.Equal(t, StatusFinalized, w.Status)
+	require.Nil(t, w.FinalizeTx)
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+
+	ethereum "github.com/zircuit-labs/l2-geth-public"
+	"github.com/zircuit-labs/l2-geth-public/rpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/optracing"
+)
+
+const tracerName = "github.com/zircuit-labs/zkr-monorepo-public/op-service/client"
+
+// TracingRPCClient is an RPC client that creates a span for each call,
+// and propagates the trace context to the server with the HTTP request headers.
+type TracingRPCClient struct {
+	c      RPC
+	tracer trace.Tracer
+}
+
+// NewTracingRPC creates a new tracing RPC client.
+func NewTracingRPC(c RPC) *TracingRPCClient {
+	return &TracingRPCClient{
+		c:      c,
+		tracer: optracing.Tracer(tracerName),
+	}
+}
+
+func (tc *TracingRPCClient) Close() {
+	tc.c.Close()
+}
+
+func (tc *TracingRPCClient) CallContext(ctx context.Context, result any, method string, args ...any) (err error) {
+	ctx, span := tc.tracer.Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("rpc.method", method)))
+	defer func() { optracing.EndSpan(span, err) }()
+	return tc.c.CallContext(rpc.NewContextWithHeaders(ctx, optracing.InjectHeaders(ctx)), result, method, args...)
+}
+
+func (tc *TracingRPCClient) BatchCallContext(ctx context.Context, b []rpc.BatchElem) (err error) {
+	ctx, span := tc.tracer.Start(ctx, "batch",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("rpc.batch_size", len(b))))
+	defer func() { optracing.EndSpan(span, err) }()
+	return tc.c.BatchCallContext(rpc.NewContextWithHeaders(ctx, optracing.InjectHeaders(ctx)), b)
+}
+
+func (tc *TracingRPCClient) EthSubscribe(ctx context.Context, channel any, args ...any) (ethereum.Subscription, error) {
+	return tc.c.EthSubscribe(ctx, channel, args...)
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zircuit-labs/l2-geth-public/rpc"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/optracing"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/optracing/test"
+)
+
+type traceAPI struct{}
+
+func (api *traceAPI) Echo(ctx context.Context, v string) string {
+	_, span := optracing.Tracer("test").Start(ctx, "handler")
+	span.End()
+	return v
+}
+
+func TestTracingRPC(t *testing.T) {
+	collector := &test.CollectorStub{}
+	collectorSrv := httptest.NewServer(collector)
+	defer collectorSrv.Close()
+
+	cfg := optracing.DefaultCLIConfig()
+	cfg.Enabled = true
+	cfg.Endpoint = collectorSrv.URL
+	svc, err := optracing.Start(context.Background(), "test-service", "v0.0.1", cfg)
+	require.NoError(t, err)
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	require.NoError(t, server.RegisterName("test", new(traceAPI)))
+	rpcSrv := httptest.NewServer(optracing.NewHTTPMiddleware(server))
+	defer rpcSrv.Close()
+
+	rpcClient, err := rpc.Dial(rpcSrv.URL)
+	require.NoError(t, err)
+	cl := NewTracingRPC(NewBaseRPCClient(rpcClient))
+	defer cl.Close()
+
+	var res string
+	require.NoError(t, cl.CallContext(context.Background(), &res, "test_echo", "hello"))
+	require.Equal(t, "hello", res)
+	var batchRes string
+	require.NoError(t, cl.BatchCallContext(context.Background(), []rpc.BatchElem{
+		{Method: "test_echo", Args: []any{"world"}, Result: &batchRes},
+	}))
+	require.Equal(t, "world", batchRes)
+
+	// Stopping flushes the spans to the collector
+	require.NoError(t, svc.Stop(context.Background()))
+
+	call := collector.Span(t, "test_echo")
+	batch := collector.Span(t, "batch")
+	var serverSpans, handlerSpans int
+	for _, clientSpan := range []*tracepb.Span{call, batch} {
+		for _, s := range collector.Spans() {
+			switch {
+			case s.Name == "rpc.server" && string(s.ParentSpanId) == string(clientSpan.SpanId):
+				require.Equal(t, clientSpan.TraceId, s.TraceId, "trace is propagated over the JSON-RPC request headers")
+				serverSpans++
+			case s.Name == "handler" && string(s.TraceId) == string(clientSpan.TraceId):
+				handlerSpans++
+			}
+		}
+	}
+	require.Equal(t, 2, serverSpans, "server span continues the trace of each client call")
+	require.Equal(t, 2, handlerSpans, "RPC handlers continue the trace of the client call")
+}
@@ -0,0 +1,29 @@
+package eth
+
+import (
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/params"
+)
+
+// RuntimeConfig is a snapshot of the runtime config of a rollup node:
+// the values loaded from L1, and the settings that can be changed with the admin API.
+type RuntimeConfig struct {
+	// L1Origin is the L1 block the L1 values were loaded from.
+	L1Origin                   BlockID                `json:"l1Origin"`
+	P2PSequencerAddress        common.Address         `json:"p2pSequencerAddress"`
+	RequiredProtocolVersion    params.ProtocolVersion `json:"requiredProtocolVersion"`
+	RecommendedProtocolVersion params.ProtocolVersion `json:"recommendedProtocolVersion"`
+	Settings                   RuntimeSettings        `json:"settings"`
+}
+
+// RuntimeSettings are the settings of a rollup node that can be changed at runtime.
+// In a snapshot, settings of disabled features are not set.
+// In an update, settings that are not set are left unchanged.
+type RuntimeSettings struct {
+	// SequencerMaxSafeLag is the maximum number of unsafe blocks the sequencer builds ahead of the safe head, 0 to disable.
+	SequencerMaxSafeLag *uint64 `json:"sequencerMaxSafeLag,omitempty"`
+	// P2PPeersLo is the low watermark of the P2P peer count, the connection manager trims the peers down to it.
+	P2PPeersLo *uint `json:"p2pPeersLo,omitempty"`
+	// P2PPeersHi is the high watermark of the P2P peer count, above which the connection manager trims the peers.
+	P2PPeersHi *uint `json:"p2pPeersHi,omitempty"`
+}
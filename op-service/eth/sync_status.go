@@ -34,4 +34,7 @@ type SyncStatus struct {
 	FinalizedL2 L2BlockRef `json:"finalized_l2"`
 	// PendingSafeL2 points to the L2 block processed from the batch, but not consolidated to the safe block yet.
 	PendingSafeL2 L2BlockRef `json:"pending_safe_l2"`
+	// CrossUnsafeL2 points to the last unsafe L2 block of which all cross-chain dependencies are known to be valid.
+	// Zeroed if interop is not enabled.
+	CrossUnsafeL2 L2BlockRef `json:"cross_unsafe_l2"`
 }
@@ -383,6 +383,13 @@ type ForkchoiceState struct {
 	CheckTransactions bool `json:"checkPayloadAttributes,omitempty"`
 }
 
+// DepositSimulationResult is the result of a dry-run of the deposits of payload attributes against
+// the circuit capacity checker of the engine.
+type DepositSimulationResult struct {
+	// the list of deposit transactions that would be rejected when building the payload
+	RejectedTransactions []common.Hash `json:"rejectedTransactions,omitempty"`
+}
+
 type ForkchoiceUpdatedResult struct {
 	// the result of the payload execution
 	PayloadStatus PayloadStatusV1 `json:"payloadStatus"`
@@ -537,4 +544,8 @@ const (
 
 	GetPayloadV2 EngineAPIMethod = "engine_getPayloadV2"
 	GetPayloadV3 EngineAPIMethod = "engine_getPayloadV3"
+
+	SimulateDepositsV1 EngineAPIMethod = "engine_simulateDepositsV1"
+
+	ExchangeCapabilities EngineAPIMethod = "engine_exchangeCapabilities"
 )
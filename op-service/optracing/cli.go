@@ -0,0 +1,82 @@
+package optracing
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/urfave/cli/v2"
+
+	opservice "github.com/zircuit-labs/zkr-monorepo-public/op-service"
+)
+
+const (
+	EnabledFlagName     = "tracing.enabled"
+	EndpointFlagName    = "tracing.endpoint"
+	SampleRatioFlagName = "tracing.sample-ratio"
+	defaultEndpoint     = "http://localhost:4318"
+	defaultSampleRatio  = 1.0
+)
+
+func DefaultCLIConfig() CLIConfig {
+	return CLIConfig{
+		Enabled:     false,
+		Endpoint:    defaultEndpoint,
+		SampleRatio: defaultSampleRatio,
+	}
+}
+
+func CLIFlags(envPrefix string) []cli.Flag {
+	return CLIFlagsWithCategory(envPrefix, "")
+}
+
+func CLIFlagsWithCategory(envPrefix string, category string) []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:     EnabledFlagName,
+			Usage:    "Enable exporting OpenTelemetry traces",
+			EnvVars:  opservice.PrefixEnvVar(envPrefix, "TRACING_ENABLED"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     EndpointFlagName,
+			Usage:    "URL of the OTLP/HTTP collector to export traces to",
+			Value:    defaultEndpoint,
+			EnvVars:  opservice.PrefixEnvVar(envPrefix, "TRACING_ENDPOINT"),
+			Category: category,
+		},
+		&cli.Float64Flag{
+			Name:     SampleRatioFlagName,
+			Usage:    "Fraction of new traces to sample, between 0 and 1. Traces continued from a remote parent follow the sampling decision of the parent.",
+			Value:    defaultSampleRatio,
+			EnvVars:  opservice.PrefixEnvVar(envPrefix, "TRACING_SAMPLE_RATIO"),
+			Category: category,
+		},
+	}
+}
+
+type CLIConfig struct {
+	Enabled     bool
+	Endpoint    string
+	SampleRatio float64
+}
+
+func (c CLIConfig) Check() error {
+	if !c.Enabled {
+		return nil
+	}
+	if _, err := url.ParseRequestURI(c.Endpoint); err != nil {
+		return errors.New("invalid tracing endpoint URL")
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return errors.New("tracing sample ratio must be between 0 and 1")
+	}
+	return nil
+}
+
+func ReadCLIConfig(ctx *cli.Context) CLIConfig {
+	return CLIConfig{
+		Enabled:     ctx.Bool(EnabledFlagName),
+		Endpoint:    ctx.String(EndpointFlagName),
+		SampleRatio: ctx.Float64(SampleRatioFlagName),
+	}
+}
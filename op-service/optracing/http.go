@@ -0,0 +1,39 @@
+package optracing
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/zircuit-labs/zkr-monorepo-public/op-service/optracing"
+
+// InjectHeaders returns the headers that propagate the trace context of ctx to a remote service.
+func InjectHeaders(ctx context.Context) http.Header {
+	h := make(http.Header)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(h))
+	return h
+}
+
+// ExtractHeaders returns ctx with the remote trace context from the given headers, if any.
+func ExtractHeaders(ctx context.Context, h http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(h))
+}
+
+// NewHTTPMiddleware continues the trace of the caller, if any, in a server span for each request.
+// The JSON-RPC handlers of the next handler receive the span through the request context.
+func NewHTTPMiddleware(next http.Handler) http.Handler {
+	tracer := Tracer(instrumentationName)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := ExtractHeaders(r.Context(), r.Header)
+		ctx, span := tracer.Start(ctx, "rpc.server",
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.String("http.target", r.URL.Path)))
+		defer span.End()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,73 @@
+// Package optracing sets up opt-in OpenTelemetry tracing, exported to an OTLP/HTTP collector.
+//
+// Instrumented code creates spans through the global tracer provider, which does not record anything
+// until a Service is started. Once started, trace context is propagated between services with W3C
+// trace-context headers.
+package optracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service exports the spans of the global tracer provider.
+type Service struct {
+	provider *sdktrace.TracerProvider
+}
+
+// Start sets up the global tracer provider to export spans to the configured collector.
+// If tracing is not enabled, a Service that does nothing is returned.
+func Start(ctx context.Context, serviceName string, version string, cfg CLIConfig) (*Service, error) {
+	// Propagate trace context regardless of whether tracing is enabled in this process,
+	// so traces across services are not broken by a service that does not export spans itself.
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	if !cfg.Enabled {
+		return &Service{}, nil
+	}
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+	res := resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+		attribute.String("service.version", version),
+	)
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return &Service{provider: provider}, nil
+}
+
+// Stop flushes the remaining spans to the collector, and stops exporting.
+func (s *Service) Stop(ctx context.Context) error {
+	if s == nil || s.provider == nil {
+		return nil
+	}
+	return s.provider.Shutdown(ctx)
+}
+
+// Tracer returns a tracer of the global tracer provider, for the given instrumentation name.
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
+// EndSpan records the error, if any, on the span, and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,74 @@
+package optracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/optracing/test"
+)
+
+func TestTracing(t *testing.T) {
+	collector := &test.CollectorStub{}
+	collectorSrv := httptest.NewServer(collector)
+	defer collectorSrv.Close()
+
+	cfg := DefaultCLIConfig()
+	cfg.Enabled = true
+	cfg.Endpoint = collectorSrv.URL
+	require.NoError(t, cfg.Check())
+	svc, err := Start(context.Background(), "test-service", "v0.0.1", cfg)
+	require.NoError(t, err)
+
+	// A server that continues the trace of the caller
+	rpcSrv := httptest.NewServer(NewHTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, span := Tracer("test").Start(r.Context(), "handler")
+		span.End()
+	})))
+	defer rpcSrv.Close()
+
+	ctx, span := Tracer("test").Start(context.Background(), "caller")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rpcSrv.URL+"/rpc", nil)
+	require.NoError(t, err)
+	for k, v := range InjectHeaders(ctx) {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	span.End()
+
+	// Stopping flushes the spans to the collector
+	require.NoError(t, svc.Stop(context.Background()))
+
+	caller := collector.Span(t, "caller")
+	server := collector.Span(t, "rpc.server")
+	handler := collector.Span(t, "handler")
+	require.Equal(t, caller.TraceId, server.TraceId, "trace is propagated over the request headers")
+	require.Equal(t, caller.SpanId, server.ParentSpanId)
+	require.Equal(t, server.SpanId, handler.ParentSpanId)
+	require.Equal(t, tracepb.Span_SPAN_KIND_SERVER, server.Kind)
+	require.Contains(t, collector.Services(), "test-service")
+}
+
+func TestStartDisabled(t *testing.T) {
+	svc, err := Start(context.Background(), "test-service", "v0.0.1", DefaultCLIConfig())
+	require.NoError(t, err)
+	require.NoError(t, svc.Stop(context.Background()))
+}
+
+func TestCLIConfig_Check(t *testing.T) {
+	require.NoError(t, CLIConfig{Endpoint: "not a url"}.Check(), "not checked when disabled")
+	cfg := DefaultCLIConfig()
+	cfg.Enabled = true
+	require.NoError(t, cfg.Check())
+	cfg.SampleRatio = 1.5
+	require.Error(t, cfg.Check())
+	cfg.SampleRatio = 0.5
+	cfg.Endpoint = "not a url"
+	require.Error(t, cfg.Check())
+}
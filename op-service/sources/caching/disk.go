@@ -0,0 +1,220 @@
+package caching
+
+import (
+	"container/list"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const tmpSuffix = ".tmp"
+
+// diskTouchInterval is how often the modification time of a value file is updated when it is read.
+// The modification times only order the values across restarts, so they do not need to be precise.
+const diskTouchInterval = time.Hour
+
+// DiskStore persists immutable values in files, within a total size budget.
+// Values are keyed by a label and a 32 byte key, typically a block hash,
+// and the least recently used values are evicted first when the budget is exceeded.
+//
+// The store is meant for data that never changes for a given key, so it can be shared
+// between restarts of a service, and between tools that fetch the same data.
+type DiskStore struct {
+	dir     string
+	maxSize int64
+
+	mu      sync.Mutex
+	size    int64
+	order   *list.List               // *diskEntry, least recently used first
+	entries map[string]*list.Element // relative path -> element in order
+}
+
+type diskEntry struct {
+	path    string
+	size    int64
+	touched time.Time // last modification time of the file
+}
+
+// OpenDiskStore opens, or creates, a store in the given directory.
+// Existing entries are retained in order of last use, up to the size budget in bytes.
+func OpenDiskStore(dir string, maxSize int64) (*DiskStore, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("invalid disk cache size: %d", maxSize)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create disk cache dir: %w", err)
+	}
+	var found []diskEntry
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if strings.HasSuffix(path, tmpSuffix) {
+			// left behind by an interrupted write
+			return os.Remove(path)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		found = append(found, diskEntry{path: rel, size: info.Size(), touched: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read disk cache dir: %w", err)
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].touched.Before(found[j].touched)
+	})
+	s := &DiskStore{
+		dir:     dir,
+		maxSize: maxSize,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, len(found)),
+	}
+	for _, f := range found {
+		e := f
+		s.entries[e.path] = s.order.PushBack(&e)
+		s.size += e.size
+	}
+	s.evict()
+	return s, nil
+}
+
+// Len returns the number of stored values.
+func (s *DiskStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// Size returns the total size of the stored values in bytes.
+func (s *DiskStore) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+func entryPath(label string, key [32]byte) string {
+	return filepath.Join(label, hex.EncodeToString(key[:]))
+}
+
+// Get returns the value stored for the label and key, if any.
+func (s *DiskStore) Get(label string, key [32]byte) ([]byte, bool) {
+	path := entryPath(label, key)
+	now := time.Now()
+	touch := false
+	s.mu.Lock()
+	el, ok := s.entries[path]
+	if ok {
+		s.order.MoveToBack(el)
+		// keep the order of use across restarts, without writing to disk on every read
+		if e := el.Value.(*diskEntry); now.Sub(e.touched) >= diskTouchInterval {
+			e.touched = now
+			touch = true
+		}
+	}
+	s.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	full := filepath.Join(s.dir, path)
+	data, err := os.ReadFile(full)
+	if err != nil {
+		// evicted concurrently, or removed from disk by something else
+		s.remove(path)
+		return nil, false
+	}
+	if touch {
+		_ = os.Chtimes(full, now, now)
+	}
+	return data, true
+}
+
+// Put stores the value for the label and key, and returns whether any older values were evicted to make room for it.
+// Values that are larger than the total size budget are not stored.
+func (s *DiskStore) Put(label string, key [32]byte, data []byte) (evicted bool, err error) {
+	size := int64(len(data))
+	if size > s.maxSize {
+		return false, nil
+	}
+	path := entryPath(label, key)
+	s.mu.Lock()
+	el, ok := s.entries[path]
+	if ok {
+		// values are immutable, there is no need to write it again
+		s.order.MoveToBack(el)
+	}
+	s.mu.Unlock()
+	if ok {
+		return false, nil
+	}
+	full := filepath.Join(s.dir, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return false, fmt.Errorf("failed to create disk cache dir: %w", err)
+	}
+	// write to a temporary file first, so readers never see a partial value
+	tmp, err := os.CreateTemp(filepath.Dir(full), filepath.Base(full)+"-*"+tmpSuffix)
+	if err != nil {
+		return false, fmt.Errorf("failed to create disk cache file: %w", err)
+	}
+	_, err = tmp.Write(data)
+	err = errors.Join(err, tmp.Close())
+	if err == nil {
+		err = os.Rename(tmp.Name(), full)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return false, fmt.Errorf("failed to write disk cache file: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[path]; ok {
+		e := el.Value.(*diskEntry)
+		s.size += size - e.size
+		e.size = size
+		e.touched = time.Now()
+		s.order.MoveToBack(el)
+	} else {
+		s.entries[path] = s.order.PushBack(&diskEntry{path: path, size: size, touched: time.Now()})
+		s.size += size
+	}
+	return s.evict(), nil
+}
+
+// evict removes the least recently used values until the store is within its size budget.
+// The caller must hold the lock, or have exclusive access.
+func (s *DiskStore) evict() (evicted bool) {
+	for s.size > s.maxSize {
+		el := s.order.Front()
+		e := el.Value.(*diskEntry)
+		s.order.Remove(el)
+		delete(s.entries, e.path)
+		s.size -= e.size
+		_ = os.Remove(filepath.Join(s.dir, e.path))
+		evicted = true
+	}
+	return evicted
+}
+
+func (s *DiskStore) remove(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[path]; ok {
+		s.order.Remove(el)
+		delete(s.entries, path)
+		s.size -= el.Value.(*diskEntry).size
+	}
+}
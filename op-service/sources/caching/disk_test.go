@@ -0,0 +1,158 @@
+package caching
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testMetrics struct {
+	hits, misses, adds map[string]int
+}
+
+func newTestMetrics() *testMetrics {
+	return &testMetrics{hits: map[string]int{}, misses: map[string]int{}, adds: map[string]int{}}
+}
+
+func (m *testMetrics) CacheAdd(label string, cacheSize int, evicted bool) {
+	m.adds[label]++
+}
+
+func (m *testMetrics) CacheGet(label string, hit bool) {
+	if hit {
+		m.hits[label]++
+	} else {
+		m.misses[label]++
+	}
+}
+
+func key(i byte) [32]byte {
+	return [32]byte{i}
+}
+
+func TestDiskStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenDiskStore(dir, 30)
+	require.NoError(t, err)
+
+	_, ok := s.Get("a", key(1))
+	require.False(t, ok)
+
+	evicted, err := s.Put("a", key(1), bytes.Repeat([]byte{1}, 10))
+	require.NoError(t, err)
+	require.False(t, evicted)
+	_, err = s.Put("a", key(2), bytes.Repeat([]byte{2}, 10))
+	require.NoError(t, err)
+	_, err = s.Put("b", key(1), bytes.Repeat([]byte{3}, 10))
+	require.NoError(t, err)
+	require.Equal(t, 3, s.Len())
+	require.Equal(t, int64(30), s.Size())
+
+	// labels are separate namespaces
+	v, ok := s.Get("a", key(1))
+	require.True(t, ok)
+	require.Equal(t, bytes.Repeat([]byte{1}, 10), v)
+	v, ok = s.Get("b", key(1))
+	require.True(t, ok)
+	require.Equal(t, bytes.Repeat([]byte{3}, 10), v)
+
+	// a/2 is the least recently used now, and is evicted first
+	evicted, err = s.Put("a", key(3), bytes.Repeat([]byte{4}, 10))
+	require.NoError(t, err)
+	require.True(t, evicted)
+	_, ok = s.Get("a", key(2))
+	require.False(t, ok)
+	require.Equal(t, int64(30), s.Size())
+
+	// values larger than the budget are not stored
+	evicted, err = s.Put("a", key(4), bytes.Repeat([]byte{5}, 31))
+	require.NoError(t, err)
+	require.False(t, evicted)
+	_, ok = s.Get("a", key(4))
+	require.False(t, ok)
+
+	// entries survive re-opening the store
+	s, err = OpenDiskStore(dir, 30)
+	require.NoError(t, err)
+	require.Equal(t, 3, s.Len())
+	for _, k := range []struct {
+		label string
+		key   byte
+	}{{"a", 1}, {"b", 1}, {"a", 3}} {
+		_, ok := s.Get(k.label, key(k.key))
+		require.True(t, ok, "%s/%d", k.label, k.key)
+	}
+
+	// a smaller budget evicts on open
+	s, err = OpenDiskStore(dir, 15)
+	require.NoError(t, err)
+	require.Equal(t, 1, s.Len())
+
+	_, err = OpenDiskStore(t.TempDir(), 0)
+	require.Error(t, err)
+}
+
+func TestDiskStoreTouch(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenDiskStore(dir, 30)
+	require.NoError(t, err)
+	_, err = s.Put("a", key(1), []byte{1})
+	require.NoError(t, err)
+	full := filepath.Join(dir, entryPath("a", key(1)))
+
+	// recently used values are not touched on read
+	written := time.Now().Add(-time.Minute)
+	require.NoError(t, os.Chtimes(full, written, written))
+	s, err = OpenDiskStore(dir, 30)
+	require.NoError(t, err)
+	_, ok := s.Get("a", key(1))
+	require.True(t, ok)
+	info, err := os.Stat(full)
+	require.NoError(t, err)
+	require.WithinDuration(t, written, info.ModTime(), time.Second)
+
+	// values that were not used for a while are
+	stale := time.Now().Add(-2 * diskTouchInterval)
+	require.NoError(t, os.Chtimes(full, stale, stale))
+	s, err = OpenDiskStore(dir, 30)
+	require.NoError(t, err)
+	_, ok = s.Get("a", key(1))
+	require.True(t, ok)
+	info, err = os.Stat(full)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), info.ModTime(), time.Minute)
+}
+
+func TestTieredCache(t *testing.T) {
+	m := newTestMetrics()
+	store, err := OpenDiskStore(t.TempDir(), 1000)
+	require.NoError(t, err)
+	codec := JSONCodec[[]string]()
+
+	c := NewTieredCache[[32]byte, []string](m, "test", 1, store, codec)
+	c.Add(key(1), []string{"one"})
+	c.Add(key(2), []string{"two"}) // evicts key 1 from memory
+	require.Equal(t, 2, m.adds["test_disk"])
+
+	v, ok := c.Get(key(1))
+	require.True(t, ok)
+	require.Equal(t, []string{"one"}, v)
+	require.Equal(t, 1, m.misses["test"])
+	require.Equal(t, 1, m.hits["test_disk"])
+
+	// a new cache, e.g. after a restart, finds the values on disk
+	c = NewTieredCache[[32]byte, []string](m, "test", 10, store, codec)
+	v, ok = c.Get(key(2))
+	require.True(t, ok)
+	require.Equal(t, []string{"two"}, v)
+	_, ok = c.Get(key(3))
+	require.False(t, ok)
+
+	// without a store the cache is only in memory
+	_, isLRU := NewTieredCache[[32]byte, []string](m, "test", 10, nil, codec).(*LRUCache[[32]byte, []string])
+	require.True(t, isLRU)
+}
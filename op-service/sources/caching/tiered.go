@@ -0,0 +1,114 @@
+package caching
+
+import "encoding/json"
+
+// Cache is implemented by the in-memory and tiered caches.
+type Cache[K comparable, V any] interface {
+	Get(key K) (value V, ok bool)
+	Add(key K, value V) (evicted bool)
+}
+
+var _ Cache[[32]byte, int] = (*LRUCache[[32]byte, int])(nil)
+
+// Codec encodes and decodes values for a persistent cache.
+type Codec[V any] struct {
+	Encode func(V) ([]byte, error)
+	Decode func([]byte) (V, error)
+}
+
+// JSONCodec encodes values as JSON.
+func JSONCodec[V any]() Codec[V] {
+	return Codec[V]{
+		Encode: func(v V) ([]byte, error) { return json.Marshal(v) },
+		Decode: func(b []byte) (v V, err error) {
+			err = json.Unmarshal(b, &v)
+			return v, err
+		},
+	}
+}
+
+// PersistentCache stores values in a DiskStore, and tracks cache metrics.
+// Values that fail to encode or decode are treated as cache misses.
+type PersistentCache[K ~[32]byte, V any] struct {
+	m     Metrics
+	label string
+	store *DiskStore
+	codec Codec[V]
+}
+
+var _ Cache[[32]byte, int] = (*PersistentCache[[32]byte, int])(nil)
+
+// NewPersistentCache creates a cache of values in the given store, labeling the values and the cache adds/gets.
+// Metrics are optional: no metrics will be tracked if m == nil.
+func NewPersistentCache[K ~[32]byte, V any](m Metrics, label string, store *DiskStore, codec Codec[V]) *PersistentCache[K, V] {
+	return &PersistentCache[K, V]{
+		m:     m,
+		label: label,
+		store: store,
+		codec: codec,
+	}
+}
+
+func (c *PersistentCache[K, V]) Get(key K) (value V, ok bool) {
+	if data, found := c.store.Get(c.label, key); found {
+		var err error
+		value, err = c.codec.Decode(data)
+		ok = err == nil
+	}
+	if c.m != nil {
+		c.m.CacheGet(c.label, ok)
+	}
+	return value, ok
+}
+
+func (c *PersistentCache[K, V]) Add(key K, value V) (evicted bool) {
+	data, err := c.codec.Encode(value)
+	if err != nil {
+		return false
+	}
+	evicted, err = c.store.Put(c.label, key, data)
+	if err != nil {
+		return false
+	}
+	if c.m != nil {
+		c.m.CacheAdd(c.label, c.store.Len(), evicted)
+	}
+	return evicted
+}
+
+// TieredCache is an in-memory LRU cache, backed by a persistent cache.
+// Values found in the persistent cache are added to the in-memory cache.
+type TieredCache[K ~[32]byte, V any] struct {
+	mem  *LRUCache[K, V]
+	disk *PersistentCache[K, V]
+}
+
+var _ Cache[[32]byte, int] = (*TieredCache[[32]byte, int])(nil)
+
+// NewTieredCache creates an in-memory LRU cache of the given size, backed by the given store if it is not nil.
+// The persistent values are tracked in metrics with a "_disk" suffix on the label.
+func NewTieredCache[K ~[32]byte, V any](m Metrics, label string, maxSize int, store *DiskStore, codec Codec[V]) Cache[K, V] {
+	mem := NewLRUCache[K, V](m, label, maxSize)
+	if store == nil {
+		return mem
+	}
+	return &TieredCache[K, V]{
+		mem:  mem,
+		disk: NewPersistentCache[K, V](m, label+"_disk", store, codec),
+	}
+}
+
+func (c *TieredCache[K, V]) Get(key K) (value V, ok bool) {
+	if value, ok = c.mem.Get(key); ok {
+		return value, true
+	}
+	if value, ok = c.disk.Get(key); ok {
+		c.mem.Add(key, value)
+	}
+	return value, ok
+}
+
+func (c *TieredCache[K, V]) Add(key K, value V) (evicted bool) {
+	c.disk.Add(key, value)
+	return c.mem.Add(key, value)
+}
@@ -3,6 +3,7 @@ package sources
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/zircuit-labs/l2-geth-public/common"
@@ -172,6 +173,31 @@ func (s *EngineAPIClient) GetPayload(ctx context.Context, payloadInfo eth.Payloa
 	return &result, nil
 }
 
+// SimulateDeposits dry-runs the deposit transactions of the payload attributes on top of the given parent block,
+// and returns the deposits the circuit capacity checker of the engine would reject.
+// No payload is built, and the forkchoice state of the engine is not changed.
+func (s *EngineAPIClient) SimulateDeposits(ctx context.Context, parent common.Hash, attrs *eth.PayloadAttributes) ([]common.Hash, error) {
+	e := s.log.New("parent", parent)
+	e.Trace("Simulating deposits")
+	var result eth.DepositSimulationResult
+	err := s.RPC.CallContext(ctx, &result, string(eth.SimulateDepositsV1), parent, attrs)
+	if err != nil {
+		e.Warn("Failed to simulate deposits", "err", err)
+		return nil, fmt.Errorf("failed to simulate deposits: %w", err)
+	}
+	e.Trace("Simulated deposits", "rejected", len(result.RejectedTransactions))
+	return result.RejectedTransactions, nil
+}
+
+// SupportsMethod returns true if the engine lists the method among its capabilities.
+func (s *EngineAPIClient) SupportsMethod(ctx context.Context, method eth.EngineAPIMethod) (bool, error) {
+	var caps []string
+	if err := s.RPC.CallContext(ctx, &caps, string(eth.ExchangeCapabilities), []string{string(method)}); err != nil {
+		return false, fmt.Errorf("failed to exchange capabilities: %w", err)
+	}
+	return slices.Contains(caps, string(method)), nil
+}
+
 func (s *EngineAPIClient) SignalSuperchainV1(ctx context.Context, recommended, required params.ProtocolVersion) (params.ProtocolVersion, error) {
 	var result params.ProtocolVersion
 	err := s.RPC.CallContext(ctx, &result, "engine_signalSuperchainV1", &catalyst.SuperchainSignal{
@@ -0,0 +1,32 @@
+package sources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testlog"
+)
+
+func TestEngineAPIClientSupportsMethod(t *testing.T) {
+	ctx := context.Background()
+	m := new(mockRPC)
+	cl := NewEngineAPIClient(m, testlog.Logger(t, log.LevelDebug), nil)
+
+	caps := []string{string(eth.FCUV3), string(eth.NewPayloadV3), string(eth.GetPayloadV3)}
+	m.On("CallContext", ctx, new([]string), string(eth.ExchangeCapabilities), mock.Anything).Run(func(args mock.Arguments) {
+		*args[1].(*[]string) = caps
+	}).Return([]error{nil})
+
+	supported, err := cl.SupportsMethod(ctx, eth.FCUV3)
+	require.NoError(t, err)
+	require.True(t, supported)
+	supported, err = cl.SupportsMethod(ctx, eth.SimulateDepositsV1)
+	require.NoError(t, err)
+	require.False(t, supported, "engine without deposit simulation")
+	m.AssertExpectations(t)
+}
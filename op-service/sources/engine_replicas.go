@@ -0,0 +1,372 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	ethereum "github.com/zircuit-labs/l2-geth-public"
+	"github.com/zircuit-labs/l2-geth-public/log"
+	"github.com/zircuit-labs/l2-geth-public/rpc"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/client"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+)
+
+const (
+	replicaHealthCheckTimeout = 5 * time.Second
+	// replicaMaxHeadLag is the number of blocks an endpoint may be behind the highest head of the endpoints,
+	// to be put back into rotation after it was unhealthy.
+	replicaMaxHeadLag = 2
+	// maxPinnedPayloads bounds the payloads that are remembered with the endpoint that builds them.
+	maxPinnedPayloads = 16
+)
+
+// ReplicaDivergenceError describes an engine API call that replicas did not agree on.
+type ReplicaDivergenceError struct {
+	Method string
+	// Primary and Replica are the endpoint indices that were compared.
+	Primary int
+	Replica int
+	Detail  string
+}
+
+func (e *ReplicaDivergenceError) Error() string {
+	return fmt.Sprintf("engine replica %d diverged from primary %d on %s: %s", e.Replica, e.Primary, e.Method, e.Detail)
+}
+
+type engineReplica struct {
+	index   int
+	rpc     client.RPC
+	healthy atomic.Bool
+}
+
+// ReplicatedEngineRPC keeps multiple execution engines in lockstep, behind a single RPC client.
+//
+// Calls that change the engine state (engine_newPayload and engine_forkchoiceUpdated) are sent to all healthy
+// endpoints. The result of the primary, the first healthy endpoint, is returned.
+// Block building is only started on the primary: replicas receive the forkchoice update without payload attributes.
+// engine_getPayload goes to the endpoint that started building the payload, even if another endpoint became primary
+// since, and other engine API calls only go to the primary.
+// All other calls are reads, which fail over to the next healthy endpoint on connection errors.
+//
+// An unhealthy endpoint is put back into rotation once it responds again, and its head is at most
+// replicaMaxHeadLag blocks behind the highest head of the endpoints.
+type ReplicatedEngineRPC struct {
+	log       log.Logger
+	endpoints []*engineReplica
+
+	payloadsLock sync.Mutex
+	// payloads maps the payloads being built to the endpoint that builds them
+	payloads map[eth.PayloadID]*engineReplica
+
+	onDivergence atomic.Pointer[func(err *ReplicaDivergenceError)]
+
+	closeOnce sync.Once
+	closing   chan struct{}
+	done      chan struct{}
+}
+
+var _ client.RPC = (*ReplicatedEngineRPC)(nil)
+
+// NewReplicatedEngineRPC creates a client for the given engine endpoints, the first endpoint is the preferred primary.
+// Endpoint health is checked at the given interval, endpoints are also marked unhealthy on connection errors.
+func NewReplicatedEngineRPC(logger log.Logger, endpoints []client.RPC, healthCheckInterval time.Duration) (*ReplicatedEngineRPC, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("no engine endpoints")
+	}
+	if healthCheckInterval <= 0 {
+		return nil, errors.New("health check interval must be positive")
+	}
+	r := &ReplicatedEngineRPC{
+		log:      logger,
+		payloads: make(map[eth.PayloadID]*engineReplica),
+		closing:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for i, cl := range endpoints {
+		replica := &engineReplica{index: i, rpc: cl}
+		replica.healthy.Store(true)
+		r.endpoints = append(r.endpoints, replica)
+	}
+	go r.healthCheckLoop(healthCheckInterval)
+	return r, nil
+}
+
+// OnDivergence sets the function that is called when a replica diverges from the primary.
+func (r *ReplicatedEngineRPC) OnDivergence(fn func(err *ReplicaDivergenceError)) {
+	r.onDivergence.Store(&fn)
+}
+
+// active returns the healthy endpoints, primary first.
+// If no endpoint is healthy, all endpoints are returned, to not stop trying.
+func (r *ReplicatedEngineRPC) active() []*engineReplica {
+	out := make([]*engineReplica, 0, len(r.endpoints))
+	for _, e := range r.endpoints {
+		if e.healthy.Load() {
+			out = append(out, e)
+		}
+	}
+	if len(out) == 0 {
+		return r.endpoints
+	}
+	return out
+}
+
+// isConnectionErr returns true if the error is not a response of the endpoint, and the endpoint should fail over.
+func isConnectionErr(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var rpcErr rpc.Error
+	return !errors.As(err, &rpcErr)
+}
+
+func (r *ReplicatedEngineRPC) markUnhealthy(e *engineReplica, err error) {
+	if e.healthy.CompareAndSwap(true, false) {
+		r.log.Warn("Engine endpoint unhealthy", "endpoint", e.index, "err", err)
+	}
+}
+
+func (r *ReplicatedEngineRPC) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	if isReplicatedEngineMethod(method) {
+		return r.fanOut(ctx, result, method, args)
+	}
+	if strings.HasPrefix(method, "engine_") {
+		// Other engine API calls are bound to the state of the primary,
+		// or of the endpoint that builds the payload.
+		endpoint := r.active()[0]
+		if strings.HasPrefix(method, "engine_getPayload") && len(args) > 0 {
+			if id, ok := args[0].(eth.PayloadID); ok {
+				if builder := r.takePayload(id); builder != nil {
+					endpoint = builder
+				}
+			}
+		}
+		err := endpoint.rpc.CallContext(ctx, result, method, args...)
+		if isConnectionErr(ctx, err) {
+			r.markUnhealthy(endpoint, err)
+		}
+		return err
+	}
+	var err error
+	for _, e := range r.active() {
+		err = e.rpc.CallContext(ctx, result, method, args...)
+		if !isConnectionErr(ctx, err) {
+			return err
+		}
+		r.markUnhealthy(e, err)
+	}
+	return err
+}
+
+func (r *ReplicatedEngineRPC) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	var err error
+	for _, e := range r.active() {
+		err = e.rpc.BatchCallContext(ctx, b)
+		if !isConnectionErr(ctx, err) {
+			return err
+		}
+		r.markUnhealthy(e, err)
+	}
+	return err
+}
+
+func (r *ReplicatedEngineRPC) EthSubscribe(ctx context.Context, channel any, args ...any) (ethereum.Subscription, error) {
+	return r.active()[0].rpc.EthSubscribe(ctx, channel, args...)
+}
+
+func isReplicatedEngineMethod(method string) bool {
+	return strings.HasPrefix(method, "engine_newPayload") || strings.HasPrefix(method, "engine_forkchoiceUpdated")
+}
+
+type replicaResult struct {
+	endpoint *engineReplica
+	result   reflect.Value
+	err      error
+}
+
+func (r *ReplicatedEngineRPC) fanOut(ctx context.Context, result any, method string, args []any) error {
+	resultType := reflect.TypeOf(result)
+	if resultType == nil || resultType.Kind() != reflect.Pointer {
+		return fmt.Errorf("unsupported result type %T for replicated call %s", result, method)
+	}
+	building := false
+	if strings.HasPrefix(method, "engine_forkchoiceUpdated") && len(args) > 1 {
+		attrs, ok := args[1].(*eth.PayloadAttributes)
+		building = !ok || attrs != nil
+	}
+	endpoints := r.active()
+	results := make([]replicaResult, len(endpoints))
+	var wg sync.WaitGroup
+	for i, e := range endpoints {
+		callArgs := args
+		if i > 0 && building {
+			// Only the primary builds blocks, replicas only follow the forkchoice.
+			callArgs = append([]any{args[0], (*eth.PayloadAttributes)(nil)}, args[2:]...)
+		}
+		wg.Add(1)
+		go func(i int, e *engineReplica, callArgs []any) {
+			defer wg.Done()
+			res := reflect.New(resultType.Elem())
+			err := e.rpc.CallContext(ctx, res.Interface(), method, callArgs...)
+			results[i] = replicaResult{endpoint: e, result: res, err: err}
+		}(i, e, callArgs)
+	}
+	wg.Wait()
+
+	var primary *replicaResult
+	for i := range results {
+		res := &results[i]
+		if isConnectionErr(ctx, res.err) {
+			r.markUnhealthy(res.endpoint, res.err)
+			continue
+		}
+		if primary == nil {
+			primary = res
+			continue
+		}
+		if detail, ok := replicaResultsMatch(primary, res); !ok {
+			r.reportDivergence(&ReplicaDivergenceError{
+				Method:  method,
+				Primary: primary.endpoint.index,
+				Replica: res.endpoint.index,
+				Detail:  detail,
+			})
+		}
+	}
+	if primary == nil || (building && primary != &results[0]) {
+		// Return the error of the preferred endpoint if it failed to respond,
+		// the replicas did not start building a block in its place.
+		return results[0].err
+	}
+	if primary.err != nil {
+		return primary.err
+	}
+	if building {
+		if res, ok := primary.result.Interface().(*eth.ForkchoiceUpdatedResult); ok && res.PayloadID != nil {
+			r.pinPayload(*res.PayloadID, primary.endpoint)
+		}
+	}
+	reflect.ValueOf(result).Elem().Set(primary.result.Elem())
+	return nil
+}
+
+// pinPayload remembers the endpoint that builds the payload, for engine_getPayload.
+func (r *ReplicatedEngineRPC) pinPayload(id eth.PayloadID, e *engineReplica) {
+	r.payloadsLock.Lock()
+	defer r.payloadsLock.Unlock()
+	if len(r.payloads) >= maxPinnedPayloads {
+		// payloads that were never fetched are long gone from their builder
+		clear(r.payloads)
+	}
+	r.payloads[id] = e
+}
+
+// takePayload returns the endpoint that builds the payload and forgets it, or nil if it is not known.
+func (r *ReplicatedEngineRPC) takePayload(id eth.PayloadID) *engineReplica {
+	r.payloadsLock.Lock()
+	defer r.payloadsLock.Unlock()
+	e := r.payloads[id]
+	delete(r.payloads, id)
+	return e
+}
+
+func (r *ReplicatedEngineRPC) reportDivergence(err *ReplicaDivergenceError) {
+	r.log.Error("Engine replica diverged", "err", err)
+	if fn := r.onDivergence.Load(); fn != nil {
+		(*fn)(err)
+	}
+}
+
+// replicaResultsMatch compares the results of two replicas, and returns a description of the difference if any.
+// A replica that is still syncing is not considered to diverge.
+func replicaResultsMatch(primary, replica *replicaResult) (string, bool) {
+	if (primary.err == nil) != (replica.err == nil) {
+		return fmt.Sprintf("primary error: %v, replica error: %v", primary.err, replica.err), false
+	}
+	if primary.err != nil {
+		return "", true
+	}
+	var a, b *eth.PayloadStatusV1
+	switch x := primary.result.Interface().(type) {
+	case *eth.PayloadStatusV1:
+		a, b = x, replica.result.Interface().(*eth.PayloadStatusV1)
+	case *eth.ForkchoiceUpdatedResult:
+		a, b = &x.PayloadStatus, &replica.result.Interface().(*eth.ForkchoiceUpdatedResult).PayloadStatus
+	default:
+		if !reflect.DeepEqual(primary.result.Interface(), replica.result.Interface()) {
+			return "different results", false
+		}
+		return "", true
+	}
+	if b.Status == eth.ExecutionSyncing || b.Status == eth.ExecutionAccepted {
+		return "", true
+	}
+	if a.Status != b.Status {
+		return fmt.Sprintf("primary status %s, replica status %s", a.Status, b.Status), false
+	}
+	if a.LatestValidHash != nil && b.LatestValidHash != nil && *a.LatestValidHash != *b.LatestValidHash {
+		return fmt.Sprintf("primary latest valid hash %s, replica latest valid hash %s", *a.LatestValidHash, *b.LatestValidHash), false
+	}
+	return "", true
+}
+
+func (r *ReplicatedEngineRPC) healthCheckLoop(interval time.Duration) {
+	defer close(r.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.checkHealth()
+		case <-r.closing:
+			return
+		}
+	}
+}
+
+// checkHealth fetches the head of every endpoint. Endpoints that do not respond are marked unhealthy,
+// unhealthy endpoints that respond are marked healthy again once they caught up with the highest head.
+func (r *ReplicatedEngineRPC) checkHealth() {
+	heads := make([]uint64, len(r.endpoints))
+	errs := make([]error, len(r.endpoints))
+	var highest uint64
+	for i, e := range r.endpoints {
+		ctx, cancel := context.WithTimeout(context.Background(), replicaHealthCheckTimeout)
+		var head eth.Uint64Quantity
+		errs[i] = e.rpc.CallContext(ctx, &head, "eth_blockNumber")
+		cancel()
+		heads[i] = uint64(head)
+		if errs[i] == nil {
+			highest = max(highest, heads[i])
+		}
+	}
+	for i, e := range r.endpoints {
+		if errs[i] != nil {
+			r.markUnhealthy(e, errs[i])
+		} else if e.healthy.Load() {
+			continue
+		} else if heads[i]+replicaMaxHeadLag < highest {
+			r.log.Info("Engine endpoint responds again, but is behind", "endpoint", e.index, "head", heads[i], "highest", highest)
+		} else if e.healthy.CompareAndSwap(false, true) {
+			r.log.Info("Engine endpoint healthy again", "endpoint", e.index, "head", heads[i])
+		}
+	}
+}
+
+// Close stops the health checks and closes all endpoints.
+func (r *ReplicatedEngineRPC) Close() {
+	r.closeOnce.Do(func() {
+		close(r.closing)
+		<-r.done
+		for _, e := range r.endpoints {
+			e.rpc.Close()
+		}
+	})
+}
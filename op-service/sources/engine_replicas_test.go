@@ -0,0 +1,259 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	ethereum "github.com/zircuit-labs/l2-geth-public"
+	"github.com/zircuit-labs/l2-geth-public/common"
+	"github.com/zircuit-labs/l2-geth-public/log"
+	"github.com/zircuit-labs/l2-geth-public/rpc"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/client"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testlog"
+)
+
+// testRPCError is an error response of an endpoint.
+type testRPCError struct {
+	code int
+	msg  string
+}
+
+func (e *testRPCError) Error() string  { return e.msg }
+func (e *testRPCError) ErrorCode() int { return e.code }
+
+type testEngineCall struct {
+	method string
+	args   []any
+}
+
+// testEngineEndpoint responds to engine API calls with a fixed payload status and head, or fails all calls with err.
+type testEngineEndpoint struct {
+	mu     sync.Mutex
+	calls  []testEngineCall
+	status eth.PayloadStatusV1
+	head   uint64
+	err    error
+}
+
+func (e *testEngineEndpoint) Close() {}
+
+func (e *testEngineEndpoint) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls = append(e.calls, testEngineCall{method: method, args: args})
+	if e.err != nil {
+		return e.err
+	}
+	switch res := result.(type) {
+	case *eth.PayloadStatusV1:
+		*res = e.status
+	case *eth.ForkchoiceUpdatedResult:
+		res.PayloadStatus = e.status
+		if len(args) > 1 && args[1].(*eth.PayloadAttributes) != nil {
+			res.PayloadID = &eth.PayloadID{1}
+		}
+	case *eth.Uint64Quantity:
+		if method == "eth_blockNumber" {
+			*res = eth.Uint64Quantity(e.head)
+		} else {
+			*res = 901
+		}
+	}
+	return nil
+}
+
+func (e *testEngineEndpoint) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	return e.CallContext(ctx, nil, "batch")
+}
+
+func (e *testEngineEndpoint) EthSubscribe(ctx context.Context, channel any, args ...any) (ethereum.Subscription, error) {
+	return nil, errors.New("not supported")
+}
+
+func (e *testEngineEndpoint) Calls() []testEngineCall {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]testEngineCall(nil), e.calls...)
+}
+
+func validStatus(hash common.Hash) eth.PayloadStatusV1 {
+	return eth.PayloadStatusV1{Status: eth.ExecutionValid, LatestValidHash: &hash}
+}
+
+func newTestReplicatedEngine(t *testing.T, endpoints ...*testEngineEndpoint) (*ReplicatedEngineRPC, *[]*ReplicaDivergenceError) {
+	rpcs := make([]client.RPC, len(endpoints))
+	for i, e := range endpoints {
+		rpcs[i] = e
+	}
+	r, err := NewReplicatedEngineRPC(testlog.Logger(t, log.LvlInfo), rpcs, time.Hour)
+	require.NoError(t, err)
+	t.Cleanup(r.Close)
+	var divergences []*ReplicaDivergenceError
+	r.OnDivergence(func(err *ReplicaDivergenceError) {
+		divergences = append(divergences, err)
+	})
+	return r, &divergences
+}
+
+func TestReplicatedEngineRPC_FanOut(t *testing.T) {
+	hash := common.Hash{0xaa}
+	primary := &testEngineEndpoint{status: validStatus(hash)}
+	replica := &testEngineEndpoint{status: validStatus(hash)}
+	r, divergences := newTestReplicatedEngine(t, primary, replica)
+
+	var status eth.PayloadStatusV1
+	require.NoError(t, r.CallContext(context.Background(), &status, "engine_newPayloadV3", &eth.ExecutionPayload{}))
+	require.Equal(t, eth.ExecutionValid, status.Status)
+	require.Len(t, primary.Calls(), 1)
+	require.Len(t, replica.Calls(), 1)
+
+	fc := &eth.ForkchoiceState{HeadBlockHash: hash}
+	var result eth.ForkchoiceUpdatedResult
+	require.NoError(t, r.CallContext(context.Background(), &result, "engine_forkchoiceUpdatedV3", fc, &eth.PayloadAttributes{}))
+	require.NotNil(t, result.PayloadID, "payload is built by the primary")
+	require.NotNil(t, primary.Calls()[1].args[1].(*eth.PayloadAttributes))
+	require.Nil(t, replica.Calls()[1].args[1].(*eth.PayloadAttributes), "replicas do not build blocks")
+
+	// Other engine API calls only go to the primary
+	var envelope eth.ExecutionPayloadEnvelope
+	require.NoError(t, r.CallContext(context.Background(), &envelope, "engine_getPayloadV3", eth.PayloadID{1}))
+	require.Len(t, primary.Calls(), 3)
+	require.Len(t, replica.Calls(), 2)
+	require.Empty(t, *divergences)
+}
+
+func TestReplicatedEngineRPC_Divergence(t *testing.T) {
+	primary := &testEngineEndpoint{status: validStatus(common.Hash{0xaa})}
+	replica := &testEngineEndpoint{status: eth.PayloadStatusV1{Status: eth.ExecutionSyncing}}
+	r, divergences := newTestReplicatedEngine(t, primary, replica)
+
+	var status eth.PayloadStatusV1
+	require.NoError(t, r.CallContext(context.Background(), &status, "engine_newPayloadV3", &eth.ExecutionPayload{}))
+	require.Empty(t, *divergences, "syncing replica does not diverge")
+
+	replica.status = eth.PayloadStatusV1{Status: eth.ExecutionInvalid}
+	require.NoError(t, r.CallContext(context.Background(), &status, "engine_newPayloadV3", &eth.ExecutionPayload{}))
+	require.Equal(t, eth.ExecutionValid, status.Status, "result of the primary is returned")
+	require.Len(t, *divergences, 1)
+	require.Equal(t, "engine_newPayloadV3", (*divergences)[0].Method)
+	require.Equal(t, 0, (*divergences)[0].Primary)
+	require.Equal(t, 1, (*divergences)[0].Replica)
+
+	replica.status = validStatus(common.Hash{0xbb})
+	require.NoError(t, r.CallContext(context.Background(), &status, "engine_newPayloadV3", &eth.ExecutionPayload{}))
+	require.Len(t, *divergences, 2)
+	require.Contains(t, (*divergences)[1].Detail, "latest valid hash")
+
+	replica.err = &testRPCError{code: -38003, msg: "invalid payload attributes"}
+	require.NoError(t, r.CallContext(context.Background(), &status, "engine_newPayloadV3", &eth.ExecutionPayload{}))
+	require.Len(t, *divergences, 3)
+}
+
+func TestReplicatedEngineRPC_Failover(t *testing.T) {
+	hash := common.Hash{0xaa}
+	primary := &testEngineEndpoint{status: validStatus(hash), err: errors.New("connection refused")}
+	replica := &testEngineEndpoint{status: validStatus(hash)}
+	r, divergences := newTestReplicatedEngine(t, primary, replica)
+
+	var chainID eth.Uint64Quantity
+	require.NoError(t, r.CallContext(context.Background(), &chainID, "eth_chainId"))
+	require.Equal(t, eth.Uint64Quantity(901), chainID)
+	require.Len(t, primary.Calls(), 1)
+
+	// The primary is no longer used until it is healthy again
+	require.NoError(t, r.CallContext(context.Background(), &chainID, "eth_chainId"))
+	require.Len(t, primary.Calls(), 1)
+	require.Len(t, replica.Calls(), 2)
+
+	var status eth.PayloadStatusV1
+	require.NoError(t, r.CallContext(context.Background(), &status, "engine_newPayloadV3", &eth.ExecutionPayload{}))
+	require.Equal(t, eth.ExecutionValid, status.Status)
+
+	primary.mu.Lock()
+	primary.err = nil
+	primary.mu.Unlock()
+	r.checkHealth()
+	require.NoError(t, r.CallContext(context.Background(), &chainID, "eth_chainId"))
+	require.Len(t, primary.Calls(), 3, "health check and read go to the recovered primary")
+	require.Empty(t, *divergences)
+}
+
+func TestReplicatedEngineRPC_FailoverNotOnResponseError(t *testing.T) {
+	primary := &testEngineEndpoint{err: &testRPCError{code: -32000, msg: "header not found"}}
+	replica := &testEngineEndpoint{}
+	r, _ := newTestReplicatedEngine(t, primary, replica)
+
+	var chainID eth.Uint64Quantity
+	err := r.CallContext(context.Background(), &chainID, "eth_chainId")
+	require.ErrorContains(t, err, "header not found")
+	require.Empty(t, replica.Calls())
+}
+
+func TestReplicatedEngineRPC_BuildingRequiresPrimary(t *testing.T) {
+	primary := &testEngineEndpoint{err: errors.New("connection refused")}
+	replica := &testEngineEndpoint{status: validStatus(common.Hash{0xaa})}
+	r, _ := newTestReplicatedEngine(t, primary, replica)
+
+	var result eth.ForkchoiceUpdatedResult
+	err := r.CallContext(context.Background(), &result, "engine_forkchoiceUpdatedV3", &eth.ForkchoiceState{}, &eth.PayloadAttributes{})
+	require.ErrorContains(t, err, "connection refused")
+}
+
+func TestReplicatedEngineRPC_RecoveredPrimaryBehind(t *testing.T) {
+	primary := &testEngineEndpoint{err: errors.New("connection refused")}
+	replica := &testEngineEndpoint{head: 100}
+	r, _ := newTestReplicatedEngine(t, primary, replica)
+
+	var chainID eth.Uint64Quantity
+	require.NoError(t, r.CallContext(context.Background(), &chainID, "eth_chainId"))
+
+	primary.mu.Lock()
+	primary.err = nil
+	primary.head = 90
+	primary.mu.Unlock()
+	r.checkHealth()
+	require.Equal(t, replica, r.active()[0].rpc, "primary that is behind stays out of rotation")
+
+	primary.mu.Lock()
+	primary.head = 100 - replicaMaxHeadLag
+	primary.mu.Unlock()
+	r.checkHealth()
+	require.Equal(t, primary, r.active()[0].rpc, "primary that caught up is back in rotation")
+}
+
+func TestReplicatedEngineRPC_GetPayloadFromBuilder(t *testing.T) {
+	hash := common.Hash{0xaa}
+	primary := &testEngineEndpoint{status: validStatus(hash), err: errors.New("connection refused")}
+	replica := &testEngineEndpoint{status: validStatus(hash)}
+	r, _ := newTestReplicatedEngine(t, primary, replica)
+
+	var chainID eth.Uint64Quantity
+	require.NoError(t, r.CallContext(context.Background(), &chainID, "eth_chainId"))
+
+	// The replica builds the payload while the primary is unhealthy
+	var result eth.ForkchoiceUpdatedResult
+	require.NoError(t, r.CallContext(context.Background(), &result, "engine_forkchoiceUpdatedV3", &eth.ForkchoiceState{HeadBlockHash: hash}, &eth.PayloadAttributes{}))
+	require.NotNil(t, result.PayloadID)
+
+	primary.mu.Lock()
+	primary.err = nil
+	primary.mu.Unlock()
+	r.checkHealth()
+	require.Equal(t, primary, r.active()[0].rpc)
+
+	var envelope eth.ExecutionPayloadEnvelope
+	require.NoError(t, r.CallContext(context.Background(), &envelope, "engine_getPayloadV3", *result.PayloadID))
+	calls := replica.Calls()
+	require.Equal(t, "engine_getPayloadV3", calls[len(calls)-1].method, "payload is fetched from its builder")
+
+	// The payload is only pinned until it is fetched
+	require.NoError(t, r.CallContext(context.Background(), &envelope, "engine_getPayloadV3", *result.PayloadID))
+	calls = primary.Calls()
+	require.Equal(t, "engine_getPayloadV3", calls[len(calls)-1].method)
+}
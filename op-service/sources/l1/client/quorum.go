@@ -0,0 +1,234 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/zircuit-labs/l2-geth-public/log"
+)
+
+// ErrNoQuorum is returned when not enough L1 providers agree on a block.
+var ErrNoQuorum = errors.New("no quorum among L1 providers")
+
+// ProviderDisagreementError describes L1 providers returning different blocks for the same block number.
+type ProviderDisagreementError struct {
+	Method string
+	Number uint64
+	// Hashes maps each returned block hash to the indices of the providers that returned it.
+	Hashes map[common.Hash][]int
+}
+
+func (e *ProviderDisagreementError) Error() string {
+	hashes := make([]string, 0, len(e.Hashes))
+	for h, providers := range e.Hashes {
+		hashes = append(hashes, fmt.Sprintf("%s by %v", h, providers))
+	}
+	sort.Strings(hashes)
+	return fmt.Sprintf("L1 providers disagree on block %d (%s): %s", e.Number, e.Method, strings.Join(hashes, ", "))
+}
+
+// QuorumRPC cross-checks block lookups by number or label across multiple L1 providers.
+//
+// eth_getBlockByNumber calls are sent to all providers, and the result is only returned if at least threshold
+// providers return the same block hash. Lookups by label that do not reach a quorum, because providers lag behind
+// each other, are retried by number, at the lowest block number returned by any provider.
+// All other calls are sent to one provider at a time, and fail over to the next provider on errors.
+type QuorumRPC struct {
+	log       log.Logger
+	endpoints []RPC
+	threshold int
+
+	onDisagreement atomic.Pointer[func(err *ProviderDisagreementError)]
+}
+
+var _ RPC = (*QuorumRPC)(nil)
+
+// NewQuorumRPC creates a client that requires threshold of the given providers to agree on blocks.
+func NewQuorumRPC(lgr log.Logger, endpoints []RPC, threshold int) (*QuorumRPC, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("no L1 providers")
+	}
+	if threshold < 1 || threshold > len(endpoints) {
+		return nil, fmt.Errorf("quorum threshold %d must be between 1 and the number of providers %d", threshold, len(endpoints))
+	}
+	return &QuorumRPC{
+		log:       lgr,
+		endpoints: endpoints,
+		threshold: threshold,
+	}, nil
+}
+
+// OnDisagreement sets the function that is called when providers return different blocks for the same number.
+func (q *QuorumRPC) OnDisagreement(fn func(err *ProviderDisagreementError)) {
+	q.onDisagreement.Store(&fn)
+}
+
+func (q *QuorumRPC) Close() {
+	for _, e := range q.endpoints {
+		e.Close()
+	}
+}
+
+func (q *QuorumRPC) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	if method == "eth_getBlockByNumber" && len(args) > 0 {
+		if id, ok := args[0].(string); ok {
+			return q.quorumBlockCall(ctx, result, method, id, args[1:])
+		}
+	}
+	return q.failover(ctx, func(e RPC) error {
+		return e.CallContext(ctx, result, method, args...)
+	})
+}
+
+func (q *QuorumRPC) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	return q.failover(ctx, func(e RPC) error {
+		return e.BatchCallContext(ctx, b)
+	})
+}
+
+func (q *QuorumRPC) EthSubscribe(ctx context.Context, channel any, args ...any) (ethereum.Subscription, error) {
+	var sub ethereum.Subscription
+	err := q.failover(ctx, func(e RPC) (err error) {
+		sub, err = e.EthSubscribe(ctx, channel, args...)
+		return err
+	})
+	return sub, err
+}
+
+func (q *QuorumRPC) failover(ctx context.Context, fn func(e RPC) error) error {
+	var err error
+	for i, e := range q.endpoints {
+		err = fn(e)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		if i+1 < len(q.endpoints) {
+			q.log.Warn("L1 provider failed, trying next provider", "provider", i, "err", err)
+		}
+	}
+	return err
+}
+
+// quorumBlock is the part of a block response that providers need to agree on.
+type quorumBlock struct {
+	Hash   common.Hash    `json:"hash"`
+	Number hexutil.Uint64 `json:"number"`
+}
+
+type quorumResponse struct {
+	raw   json.RawMessage
+	block *quorumBlock
+	err   error
+}
+
+func (q *QuorumRPC) quorumBlockCall(ctx context.Context, result any, method string, id string, rest []any) error {
+	responses := q.fanOut(ctx, method, append([]any{id}, rest...))
+	raw, ok := q.tally(method, responses)
+	if !ok && !strings.HasPrefix(id, "0x") {
+		// Providers may lag behind each other on the block for a label. Use the lowest block that any of them returned.
+		var lowest *quorumBlock
+		for _, res := range responses {
+			if res.block != nil && (lowest == nil || res.block.Number < lowest.Number) {
+				lowest = res.block
+			}
+		}
+		if lowest != nil {
+			q.log.Debug("No quorum on L1 block label, checking lowest block", "label", id, "number", uint64(lowest.Number))
+			responses = q.fanOut(ctx, method, append([]any{hexutil.EncodeUint64(uint64(lowest.Number))}, rest...))
+			raw, ok = q.tally(method, responses)
+		}
+	}
+	if !ok {
+		err := fmt.Errorf("%w: block %s requires %d of %d providers to agree", ErrNoQuorum, id, q.threshold, len(q.endpoints))
+		for i, res := range responses {
+			if res.err != nil {
+				err = errors.Join(err, fmt.Errorf("provider %d: %w", i, res.err))
+			}
+		}
+		return err
+	}
+	return json.Unmarshal(raw, result)
+}
+
+func (q *QuorumRPC) fanOut(ctx context.Context, method string, args []any) []quorumResponse {
+	responses := make([]quorumResponse, len(q.endpoints))
+	var wg sync.WaitGroup
+	for i, e := range q.endpoints {
+		wg.Add(1)
+		go func(i int, e RPC) {
+			defer wg.Done()
+			var raw json.RawMessage
+			if err := e.CallContext(ctx, &raw, method, args...); err != nil {
+				responses[i] = quorumResponse{err: err}
+				return
+			}
+			res := quorumResponse{raw: raw}
+			if len(raw) > 0 && string(raw) != "null" {
+				var block quorumBlock
+				if err := json.Unmarshal(raw, &block); err != nil {
+					responses[i] = quorumResponse{err: fmt.Errorf("failed to decode block: %w", err)}
+					return
+				}
+				res.block = &block
+			}
+			responses[i] = res
+		}(i, e)
+	}
+	wg.Wait()
+	return responses
+}
+
+// tally returns the response that at least threshold providers agree on.
+// Providers that do not know the block are counted as agreeing on a nil block, but are not considered to disagree.
+// Different blocks for the same number are reported as a disagreement, even if there is a quorum.
+func (q *QuorumRPC) tally(method string, responses []quorumResponse) (json.RawMessage, bool) {
+	votes := make(map[common.Hash][]int)
+	numbers := make(map[uint64]map[common.Hash][]int)
+	var notFound []int
+	for i, res := range responses {
+		if res.err != nil {
+			continue
+		}
+		if res.block == nil {
+			notFound = append(notFound, i)
+			continue
+		}
+		votes[res.block.Hash] = append(votes[res.block.Hash], i)
+		num := uint64(res.block.Number)
+		if numbers[num] == nil {
+			numbers[num] = make(map[common.Hash][]int)
+		}
+		numbers[num][res.block.Hash] = append(numbers[num][res.block.Hash], i)
+	}
+	for num, hashes := range numbers {
+		if len(hashes) > 1 {
+			q.reportDisagreement(&ProviderDisagreementError{Method: method, Number: num, Hashes: hashes})
+		}
+	}
+	for _, providers := range votes {
+		if len(providers) >= q.threshold {
+			return responses[providers[0]].raw, true
+		}
+	}
+	if len(notFound) >= q.threshold {
+		return responses[notFound[0]].raw, true
+	}
+	return nil, false
+}
+
+func (q *QuorumRPC) reportDisagreement(err *ProviderDisagreementError) {
+	q.log.Error("L1 providers disagree", "err", err)
+	if fn := q.onDisagreement.Load(); fn != nil {
+		(*fn)(err)
+	}
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testlog"
+)
+
+// testProvider serves blocks 0 up to and including head, block hashes are derived from the number and fork.
+type testProvider struct {
+	head  uint64
+	fork  byte
+	err   error
+	calls int
+}
+
+func (p *testProvider) hash(num uint64) common.Hash {
+	return common.Hash{p.fork, byte(num)}
+}
+
+func (p *testProvider) Close() {}
+
+func (p *testProvider) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	p.calls++
+	if p.err != nil {
+		return p.err
+	}
+	var num uint64
+	switch id := args[0].(type) {
+	case string:
+		if id == "latest" {
+			num = p.head
+		} else {
+			n, err := hexutil.DecodeUint64(id)
+			if err != nil {
+				return err
+			}
+			num = n
+		}
+	default:
+		return errors.New("unsupported block id")
+	}
+	raw := json.RawMessage("null")
+	if num <= p.head {
+		data, err := json.Marshal(quorumBlock{Hash: p.hash(num), Number: hexutil.Uint64(num)})
+		if err != nil {
+			return err
+		}
+		raw = data
+	}
+	return json.Unmarshal(raw, result)
+}
+
+func (p *testProvider) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	p.calls++
+	return p.err
+}
+
+func (p *testProvider) EthSubscribe(ctx context.Context, channel any, args ...any) (ethereum.Subscription, error) {
+	return nil, errors.New("not supported")
+}
+
+func newTestQuorumRPC(t *testing.T, threshold int, providers ...*testProvider) (*QuorumRPC, *[]*ProviderDisagreementError) {
+	endpoints := make([]RPC, len(providers))
+	for i, p := range providers {
+		endpoints[i] = p
+	}
+	q, err := NewQuorumRPC(testlog.Logger(t, log.LvlInfo), endpoints, threshold)
+	require.NoError(t, err)
+	var disagreements []*ProviderDisagreementError
+	q.OnDisagreement(func(err *ProviderDisagreementError) {
+		disagreements = append(disagreements, err)
+	})
+	return q, &disagreements
+}
+
+func TestQuorumRPC_ByNumber(t *testing.T) {
+	a := &testProvider{head: 10}
+	b := &testProvider{head: 10}
+	c := &testProvider{head: 10, fork: 1}
+	q, disagreements := newTestQuorumRPC(t, 2, a, b, c)
+
+	var block *quorumBlock
+	require.NoError(t, q.CallContext(context.Background(), &block, "eth_getBlockByNumber", hexutil.EncodeUint64(5), false))
+	require.Equal(t, a.hash(5), block.Hash)
+	require.Len(t, *disagreements, 1, "minority provider is reported")
+	require.Equal(t, uint64(5), (*disagreements)[0].Number)
+	require.Equal(t, []int{2}, (*disagreements)[0].Hashes[c.hash(5)])
+
+	// No quorum if the majority provider fails
+	b.err = errors.New("connection refused")
+	err := q.CallContext(context.Background(), &block, "eth_getBlockByNumber", hexutil.EncodeUint64(5), false)
+	require.ErrorIs(t, err, ErrNoQuorum)
+	require.ErrorContains(t, err, "connection refused")
+	require.Len(t, *disagreements, 2)
+}
+
+func TestQuorumRPC_NotFound(t *testing.T) {
+	q, disagreements := newTestQuorumRPC(t, 2, &testProvider{head: 10}, &testProvider{head: 5}, &testProvider{head: 5})
+
+	block := &quorumBlock{}
+	require.NoError(t, q.CallContext(context.Background(), &block, "eth_getBlockByNumber", hexutil.EncodeUint64(8), false))
+	require.Nil(t, block, "lagging providers agree the block does not exist yet")
+	require.Empty(t, *disagreements)
+}
+
+func TestQuorumRPC_LaggingLabel(t *testing.T) {
+	q, disagreements := newTestQuorumRPC(t, 2, &testProvider{head: 12}, &testProvider{head: 11}, &testProvider{head: 10})
+
+	var block *quorumBlock
+	require.NoError(t, q.CallContext(context.Background(), &block, "eth_getBlockByNumber", "latest", false))
+	require.Equal(t, hexutil.Uint64(10), block.Number, "lowest head is agreed on")
+	require.Empty(t, *disagreements, "lagging providers do not disagree")
+}
+
+func TestQuorumRPC_Failover(t *testing.T) {
+	a := &testProvider{err: errors.New("connection refused")}
+	b := &testProvider{}
+	q, _ := newTestQuorumRPC(t, 1, a, b)
+
+	require.NoError(t, q.BatchCallContext(context.Background(), nil))
+	require.Equal(t, 1, a.calls)
+	require.Equal(t, 1, b.calls)
+
+	b.err = errors.New("also down")
+	require.ErrorContains(t, q.BatchCallContext(context.Background(), nil), "also down")
+}
+
+func TestNewQuorumRPC(t *testing.T) {
+	logger := testlog.Logger(t, log.LvlInfo)
+	_, err := NewQuorumRPC(logger, nil, 1)
+	require.Error(t, err)
+	_, err = NewQuorumRPC(logger, []RPC{&testProvider{}}, 2)
+	require.Error(t, err)
+	_, err = NewQuorumRPC(logger, []RPC{&testProvider{}}, 0)
+	require.Error(t, err)
+}
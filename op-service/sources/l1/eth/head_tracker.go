@@ -0,0 +1,173 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/zircuit-labs/l2-geth-public/log"
+	l2eth "github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+)
+
+// maxHeadBackfill is the maximum number of skipped L1 blocks that are fetched to fill a gap between two heads.
+const maxHeadBackfill = 256
+
+// headResubscribeInterval is the time between subscription attempts, if polling is disabled.
+const headResubscribeInterval = 10 * time.Second
+
+var errHeadSubscriptionClosed = errors.New("head subscription closed")
+
+// HeadTrackerSource provides new L1 heads, and the L1 blocks to fill gaps between heads with.
+type HeadTrackerSource interface {
+	NewHeadSource
+	L1BlockRefsSource
+	L1BlockRefByNumber(ctx context.Context, num uint64) (l2eth.L1BlockRef, error)
+}
+
+// TrackHeads signals every new L1 head to fn.
+// Heads are pushed by a new-head subscription when available, and are not polled while subscribed.
+// When the subscription fails or drops, the head is polled at the given interval instead,
+// and the subscription is retried after every poll.
+// Heights skipped between two heads, e.g. while switching between the two, are fetched and signaled in order,
+// such that consecutive signals are contiguous, unless the gap is larger than maxHeadBackfill blocks.
+// Polling is disabled if the interval is 0 or negative, heads are then only signaled while subscribed.
+// Requests to the source are bounded by the given timeout. The callback fn may block to back-pressure tracking.
+func TrackHeads(log log.Logger, src HeadTrackerSource, fn HeadSignalFn, pollInterval time.Duration, timeout time.Duration) ethereum.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		eventsCtx, eventsCancel := context.WithCancel(context.Background())
+		defer eventsCancel()
+		// We can handle a quit signal while fn is running, by closing the ctx.
+		go func() {
+			select {
+			case <-quit:
+				eventsCancel()
+			case <-eventsCtx.Done(): // don't wait for quit signal if we closed for other reasons.
+				return
+			}
+		}()
+
+		t := &headTracker{log: log, src: src, fn: fn, timeout: timeout, polling: pollInterval > 0}
+		if !t.polling {
+			log.Warn("polling of L1 heads is disabled", "interval", pollInterval)
+			pollInterval = headResubscribeInterval
+		}
+		t.run(eventsCtx, pollInterval)
+		return nil
+	})
+}
+
+type headTracker struct {
+	log     log.Logger
+	src     HeadTrackerSource
+	fn      HeadSignalFn
+	timeout time.Duration
+	polling bool
+
+	last *l2eth.L1BlockRef
+}
+
+func (t *headTracker) run(ctx context.Context, pollInterval time.Duration) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		headers := make(chan *types.Header, 10)
+		subCtx, subCancel := context.WithTimeout(ctx, t.timeout)
+		sub, err := t.src.SubscribeNewHead(subCtx, headers)
+		subCancel()
+		if err == nil {
+			t.log.Info("Subscribed to L1 heads")
+			ticker.Stop()
+			err = t.follow(ctx, sub, headers)
+			sub.Unsubscribe()
+			if ctx.Err() != nil {
+				return
+			}
+			t.log.Warn("L1 head subscription dropped, polling for L1 heads", "err", err)
+			// Catch up right away, instead of waiting for the next poll.
+			t.poll(ctx)
+			ticker.Reset(pollInterval)
+		} else if ctx.Err() != nil {
+			return
+		} else {
+			t.log.Warn("Failed to subscribe to L1 heads, polling for L1 heads", "err", err)
+		}
+
+		select {
+		case <-ticker.C:
+			t.poll(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// follow signals the heads of the subscription, until the subscription fails or the ctx is done.
+func (t *headTracker) follow(ctx context.Context, sub ethereum.Subscription, headers <-chan *types.Header) error {
+	for {
+		select {
+		case header := <-headers:
+			t.onHead(ctx, headerToL1BlockRef(header))
+		case err := <-sub.Err():
+			if err == nil {
+				err = errHeadSubscriptionClosed
+			}
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (t *headTracker) poll(ctx context.Context) {
+	if !t.polling {
+		return
+	}
+	reqCtx, reqCancel := context.WithTimeout(ctx, t.timeout)
+	ref, err := t.src.L1BlockRefByLabel(reqCtx, l2eth.Unsafe)
+	reqCancel()
+	if err != nil {
+		t.log.Warn("Failed to poll L1 head", "err", err)
+		return
+	}
+	t.onHead(ctx, ref)
+}
+
+func (t *headTracker) onHead(ctx context.Context, ref l2eth.L1BlockRef) {
+	if t.last != nil {
+		if t.last.Hash == ref.Hash {
+			return
+		}
+		if ref.Number > t.last.Number+1 && !t.backfill(ctx, *t.last, ref) {
+			return
+		}
+	}
+	t.fn(ctx, ref)
+	t.last = &ref
+}
+
+// backfill signals the blocks between the last signaled head and the new head.
+// It returns false if the gap could not be filled, in which case the new head is not signaled yet,
+// and filling the gap is retried on the next head.
+func (t *headTracker) backfill(ctx context.Context, last l2eth.L1BlockRef, head l2eth.L1BlockRef) bool {
+	from := last.Number + 1
+	if head.Number-from > maxHeadBackfill {
+		t.log.Warn("Gap between L1 heads is too large to backfill completely", "last", last, "head", head)
+		from = head.Number - maxHeadBackfill
+	}
+	t.log.Info("Backfilling skipped L1 heads", "last", last, "head", head)
+	for num := from; num < head.Number; num++ {
+		reqCtx, reqCancel := context.WithTimeout(ctx, t.timeout)
+		ref, err := t.src.L1BlockRefByNumber(reqCtx, num)
+		reqCancel()
+		if err != nil {
+			t.log.Warn("Failed to backfill skipped L1 head", "number", num, "err", err)
+			return false
+		}
+		t.fn(ctx, ref)
+		t.last = &ref
+	}
+	return true
+}
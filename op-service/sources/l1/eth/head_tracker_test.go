@@ -0,0 +1,356 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+	"github.com/zircuit-labs/l2-geth-public/log"
+
+	l2eth "github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/testlog"
+)
+
+// fakeHeadSource is a chain of L1 headers, with a new-head subscription that can be dropped.
+type fakeHeadSource struct {
+	mu       sync.Mutex
+	headers  []*types.Header
+	subErr   chan error
+	subCh    chan<- *types.Header
+	canSub   bool
+	subCount int
+}
+
+func newFakeHeadSource(n int) *fakeHeadSource {
+	src := &fakeHeadSource{canSub: true}
+	for i := 0; i < n; i++ {
+		src.addHeader()
+	}
+	return src
+}
+
+func (f *fakeHeadSource) addHeader() *types.Header {
+	header := &types.Header{Number: big.NewInt(int64(len(f.headers))), Time: uint64(len(f.headers)) * 12}
+	if len(f.headers) > 0 {
+		header.ParentHash = f.headers[len(f.headers)-1].Hash()
+	}
+	f.headers = append(f.headers, header)
+	return header
+}
+
+// mine adds a block, and pushes it to the subscriber, if any.
+func (f *fakeHeadSource) mine() {
+	f.mu.Lock()
+	header := f.addHeader()
+	ch := f.subCh
+	f.mu.Unlock()
+	if ch != nil {
+		ch <- header
+	}
+}
+
+// drop fails the subscription, and prevents new subscriptions until allowed again.
+func (f *fakeHeadSource) drop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.canSub = false
+	f.subCh = nil
+	f.subErr <- errors.New("connection lost")
+}
+
+func (f *fakeHeadSource) allowSubscribe() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.canSub = true
+}
+
+func (f *fakeHeadSource) subscriptions() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.subCount
+}
+
+func (f *fakeHeadSource) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.canSub {
+		return nil, errors.New("subscriptions not available")
+	}
+	f.subCount++
+	f.subCh = ch
+	subErr := make(chan error, 1)
+	f.subErr = subErr
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		select {
+		case err := <-subErr:
+			return err
+		case <-quit:
+			return nil
+		}
+	}), nil
+}
+
+func (f *fakeHeadSource) L1BlockRefByLabel(ctx context.Context, label l2eth.BlockLabel) (l2eth.L1BlockRef, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return headerToL1BlockRef(f.headers[len(f.headers)-1]), nil
+}
+
+func (f *fakeHeadSource) L1BlockRefByNumber(ctx context.Context, num uint64) (l2eth.L1BlockRef, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if num >= uint64(len(f.headers)) {
+		return l2eth.L1BlockRef{}, ethereum.NotFound
+	}
+	return headerToL1BlockRef(f.headers[num]), nil
+}
+
+type headRecorder struct {
+	mu    sync.Mutex
+	heads []l2eth.L1BlockRef
+}
+
+func (r *headRecorder) onHead(ctx context.Context, sig l2eth.L1BlockRef) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.heads = append(r.heads, sig)
+}
+
+func (r *headRecorder) numbers() []uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []uint64
+	for _, h := range r.heads {
+		out = append(out, h.Number)
+	}
+	return out
+}
+
+func (r *headRecorder) waitFor(t *testing.T, num uint64) {
+	require.Eventually(t, func() bool {
+		nums := r.numbers()
+		return len(nums) > 0 && nums[len(nums)-1] == num
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestTrackHeads(t *testing.T) {
+	logger := testlog.Logger(t, log.LvlInfo)
+	src := newFakeHeadSource(1)
+	rec := &headRecorder{}
+	sub := TrackHeads(logger, src, rec.onHead, 50*time.Millisecond, time.Second)
+	defer sub.Unsubscribe()
+
+	require.Eventually(t, func() bool { return src.subscriptions() == 1 }, 5*time.Second, 10*time.Millisecond)
+	src.mine()
+	src.mine()
+	rec.waitFor(t, 2)
+
+	// Heads are polled while the subscription is down
+	src.drop()
+	src.mine()
+	rec.waitFor(t, 3)
+
+	// Blocks that are skipped by the polling are backfilled
+	src.mine()
+	src.mine()
+	src.mine()
+	rec.waitFor(t, 6)
+
+	// The subscription is used again once it is available
+	src.allowSubscribe()
+	require.Eventually(t, func() bool { return src.subscriptions() == 2 }, 5*time.Second, 10*time.Millisecond)
+	src.mine()
+	rec.waitFor(t, 7)
+
+	require.Equal(t, []uint64{1, 2, 3, 4, 5, 6, 7}, rec.numbers(), "heads must be contiguous")
+}
+
+func TestTrackHeads_LargeGap(t *testing.T) {
+	logger := testlog.Logger(t, log.LvlInfo)
+	src := newFakeHeadSource(1)
+	rec := &headRecorder{}
+	tracker := &headTracker{log: logger, src: src, fn: rec.onHead, timeout: time.Second, polling: true}
+
+	tracker.poll(context.Background())
+	for i := 0; i < maxHeadBackfill+10; i++ {
+		src.mine()
+	}
+	tracker.poll(context.Background())
+	nums := rec.numbers()
+	require.Len(t, nums, maxHeadBackfill+2)
+	require.Equal(t, uint64(0), nums[0])
+	require.Equal(t, uint64(maxHeadBackfill+10-maxHeadBackfill), nums[1], "backfill is limited")
+	require.Equal(t, uint64(maxHeadBackfill+10), nums[len(nums)-1])
+}
+
+// testChainService serves a chain of L1 headers with eth_getBlockByNumber, and new heads with eth_subscribe.
+type testChainService struct {
+	mu      sync.Mutex
+	headers []*types.Header
+	feed    event.Feed
+
+	subCount   atomic.Int32
+	blockCalls atomic.Int32
+}
+
+func newTestChainService(n int) *testChainService {
+	s := &testChainService{}
+	for i := 0; i < n; i++ {
+		s.mine()
+	}
+	return s
+}
+
+// mine adds a block, and pushes it to the subscribers.
+func (s *testChainService) mine() {
+	s.mu.Lock()
+	header := &types.Header{Number: big.NewInt(int64(len(s.headers))), Time: uint64(len(s.headers)) * 12, Difficulty: new(big.Int)}
+	if len(s.headers) > 0 {
+		header.ParentHash = s.headers[len(s.headers)-1].Hash()
+	}
+	s.headers = append(s.headers, header)
+	s.mu.Unlock()
+	s.feed.Send(header)
+}
+
+func (s *testChainService) GetBlockByNumber(ctx context.Context, number rpc.BlockNumber, full bool) (*types.Header, error) {
+	s.blockCalls.Add(1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if number == rpc.LatestBlockNumber {
+		return s.headers[len(s.headers)-1], nil
+	}
+	if number < 0 || int(number) >= len(s.headers) {
+		return nil, nil
+	}
+	return s.headers[number], nil
+}
+
+func (s *testChainService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	headers := make(chan *types.Header, 10)
+	feedSub := s.feed.Subscribe(headers)
+	s.subCount.Add(1)
+	go func() {
+		defer feedSub.Unsubscribe()
+		for {
+			select {
+			case header := <-headers:
+				_ = notifier.Notify(sub.ID, header)
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+// droppingListener tracks the accepted connections, so they can be dropped.
+type droppingListener struct {
+	net.Listener
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func (l *droppingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.mu.Lock()
+		l.conns = append(l.conns, conn)
+		l.mu.Unlock()
+	}
+	return conn, err
+}
+
+func (l *droppingListener) drop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, conn := range l.conns {
+		_ = conn.Close()
+	}
+	l.conns = nil
+}
+
+// ethClientHeadSource tracks heads with an RPC client.
+type ethClientHeadSource struct {
+	*ethclient.Client
+}
+
+func (s ethClientHeadSource) L1BlockRefByLabel(ctx context.Context, label l2eth.BlockLabel) (l2eth.L1BlockRef, error) {
+	header, err := s.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return l2eth.L1BlockRef{}, err
+	}
+	return headerToL1BlockRef(header), nil
+}
+
+func (s ethClientHeadSource) L1BlockRefByNumber(ctx context.Context, num uint64) (l2eth.L1BlockRef, error) {
+	header, err := s.HeaderByNumber(ctx, new(big.Int).SetUint64(num))
+	if err != nil {
+		return l2eth.L1BlockRef{}, err
+	}
+	return headerToL1BlockRef(header), nil
+}
+
+func TestTrackHeads_RPC(t *testing.T) {
+	logger := testlog.Logger(t, log.LvlInfo)
+	chain := newTestChainService(1)
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", chain))
+	t.Cleanup(server.Stop)
+
+	// the socket path must be short, so it is not placed in the test temp dir
+	dir, err := os.MkdirTemp("", "heads")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	endpoint := filepath.Join(dir, "l1.ipc")
+	l, err := net.Listen("unix", endpoint)
+	require.NoError(t, err)
+	listener := &droppingListener{Listener: l}
+	go func() { _ = server.ServeListener(listener) }()
+	t.Cleanup(func() { _ = l.Close() })
+
+	cl, err := rpc.DialIPC(context.Background(), endpoint)
+	require.NoError(t, err)
+	t.Cleanup(cl.Close)
+
+	rec := &headRecorder{}
+	sub := TrackHeads(logger, ethClientHeadSource{ethclient.NewClient(cl)}, rec.onHead, 50*time.Millisecond, time.Second)
+	defer sub.Unsubscribe()
+
+	require.Eventually(t, func() bool { return chain.subCount.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+	chain.mine()
+	chain.mine()
+	rec.waitFor(t, 2)
+	// heads are not polled while the subscription is healthy
+	time.Sleep(200 * time.Millisecond)
+	require.Zero(t, chain.blockCalls.Load())
+
+	// heads are polled when the connection drops, and the client reconnects to subscribe again
+	listener.drop()
+	chain.mine()
+	rec.waitFor(t, 3)
+	require.Eventually(t, func() bool { return chain.subCount.Load() == 2 }, 5*time.Second, 10*time.Millisecond)
+	chain.mine()
+	chain.mine()
+	rec.waitFor(t, 5)
+
+	require.Equal(t, []uint64{1, 2, 3, 4, 5}, rec.numbers(), "heads must be contiguous")
+}
@@ -44,12 +44,7 @@ func WatchHeadChanges(ctx context.Context, src NewHeadSource, fn HeadSignalFn) (
 		for {
 			select {
 			case header := <-headChanges:
-				fn(eventsCtx, l2eth.L1BlockRef{
-					Hash:       ConvertHashToL2(header.Hash()),
-					Number:     header.Number.Uint64(),
-					ParentHash: ConvertHashToL2(header.ParentHash),
-					Time:       header.Time,
-				})
+				fn(eventsCtx, headerToL1BlockRef(header))
 			case <-eventsCtx.Done():
 				return nil
 			case err := <-sub.Err(): // if the underlying subscription fails, stop
@@ -59,6 +54,15 @@ func WatchHeadChanges(ctx context.Context, src NewHeadSource, fn HeadSignalFn) (
 	}), nil
 }
 
+func headerToL1BlockRef(header *types.Header) l2eth.L1BlockRef {
+	return l2eth.L1BlockRef{
+		Hash:       ConvertHashToL2(header.Hash()),
+		Number:     header.Number.Uint64(),
+		ParentHash: ConvertHashToL2(header.ParentHash),
+		Time:       header.Time,
+	}
+}
+
 type L1BlockRefsSource interface {
 	L1BlockRefByLabel(ctx context.Context, label l2eth.BlockLabel) (l2eth.L1BlockRef, error)
 }
@@ -13,9 +13,11 @@ import (
 	"sync"
 
 	"github.com/ethereum/go-ethereum"
+	"github.com/zircuit-labs/l2-geth-public/common"
 	"github.com/zircuit-labs/l2-geth-public/crypto/kzg4844"
 
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/caching"
 	l1client "github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/l1/client"
 )
 
@@ -28,6 +30,12 @@ const (
 
 type L1BeaconClientConfig struct {
 	FetchAllSidecars bool
+
+	// [OPTIONAL] Store to persist verified blobs in, by versioned hash.
+	// The blobs are then fetched only once across restarts.
+	PersistentCache *caching.DiskStore
+	// [OPTIONAL] Metrics of the persisted blobs cache.
+	Metrics caching.Metrics
 }
 
 // L1BeaconClient is a high level golang client for the Beacon API.
@@ -36,6 +44,9 @@ type L1BeaconClient struct {
 	pool *ClientPool[BlobSideCarsFetcher]
 	cfg  L1BeaconClientConfig
 
+	// nil if blobs are not persisted
+	blobsCache *caching.PersistentCache[common.Hash, *eth.Blob]
+
 	initLock     sync.Mutex
 	timeToSlotFn TimeToSlotFn
 }
@@ -164,13 +175,33 @@ func (p *ClientPool[T]) MoveToNext() {
 // the `cl` and the fallbacks whenever a client runs into an error while fetching blobs.
 func NewL1BeaconClient(cl BeaconClient, cfg L1BeaconClientConfig, fallbacks ...BlobSideCarsFetcher) *L1BeaconClient {
 	cs := append([]BlobSideCarsFetcher{cl}, fallbacks...)
+	var blobsCache *caching.PersistentCache[common.Hash, *eth.Blob]
+	if cfg.PersistentCache != nil {
+		blobsCache = caching.NewPersistentCache[common.Hash, *eth.Blob](cfg.Metrics, "blobs", cfg.PersistentCache, blobCodec)
+	}
 	return &L1BeaconClient{
-		cl:   cl,
-		pool: NewClientPool(cs...),
-		cfg:  cfg,
+		cl:         cl,
+		pool:       NewClientPool(cs...),
+		cfg:        cfg,
+		blobsCache: blobsCache,
 	}
 }
 
+// blobCodec persists blobs as raw bytes.
+var blobCodec = caching.Codec[*eth.Blob]{
+	Encode: func(b *eth.Blob) ([]byte, error) {
+		return b[:], nil
+	},
+	Decode: func(data []byte) (*eth.Blob, error) {
+		var b eth.Blob
+		if len(data) != len(b) {
+			return nil, fmt.Errorf("invalid blob size %d", len(data))
+		}
+		copy(b[:], data)
+		return &b, nil
+	},
+}
+
 type TimeToSlotFn func(timestamp uint64) (uint64, error)
 
 // GetTimeToSlotFn returns a function that converts a timestamp to a slot number.
@@ -275,11 +306,40 @@ func (cl *L1BeaconClient) GetBlobSidecars(ctx context.Context, ref eth.L1BlockRe
 // blob's validity by checking its proof against the commitment, and confirming the commitment
 // hashes to the expected value. Returns error if any blob is found invalid.
 func (cl *L1BeaconClient) GetBlobs(ctx context.Context, ref eth.L1BlockRef, hashes []eth.IndexedBlobHash) ([]*eth.Blob, error) {
+	if blobs, ok := cl.cachedBlobs(hashes); ok {
+		return blobs, nil
+	}
 	blobSidecars, err := cl.GetBlobSidecars(ctx, ref, hashes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get blob sidecars for L1BlockRef %s: %w", ref, err)
 	}
-	return blobsFromSidecars(blobSidecars, hashes)
+	blobs, err := blobsFromSidecars(blobSidecars, hashes)
+	if err != nil {
+		return nil, err
+	}
+	if cl.blobsCache != nil {
+		// only verified blobs are persisted, the versioned hash commits to the blob contents
+		for i, ih := range hashes {
+			cl.blobsCache.Add(ih.Hash, blobs[i])
+		}
+	}
+	return blobs, nil
+}
+
+// cachedBlobs returns the persisted blobs for the hashes, if all of them are persisted.
+func (cl *L1BeaconClient) cachedBlobs(hashes []eth.IndexedBlobHash) ([]*eth.Blob, bool) {
+	if cl.blobsCache == nil || len(hashes) == 0 {
+		return nil, false
+	}
+	out := make([]*eth.Blob, len(hashes))
+	for i, ih := range hashes {
+		blob, ok := cl.blobsCache.Get(ih.Hash)
+		if !ok {
+			return nil, false
+		}
+		out[i] = blob
+	}
+	return out, true
 }
 
 func blobsFromSidecars(blobSidecars []*eth.BlobSidecar, hashes []eth.IndexedBlobHash) ([]*eth.Blob, error) {
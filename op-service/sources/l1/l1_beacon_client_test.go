@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/zircuit-labs/l2-geth-public/crypto/kzg4844"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/caching"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/mocks"
 )
 
@@ -130,6 +131,48 @@ func TestBeaconClientNoErrorPrimary(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestBeaconClientPersistentBlobs(t *testing.T) {
+	index0, sidecar0 := makeTestBlobSidecar(3)
+	index1, sidecar1 := makeTestBlobSidecar(4)
+	hashes := []eth.IndexedBlobHash{index0, index1}
+	apiSidecars := toAPISideCars([]*eth.BlobSidecar{sidecar0, sidecar1})
+	store, err := caching.OpenDiskStore(t.TempDir(), 1<<20)
+	require.NoError(t, err)
+	m := &testCacheMetrics{}
+	cfg := L1BeaconClientConfig{PersistentCache: store, Metrics: m}
+
+	ctx := context.Background()
+	p := mocks.NewBeaconClient(t)
+	p.EXPECT().BeaconGenesis(ctx).Return(eth.APIGenesisResponse{Data: eth.ReducedGenesisData{GenesisTime: 10}}, nil).Once()
+	p.EXPECT().ConfigSpec(ctx).Return(eth.APIConfigResponse{Data: eth.ReducedConfigData{SecondsPerSlot: 2}}, nil).Once()
+	p.EXPECT().BeaconBlobSideCars(ctx, false, uint64(1), hashes).Return(eth.APIGetBlobSidecarsResponse{Data: apiSidecars}, nil).Once()
+
+	blobs, err := NewL1BeaconClient(p, cfg).GetBlobs(ctx, eth.L1BlockRef{Time: 12}, hashes)
+	require.NoError(t, err)
+	require.Equal(t, []*eth.Blob{&sidecar0.Blob, &sidecar1.Blob}, blobs)
+
+	// a new client, like after a restart, finds the blobs in the store without any calls
+	blobs, err = NewL1BeaconClient(p, cfg).GetBlobs(ctx, eth.L1BlockRef{Time: 12}, hashes)
+	require.NoError(t, err)
+	require.Equal(t, []*eth.Blob{&sidecar0.Blob, &sidecar1.Blob}, blobs)
+	require.Equal(t, 2, m.hits["blobs"], "blob cache hits are tracked")
+}
+
+type testCacheMetrics struct {
+	hits map[string]int
+}
+
+func (m *testCacheMetrics) CacheAdd(label string, cacheSize int, evicted bool) {}
+
+func (m *testCacheMetrics) CacheGet(label string, hit bool) {
+	if m.hits == nil {
+		m.hits = make(map[string]int)
+	}
+	if hit {
+		m.hits[label]++
+	}
+}
+
 func TestBeaconClientFallback(t *testing.T) {
 	indices := []uint64{5, 7, 2}
 	index0, sidecar0 := makeTestBlobSidecar(indices[0])
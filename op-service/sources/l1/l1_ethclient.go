@@ -10,6 +10,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
 
 	"github.com/zircuit-labs/l2-geth-public/log"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/caching"
@@ -35,6 +36,10 @@ type L1EthClientConfig struct {
 	// Number of payloads to cache
 	PayloadsCacheSize int
 
+	// [OPTIONAL] Store to persist immutable data by block hash in, in addition to the in-memory caches:
+	// headers, transactions and receipts. The data is then fetched only once across restarts.
+	PersistentCache *caching.DiskStore
+
 	// If the RPC is untrusted, then we should not use cached information from responses,
 	// and instead verify against the block-hash.
 	// Of real L1 blocks no deposits can be missed/faked, no batches can be missed/faked,
@@ -108,11 +113,11 @@ type L1EthClient struct {
 
 	// cache transactions in bundles per block hash
 	// common.Hash -> types.Transactions
-	transactionsCache *caching.LRUCache[common.Hash, types.Transactions]
+	transactionsCache caching.Cache[common.Hash, types.Transactions]
 
 	// cache block headers of blocks by hash
 	// common.Hash -> *HeaderInfo
-	headersCache *caching.LRUCache[common.Hash, l1eth.BlockInfo]
+	headersCache caching.Cache[common.Hash, l1eth.BlockInfo]
 }
 
 // NewEthClient returns an [L1EthClient], wrapping an RPC with bindings to fetch ethereum data with added error logging,
@@ -133,11 +138,25 @@ func NewL1EthClient(client client.RPC, log log.Logger, metrics caching.Metrics,
 		trustRPC:          config.TrustRPC,
 		mustBePostMerge:   config.MustBePostMerge,
 		log:               log,
-		transactionsCache: caching.NewLRUCache[common.Hash, types.Transactions](metrics, "txs", config.TransactionsCacheSize),
-		headersCache:      caching.NewLRUCache[common.Hash, l1eth.BlockInfo](metrics, "headers", config.HeadersCacheSize),
+		transactionsCache: caching.NewTieredCache[common.Hash, types.Transactions](metrics, "txs", config.TransactionsCacheSize, config.PersistentCache, caching.JSONCodec[types.Transactions]()),
+		headersCache:      caching.NewTieredCache[common.Hash, l1eth.BlockInfo](metrics, "headers", config.HeadersCacheSize, config.PersistentCache, headerCodec),
 	}, nil
 }
 
+// headerCodec persists block headers as RLP.
+var headerCodec = caching.Codec[l1eth.BlockInfo]{
+	Encode: func(info l1eth.BlockInfo) ([]byte, error) {
+		return info.HeaderRLP()
+	},
+	Decode: func(data []byte) (l1eth.BlockInfo, error) {
+		var header types.Header
+		if err := rlp.DecodeBytes(data, &header); err != nil {
+			return nil, err
+		}
+		return l1eth.HeaderBlockInfo(&header), nil
+	},
+}
+
 // SubscribeNewHead subscribes to notifications about the current blockchain head on the given channel.
 func (s *L1EthClient) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
 	// Note that *types.Header does not cache the block hash unlike *HeaderInfo, it always recomputes.
@@ -126,6 +126,40 @@ func TestEthClient_InfoByHash(t *testing.T) {
 	m.Mock.AssertExpectations(t)
 }
 
+func TestEthClient_PersistentCache(t *testing.T) {
+	t.Parallel()
+	block, _ := randomRpcBlockAndReceipts(rand.New(rand.NewSource(42)), 4)
+	expectedInfo, expectedTxs, err := block.Info(true, false)
+	require.NoError(t, err)
+	ctx := context.Background()
+	cfg := *testEthClientConfig
+	cfg.PersistentCache, err = caching.OpenDiskStore(t.TempDir(), 1<<20)
+	require.NoError(t, err)
+
+	m := new(mockRPC)
+	m.On("CallContext", ctx, new(*RPCBlock),
+		"eth_getBlockByHash", []any{block.Hash, true}).Run(func(args mock.Arguments) {
+		*args[1].(**RPCBlock) = block
+	}).Return([]error{nil}).Once()
+	s, err := NewL1EthClient(m, nil, nil, &cfg)
+	require.NoError(t, err)
+	_, _, err = s.InfoAndTxsByHash(ctx, block.Hash)
+	require.NoError(t, err)
+
+	// A new client, like after a restart, finds the block in the store without any calls
+	s, err = NewL1EthClient(m, nil, nil, &cfg)
+	require.NoError(t, err)
+	info, txs, err := s.InfoAndTxsByHash(ctx, block.Hash)
+	require.NoError(t, err)
+	require.Equal(t, expectedInfo.Hash(), info.Hash())
+	require.Equal(t, expectedInfo.NumberU64(), info.NumberU64())
+	require.Len(t, txs, len(expectedTxs))
+	for i, tx := range txs {
+		require.Equal(t, expectedTxs[i].Hash(), tx.Hash())
+	}
+	m.Mock.AssertExpectations(t)
+}
+
 func TestEthClient_InfoByNumber(t *testing.T) {
 	t.Parallel()
 	m := new(mockRPC)
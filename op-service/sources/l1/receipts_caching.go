@@ -15,7 +15,7 @@ import (
 // ReceiptsProvider. It also avoids duplicate in-flight requests per block hash.
 type CachingReceiptsProvider struct {
 	inner ReceiptsProvider
-	cache *caching.LRUCache[common.Hash, types.Receipts]
+	cache caching.Cache[common.Hash, types.Receipts]
 
 	// lock fetching process for each block hash to avoid duplicate requests
 	fetching   map[common.Hash]*sync.Mutex
@@ -23,15 +23,21 @@ type CachingReceiptsProvider struct {
 }
 
 func NewCachingReceiptsProvider(inner ReceiptsProvider, m caching.Metrics, cacheSize int) *CachingReceiptsProvider {
+	return NewPersistentCachingReceiptsProvider(inner, m, cacheSize, nil)
+}
+
+// NewPersistentCachingReceiptsProvider is like NewCachingReceiptsProvider,
+// but also persists the receipts in the given store, if it is not nil.
+func NewPersistentCachingReceiptsProvider(inner ReceiptsProvider, m caching.Metrics, cacheSize int, store *caching.DiskStore) *CachingReceiptsProvider {
 	return &CachingReceiptsProvider{
 		inner:    inner,
-		cache:    caching.NewLRUCache[common.Hash, types.Receipts](m, "receipts", cacheSize),
+		cache:    caching.NewTieredCache[common.Hash, types.Receipts](m, "receipts", cacheSize, store, caching.JSONCodec[types.Receipts]()),
 		fetching: make(map[common.Hash]*sync.Mutex),
 	}
 }
 
-func NewCachingRPCReceiptsProvider(client rpcClient, log log.Logger, config RPCReceiptsConfig, m caching.Metrics, cacheSize int) *CachingReceiptsProvider {
-	return NewCachingReceiptsProvider(NewRPCReceiptsFetcher(client, log, config), m, cacheSize)
+func NewCachingRPCReceiptsProvider(client rpcClient, log log.Logger, config RPCReceiptsConfig, m caching.Metrics, cacheSize int, store *caching.DiskStore) *CachingReceiptsProvider {
+	return NewPersistentCachingReceiptsProvider(NewRPCReceiptsFetcher(client, log, config), m, cacheSize, store)
 }
 
 func (p *CachingReceiptsProvider) getOrCreateFetchingLock(blockHash common.Hash) *sync.Mutex {
@@ -10,6 +10,8 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/caching"
 	l1eth "github.com/zircuit-labs/zkr-monorepo-public/op-service/sources/l1/eth"
 )
 
@@ -47,6 +49,36 @@ func TestCachingReceiptsProvider_Caching(t *testing.T) {
 	mrp.AssertExpectations(t)
 }
 
+func TestCachingReceiptsProvider_Persistent(t *testing.T) {
+	block, receipts := randomRpcBlockAndReceipts(rand.New(rand.NewSource(69)), 4)
+	txHashes := receiptTxHashes(receipts)
+	blockid := block.BlockID()
+	store, err := caching.OpenDiskStore(t.TempDir(), 1<<20)
+	require.NoError(t, err)
+	ctx, done := context.WithTimeout(context.Background(), 10*time.Second)
+	defer done()
+
+	mrp := new(mockReceiptsProvider)
+	mrp.On("FetchReceipts", ctx, blockid, txHashes).
+		Return(types.Receipts(receipts), error(nil)).
+		Once() // receipts should be persisted after first fetch
+
+	bInfo, _, _ := block.Info(true, true)
+	rp := NewPersistentCachingReceiptsProvider(mrp, nil, 1, store)
+	_, err = rp.FetchReceipts(ctx, bInfo, txHashes)
+	require.NoError(t, err)
+
+	// a new provider, like after a restart, finds the receipts in the store
+	rp = NewPersistentCachingReceiptsProvider(mrp, nil, 1, store)
+	gotRecs, err := rp.FetchReceipts(ctx, bInfo, txHashes)
+	require.NoError(t, err)
+	require.Len(t, gotRecs, len(receipts))
+	for i, gotRec := range gotRecs {
+		requireEqualReceipt(t, receipts[i], gotRec)
+	}
+	mrp.AssertExpectations(t)
+}
+
 func TestCachingReceiptsProvider_Concurrency(t *testing.T) {
 	block, receipts := randomRpcBlockAndReceipts(rand.New(rand.NewSource(69)), 4)
 	txHashes := receiptTxHashes(receipts)
@@ -21,7 +21,7 @@ func newRPCRecProviderFromConfig(client client.RPC, log log.Logger, metrics cach
 		ProviderKind:        config.RPCProviderKind,
 		MethodResetDuration: config.MethodResetDuration,
 	}
-	return NewCachingRPCReceiptsProvider(client, log, recCfg, metrics, config.ReceiptsCacheSize)
+	return NewCachingRPCReceiptsProvider(client, log, recCfg, metrics, config.ReceiptsCacheSize, config.PersistentCache)
 }
 
 type rpcClient interface {
@@ -8,6 +8,7 @@ import (
 	"github.com/zircuit-labs/l2-geth-public/common/hexutil"
 
 	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-node/rollup/derive"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/client"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
 )
@@ -32,6 +33,12 @@ func (r *RollupClient) SafeHeadAtL1Block(ctx context.Context, blockNum uint64) (
 	return output, err
 }
 
+func (r *RollupClient) DerivationAudit(ctx context.Context, blockNum uint64) (*derive.DerivationAudit, error) {
+	var output *derive.DerivationAudit
+	err := r.rpc.CallContext(ctx, &output, "optimism_derivationAudit", hexutil.Uint64(blockNum))
+	return output, err
+}
+
 func (r *RollupClient) SyncStatus(ctx context.Context) (*eth.SyncStatus, error) {
 	var output *eth.SyncStatus
 	err := r.rpc.CallContext(ctx, &output, "optimism_syncStatus")
@@ -70,6 +77,13 @@ func (r *RollupClient) PostUnsafePayload(ctx context.Context, payload *eth.Execu
 	return r.rpc.CallContext(ctx, nil, "admin_postUnsafePayload", payload)
 }
 
+// SetRuntimeSettings changes the runtime settings that are set, and returns all the current settings.
+func (r *RollupClient) SetRuntimeSettings(ctx context.Context, settings eth.RuntimeSettings) (eth.RuntimeSettings, error) {
+	var result eth.RuntimeSettings
+	err := r.rpc.CallContext(ctx, &result, "admin_setRuntimeSettings", settings)
+	return result, err
+}
+
 func (r *RollupClient) SetLogLevel(ctx context.Context, lvl slog.Level) error {
 	return r.rpc.CallContext(ctx, nil, "admin_setLogLevel", lvl.String())
 }
@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/zircuit-labs/l2-geth-public/core/types"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -54,13 +55,17 @@ func (q *Queue[T]) Wait() {
 // Send will wait until the number of pending txs is below the max pending,
 // and then send the next tx.
 //
+// The send continues the trace of ctx, if any. It is canceled with the queue's
+// context, not with ctx.
+//
 // The actual tx sending is non-blocking, with the receipt returned on the
 // provided receipt channel. If the channel is unbuffered, the goroutine is
 // blocked from completing until the channel is read from.
-func (q *Queue[T]) Send(id T, candidate TxCandidate, receiptCh chan TxReceipt[T]) {
-	group, ctx := q.groupContext()
+func (q *Queue[T]) Send(ctx context.Context, id T, candidate TxCandidate, receiptCh chan TxReceipt[T]) {
+	group, groupCtx := q.groupContext()
+	sendCtx := trace.ContextWithSpanContext(groupCtx, trace.SpanContextFromContext(ctx))
 	group.Go(func() error {
-		return q.sendTx(ctx, id, candidate, receiptCh)
+		return q.sendTx(sendCtx, id, candidate, receiptCh)
 	})
 }
 
@@ -70,13 +75,16 @@ func (q *Queue[T]) Send(id T, candidate TxCandidate, receiptCh chan TxReceipt[T]
 // Returns false if there is no room in the queue to send. Otherwise, the
 // transaction is queued and this method returns true.
 //
+// The send continues the trace of ctx, if any, like with Send.
+//
 // The actual tx sending is non-blocking, with the receipt returned on the
 // provided receipt channel. If the channel is unbuffered, the goroutine is
 // blocked from completing until the channel is read from.
-func (q *Queue[T]) TrySend(id T, candidate TxCandidate, receiptCh chan TxReceipt[T]) bool {
-	group, ctx := q.groupContext()
+func (q *Queue[T]) TrySend(ctx context.Context, id T, candidate TxCandidate, receiptCh chan TxReceipt[T]) bool {
+	group, groupCtx := q.groupContext()
+	sendCtx := trace.ContextWithSpanContext(groupCtx, trace.SpanContextFromContext(ctx))
 	return group.TryGo(func() error {
-		return q.sendTx(ctx, id, candidate, receiptCh)
+		return q.sendTx(sendCtx, id, candidate, receiptCh)
 	})
 }
 
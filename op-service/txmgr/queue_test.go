@@ -21,12 +21,12 @@ import (
 type queueFunc func(id int, candidate TxCandidate, receiptCh chan TxReceipt[int], q *Queue[int]) bool
 
 func sendQueueFunc(id int, candidate TxCandidate, receiptCh chan TxReceipt[int], q *Queue[int]) bool {
-	q.Send(id, candidate, receiptCh)
+	q.Send(context.Background(), id, candidate, receiptCh)
 	return true
 }
 
 func trySendQueueFunc(id int, candidate TxCandidate, receiptCh chan TxReceipt[int], q *Queue[int]) bool {
-	return q.TrySend(id, candidate, receiptCh)
+	return q.TrySend(context.Background(), id, candidate, receiptCh)
 }
 
 type queueCall struct {
@@ -20,9 +20,12 @@ import (
 	"github.com/zircuit-labs/l2-geth-public/crypto/kzg4844"
 	"github.com/zircuit-labs/l2-geth-public/log"
 	"github.com/zircuit-labs/l2-geth-public/params"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/errutil"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/eth"
+	"github.com/zircuit-labs/zkr-monorepo-public/op-service/optracing"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/retry"
 	"github.com/zircuit-labs/zkr-monorepo-public/op-service/txmgr/metrics"
 )
@@ -41,6 +44,8 @@ var (
 	// geth enforces a 1 gwei minimum for blob tx fee
 	minBlobTxFee = big.NewInt(params.GWei)
 
+	tracer = optracing.Tracer("github.com/zircuit-labs/zkr-monorepo-public/op-service/txmgr")
+
 	oneHundred = big.NewInt(100)
 	ninetyNine = big.NewInt(99)
 	two        = big.NewInt(2)
@@ -215,10 +220,17 @@ func (m *SimpleTxManager) Send(ctx context.Context, candidate TxCandidate) (*typ
 	defer func() {
 		m.metr.RecordPendingTx(m.pending.Add(-1))
 	}()
+	ctx, span := tracer.Start(ctx, "txmgr.send", trace.WithAttributes(
+		attribute.Int("blobs", len(candidate.Blobs)),
+		attribute.Int("calldata_size", len(candidate.TxData)),
+	))
 	receipt, err := m.send(ctx, candidate)
 	if err != nil {
 		m.resetNonce()
+	} else {
+		span.SetAttributes(attribute.String("tx_hash", receipt.TxHash.String()))
 	}
+	optracing.EndSpan(span, err)
 	return receipt, err
 }
 
@@ -492,6 +504,11 @@ func (m *SimpleTxManager) publishTx(ctx context.Context, tx *types.Transaction,
 		err := m.backend.SendTransaction(cCtx, tx)
 		cancel()
 		sendState.ProcessSendError(err)
+		trace.SpanFromContext(ctx).AddEvent("publish", trace.WithAttributes(
+			attribute.String("tx_hash", tx.Hash().String()),
+			attribute.Int64("nonce", int64(tx.Nonce())),
+			attribute.Bool("ok", err == nil),
+		))
 
 		if err == nil {
 			m.metr.TxPublished("")